@@ -2,37 +2,102 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"opencoderouter/internal/admin"
 	"opencoderouter/internal/api"
 	"opencoderouter/internal/auth"
 	"opencoderouter/internal/cache"
 	"opencoderouter/internal/config"
+	"opencoderouter/internal/consul"
 	"opencoderouter/internal/discovery"
 	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/middleware"
 	"opencoderouter/internal/proxy"
 	"opencoderouter/internal/registry"
 	"opencoderouter/internal/scanner"
 	"opencoderouter/internal/session"
+	"opencoderouter/internal/telemetry"
 	"opencoderouter/internal/terminal"
+	"opencoderouter/internal/tui"
+	"opencoderouter/internal/version"
+	"opencoderouter/internal/watcher"
 )
 
 func runRouter(cfg config.Config, projectPaths []string, logger *slog.Logger) error {
+	// Bind first so that, with --port 0, everything below sees the port the
+	// OS assigned.
+	ln, err := cfg.Listen()
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+	defer ln.Close()
+	if cfg.ProxyProtocol {
+		ln = proxy.ProxyProtocolListener(ln)
+	}
+	if cfg.UnixSocket != "" {
+		defer os.Remove(cfg.UnixSocket)
+	}
+	if err := cfg.WritePortFile(); err != nil {
+		return err
+	}
+	if cfg.PortFile != "" {
+		defer os.Remove(cfg.PortFile)
+	}
+	var redirectLn net.Listener
+	if cfg.TLSEnabled && cfg.HTTPRedirectPort > 0 {
+		host, _, _ := net.SplitHostPort(cfg.ListenAddr)
+		redirectLn, err = net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(cfg.HTTPRedirectPort)))
+		if err != nil {
+			return fmt.Errorf("http redirect listen failed: %w", err)
+		}
+		defer redirectLn.Close()
+	}
+	var adminLn net.Listener
+	if cfg.AdminPort > 0 {
+		adminLn, err = net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(cfg.AdminPort)))
+		if err != nil {
+			return fmt.Errorf("admin listen failed: %w", err)
+		}
+		defer adminLn.Close()
+	}
+
 	var lnch *launcher.Launcher
 	if len(projectPaths) > 0 {
-		lnch = launcher.New(cfg.ScanPortStart, cfg.ScanPortEnd, logger.With("component", "launcher"))
+		lnch = launcher.New(cfg.ScanPortStart, cfg.ScanPortEnd, logger.With("component", "launcher"),
+			launcher.WithRestartPolicy(launcher.RestartPolicy(cfg.RestartPolicy)),
+			launcher.WithLogDir(cfg.LogDir, cfg.MaxLogSize),
+			launcher.WithBinaryPath(cfg.OpenCodeBinary),
+		)
 		if err := lnch.Launch(projectPaths); err != nil {
 			return fmt.Errorf("launcher error: %w", err)
 		}
 	}
 
-	reg := registry.New(cfg.StaleAfter, logger.With("component", "registry"))
+	if cfg.DryRun {
+		logger.Warn("DRY RUN: scanner discoveries are only logged; the registry is not updated and stale backends are not pruned")
+		fmt.Fprintln(os.Stderr, "WARNING: dry-run mode is active; discovered backends are logged, not registered")
+	}
+	reg := registry.New(cfg.StaleAfter, logger.With("component", "registry"),
+		registry.WithSlugCollision(cfg.SlugCollision),
+		registry.WithDryRun(cfg.DryRun),
+		registry.WithDrainPeriod(cfg.DrainPeriod),
+		registry.WithUsernameFromPath(cfg.UsernameFromPath),
+	)
+	if cfg.PinnedFile != "" {
+		if err := reg.ImportPinned(cfg.PinnedFile); err != nil {
+			return err
+		}
+	}
 	sc := scanner.New(
 		reg,
 		cfg.ScanPortStart,
@@ -41,9 +106,91 @@ func runRouter(cfg config.Config, projectPaths []string, logger *slog.Logger) er
 		cfg.ScanConcurrency,
 		cfg.ProbeTimeout,
 		logger.With("component", "scanner"),
+		scanner.WithH2CProbe(cfg.UseH2C),
+		scanner.WithTLSProbe(cfg.ProbeTLS, cfg.ProbeInsecureSkipVerify),
+		scanner.WithIPv6Probe(cfg.ScanIPv6),
+		scanner.WithProbePaths(cfg.HealthPath, cfg.ProjectPath),
+		scanner.WithUserAgent(cfg.ProbeUserAgent),
+		scanner.WithProbeRetries(cfg.ProbeRetries, cfg.ProbeRetryDelay),
+		scanner.WithExcludePorts(cfg.ScanExcludedPorts()),
+		scanner.WithPortGroups(cfg.ScanPortGroups),
+		scanner.WithExcludeRanges(cfg.ScanExcludeRanges),
+		scanner.WithBlocklist(cfg.ScanPortBlocklist),
+		scanner.WithAdaptiveConcurrency(cfg.ScanConcurrencyAuto),
+		scanner.WithDryRun(cfg.DryRun),
+		scanner.WithPassive(cfg.PassiveScan),
+		scanner.WithPortDir(cfg.PortDir),
 	)
+	if cfg.ListenPortInScanRange() {
+		logger.Warn("listen port is inside the scan range; excluding it from scans",
+			"port", cfg.ListenPort, "scan_range", cfg.ScanRangeString())
+	}
+	accessLog, closeAccessLog, err := setupAccessLogger(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAccessLog()
+	traceFile, closeTraceFile, err := setupTraceFile(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeTraceFile()
+	if traceFile != nil {
+		logger.Warn("tracing full requests and responses; the trace file will contain credentials and prompts",
+			"trace_file", cfg.TraceFile, "trace_slug", cfg.TraceSlug)
+	}
+
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), cfg.OTelEndpoint)
+	if err != nil {
+		return fmt.Errorf("tracing setup failed: %w", err)
+	}
+	defer func() {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warn("failed to flush traces", "error", err)
+		}
+	}()
+	if cfg.OTelEndpoint != "" {
+		logger.Info("tracing enabled", "otel_endpoint", cfg.OTelEndpoint)
+	}
+
 	uiHandler := http.FileServer(getWebFS())
-	rt := proxy.New(reg, cfg, logger.With("component", "proxy"), uiHandler)
+	remotes := discovery.NewRemoteRegistry()
+
+	var (
+		adv     *discovery.Advertiser
+		browser *discovery.Browser
+	)
+	if cfg.EnableMDNS && cfg.HostSuffix != config.DefaultHostSuffix {
+		logger.Warn("mDNS only resolves .local names; disabling it for the custom host suffix",
+			"host_suffix", cfg.HostSuffix)
+		cfg.EnableMDNS = false
+	}
+	if cfg.EnableMDNS && !cfg.AllowRemote && cfg.UnixSocket == "" {
+		logger.Warn("mDNS advertises this machine to the LAN, but the router only listens on 127.0.0.1; start it with --allow-remote to accept remote clients")
+	}
+	if cfg.EnableMDNS {
+		adv = discovery.New(cfg, logger.With("component", "mdns"))
+		browser = discovery.NewBrowser(cfg, remotes, logger.With("component", "mdns-browser"))
+	}
+	var consulClient *consul.Client
+	if cfg.ConsulAddr != "" {
+		consulClient, err = consul.New(cfg, logger.With("component", "consul"))
+		if err != nil {
+			return err
+		}
+	}
+
+	rt := proxy.New(reg, cfg, logger.With("component", "proxy"), uiHandler,
+		proxy.WithRemotes(remotes),
+		proxy.WithAccessLog(accessLog),
+		proxy.WithTrace(traceFile, cfg.TraceSlug),
+		proxy.WithLauncher(lnch),
+		proxy.WithAdvertiser(adv),
+		proxy.WithScanner(sc),
+		proxy.WithDashboardTemplate(getDashboardTemplate()),
+	)
 
 	eventBus := session.NewEventBus(100)
 	scrollbackCache, err := cache.NewJSONLCache(cache.CacheConfig{})
@@ -77,55 +224,207 @@ func runRouter(cfg config.Config, projectPaths []string, logger *slog.Logger) er
 		AuthConfig:      auth.LoadFromEnv(),
 		ScrollbackCache: scrollbackCache,
 		Fallback:        rt,
+		CORS:            rt.CORS(),
 	})
 
-	var adv *discovery.Advertiser
-	if cfg.EnableMDNS {
-		adv = discovery.New(cfg, logger.With("component", "mdns"))
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go sc.Run(ctx)
+	if lnch != nil {
+		go removeDeadBackends(ctx, lnch, reg)
+	}
+	if cfg.PinnedFile != "" {
+		pinnedLogger := logger.With("component", "pinned-watcher")
+		path := cfg.PinnedFile
+		fw := watcher.NewFileWatcher(path, func() { reloadPinned(reg, path, pinnedLogger) }, pinnedLogger)
+		if err := fw.Start(ctx); err != nil {
+			logger.Warn("pinned file watcher failed to start; reload with SIGHUP", "error", err)
+		}
+	}
+	if len(cfg.WatchDirs) > 0 {
+		watcher := scanner.NewWatcher(cfg.WatchDirs, sc.Trigger, logger.With("component", "watcher"))
+		if err := watcher.Start(ctx); err != nil {
+			logger.Warn("filesystem watcher failed to start", "error", err)
+		}
+	}
+	var syncers []backendSyncer
 	if adv != nil {
-		go runMDNSSyncLoop(ctx, adv, reg, cfg.ScanInterval)
+		syncers = append(syncers, adv)
+	}
+	if consulClient != nil {
+		syncers = append(syncers, consulClient)
+	}
+	if len(syncers) > 0 {
+		go runDiscoverySyncLoop(ctx, reg, sc.Interval, syncers...)
+	}
+	if browser != nil {
+		if err := browser.Start(ctx); err != nil {
+			logger.Warn("mDNS browser failed to start", "error", err)
+		}
 	}
 
 	srv := &http.Server{
 		Addr:         cfg.ListenAddr,
-		Handler:      apiRouter,
+		Handler:      serverHandler(cfg, rt, apiRouter),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 120 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
+	var tlsFingerprint string
+	if cfg.TLSEnabled {
+		cert, generated, err := resolveTLSCertificate(cfg)
+		if err != nil {
+			return fmt.Errorf("tls setup failed: %w", err)
+		}
+		if generated {
+			srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		}
+		tlsFingerprint = config.CertFingerprint(cert)
+		logger.Info("TLS enabled", "self_signed", generated, "sha256_fingerprint", tlsFingerprint)
+	}
+
 	serverErrCh := make(chan error, 1)
 	go func() {
-		logger.Info("HTTP server listening", "addr", cfg.ListenAddr)
-		if serveErr := srv.ListenAndServe(); serveErr != nil && serveErr != http.ErrServerClosed {
+		logger.Info("HTTP server listening", "addr", cfg.ListenDisplay(), "tls", cfg.TLSEnabled)
+		var serveErr error
+		if cfg.TLSEnabled {
+			serveErr = srv.ServeTLS(ln, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			serveErr = srv.Serve(ln)
+		}
+		if serveErr != nil && serveErr != http.ErrServerClosed {
 			serverErrCh <- serveErr
 		}
 	}()
 
-	printAccessInfo(cfg, projectPaths)
+	var redirectSrv *http.Server
+	if redirectLn != nil {
+		redirectSrv = &http.Server{
+			Handler:      proxy.HTTPSRedirect(cfg.ListenPort),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+		go func() {
+			logger.Info("HTTP redirect listening", "addr", redirectLn.Addr().String(), "https_port", cfg.ListenPort)
+			if err := redirectSrv.Serve(redirectLn); err != nil && err != http.ErrServerClosed {
+				select {
+				case serverErrCh <- err:
+				default:
+				}
+			}
+		}()
+	}
+
+	var adminSrv *http.Server
+	if adminLn != nil {
+		adminSrv = &http.Server{
+			Handler: admin.NewHandler(admin.Config{
+				Routes: rt.AdminRoutes(),
+				Token:  cfg.AdminToken,
+			}),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+		go func() {
+			logger.Info("admin API listening", "addr", adminLn.Addr().String())
+			if err := adminSrv.Serve(adminLn); err != nil && err != http.ErrServerClosed {
+				select {
+				case serverErrCh <- err:
+				default:
+				}
+			}
+		}()
+	}
+
+	var processes []launcher.ProcessStatus
+	if lnch != nil {
+		processes = lnch.Status()
+	}
+	// The dashboard replaces the startup summary; quitting it stops the
+	// router.
+	var tuiDone chan error
+	if cfg.TUI {
+		tuiDone = make(chan error, 1)
+		go func() {
+			tuiDone <- tui.Run(ctx, tui.Config{
+				Backends:   reg.All,
+				StaleAfter: cfg.StaleAfter,
+				URL: func(b *registry.Backend) string {
+					return fmt.Sprintf("%s://localhost:%d/%s/", cfg.Scheme(), cfg.ListenPort, b.Slug)
+				},
+				Rescan: sc.Trigger,
+				Title:  fmt.Sprintf("OpenCodeRouter %s on %s", version.Version, cfg.ListenDisplay()),
+			})
+		}()
+	} else {
+		printAccessInfo(cfg, processes, tlsFingerprint)
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	targets := reloadTargets{scanner: sc, registry: reg, adv: adv, browser: browser, flags: explicitFlags()}
+
 	var serverErr error
-	select {
-	case sig := <-sigCh:
-		logger.Info("received signal, shutting down", "signal", sig)
-	case serverErr = <-serverErrCh:
-		logger.Error("HTTP server error", "error", serverErr)
+wait:
+	for {
+		select {
+		case <-hupCh:
+			if cfg.PinnedFile != "" {
+				reloadPinned(reg, cfg.PinnedFile, logger)
+			}
+			if cfg.ConfigFile == "" {
+				if cfg.PinnedFile == "" {
+					logger.Warn("received SIGHUP but no --config file is set; ignoring")
+				}
+				continue
+			}
+			next, err := reloadConfig(cfg, targets, logger.With("component", "reload"))
+			if err != nil {
+				logger.Error("config reload failed; keeping current config", "error", err)
+				continue
+			}
+			cfg = next
+		case sig := <-sigCh:
+			logger.Info("received signal, shutting down", "signal", sig)
+			break wait
+		case serverErr = <-serverErrCh:
+			logger.Error("HTTP server error", "error", serverErr)
+			break wait
+		case err := <-tuiDone:
+			if err != nil {
+				logger.Error("terminal dashboard failed", "error", err)
+			}
+			logger.Info("terminal dashboard closed, shutting down")
+			break wait
+		}
 	}
 
 	cancel()
+	// Let in-flight requests finish before the launcher stops the backends
+	// they are talking to.
+	if n := rt.InFlight(); n > 0 {
+		logger.Info("draining in-flight requests", "count", n, "timeout", cfg.DrainTimeout)
+		if !rt.Drain(cfg.DrainTimeout) {
+			logger.Warn("drain timeout elapsed", "remaining", rt.InFlight())
+		}
+	}
+	if browser != nil {
+		browser.Close()
+	}
 	if adv != nil {
 		adv.Shutdown()
 	}
+	if consulClient != nil {
+		consulClient.Shutdown()
+	}
 	if lnch != nil {
 		lnch.Shutdown()
 	}
@@ -135,6 +434,16 @@ func runRouter(cfg config.Config, projectPaths []string, logger *slog.Logger) er
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("server shutdown error", "error", err)
 	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("http redirect shutdown error", "error", err)
+		}
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("admin server shutdown error", "error", err)
+		}
+	}
 
 	if serverErr != nil {
 		return serverErr
@@ -143,15 +452,32 @@ func runRouter(cfg config.Config, projectPaths []string, logger *slog.Logger) er
 	return nil
 }
 
-func runMDNSSyncLoop(ctx context.Context, adv *discovery.Advertiser, reg *registry.Registry, interval time.Duration) {
+// backendSyncer publishes the registry's backends somewhere, such as mDNS
+// or Consul.
+type backendSyncer interface {
+	Sync(backends []*registry.Backend)
+}
+
+// runDiscoverySyncLoop periodically hands the registry's backends to every
+// syncer, so mDNS and Consul can be active at the same time. interval is
+// re-read after every sync, so a reloaded scan interval takes effect.
+func runDiscoverySyncLoop(ctx context.Context, reg *registry.Registry, interval func() time.Duration, syncers ...backendSyncer) {
+	syncAll := func() {
+		backends := reg.All()
+		for _, s := range syncers {
+			s.Sync(backends)
+		}
+	}
+
 	select {
 	case <-time.After(3 * time.Second):
-		adv.Sync(reg.All())
+		syncAll()
 	case <-ctx.Done():
 		return
 	}
 
-	ticker := time.NewTicker(interval)
+	current := interval()
+	ticker := time.NewTicker(current)
 	defer ticker.Stop()
 
 	for {
@@ -159,25 +485,160 @@ func runMDNSSyncLoop(ctx context.Context, adv *discovery.Advertiser, reg *regist
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			adv.Sync(reg.All())
+			syncAll()
+			if d := interval(); d != current {
+				current = d
+				ticker.Reset(current)
+			}
+		}
+	}
+}
+
+// removeDeadBackends drops a launched backend from the registry as soon as
+// its process exits, instead of waiting for it to go stale.
+func removeDeadBackends(ctx context.Context, lnch *launcher.Launcher, reg *registry.Registry) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case port := <-lnch.Died():
+			reg.RemoveByPort(port)
 		}
 	}
 }
 
-func printAccessInfo(cfg config.Config, projectPaths []string) {
+// serverHandler returns the main listener's handler: apiRouter, which
+// falls back to rt, behind rt.Guard so its access checks and drain
+// accounting cover the session API as well as the proxy.
+func serverHandler(cfg config.Config, rt *proxy.Router, apiRouter http.Handler) http.Handler {
+	handler := rt.Guard()(apiRouter)
+	if cfg.GRPCEnabled && !cfg.TLSEnabled {
+		// TLS listeners negotiate HTTP/2 through ALPN already.
+		handler = proxy.AcceptH2C(handler)
+	}
+	if cfg.TLSEnabled && cfg.HSTSMaxAge > 0 {
+		handler = middleware.HSTS(cfg.HSTSMaxAge)(handler)
+	}
+	return handler
+}
+
+// reloadPinned re-imports the pinned backends file and logs what changed.
+// A bad edit is rejected as a whole, keeping the current backends.
+func reloadPinned(reg *registry.Registry, path string, logger *slog.Logger) {
+	before := reg.All()
+	if err := reg.ImportPinned(path); err != nil {
+		logger.Error("pinned file reload failed; keeping current backends", "error", err)
+		return
+	}
+	added, removed, updated := reg.Diff(before)
+	if len(added) > 0 || len(removed) > 0 || len(updated) > 0 {
+		logger.Info("pinned file reloaded", "added", slugsOf(added), "removed", slugsOf(removed), "updated", slugsOf(updated))
+	}
+}
+
+// slugsOf returns the slugs of backends, for logging.
+func slugsOf(backends []*registry.Backend) []string {
+	slugs := make([]string, 0, len(backends))
+	for _, b := range backends {
+		slugs = append(slugs, b.Slug)
+	}
+	return slugs
+}
+
+// reloadTargets are the running components whose settings can change on SIGHUP.
+// Nil advertiser/browser means mDNS is disabled.
+type reloadTargets struct {
+	scanner  *scanner.Scanner
+	registry *registry.Registry
+	adv      *discovery.Advertiser
+	browser  *discovery.Browser
+	// flags are the flags given on the command line, re-applied over the
+	// file so they keep precedence; see explicitFlags.
+	flags map[string]string
+}
+
+// reloadConfig re-reads cur.ConfigFile, re-applies the command-line flags
+// in t.flags on top as at startup, and applies the settings that can
+// change without a restart: scan interval and concurrency, probe timeout,
+// stale-after and the mDNS service type. Other changed fields are logged and
+// left at their current values. Returns the config now in effect.
+func reloadConfig(cur config.Config, t reloadTargets, logger *slog.Logger) (config.Config, error) {
+	loaded, err := config.LoadFile(cur.ConfigFile, cur)
+	if err != nil {
+		return cur, err
+	}
+	if err := reapplyFlags(&loaded, t.flags); err != nil {
+		return cur, err
+	}
+	if err := loaded.Validate(); err != nil {
+		return cur, fmt.Errorf("invalid config: %w", err)
+	}
+
+	next := cur
+	next.ScanInterval = loaded.ScanInterval
+	next.ScanConcurrency = loaded.ScanConcurrency
+	next.ScanConcurrencyAuto = loaded.ScanConcurrencyAuto
+	next.ProbeTimeout = loaded.ProbeTimeout
+	next.ProbeRetries = loaded.ProbeRetries
+	next.ProbeRetryDelay = loaded.ProbeRetryDelay
+	next.StaleAfter = loaded.StaleAfter
+	next.MDNSServiceType = loaded.MDNSServiceType
+
+	t.scanner.Reconfigure(next)
+	t.registry.SetStaleAfter(next.StaleAfter)
+	if next.MDNSServiceType != cur.MDNSServiceType {
+		if t.adv != nil {
+			t.adv.SetServiceType(next.MDNSServiceType)
+		}
+		if t.browser != nil {
+			t.browser.SetServiceType(next.MDNSServiceType)
+		}
+	}
+
+	if ignored := config.Diff(next, loaded); len(ignored) > 0 {
+		logger.Warn("config changes require a restart to take effect", "fields", ignored)
+	}
+	logger.Info("config reloaded", "file", cur.ConfigFile, "changed", config.Diff(cur, next))
+	return next, nil
+}
+
+// resolveTLSCertificate loads the configured key pair, or generates a
+// self-signed certificate when none is configured.
+func resolveTLSCertificate(cfg config.Config) (tls.Certificate, bool, error) {
+	if cfg.TLSCert != "" && cfg.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		return cert, false, err
+	}
+	cert, err := config.GenerateSelfSignedCert(config.GetOutboundIP())
+	return cert, true, err
+}
+
+func printAccessInfo(cfg config.Config, processes []launcher.ProcessStatus, tlsFingerprint string) {
 	outboundIP := config.GetOutboundIP()
+	scheme := cfg.Scheme()
 	fmt.Println()
-	fmt.Printf("  Dashboard:     http://localhost:%d\n", cfg.ListenPort)
-	fmt.Printf("  Network:       http://%s:%d\n", outboundIP, cfg.ListenPort)
-	fmt.Printf("  API:           http://localhost:%d/api/backends\n", cfg.ListenPort)
+	if cfg.UnixSocket != "" {
+		fmt.Printf("  Socket:        %s (curl --unix-socket %s %s://localhost/api/health)\n", cfg.UnixSocket, cfg.UnixSocket, scheme)
+	}
+	fmt.Printf("  Dashboard:     %s://localhost:%d\n", scheme, cfg.ListenPort)
+	if cfg.AllowRemote {
+		fmt.Printf("  Network:       %s://%s:%d\n", scheme, outboundIP, cfg.ListenPort)
+	}
+	fmt.Printf("  API:           %s://localhost:%d/api/backends\n", scheme, cfg.ListenPort)
 	fmt.Printf("  Username:      %s\n", cfg.Username)
-	fmt.Printf("  Domain format: {project}-%s.local:%d\n", cfg.Username, cfg.ListenPort)
-	fmt.Printf("  Path format:   http://localhost:%d/{project}/...\n", cfg.ListenPort)
+	fmt.Printf("  Domain format: %s:%d\n", cfg.DomainFor("{project}"), cfg.ListenPort)
+	fmt.Printf("  Path format:   %s://localhost:%d/{project}/...\n", scheme, cfg.ListenPort)
+	if tlsFingerprint != "" {
+		fmt.Printf("  TLS SHA-256:   %s\n", tlsFingerprint)
+	}
 	if cfg.EnableMDNS {
 		fmt.Printf("  mDNS:          enabled (type: %s)\n", cfg.MDNSServiceType)
 	}
-	if len(projectPaths) > 0 {
-		fmt.Printf("  Projects:      %d managed\n", len(projectPaths))
+	if len(processes) > 0 {
+		fmt.Printf("  Projects:      %d managed\n", len(processes))
+		for _, p := range processes {
+			fmt.Printf("    %s → port %d (pid %d)\n", p.Path, p.Port, p.PID)
+		}
 	}
 	fmt.Println()
 }
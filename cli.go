@@ -3,45 +3,212 @@ package main
 import (
 	"flag"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"opencoderouter/internal/config"
 )
 
+// bindConfigFlags defines on fs the flags that set a config.Config field
+// directly, writing into cfg.
+func bindConfigFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.IntVar(&cfg.ListenPort, "port", cfg.ListenPort, "Port for the router to listen on (0 picks a free port)")
+	fs.StringVar(&cfg.PortFile, "port-file", cfg.PortFile, "Write the port the router listens on to this file once bound")
+	fs.StringVar(&cfg.PortDir, "port-dir", cfg.PortDir, "Keep one file per backend slug holding 127.0.0.1:{port} in this directory")
+	fs.StringVar(&cfg.Username, "username", cfg.Username, "Username for domain naming (default: OS user)")
+	fs.BoolVar(&cfg.UsernameFromPath, "username-from-path", cfg.UsernameFromPath, "Take each backend's username from its project's parent directory, e.g. /home/alice/proj → alice")
+	fs.IntVar(&cfg.ScanPortStart, "scan-start", cfg.ScanPortStart, "Start of port scan range")
+	fs.IntVar(&cfg.ScanPortEnd, "scan-end", cfg.ScanPortEnd, "End of port scan range")
+	fs.IntVar(&cfg.SessionPortStart, "session-port-start", cfg.SessionPortStart, "Start of port range for managed OpenCode session daemons")
+	fs.IntVar(&cfg.SessionPortEnd, "session-port-end", cfg.SessionPortEnd, "End of port range for managed OpenCode session daemons")
+	fs.DurationVar(&cfg.ScanInterval, "scan-interval", cfg.ScanInterval, "How often to scan for instances")
+	fs.IntVar(&cfg.ScanConcurrency, "scan-concurrency", cfg.ScanConcurrency, "Max concurrent port probes")
+	fs.BoolVar(&cfg.ScanConcurrencyAuto, "scan-concurrency-auto", cfg.ScanConcurrencyAuto, "Size probe concurrency from the CPU count and back off when the CPU is busy (overrides --scan-concurrency)")
+	fs.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "Log backends the scanner finds without registering them or pruning stale ones")
+	fs.BoolVar(&cfg.PassiveScan, "passive", cfg.PassiveScan, "Don't sweep the port range; backends self-register with POST /api/register and only registered ports are re-probed")
+	fs.DurationVar(&cfg.ProbeTimeout, "probe-timeout", cfg.ProbeTimeout, "Timeout for each port probe")
+	fs.IntVar(&cfg.ProbeRetries, "probe-retries", cfg.ProbeRetries, "Retries for a health check that fails with a timeout, reset connection or 5xx")
+	fs.DurationVar(&cfg.ProbeRetryDelay, "probe-retry-delay", cfg.ProbeRetryDelay, "Delay before each probe retry, with 50% jitter")
+	fs.StringVar(&cfg.HealthPath, "health-path", cfg.HealthPath, "Health endpoint probed on each scanned port")
+	fs.StringVar(&cfg.ProjectPath, "project-path", cfg.ProjectPath, "Project metadata endpoint queried on healthy ports")
+	fs.StringVar(&cfg.ProbeUserAgent, "probe-user-agent", cfg.ProbeUserAgent, "User-Agent header sent on scanner probes")
+	fs.DurationVar(&cfg.StaleAfter, "stale-after", cfg.StaleAfter, "Remove backends unseen for this duration")
+	fs.DurationVar(&cfg.DrainTimeout, "drain-timeout", cfg.DrainTimeout, "On shutdown, wait this long for in-flight proxied requests to finish")
+	fs.DurationVar(&cfg.DrainPeriod, "drain-period", cfg.DrainPeriod, "Keep a stale backend out of rotation this long before removing it (0 removes at once)")
+	fs.StringVar(&cfg.UnixSocket, "unix", cfg.UnixSocket, "Listen on this unix domain socket instead of TCP")
+	fs.BoolVar(&cfg.EnableMDNS, "mdns", cfg.EnableMDNS, "Enable mDNS service advertisement")
+	fs.StringVar(&cfg.HostSuffix, "host-suffix", cfg.HostSuffix, "Domain suffix for host-based routing, e.g. .internal (mDNS is disabled unless .local)")
+	fs.IntVar(&cfg.MDNSSRVPriority, "mdns-srv-priority", cfg.MDNSSRVPriority, "DNS-SD priority advertised for each backend (lower is preferred)")
+	fs.IntVar(&cfg.MDNSSRVWeight, "mdns-srv-weight", cfg.MDNSSRVWeight, "DNS-SD weight advertised for each backend within its priority")
+	fs.DurationVar(&cfg.PeerTimeout, "peer-timeout", cfg.PeerTimeout, "Forget projects advertised by other routers after this long without a re-announcement")
+	fs.StringVar(&cfg.ConsulAddr, "consul-addr", cfg.ConsulAddr, "Also register backends with the Consul agent at this address (e.g. localhost:8500)")
+	fs.BoolVar(&cfg.AccessLog, "access-log", cfg.AccessLog, "Log every proxied request as JSON")
+	fs.StringVar(&cfg.AccessLogFile, "access-log-file", cfg.AccessLogFile, "Write access log to this file instead of stderr")
+	fs.StringVar(&cfg.TraceFile, "trace-file", cfg.TraceFile, "Append a dump of every proxied request and response, with headers and bodies, to this file")
+	fs.StringVar(&cfg.TraceSlug, "trace-slug", cfg.TraceSlug, "Only trace backends whose slug matches this glob (default all)")
+	fs.BoolVar(&cfg.TUI, "tui", cfg.TUI, "Show a live terminal dashboard of the backends")
+	fs.BoolVar(&cfg.TLSEnabled, "tls", cfg.TLSEnabled, "Serve HTTPS (self-signed certificate unless --tls-cert/--tls-key are given)")
+	fs.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "PEM certificate file for --tls")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "PEM private key file for --tls")
+	fs.DurationVar(&cfg.HSTSMaxAge, "hsts-max-age", cfg.HSTSMaxAge, "Strict-Transport-Security max-age sent with --tls (0 disables the header)")
+	fs.IntVar(&cfg.HTTPRedirectPort, "http-redirect-port", cfg.HTTPRedirectPort, "With --tls, also serve plain HTTP on this port, redirecting every request to HTTPS")
+
+	fs.BoolVar(&cfg.BufferRequests, "buffer-requests", cfg.BufferRequests, "Buffer chunked request bodies so backends receive Content-Length")
+	fs.Int64Var(&cfg.BufferMaxSize, "buffer-max-size", cfg.BufferMaxSize, "Max buffered request body in bytes (413 above this)")
+	fs.BoolVar(&cfg.UseH2C, "h2c", cfg.UseH2C, "Use cleartext HTTP/2 to backends that support it")
+	fs.BoolVar(&cfg.RedactConfig, "redact-config", cfg.RedactConfig, "Hide the username and file paths from GET /api/config")
+	fs.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken, "Bearer token for GET /api/snapshot and POST /api/restore (empty disables them)")
+	fs.IntVar(&cfg.AdminPort, "admin-port", cfg.AdminPort, "Serve backend pinning, removal, renames, restarts, label edits, scans, snapshot and restore on 127.0.0.1 at this port only (0 disables)")
+	fs.StringVar(&cfg.BasicAuthUser, "auth-user", cfg.BasicAuthUser, "Require HTTP Basic Auth with this user (needs --auth-pass-hash)")
+	fs.StringVar(&cfg.BasicAuthPass, "auth-pass-hash", cfg.BasicAuthPass, "bcrypt hash of the Basic Auth password, from htpasswd -nbB")
+	fs.BoolVar(&cfg.GRPCEnabled, "grpc", cfg.GRPCEnabled, "Accept cleartext HTTP/2 and forward gRPC requests to backends over HTTP/2")
+	fs.BoolVar(&cfg.ProbeTLS, "probe-tls", cfg.ProbeTLS, "Probe backends over HTTPS before falling back to HTTP")
+	fs.BoolVar(&cfg.ScanIPv6, "ipv6", cfg.ScanIPv6, "Also probe the IPv6 loopback [::1] on ports that do not answer on 127.0.0.1")
+	fs.BoolVar(&cfg.ProbeInsecureSkipVerify, "probe-insecure-skip-verify", cfg.ProbeInsecureSkipVerify, "Skip certificate verification when probing backends over HTTPS")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Minimum log level: debug, info, warn, error")
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log format: text, json")
+	fs.StringVar(&cfg.OTelEndpoint, "otel-endpoint", cfg.OTelEndpoint, "OTLP/HTTP collector for request traces (host:port or URL); empty disables tracing")
+	fs.StringVar(&cfg.LogDir, "log-dir", cfg.LogDir, "Write each launched project's output to {slug}-{port}.log in this directory")
+	fs.StringVar(&cfg.PinnedFile, "pinned-file", cfg.PinnedFile, "JSON file of backends to pin at startup, re-imported when it changes and on SIGHUP")
+	fs.Int64Var(&cfg.MaxLogSize, "max-log-size", cfg.MaxLogSize, "Rotate project logs to {slug}-{port}.log.1 above this many bytes (0 disables)")
+	fs.BoolVar(&cfg.StrictMode, "strict", cfg.StrictMode, "Return 404 JSON for unknown slugs instead of the dashboard")
+	fs.DurationVar(&cfg.DashboardTimeout, "dashboard-timeout", cfg.DashboardTimeout, "Serve a plain-text backend list if the dashboard takes longer than this to render (0 waits)")
+	fs.BoolVar(&cfg.NoInjectHeaders, "no-inject-headers", cfg.NoInjectHeaders, "Don't add X-OpenCode-Slug / X-OpenCode-Router-Version to proxied responses")
+	fs.BoolVar(&cfg.InjectRequestID, "inject-request-id", cfg.InjectRequestID, "Add X-Request-ID to requests that lack one and forward it to the backend")
+	fs.StringVar(&cfg.OpenCodeBinary, "opencode-bin", cfg.OpenCodeBinary, "opencode executable used for project paths (name on PATH or full path)")
+	fs.StringVar(&cfg.RestartPolicy, "restart-policy", cfg.RestartPolicy, "Restart policy for managed projects: never, on-failure, always")
+
+	fs.StringVar(&cfg.Balance, "balance", cfg.Balance, "Strategy for slugs served by several instances: round-robin, first")
+	fs.BoolVar(&cfg.StickySession, "sticky-session", cfg.StickySession, "Pin each client to one instance of a slug with an X-OCR-Sticky cookie")
+	fs.DurationVar(&cfg.StickyMaxAge, "sticky-max-age", cfg.StickyMaxAge, "Lifetime of the --sticky-session cookie")
+	fs.StringVar(&cfg.SlugCollision, "slug-collision", cfg.SlugCollision, "Resolve projects sharing a slug: group, port, path-suffix, error")
+	fs.BoolVar(&cfg.EnableCompression, "compress", cfg.EnableCompression, "Compress API and dashboard responses (gzip or zstd) for clients that accept it")
+	fs.BoolVar(&cfg.BehindProxy, "behind-proxy", cfg.BehindProxy, "Take the client address from X-Forwarded-For / X-Real-IP on requests from --trusted-proxies")
+	fs.BoolVar(&cfg.ProxyProtocol, "proxy-protocol", cfg.ProxyProtocol, "Read the client address from a PROXY protocol header sent by a load balancer such as HAProxy or an AWS NLB")
+	fs.BoolVar(&cfg.AllowRemote, "allow-remote", cfg.AllowRemote, "Bind to all interfaces (0.0.0.0) instead of 127.0.0.1 so other machines can connect")
+}
+
 func parseCLIConfig() (config.Config, []string, bool, error) {
-	cfg := config.Defaults()
-
-	flag.IntVar(&cfg.ListenPort, "port", cfg.ListenPort, "Port for the router to listen on")
-	flag.StringVar(&cfg.Username, "username", cfg.Username, "Username for domain naming (default: OS user)")
-	flag.IntVar(&cfg.ScanPortStart, "scan-start", cfg.ScanPortStart, "Start of port scan range")
-	flag.IntVar(&cfg.ScanPortEnd, "scan-end", cfg.ScanPortEnd, "End of port scan range")
-	flag.IntVar(&cfg.SessionPortStart, "session-port-start", cfg.SessionPortStart, "Start of port range for managed OpenCode session daemons")
-	flag.IntVar(&cfg.SessionPortEnd, "session-port-end", cfg.SessionPortEnd, "End of port range for managed OpenCode session daemons")
-	flag.DurationVar(&cfg.ScanInterval, "scan-interval", cfg.ScanInterval, "How often to scan for instances")
-	flag.IntVar(&cfg.ScanConcurrency, "scan-concurrency", cfg.ScanConcurrency, "Max concurrent port probes")
-	flag.DurationVar(&cfg.ProbeTimeout, "probe-timeout", cfg.ProbeTimeout, "Timeout for each port probe")
-	flag.DurationVar(&cfg.StaleAfter, "stale-after", cfg.StaleAfter, "Remove backends unseen for this duration")
-	flag.BoolVar(&cfg.EnableMDNS, "mdns", cfg.EnableMDNS, "Enable mDNS service advertisement")
+	// Precedence: flags > config file > OPENCODEROUTER_* env > defaults.
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return config.Config{}, nil, false, err
+	}
+
+	bindConfigFlags(flag.CommandLine, &cfg)
 
+	excludePorts := flag.String("exclude-ports", "", "Comma-separated ports the scanner never probes")
+	scanBlocklist := flag.String("scan-blocklist", "", `Comma-separated ports of known non-OpenCode services the scanner never probes (e.g. "30001,30002")`)
+	scanGroups := flag.String("scan-groups", "", `Comma-separated disjoint port ranges to scan instead of --scan-start/--scan-end (e.g. "30000-30999,40000-40099")`)
+	scanExclude := flag.String("scan-exclude", "", `Comma-separated port ranges within the scan range the scanner never probes (e.g. "30500-30600,30800-30850")`)
+	corsOrigins := flag.String("cors-origins", "", `Comma-separated browser origins allowed to call the router cross-origin ("*" for any)`)
+	trustedProxies := flag.String("trusted-proxies", "", "Comma-separated CIDRs of reverse proxies in front of the router (default loopback); requires --behind-proxy")
+	mdnsIfaces := flag.String("mdns-interfaces", "", "Comma-separated interfaces for mDNS (e.g. eth0); default all")
+	watchDirs := flag.String("watch-dirs", "", "Colon-separated project roots to watch; new projects trigger an immediate scan")
+	configFile := flag.String("config", "", "JSON config file (re-read on SIGHUP); explicit flags take precedence")
+	mdnsSubtypes := flag.String("mdns-subtypes", "", `Also advertise backends under DNS-SD subtypes by version prefix, as "prefix=subtype,..." (e.g. "2.=_v2")`)
+	rateLimits := flag.String("rate-limit", "", `Per-slug rate limits as "slug=rps:burst[:ip],..." ("*" matches any slug)`)
 	cleanupOrphans := flag.Bool("cleanup-orphans", false, "Cleanup likely orphan opencode serve processes in scan range on startup")
-	hostname := flag.String("hostname", "0.0.0.0", "Hostname/IP to bind the router to")
+	allowedClients := flag.String("allowed-client-cidrs", "", "Comma-separated CIDRs or IPs allowed to use the router; others get 403 (default any)")
+	var launchDirs listFlag
+	flag.Var(&launchDirs, "launch", "Project directory to run opencode serve in (repeatable or comma-separated); positional arguments are added too")
 
 	flag.Parse()
-	projectPaths := flag.Args()
+	projectPaths := append([]string(launchDirs), flag.Args()...)
+
+	if *configFile != "" {
+		if err := applyConfigFile(&cfg, *configFile); err != nil {
+			return config.Config{}, nil, false, err
+		}
+	}
+
+	cfg.ListenAddr = cfg.BindAddr()
+	if cfg.UnixSocket != "" {
+		cfg.ListenAddr = ""
+	}
+
+	if *corsOrigins != "" {
+		cfg.CORSOrigins = config.SplitList(*corsOrigins)
+	}
+	if *trustedProxies != "" {
+		cfg.TrustedProxies = config.SplitList(*trustedProxies)
+	}
+	if *allowedClients != "" {
+		cfg.AllowedClientCIDRs = config.SplitList(*allowedClients)
+	}
+	if *mdnsIfaces != "" {
+		cfg.MDNSInterfaces = config.SplitList(*mdnsIfaces)
+	}
+	if *excludePorts != "" {
+		ports, err := config.ParsePorts(*excludePorts)
+		if err != nil {
+			return config.Config{}, nil, false, fmt.Errorf("--exclude-ports: %w", err)
+		}
+		cfg.ExcludePorts = ports
+	}
+	if *scanBlocklist != "" {
+		ports, err := config.ParsePorts(*scanBlocklist)
+		if err != nil {
+			return config.Config{}, nil, false, fmt.Errorf("--scan-blocklist: %w", err)
+		}
+		cfg.ScanPortBlocklist = ports
+	}
+	if *scanGroups != "" {
+		groups, err := config.ParsePortRanges(*scanGroups)
+		if err != nil {
+			return config.Config{}, nil, false, fmt.Errorf("--scan-groups: %w", err)
+		}
+		cfg.ScanPortGroups = groups
+	}
+	if *scanExclude != "" {
+		ranges, err := config.ParsePortRanges(*scanExclude)
+		if err != nil {
+			return config.Config{}, nil, false, fmt.Errorf("--scan-exclude: %w", err)
+		}
+		cfg.ScanExcludeRanges = ranges
+	}
+	if *watchDirs != "" {
+		cfg.WatchDirs = filepath.SplitList(*watchDirs)
+	}
+
+	if *mdnsSubtypes != "" {
+		subtypes, err := config.ParseMDNSSubtypes(*mdnsSubtypes)
+		if err != nil {
+			return config.Config{}, nil, false, fmt.Errorf("--mdns-subtypes: %w", err)
+		}
+		cfg.MDNSSubtypes = subtypes
+	}
+
+	limits, err := config.ParseRateLimits(*rateLimits)
+	if err != nil {
+		return config.Config{}, nil, false, err
+	}
+	cfg.RateLimits = limits
 
-	cfg.ListenAddr = fmt.Sprintf("%s:%d", *hostname, cfg.ListenPort)
 	defaultSessionStartOffset := cfg.SessionPortStart - cfg.ScanPortStart
 	defaultSessionEndOffset := cfg.SessionPortEnd - cfg.ScanPortEnd
 
-	sessionStartFlagSet, sessionEndFlagSet := false, false
+	sessionStartFlagSet, sessionEndFlagSet, scanRangeFlagSet := false, false, false
 	flag.Visit(func(f *flag.Flag) {
 		switch f.Name {
 		case "session-port-start":
 			sessionStartFlagSet = true
 		case "session-port-end":
 			sessionEndFlagSet = true
+		case "scan-start", "scan-end":
+			scanRangeFlagSet = true
 		}
 	})
 
+	// Keep the default scan range clear of the OS ephemeral ports unless the
+	// user (or config file) picked one. Detection failures keep the default.
+	defaults := config.Defaults()
+	if !scanRangeFlagSet && cfg.ScanPortStart == defaults.ScanPortStart && cfg.ScanPortEnd == defaults.ScanPortEnd {
+		if start, end, err := config.AutoPortRange(); err == nil {
+			cfg.ScanPortStart, cfg.ScanPortEnd = start, end
+		}
+	}
+
 	if !sessionStartFlagSet {
 		cfg.SessionPortStart = cfg.ScanPortStart + defaultSessionStartOffset
 	}
@@ -55,3 +222,55 @@ func parseCLIConfig() (config.Config, []string, bool, error) {
 
 	return cfg, projectPaths, *cleanupOrphans, nil
 }
+
+// listFlag is a string flag that may be repeated, each value holding one
+// or more comma-separated items.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, config.SplitList(v)...)
+	return nil
+}
+
+// applyConfigFile layers the config file over cfg, then re-applies any flags
+// given explicitly on the command line so they keep precedence.
+func applyConfigFile(cfg *config.Config, path string) error {
+	loaded, err := config.LoadFile(path, *cfg)
+	if err != nil {
+		return err
+	}
+	if err := reapplyFlags(&loaded, explicitFlags()); err != nil {
+		return err
+	}
+	*cfg = loaded
+	cfg.ConfigFile = path
+	return nil
+}
+
+// explicitFlags returns the flags given on the command line, by name.
+func explicitFlags() map[string]string {
+	explicit := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	return explicit
+}
+
+// reapplyFlags sets the config fields of the explicit flags in cfg again,
+// so they win over a config file layered on top. Flags that do not set a
+// field directly, such as the comma-separated lists, are left out.
+func reapplyFlags(cfg *config.Config, explicit map[string]string) error {
+	fs := flag.NewFlagSet("reapply", flag.ContinueOnError)
+	bindConfigFlags(fs, cfg)
+	for name, value := range explicit {
+		if fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("re-apply flag --%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package admin serves the router's privileged registry operations on a
+// separate loopback-only port, away from the public /api endpoints.
+package admin
+
+import (
+	"net/http"
+
+	"opencoderouter/internal/proxy"
+)
+
+// Config holds the admin handler's dependencies.
+type Config struct {
+	// Routes serves the endpoints, see proxy.Router.AdminRoutes; nil
+	// answers every request with 404.
+	Routes http.Handler
+	// Token, when set, is a bearer token every request must carry. The
+	// loopback binding is the only guard without it; snapshot and restore
+	// stay disabled until one is set.
+	Token string
+}
+
+// Handler serves the admin API, checking Config.Token in front of
+// Config.Routes.
+type Handler struct {
+	routes http.Handler
+	token  string
+}
+
+// NewHandler returns the admin API handler.
+func NewHandler(cfg Config) *Handler {
+	return &Handler{routes: cfg.Routes, token: cfg.Token}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" && !proxy.HasBearerToken(r, h.token) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="opencoderouter-admin"`)
+		http.Error(w, "invalid or missing admin token", http.StatusUnauthorized)
+		return
+	}
+	if h.routes == nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.routes.ServeHTTP(w, r)
+}
@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/proxy"
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+// newAdminServer serves reg on an admin port in front of a router with cfg.
+// The returned main server is the router's own listener.
+func newAdminServer(t *testing.T, reg *registry.Registry, cfg config.Config, opts ...proxy.Option) (main, admin *httptest.Server) {
+	t.Helper()
+	if cfg.AdminPort == 0 {
+		cfg.AdminPort = 9090
+	}
+	rt := proxy.New(reg, cfg, testLogger(), nil, opts...)
+	main = httptest.NewServer(rt)
+	t.Cleanup(main.Close)
+	admin = httptest.NewServer(NewHandler(Config{Routes: rt.AdminRoutes(), Token: cfg.AdminToken}))
+	t.Cleanup(admin.Close)
+	return main, admin
+}
+
+func testConfig() config.Config {
+	cfg := config.Defaults()
+	cfg.Username = "testuser"
+	return cfg
+}
+
+func newTestRegistry() *registry.Registry {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(30000, "alpha", "/home/test/alpha", "1.0")
+	reg.Upsert(30001, "beta", "/home/test/beta", "1.0")
+	return reg
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestDeleteOnlyOnAdminPort(t *testing.T) {
+	reg := newTestRegistry()
+	main, adminSrv := newAdminServer(t, reg, testConfig())
+
+	resp := do(t, http.MethodDelete, main.URL+"/api/backends/alpha", "", nil)
+	if resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("main port DELETE status = %d, want 404 or 405", resp.StatusCode)
+	}
+	if _, ok := reg.Lookup("alpha"); !ok {
+		t.Fatal("the main port must not remove backends when the admin port is enabled")
+	}
+	for _, path := range []string{"/api/scan", "/api/restore"} {
+		if resp := do(t, http.MethodPost, main.URL+path, "", nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("main port POST %s status = %d, want 404", path, resp.StatusCode)
+		}
+	}
+
+	resp = do(t, http.MethodDelete, adminSrv.URL+"/api/backends/alpha", "", nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("admin port DELETE status = %d, want 204", resp.StatusCode)
+	}
+	if _, ok := reg.Lookup("alpha"); ok {
+		t.Error("alpha should be removed")
+	}
+
+	resp = do(t, http.MethodDelete, adminSrv.URL+"/api/backends/alpha", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("second DELETE status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestBackendMutationsOnlyOnAdminPort(t *testing.T) {
+	reg := newTestRegistry()
+	main, adminSrv := newAdminServer(t, reg, testConfig())
+
+	for _, tc := range []struct{ path, body string }{
+		{"/api/backends", `{"port":30002,"project_path":"/home/test/gamma"}`},
+		{"/api/backends/alpha/rename", `{"new_slug":"renamed"}`},
+		{"/api/backends/alpha/restart", ""},
+	} {
+		if resp := do(t, http.MethodPost, main.URL+tc.path, tc.body, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("main port POST %s status = %d, want 404", tc.path, resp.StatusCode)
+		}
+	}
+	if resp := do(t, http.MethodGet, main.URL+"/api/backends", "", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("main port GET /api/backends status = %d, want 200", resp.StatusCode)
+	}
+	if _, ok := reg.Lookup("alpha"); !ok {
+		t.Fatal("the main port must not rename backends when the admin port is enabled")
+	}
+
+	resp := do(t, http.MethodPost, adminSrv.URL+"/api/backends/alpha/rename", `{"new_slug":"renamed"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("admin port rename status = %d, want 200", resp.StatusCode)
+	}
+	if _, ok := reg.Lookup("renamed"); !ok {
+		t.Error("alpha should be renamed")
+	}
+}
+
+func TestMainPortKeepsEndpointsWithoutAdminPort(t *testing.T) {
+	main := httptest.NewServer(proxy.New(newTestRegistry(), testConfig(), testLogger(), nil))
+	defer main.Close()
+
+	if resp := do(t, http.MethodDelete, main.URL+"/api/backends/alpha", "", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want 204", resp.StatusCode)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	reg := newTestRegistry()
+	_, srv := newAdminServer(t, reg, testConfig())
+
+	resp := do(t, http.MethodPut, srv.URL+"/api/backends/beta/labels", `{"team":"infra","env":"dev"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT labels status = %d, want 200", resp.StatusCode)
+	}
+	resp = do(t, http.MethodPut, srv.URL+"/api/backends/beta/labels", `{"env":""}`, nil)
+	var body struct {
+		Slug   string            `json:"slug"`
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Slug != "beta" || len(body.Labels) != 1 || body.Labels["team"] != "infra" {
+		t.Errorf("response = %+v, want only team=infra", body)
+	}
+	if b, _ := reg.Lookup("beta"); b.Labels["team"] != "infra" || b.Labels["env"] != "" {
+		t.Errorf("registry labels = %v", b.Labels)
+	}
+
+	if resp := do(t, http.MethodPut, srv.URL+"/api/backends/missing/labels", `{"a":"b"}`, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown slug status = %d, want 404", resp.StatusCode)
+	}
+	if resp := do(t, http.MethodPut, srv.URL+"/api/backends/beta/labels", `[1]`, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid body status = %d, want 400", resp.StatusCode)
+	}
+	if resp := do(t, http.MethodGet, srv.URL+"/api/backends/beta/labels", "", nil); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET labels status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestScan(t *testing.T) {
+	reg := newTestRegistry()
+	sc := scanner.New(reg, 1, 0, time.Hour, 1, time.Second, testLogger()) // empty range
+	_, srv := newAdminServer(t, reg, testConfig(), proxy.WithScanner(sc))
+
+	resp := do(t, http.MethodPost, srv.URL+"/api/scan", "", nil)
+	var body struct {
+		ScanID string `json:"scan_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, err = %v; want 202", resp.StatusCode, err)
+	}
+	if _, ok := sc.ScanStatus(body.ScanID); !ok {
+		t.Errorf("scan %q was not started", body.ScanID)
+	}
+
+	_, noScanner := newAdminServer(t, newTestRegistry(), testConfig())
+	if resp := do(t, http.MethodPost, noScanner.URL+"/api/scan", "", nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("without a scanner status = %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	cfg := testConfig()
+	cfg.AdminToken = "s3cret"
+	token := http.Header{"Authorization": {"Bearer s3cret"}}
+	_, srcSrv := newAdminServer(t, newTestRegistry(), cfg)
+
+	resp := do(t, http.MethodGet, srcSrv.URL+"/api/snapshot", "", token)
+	snapshot, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("snapshot status = %d, err = %v", resp.StatusCode, err)
+	}
+
+	target := registry.New(30*time.Second, testLogger())
+	_, dstSrv := newAdminServer(t, target, cfg)
+	if resp := do(t, http.MethodPost, dstSrv.URL+"/api/restore", string(snapshot), token); resp.StatusCode != http.StatusOK {
+		t.Fatalf("restore status = %d, want 200", resp.StatusCode)
+	}
+	if target.Len() != 2 {
+		t.Errorf("restored %d backends, want 2", target.Len())
+	}
+	if resp := do(t, http.MethodPost, dstSrv.URL+"/api/restore", "not json", token); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid snapshot status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestSnapshotRestoreNeedToken(t *testing.T) {
+	reg := newTestRegistry()
+	_, srv := newAdminServer(t, reg, testConfig())
+
+	if resp := do(t, http.MethodGet, srv.URL+"/api/snapshot", "", nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("snapshot without --admin-token status = %d, want 403", resp.StatusCode)
+	}
+	if resp := do(t, http.MethodPost, srv.URL+"/api/restore", `{"backends":[]}`, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("restore without --admin-token status = %d, want 403", resp.StatusCode)
+	}
+	if reg.Len() != 2 {
+		t.Errorf("registry has %d backends after a refused restore, want 2", reg.Len())
+	}
+}
+
+func TestToken(t *testing.T) {
+	cfg := testConfig()
+	cfg.AdminToken = "s3cret"
+	_, srv := newAdminServer(t, newTestRegistry(), cfg)
+
+	if resp := do(t, http.MethodGet, srv.URL+"/api/snapshot", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token status = %d, want 401", resp.StatusCode)
+	}
+	wrong := http.Header{"Authorization": {"Bearer nope"}}
+	if resp := do(t, http.MethodGet, srv.URL+"/api/snapshot", "", wrong); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong token status = %d, want 401", resp.StatusCode)
+	}
+	right := http.Header{"Authorization": {"Bearer s3cret"}}
+	if resp := do(t, http.MethodGet, srv.URL+"/api/snapshot", "", right); resp.StatusCode != http.StatusOK {
+		t.Errorf("valid token status = %d, want 200", resp.StatusCode)
+	}
+	if resp := do(t, http.MethodPut, srv.URL+"/api/backends/beta/labels", `{"a":"b"}`, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("labels without token status = %d, want 401", resp.StatusCode)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"opencoderouter/internal/auth"
 	"opencoderouter/internal/cache"
+	"opencoderouter/internal/middleware"
 	"opencoderouter/internal/session"
 	"opencoderouter/internal/terminal"
 )
@@ -16,6 +17,9 @@ type RouterConfig struct {
 	AuthConfig            auth.Config
 	ScrollbackCache       cache.ScrollbackCache
 	Fallback              http.Handler
+	// CORS, when set, wraps the whole API and replaces the CORS handling of
+	// AuthConfig, so the API and Fallback share one cross-origin policy.
+	CORS middleware.Middleware
 }
 
 func NewRouter(cfg RouterConfig) http.Handler {
@@ -50,5 +54,9 @@ func NewRouter(cfg RouterConfig) http.Handler {
 		authCfg.BasicAuth = map[string]string{}
 	}
 
+	if cfg.CORS != nil {
+		authCfg.DisableCORS = true
+		return cfg.CORS(auth.Middleware(mux, authCfg))
+	}
 	return auth.Middleware(mux, authCfg)
 }
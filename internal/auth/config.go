@@ -18,6 +18,9 @@ type Config struct {
 	BasicAuth          map[string]string
 	CORSAllowedOrigins []string
 	BypassPaths        map[string]struct{}
+	// DisableCORS leaves CORS headers and OPTIONS requests to an outer
+	// middleware; CORSAllowedOrigins is then unused.
+	DisableCORS bool
 }
 
 func Defaults() Config {
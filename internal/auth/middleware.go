@@ -56,6 +56,9 @@ func withRateLimit(next http.Handler, limiter RateLimiter) http.Handler {
 }
 
 func withCORS(next http.Handler, cfg Config) http.Handler {
+	if cfg.DisableCORS {
+		return next
+	}
 	allowed := cfg.CORSAllowedOrigins
 	if len(allowed) == 0 {
 		allowed = []string{"*"}
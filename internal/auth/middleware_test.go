@@ -136,6 +136,29 @@ func TestMiddleware_CORSAllowlist(t *testing.T) {
 	}
 }
 
+func TestMiddleware_DisableCORS(t *testing.T) {
+	cfg := Defaults()
+	cfg.DisableCORS = true
+
+	called := false
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}), cfg)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/health", nil)
+	req.Header.Set("Origin", "https://any.example")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if !called || w.Code != http.StatusOK {
+		t.Fatalf("expected OPTIONS to reach the handler, got %d (called %v)", w.Code, called)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers, got %q", got)
+	}
+}
+
 func TestMiddleware_SetsRequestIDHeader(t *testing.T) {
 	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
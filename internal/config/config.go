@@ -3,37 +3,469 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
+	"os"
 	"os/user"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Config holds all router configuration.
 type Config struct {
-	// ListenPort is the port the router listens on.
+	// ListenPort is the port the router listens on. Zero lets the OS pick a
+	// free port; Listen then records the port it was given.
 	ListenPort int
-	// ListenAddr is the full bind address (e.g. "0.0.0.0:8080").
+	// ListenAddr is the full bind address (e.g. "127.0.0.1:8080"). See
+	// BindAddr.
 	ListenAddr string
+	// AllowRemote binds the router to all interfaces instead of loopback,
+	// so other machines can reach it.
+	AllowRemote bool
+	// AllowedClientCIDRs, when set, limits clients to these CIDRs (or bare
+	// IPs); others get 403 Forbidden.
+	AllowedClientCIDRs []string
+	// UnixSocket, when set, replaces the TCP listener with a unix domain
+	// socket at this path. ListenPort is still advertised over mDNS.
+	UnixSocket string
+	// PortFile, when set, receives the TCP port the router bound, as a
+	// decimal string, once it is listening.
+	PortFile string
+	// PortDir, when set, gets one file per backend slug holding
+	// "127.0.0.1:{port}", rewritten after every scan, for shell scripts.
+	PortDir string
 	// Username is the OS username of the server runner.
 	// Used in domain naming and to filter discovered instances.
 	Username string
+	// UsernameFromPath names each backend's domain after the directory
+	// holding its project, e.g. /home/alice/proj → "proj-alice.local",
+	// instead of Username. For containers where every project runs as root.
+	UsernameFromPath bool
 	// ScanPortStart is the beginning of the port range to scan (inclusive).
 	ScanPortStart int
 	// ScanPortEnd is the end of the port range to scan (inclusive).
-	ScanPortEnd      int
-	SessionPortStart int
-	SessionPortEnd   int
+	ScanPortEnd int
+	// ScanPortGroups, when set, are scanned instead of the
+	// ScanPortStart-ScanPortEnd range, for instances spread over disjoint
+	// ranges with a wide gap between them. Groups must not overlap.
+	ScanPortGroups []PortRange
+	// ExcludePorts are never probed by the scanner. See ScanExcludedPorts.
+	ExcludePorts []int
+	// ScanPortBlocklist lists ports of known non-OpenCode services, such as
+	// IoT devices or Redis sentinels, that the scanner never probes.
+	ScanPortBlocklist []int
+	// ScanExcludeRanges are sub-ranges of the scan range that are never
+	// probed, e.g. ports reserved for another service.
+	ScanExcludeRanges []PortRange
+	SessionPortStart  int
+	SessionPortEnd    int
 	// ScanInterval controls how often the scanner runs.
 	ScanInterval time.Duration
 	// ScanConcurrency is the max number of concurrent port probes.
 	ScanConcurrency int
+	// ScanConcurrencyAuto ignores ScanConcurrency and sizes probe
+	// concurrency from the CPU count, backing off when the CPU is busy.
+	ScanConcurrencyAuto bool
+	// DryRun makes the scanner log the backends it finds without registering
+	// them, and stops stale backends from being pruned.
+	DryRun bool
+	// PassiveScan stops the scanner from sweeping the port range: backends
+	// register themselves with POST /api/register, and scans re-probe only
+	// the registered ports.
+	PassiveScan bool
 	// ProbeTimeout is the HTTP timeout for each port probe.
 	ProbeTimeout time.Duration
+	// ProbeRetries is how many more times a health check that failed with
+	// a transient error (a timeout, reset connection or 5xx) is retried.
+	ProbeRetries int
+	// ProbeRetryDelay is the wait before each retry, with 50% jitter.
+	ProbeRetryDelay time.Duration
+	// WatchDirs are project root directories watched for new projects; a
+	// change triggers an immediate scan instead of waiting for ScanInterval.
+	WatchDirs []string
 	// StaleAfter is how long a backend can go unseen before removal.
 	StaleAfter time.Duration
 	// EnableMDNS controls mDNS service advertisement.
 	EnableMDNS bool
+	// HostSuffix ends the hostnames used for host-based routing,
+	// "{slug}-{username}{suffix}". mDNS only resolves ".local".
+	HostSuffix string
 	// MDNSServiceType is the DNS-SD service type to advertise.
 	MDNSServiceType string
+	// MDNSInterfaces restricts mDNS advertising and browsing to these
+	// interface names (e.g. "eth0"). Empty means all interfaces.
+	MDNSInterfaces []string
+	// MDNSSRVPriority and MDNSSRVWeight are the DNS-SD priority and weight
+	// advertised for each backend (RFC 2782: lower priority is preferred,
+	// weight splits load within a priority). A backend's "mdns_priority"
+	// and "mdns_weight" labels override them.
+	MDNSSRVPriority int
+	MDNSSRVWeight   int
+	// MDNSSubtypes maps a version prefix (e.g. "2.") to a DNS-SD subtype
+	// (e.g. "_v2"). A backend whose version starts with the prefix is also
+	// advertised under "_v2._sub." plus MDNSServiceType.
+	MDNSSubtypes map[string]string
+	// PeerTimeout is how long a project advertised by another router is
+	// kept without being re-announced.
+	PeerTimeout time.Duration
+	// ConsulAddr is a Consul agent address (e.g. "localhost:8500"). When set,
+	// backends are also registered as Consul services. Empty disables Consul.
+	ConsulAddr string
+	// AccessLog enables a structured JSON record for every proxied request.
+	AccessLog bool
+	// AccessLogFile is where access records are written. Empty means stderr.
+	AccessLogFile string
+	// TraceFile, when set, receives a dump of every proxied request and
+	// response, headers and bodies, for debugging. TraceSlug restricts it
+	// to slugs matching a glob (path.Match syntax); empty traces every slug.
+	TraceFile string
+	TraceSlug string
+	// TUI shows a live terminal dashboard of the backends instead of the
+	// startup access info.
+	TUI bool
+	// TLSEnabled serves HTTPS instead of HTTP.
+	TLSEnabled bool
+	// TLSCert and TLSKey are PEM file paths. When both are empty and TLS is
+	// enabled, an ephemeral self-signed certificate is generated.
+	TLSCert string
+	TLSKey  string
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header
+	// sent on every response while TLS is enabled. Zero omits the header.
+	HSTSMaxAge time.Duration
+	// HTTPRedirectPort, when set with TLS, also listens for plain HTTP on
+	// this port and answers every request with a 301 to HTTPS.
+	HTTPRedirectPort int
+	// RateLimits maps a backend slug to its token-bucket limit. The key
+	// RateLimitDefaultKey applies to slugs without an explicit entry.
+	RateLimits map[string]RateLimit
+	// CORSOrigins are the browser origins (e.g. "https://app.example.com")
+	// allowed to call the router cross-origin; "*" allows any. A backend's
+	// "cors_origin" label replaces the list for that backend.
+	CORSOrigins []string
+	// EnableCompression gzip- or zstd-encodes API and dashboard responses of
+	// at least 1 KB for clients that accept it. Proxied responses are never
+	// re-encoded.
+	EnableCompression bool
+	// BehindProxy takes the client address from X-Forwarded-For or
+	// X-Real-IP on requests that come from one of TrustedProxies.
+	BehindProxy bool
+	// ProxyProtocol reads a PROXY protocol header, as sent by HAProxy or an
+	// AWS NLB, at the start of each connection to the listen address and
+	// takes the client address from it.
+	ProxyProtocol bool
+	// TrustedProxies are the CIDRs (or bare IPs) of reverse proxies in front
+	// of the router. Empty means loopback only.
+	TrustedProxies []string
+	// RestartPolicy controls relaunching of launcher-managed processes:
+	// "never", "on-failure" or "always".
+	RestartPolicy string
+	// Balance selects how requests are spread across instances sharing a
+	// slug: "round-robin" or "first".
+	Balance string
+	// StickySession pins each client to the instance that served its first
+	// request, via an X-OCR-Sticky cookie holding the backend port.
+	StickySession bool
+	// StickyMaxAge is the lifetime of the sticky cookie.
+	StickyMaxAge time.Duration
+	// SlugCollision resolves two projects with the same slug: "group"
+	// (balance across both), "port", "path-suffix" or "error".
+	SlugCollision string
+	// BufferRequests reads request bodies of unknown length into memory before
+	// proxying so the backend receives a Content-Length header.
+	BufferRequests bool
+	// BufferMaxSize caps buffered bodies in bytes; larger requests get 413.
+	BufferMaxSize int64
+	// UseH2C proxies to backends over cleartext HTTP/2 when the scanner has
+	// seen them accept an h2c upgrade.
+	UseH2C bool
+	// GRPCEnabled accepts cleartext HTTP/2 from clients and forwards gRPC
+	// (Content-Type application/grpc) to backends over HTTP/2.
+	GRPCEnabled bool
+	// OTelEndpoint is the OTLP/HTTP collector for proxy traces. Empty disables tracing.
+	OTelEndpoint string
+	// ConfigFile is the JSON file the config was loaded from, re-read on SIGHUP.
+	ConfigFile string
+	// LogLevel is the minimum level written to the debug log: "debug",
+	// "info", "warn" or "error".
+	LogLevel string
+	// LogFormat is the debug log encoding: "text" or "json".
+	LogFormat string
+	// HealthPath is the endpoint the scanner probes for health on each port.
+	HealthPath string
+	// ProjectPath is the endpoint the scanner queries for project metadata.
+	ProjectPath string
+	// ProbeUserAgent is the User-Agent header sent on scanner probes.
+	ProbeUserAgent string
+	// ProbeTLS makes the scanner try HTTPS on each port before falling back
+	// to HTTP. Backends found over HTTPS are also proxied over HTTPS.
+	ProbeTLS bool
+	// ProbeInsecureSkipVerify skips certificate verification when probing
+	// over HTTPS, for local backends with self-signed certificates.
+	ProbeInsecureSkipVerify bool
+	// ScanIPv6 makes the scanner also probe [::1] on ports that do not
+	// answer on 127.0.0.1, for backends bound to the IPv6 loopback only.
+	ScanIPv6 bool
+	// PinnedFile is a JSON list of backends registered at startup and again
+	// whenever the file changes or on SIGHUP, pinned so they are never
+	// pruned. Empty disables it.
+	PinnedFile string
+	// LogDir receives "{slug}.log" with the output of each launched
+	// opencode serve process. Empty discards it.
+	LogDir string
+	// OpenCodeBinary is the opencode executable launched for project paths.
+	OpenCodeBinary string
+	// StrictMode answers unknown path slugs with a JSON 404 instead of
+	// falling through to the dashboard.
+	StrictMode bool
+	// DashboardTimeout bounds rendering the dashboard page; past it a plain
+	// text list of backends is served instead. Zero waits indefinitely.
+	DashboardTimeout time.Duration
+	// NoInjectHeaders stops the proxy from adding X-OpenCode-Slug and
+	// X-OpenCode-Router-Version to proxied responses.
+	NoInjectHeaders bool
+	// InjectRequestID gives every request an X-Request-ID, keeping one sent
+	// by the client, and forwards it to the backend and back on the response.
+	InjectRequestID bool
+	// DrainTimeout is how long shutdown waits for in-flight proxied requests
+	// before stopping managed backends and the HTTP server.
+	DrainTimeout time.Duration
+	// DrainPeriod is how long a stale backend stays listed, but receives no
+	// new requests, before it is removed. Zero removes it at once.
+	DrainPeriod time.Duration
+	// RedactConfig hides private values such as the username and file paths
+	// from GET /api/config.
+	RedactConfig bool
+	// BasicAuthUser and BasicAuthPass, a bcrypt hash (htpasswd -nbB), put
+	// HTTP Basic Auth in front of everything but GET /api/health and the
+	// admin-token endpoints. Empty disables it.
+	BasicAuthUser string
+	BasicAuthPass string
+	// AdminToken is the bearer token required by GET /api/snapshot and
+	// POST /api/restore. Empty disables both endpoints.
+	AdminToken string
+	// AdminPort, when set, serves the privileged registry endpoints on
+	// 127.0.0.1:AdminPort and withdraws them from the main listener.
+	AdminPort int
+	// MaxLogSize is the size in bytes at which a process log is rotated to
+	// "{slug}.log.1". Zero disables rotation.
+	MaxLogSize int64
+}
+
+// DefaultMaxLogSize is the default rotation threshold for process logs (10 MB).
+const DefaultMaxLogSize = 10 << 20
+
+// Default OpenCode probe endpoints.
+const (
+	DefaultHealthPath  = "/global/health"
+	DefaultProjectPath = "/project/current"
+)
+
+// DefaultProbeUserAgent identifies scanner probes to backends.
+const DefaultProbeUserAgent = "OpenCodeRouter/1.0 scanner"
+
+// DefaultBufferMaxSize is the default limit for buffered request bodies (10 MB).
+const DefaultBufferMaxSize = 10 << 20
+
+// Defaults for retrying health checks that fail transiently.
+const (
+	DefaultProbeRetries    = 2
+	DefaultProbeRetryDelay = 100 * time.Millisecond
+)
+
+// DefaultDashboardTimeout is how long the dashboard page may take to render.
+const DefaultDashboardTimeout = 2 * time.Second
+
+// DefaultHSTSMaxAge is how long browsers are told to insist on HTTPS once
+// they have seen the router over TLS.
+const DefaultHSTSMaxAge = 365 * 24 * time.Hour
+
+// DefaultHostSuffix is the mDNS domain used for host-based routing.
+const DefaultHostSuffix = ".local"
+
+// DefaultMDNSSRVWeight is the default DNS-SD weight for advertised backends.
+const DefaultMDNSSRVWeight = 100
+
+// ParseMDNSSubtypes parses a comma-separated list of "prefix=subtype"
+// entries, e.g. "2.=_v2,3.=_v3".
+func ParseMDNSSubtypes(raw string) (map[string]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	subtypes := make(map[string]string)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, subtype, ok := strings.Cut(entry, "=")
+		prefix, subtype = strings.TrimSpace(prefix), strings.TrimSpace(subtype)
+		if !ok || prefix == "" || subtype == "" {
+			return nil, fmt.Errorf("mDNS subtype %q: expected prefix=subtype", entry)
+		}
+		subtypes[prefix] = subtype
+	}
+	return subtypes, nil
+}
+
+// RateLimitDefaultKey is the RateLimits key that applies to every slug
+// without its own entry.
+const RateLimitDefaultKey = "*"
+
+// RateLimit is a token-bucket limit for requests proxied to one backend.
+type RateLimit struct {
+	// RequestsPerSecond is the bucket refill rate.
+	RequestsPerSecond float64
+	// Burst is the bucket capacity.
+	Burst int
+	// PerClientIP gives each client IP its own bucket instead of sharing one per slug.
+	PerClientIP bool
+}
+
+// ParseRateLimits parses a comma-separated list of "slug=rps:burst[:ip]"
+// entries, e.g. "myproject=5:10,*=20:40:ip".
+func ParseRateLimits(raw string) (map[string]RateLimit, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		slug, spec, ok := strings.Cut(entry, "=")
+		slug = strings.TrimSpace(slug)
+		if !ok || slug == "" {
+			return nil, fmt.Errorf("rate limit %q: expected slug=rps:burst", entry)
+		}
+
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("rate limit %q: expected slug=rps:burst[:ip]", entry)
+		}
+		rps, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: invalid rps: %w", entry, err)
+		}
+		burst, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: invalid burst: %w", entry, err)
+		}
+		limit := RateLimit{RequestsPerSecond: rps, Burst: burst}
+		if len(parts) == 3 {
+			if strings.TrimSpace(parts[2]) != "ip" {
+				return nil, fmt.Errorf("rate limit %q: unknown modifier %q", entry, parts[2])
+			}
+			limit.PerClientIP = true
+		}
+		limits[slug] = limit
+	}
+	return limits, nil
+}
+
+// DefaultTrustedProxies are trusted when BehindProxy is set without any
+// TrustedProxies: a reverse proxy on the same host.
+var DefaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// ParseCIDRs parses CIDR strings such as "10.0.0.0/8". A bare IP address is
+// taken as a single-host network.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, raw := range cidrs {
+		raw = strings.TrimSpace(raw)
+		if ip := net.ParseIP(raw); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", raw)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// PortRange is an inclusive range of TCP ports.
+type PortRange struct {
+	Start, End int
+}
+
+// Contains reports whether port lies in the range.
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// String formats the range as "start-end".
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// validatePortGroups checks that each scan group is a valid port range and
+// that no two groups overlap.
+func validatePortGroups(groups []PortRange) error {
+	for _, g := range groups {
+		if g.Start < 1 || g.End > 65535 {
+			return fmt.Errorf("scan group %s must lie within 1-65535", g)
+		}
+		if g.End < g.Start {
+			return fmt.Errorf("scan group %s: end must be >= start", g)
+		}
+	}
+	sorted := slices.Clone(groups)
+	slices.SortFunc(sorted, func(a, b PortRange) int { return a.Start - b.Start })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start <= sorted[i-1].End {
+			return fmt.Errorf("scan groups %s and %s overlap", sorted[i-1], sorted[i])
+		}
+	}
+	return nil
+}
+
+// ParsePortRanges parses a comma-separated list of "start-end" ranges, e.g.
+// "30500-30600,30800-30850". A single port is a one-port range.
+func ParsePortRanges(raw string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, item := range SplitList(raw) {
+		lo, hi, isRange := strings.Cut(item, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid port range %q: %w", item, err)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("invalid port range %q: %w", item, err)
+			}
+		}
+		ranges = append(ranges, PortRange{Start: start, End: end})
+	}
+	return ranges, nil
+}
+
+// ParsePorts parses a comma-separated list of port numbers, e.g. "4100,4102".
+func ParsePorts(raw string) ([]int, error) {
+	var ports []int
+	for _, item := range SplitList(raw) {
+		port, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", item, err)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
 }
 
 // Defaults returns a Config with sensible defaults.
@@ -43,30 +475,66 @@ func Defaults() Config {
 		username = u.Username
 	}
 
-	scanStart := 30000
-	scanEnd := 31000
+	scanStart := defaultScanPortStart
+	scanEnd := defaultScanPortEnd
 
 	return Config{
-		ListenPort:       8080,
-		ListenAddr:       "0.0.0.0:8080",
-		Username:         username,
-		ScanPortStart:    scanStart,
-		ScanPortEnd:      scanEnd,
-		SessionPortStart: scanStart + 100,
-		SessionPortEnd:   scanEnd + 100,
-		ScanInterval:     5 * time.Second,
-		ScanConcurrency:  20,
-		ProbeTimeout:     800 * time.Millisecond,
-		StaleAfter:       30 * time.Second,
-		EnableMDNS:       true,
-		MDNSServiceType:  "_opencode._tcp",
+		ListenPort:              8080,
+		ListenAddr:              "127.0.0.1:8080",
+		Username:                username,
+		ScanPortStart:           scanStart,
+		ScanPortEnd:             scanEnd,
+		SessionPortStart:        scanStart + sessionPortOffset,
+		SessionPortEnd:          scanEnd + sessionPortOffset,
+		ScanInterval:            5 * time.Second,
+		ScanConcurrency:         20,
+		ProbeTimeout:            800 * time.Millisecond,
+		ProbeRetries:            DefaultProbeRetries,
+		ProbeRetryDelay:         DefaultProbeRetryDelay,
+		DashboardTimeout:        DefaultDashboardTimeout,
+		StaleAfter:              30 * time.Second,
+		EnableMDNS:              true,
+		HostSuffix:              DefaultHostSuffix,
+		MDNSServiceType:         "_opencode._tcp",
+		MDNSSRVWeight:           DefaultMDNSSRVWeight,
+		PeerTimeout:             60 * time.Second,
+		RestartPolicy:           "never",
+		Balance:                 "round-robin",
+		StickyMaxAge:            time.Hour,
+		SlugCollision:           "group",
+		BufferMaxSize:           DefaultBufferMaxSize,
+		LogLevel:                "debug",
+		LogFormat:               "text",
+		HealthPath:              DefaultHealthPath,
+		ProjectPath:             DefaultProjectPath,
+		ProbeUserAgent:          DefaultProbeUserAgent,
+		ProbeInsecureSkipVerify: true,
+		MaxLogSize:              DefaultMaxLogSize,
+		OpenCodeBinary:          "opencode",
+		DrainTimeout:            10 * time.Second,
+		DrainPeriod:             10 * time.Second,
+		HSTSMaxAge:              DefaultHSTSMaxAge,
+		InjectRequestID:         true,
+		EnableCompression:       true,
 	}
 }
 
 // Validate checks the config for obvious errors.
 func (c *Config) Validate() error {
-	if c.ListenPort < 1 || c.ListenPort > 65535 {
-		return fmt.Errorf("listen port must be 1-65535, got %d", c.ListenPort)
+	if c.ListenPort < 0 || c.ListenPort > 65535 {
+		return fmt.Errorf("listen port must be 0-65535, got %d", c.ListenPort)
+	}
+	if c.ListenAddr == "" && c.UnixSocket == "" {
+		return fmt.Errorf("one of listen address or unix socket must be set")
+	}
+	if c.ListenAddr != "" && c.UnixSocket != "" {
+		return fmt.Errorf("listen address and unix socket are mutually exclusive")
+	}
+	if c.UnixSocket != "" && c.ListenPort == 0 {
+		return fmt.Errorf("listen port 0 (OS-assigned) needs a TCP listener, not a unix socket")
+	}
+	if c.UnixSocket != "" && c.PortFile != "" {
+		return fmt.Errorf("port file needs a TCP listener, not a unix socket")
 	}
 	if c.ScanPortStart < 1 || c.ScanPortStart > 65535 {
 		return fmt.Errorf("scan port start must be 1-65535, got %d", c.ScanPortStart)
@@ -77,6 +545,33 @@ func (c *Config) Validate() error {
 	if c.ScanPortEnd > 65535 {
 		return fmt.Errorf("scan port end must be <= 65535, got %d", c.ScanPortEnd)
 	}
+	if c.ProbeRetries < 0 {
+		return fmt.Errorf("probe retries must be >= 0, got %d", c.ProbeRetries)
+	}
+	if c.ProbeRetryDelay < 0 {
+		return fmt.Errorf("probe retry delay must be >= 0, got %s", c.ProbeRetryDelay)
+	}
+	for _, port := range c.ExcludePorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("excluded port must be 1-65535, got %d", port)
+		}
+	}
+	for _, port := range c.ScanPortBlocklist {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("blocklisted port must be 1-65535, got %d", port)
+		}
+	}
+	if err := validatePortGroups(c.ScanPortGroups); err != nil {
+		return err
+	}
+	for _, r := range c.ScanExcludeRanges {
+		if r.End < r.Start {
+			return fmt.Errorf("excluded range %s: end must be >= start", r)
+		}
+		if !c.scanRangeContains(r) {
+			return fmt.Errorf("excluded range %s must lie within the scan range %s", r, c.ScanRangeString())
+		}
+	}
 	if c.SessionPortStart < 1 || c.SessionPortStart > 65535 {
 		return fmt.Errorf("session port start must be 1-65535, got %d", c.SessionPortStart)
 	}
@@ -89,16 +584,230 @@ func (c *Config) Validate() error {
 	if c.Username == "" {
 		return fmt.Errorf("username must not be empty")
 	}
+	if len(c.HostSuffix) < 2 || c.HostSuffix[0] != '.' || strings.ContainsAny(c.HostSuffix, ":/ ") {
+		return fmt.Errorf("host suffix must be a domain starting with \".\", e.g. \".internal\", got %q", c.HostSuffix)
+	}
 	if c.ScanInterval < 1*time.Second {
 		return fmt.Errorf("scan interval must be >= 1s, got %s", c.ScanInterval)
 	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("tls cert and key must be provided together")
+	}
+	if c.DashboardTimeout < 0 {
+		return fmt.Errorf("dashboard timeout must be >= 0, got %s", c.DashboardTimeout)
+	}
+	if c.HSTSMaxAge < 0 {
+		return fmt.Errorf("hsts max age must be >= 0, got %s", c.HSTSMaxAge)
+	}
+	if c.HTTPRedirectPort < 0 || c.HTTPRedirectPort > 65535 {
+		return fmt.Errorf("http redirect port must be 0-65535, got %d", c.HTTPRedirectPort)
+	}
+	if c.HTTPRedirectPort != 0 {
+		switch {
+		case !c.TLSEnabled:
+			return fmt.Errorf("http redirect port requires TLS to be enabled")
+		case c.UnixSocket != "":
+			return fmt.Errorf("http redirect port cannot be used with a unix socket")
+		case c.HTTPRedirectPort == c.ListenPort:
+			return fmt.Errorf("http redirect port must differ from the listen port %d", c.ListenPort)
+		}
+	}
+	if c.AdminPort < 0 || c.AdminPort > 65535 {
+		return fmt.Errorf("admin port must be 0-65535, got %d", c.AdminPort)
+	}
+	if c.AdminPort != 0 && (c.AdminPort == c.ListenPort || c.AdminPort == c.HTTPRedirectPort) {
+		return fmt.Errorf("admin port %d is already used by another listener", c.AdminPort)
+	}
+	if c.TLSCert != "" {
+		if !c.TLSEnabled {
+			return fmt.Errorf("tls cert/key require TLS to be enabled")
+		}
+		for _, path := range []string{c.TLSCert, c.TLSKey} {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("tls file %q: %w", path, err)
+			}
+		}
+	}
+	switch c.RestartPolicy {
+	case "", "never", "on-failure", "always":
+	default:
+		return fmt.Errorf("restart policy must be never, on-failure or always, got %q", c.RestartPolicy)
+	}
+	switch c.Balance {
+	case "", "round-robin", "first":
+	default:
+		return fmt.Errorf("balance strategy must be round-robin or first, got %q", c.Balance)
+	}
+	switch c.SlugCollision {
+	case "", "group", "port", "path-suffix", "error":
+	default:
+		return fmt.Errorf("slug collision strategy must be group, port, path-suffix or error, got %q", c.SlugCollision)
+	}
+	for name, path := range map[string]string{"health": c.HealthPath, "project": c.ProjectPath} {
+		if path != "" && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("%s path must start with /, got %q", name, path)
+		}
+	}
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("drain timeout must be >= 0, got %s", c.DrainTimeout)
+	}
+	if (c.BasicAuthUser == "") != (c.BasicAuthPass == "") {
+		return fmt.Errorf("auth user and auth pass hash must be provided together")
+	}
+	if c.BasicAuthPass != "" {
+		if _, err := bcrypt.Cost([]byte(c.BasicAuthPass)); err != nil {
+			return fmt.Errorf("auth pass hash must be a bcrypt hash (htpasswd -nbB): %w", err)
+		}
+	}
+	if c.DrainPeriod < 0 {
+		return fmt.Errorf("drain period must be >= 0, got %s", c.DrainPeriod)
+	}
+	for name, v := range map[string]int{"priority": c.MDNSSRVPriority, "weight": c.MDNSSRVWeight} {
+		if v < 0 || v > 65535 {
+			return fmt.Errorf("mDNS SRV %s must be 0-65535, got %d", name, v)
+		}
+	}
+	for prefix, subtype := range c.MDNSSubtypes {
+		if prefix == "" {
+			return fmt.Errorf("mDNS subtype %q: version prefix must not be empty", subtype)
+		}
+		if !strings.HasPrefix(subtype, "_") || len(subtype) < 2 || len(subtype) > 63 || strings.Contains(subtype, ".") {
+			return fmt.Errorf("mDNS subtype for %q must be a single DNS label starting with _ (e.g. _v2), got %q", prefix, subtype)
+		}
+	}
+	if c.PeerTimeout < 0 {
+		return fmt.Errorf("peer timeout must be >= 0, got %s", c.PeerTimeout)
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			return fmt.Errorf("cors origin must be * or scheme://host[:port], got %q", origin)
+		}
+	}
+	if len(c.TrustedProxies) > 0 && !c.BehindProxy {
+		return fmt.Errorf("trusted proxies require behind proxy to be enabled")
+	}
+	if _, err := ParseCIDRs(c.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
+	if _, err := ParseCIDRs(c.AllowedClientCIDRs); err != nil {
+		return fmt.Errorf("allowed client CIDRs: %w", err)
+	}
+	if len(c.AllowedClientCIDRs) > 0 && c.UnixSocket != "" {
+		return fmt.Errorf("allowed client CIDRs cannot be used with a unix socket")
+	}
+	if _, err := path.Match(c.TraceSlug, ""); err != nil {
+		return fmt.Errorf("trace slug pattern %q: %w", c.TraceSlug, err)
+	}
+	if c.TUI && c.AccessLog && c.AccessLogFile == "" {
+		return fmt.Errorf("the terminal dashboard needs an access log file; stderr is the terminal")
+	}
+	if c.StickyMaxAge < 0 {
+		return fmt.Errorf("sticky max age must be >= 0, got %s", c.StickyMaxAge)
+	}
+	if c.MaxLogSize < 0 {
+		return fmt.Errorf("max log size must be >= 0, got %d", c.MaxLogSize)
+	}
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+	switch c.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log format must be text or json, got %q", c.LogFormat)
+	}
+	if c.BufferRequests && c.BufferMaxSize < 1 {
+		return fmt.Errorf("buffer max size must be >= 1 byte, got %d", c.BufferMaxSize)
+	}
+	for slug, limit := range c.RateLimits {
+		if limit.RequestsPerSecond <= 0 {
+			return fmt.Errorf("rate limit for %q: requests per second must be > 0, got %g", slug, limit.RequestsPerSecond)
+		}
+		if limit.Burst < 1 {
+			return fmt.Errorf("rate limit for %q: burst must be >= 1, got %d", slug, limit.Burst)
+		}
+	}
 	return nil
 }
 
-// DomainFor returns the mDNS hostname for a project slug.
-// Format: {slug}-{username}.local
+// ListenPortInScanRange reports whether the router's own TCP port lies in
+// the scan range, in which case the scanner would probe the router itself.
+func (c *Config) ListenPortInScanRange() bool {
+	return c.UnixSocket == "" && c.scanRangeContains(PortRange{Start: c.ListenPort, End: c.ListenPort})
+}
+
+// ScanRanges returns the port ranges the scanner probes: ScanPortGroups
+// when set, otherwise the single ScanPortStart-ScanPortEnd range.
+func (c *Config) ScanRanges() []PortRange {
+	if len(c.ScanPortGroups) > 0 {
+		return c.ScanPortGroups
+	}
+	return []PortRange{{Start: c.ScanPortStart, End: c.ScanPortEnd}}
+}
+
+// scanRangeContains reports whether r lies within one of the ScanRanges.
+func (c *Config) scanRangeContains(r PortRange) bool {
+	for _, g := range c.ScanRanges() {
+		if r.Start >= g.Start && r.End <= g.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanRangeString formats the ScanRanges for logs and errors, e.g.
+// "30000-30999,40000-40099".
+func (c *Config) ScanRangeString() string {
+	parts := make([]string, 0, len(c.ScanRanges()))
+	for _, g := range c.ScanRanges() {
+		parts = append(parts, g.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// ScanExcludedPorts returns ExcludePorts plus the listen port when it lies
+// in the scan range.
+func (c *Config) ScanExcludedPorts() []int {
+	ports := append([]int(nil), c.ExcludePorts...)
+	if c.ListenPortInScanRange() && !slices.Contains(ports, c.ListenPort) {
+		ports = append(ports, c.ListenPort)
+	}
+	return ports
+}
+
+// BindAddr returns the TCP address for ListenPort: every interface with
+// AllowRemote, loopback only otherwise.
+func (c *Config) BindAddr() string {
+	host := "127.0.0.1"
+	if c.AllowRemote {
+		host = "0.0.0.0"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.ListenPort))
+}
+
+// Scheme returns "https" when TLS is enabled, otherwise "http".
+func (c *Config) Scheme() string {
+	if c.TLSEnabled {
+		return "https"
+	}
+	return "http"
+}
+
+// DomainFor returns the host-based routing hostname for a project slug.
+// Format: {slug}-{username}{suffix}, e.g. "myproject-alice.local"
 func (c *Config) DomainFor(slug string) string {
-	return fmt.Sprintf("%s-%s.local", slug, c.Username)
+	return c.DomainForUser(slug, "")
+}
+
+// DomainForUser is DomainFor for a backend owned by username, as set with
+// UsernameFromPath. An empty username means c.Username.
+func (c *Config) DomainForUser(slug, username string) string {
+	if username == "" {
+		username = c.Username
+	}
+	return fmt.Sprintf("%s-%s%s", slug, username, c.HostSuffix)
 }
 
 // GetOutboundIP returns the preferred outbound IP of this machine.
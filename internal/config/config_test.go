@@ -1,8 +1,13 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // ---------------------------------------------------------------------------
@@ -71,12 +76,31 @@ func TestValidate_ValidDefaults(t *testing.T) {
 	}
 }
 
+func TestValidate_ListenPortZero(t *testing.T) {
+	cfg := Defaults()
+	cfg.ListenPort = 0
+	cfg.ListenAddr = "127.0.0.1:0"
+	cfg.PortFile = "/tmp/ocr.port"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("port 0 over TCP should be valid, got: %v", err)
+	}
+
+	cfg.ListenAddr = ""
+	cfg.UnixSocket = "/tmp/ocr.sock"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for port 0 with a unix socket")
+	}
+	cfg.ListenPort = 8080
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a port file with a unix socket")
+	}
+}
+
 func TestValidate_InvalidListenPort(t *testing.T) {
 	tests := []struct {
 		name string
 		port int
 	}{
-		{"zero", 0},
 		{"negative", -1},
 		{"too high", 70000},
 	}
@@ -184,6 +208,51 @@ func TestDomainFor(t *testing.T) {
 	}
 }
 
+func TestDomainFor_HostSuffix(t *testing.T) {
+	cfg := Defaults()
+	cfg.Username = "alice"
+	for suffix, want := range map[string]string{
+		".internal":        "myproject-alice.internal",
+		".dev":             "myproject-alice.dev",
+		".corp.example.io": "myproject-alice.corp.example.io",
+	} {
+		cfg.HostSuffix = suffix
+		if got := cfg.DomainFor("myproject"); got != want {
+			t.Errorf("DomainFor with suffix %q = %q, want %q", suffix, got, want)
+		}
+	}
+}
+
+func TestDomainForUser(t *testing.T) {
+	cfg := Defaults()
+	cfg.Username = "root"
+	if got := cfg.DomainForUser("proj", "alice"); got != "proj-alice.local" {
+		t.Errorf("DomainForUser(proj, alice) = %q", got)
+	}
+	if got := cfg.DomainForUser("proj", ""); got != cfg.DomainFor("proj") {
+		t.Errorf("DomainForUser without a username = %q, want %q", got, cfg.DomainFor("proj"))
+	}
+}
+
+func TestValidate_HostSuffix(t *testing.T) {
+	for suffix, ok := range map[string]bool{
+		".local":    true,
+		".internal": true,
+		".a.b":      true,
+		"":          false,
+		".":         false,
+		"internal":  false,
+		".dev:80":   false,
+		".my dev":   false,
+	} {
+		cfg := Defaults()
+		cfg.HostSuffix = suffix
+		if err := cfg.Validate(); (err == nil) != ok {
+			t.Errorf("Validate with host suffix %q: err = %v, want ok=%v", suffix, err, ok)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // GetOutboundIP
 // ---------------------------------------------------------------------------
@@ -201,3 +270,455 @@ func TestGetOutboundIP_NotEmpty(t *testing.T) {
 		t.Errorf("GetOutboundIP returned invalid IP: %v", ip)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// TLS
+// ---------------------------------------------------------------------------
+
+func TestBindAddr(t *testing.T) {
+	cfg := Defaults()
+	cfg.ListenPort = 9000
+	if got := cfg.BindAddr(); got != "127.0.0.1:9000" {
+		t.Errorf("default BindAddr = %q, want 127.0.0.1:9000", got)
+	}
+	cfg.AllowRemote = true
+	if got := cfg.BindAddr(); got != "0.0.0.0:9000" {
+		t.Errorf("BindAddr with AllowRemote = %q, want 0.0.0.0:9000", got)
+	}
+}
+
+func TestValidate_AllowedClientCIDRs(t *testing.T) {
+	cfg := Defaults()
+	cfg.AllowedClientCIDRs = []string{"10.0.0.0/8", "192.168.1.5"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid CIDRs rejected: %v", err)
+	}
+	cfg.AllowedClientCIDRs = []string{"10.0.0.0/33"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+	cfg.AllowedClientCIDRs = []string{"10.0.0.0/8"}
+	cfg.ListenAddr, cfg.UnixSocket = "", "/tmp/router.sock"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for an allowlist on a unix socket")
+	}
+}
+
+func TestValidate_BasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		user, pass string
+		wantErr    bool
+	}{
+		{"disabled", "", "", false},
+		{"user and hash", "alice", string(hash), false},
+		{"user without hash", "alice", "", true},
+		{"hash without user", "", string(hash), true},
+		{"plaintext password", "alice", "s3cret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.BasicAuthUser, cfg.BasicAuthPass = tt.user, tt.pass
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TLSCertWithoutKey(t *testing.T) {
+	cfg := Defaults()
+	cfg.TLSEnabled = true
+	cfg.TLSCert = "/tmp/cert.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when TLS cert is set without key")
+	}
+}
+
+func TestValidate_TLSFilesRequireTLS(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Defaults()
+	cfg.TLSCert = filepath.Join(dir, "cert.pem")
+	cfg.TLSKey = filepath.Join(dir, "key.pem")
+	for _, p := range []string{cfg.TLSCert, cfg.TLSKey} {
+		if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when cert/key are set but TLS is disabled")
+	}
+	cfg.TLSEnabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid TLS config, got %v", err)
+	}
+}
+
+func TestValidate_TLSMissingFile(t *testing.T) {
+	cfg := Defaults()
+	cfg.TLSEnabled = true
+	cfg.TLSCert = "/nonexistent/cert.pem"
+	cfg.TLSKey = "/nonexistent/key.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for missing TLS files")
+	}
+}
+
+func TestScheme(t *testing.T) {
+	cfg := Defaults()
+	if cfg.Scheme() != "http" {
+		t.Errorf("expected http, got %q", cfg.Scheme())
+	}
+	cfg.TLSEnabled = true
+	if cfg.Scheme() != "https" {
+		t.Errorf("expected https, got %q", cfg.Scheme())
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Rate limits
+// ---------------------------------------------------------------------------
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits("proj=5:10, *=20.5:40:ip")
+	if err != nil {
+		t.Fatalf("ParseRateLimits: %v", err)
+	}
+	if got := limits["proj"]; got.RequestsPerSecond != 5 || got.Burst != 10 || got.PerClientIP {
+		t.Errorf("unexpected proj limit %+v", got)
+	}
+	if got := limits[RateLimitDefaultKey]; got.RequestsPerSecond != 20.5 || got.Burst != 40 || !got.PerClientIP {
+		t.Errorf("unexpected default limit %+v", got)
+	}
+
+	if limits, err := ParseRateLimits(""); err != nil || limits != nil {
+		t.Errorf("empty input should yield nil, got %v, %v", limits, err)
+	}
+
+	for _, bad := range []string{"proj", "=1:1", "proj=1", "proj=x:1", "proj=1:y", "proj=1:1:zz"} {
+		if _, err := ParseRateLimits(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestValidate_RateLimits(t *testing.T) {
+	cfg := Defaults()
+	cfg.RateLimits = map[string]RateLimit{"proj": {RequestsPerSecond: 0, Burst: 1}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero rps")
+	}
+	cfg.RateLimits = map[string]RateLimit{"proj": {RequestsPerSecond: 1, Burst: 0}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero burst")
+	}
+}
+
+func TestValidate_Balance(t *testing.T) {
+	cfg := Defaults()
+	cfg.Balance = "random"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown balance strategy")
+	}
+}
+
+func TestValidate_ListenAddrAndUnixSocket(t *testing.T) {
+	cfg := Defaults()
+	cfg.UnixSocket = "/run/opencode-router.sock"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when both listen address and unix socket are set")
+	}
+
+	cfg.ListenAddr = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unix socket alone should be valid: %v", err)
+	}
+
+	cfg.UnixSocket = ""
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when neither listen address nor unix socket is set")
+	}
+}
+
+func TestValidate_ProbePaths(t *testing.T) {
+	cfg := Defaults()
+	cfg.HealthPath = "health"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for health path without leading slash")
+	}
+}
+
+func TestValidate_MDNSSRV(t *testing.T) {
+	cfg := Defaults()
+	cfg.MDNSSRVPriority = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative SRV priority")
+	}
+
+	cfg = Defaults()
+	cfg.MDNSSRVWeight = 70000
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for SRV weight > 65535")
+	}
+}
+
+func TestListenPortInScanRange(t *testing.T) {
+	cfg := Defaults()
+	cfg.ScanPortStart, cfg.ScanPortEnd = 8000, 8100
+	cfg.ListenPort = 8080
+	cfg.ExcludePorts = []int{8001}
+
+	if !cfg.ListenPortInScanRange() {
+		t.Error("expected listen port 8080 to be detected inside 8000-8100")
+	}
+	if got := cfg.ScanExcludedPorts(); !slices.Equal(got, []int{8001, 8080}) {
+		t.Errorf("ScanExcludedPorts = %v, want [8001 8080]", got)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("overlap should only warn, got %v", err)
+	}
+
+	cfg.UnixSocket, cfg.ListenAddr = "/run/opencode-router.sock", ""
+	if cfg.ListenPortInScanRange() {
+		t.Error("unix socket listener should not overlap the scan range")
+	}
+
+	cfg.UnixSocket, cfg.ListenAddr = "", "0.0.0.0:9090"
+	cfg.ListenPort = 9090
+	if got := cfg.ScanExcludedPorts(); !slices.Equal(got, []int{8001}) {
+		t.Errorf("ScanExcludedPorts = %v, want [8001]", got)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := ParsePorts("4100, 4102,,")
+	if err != nil || !slices.Equal(ports, []int{4100, 4102}) {
+		t.Errorf("ParsePorts = %v, %v", ports, err)
+	}
+	if _, err := ParsePorts("4100,abc"); err == nil {
+		t.Error("expected error for non-numeric port")
+	}
+
+	cfg := Defaults()
+	cfg.ExcludePorts = []int{70000}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for out-of-range excluded port")
+	}
+}
+
+func TestParsePortRanges(t *testing.T) {
+	ranges, err := ParsePortRanges("30500-30600, 30800 - 30850,30900")
+	want := []PortRange{{30500, 30600}, {30800, 30850}, {30900, 30900}}
+	if err != nil || !slices.Equal(ranges, want) {
+		t.Errorf("ParsePortRanges = %v, %v, want %v", ranges, err, want)
+	}
+	for _, bad := range []string{"30500-", "-30600", "a-b", "30500-30600-30700"} {
+		if _, err := ParsePortRanges(bad); err == nil {
+			t.Errorf("ParsePortRanges(%q): expected error", bad)
+		}
+	}
+}
+
+func TestValidate_ScanExcludeRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []PortRange
+		wantErr bool
+	}{
+		{"inside", []PortRange{{30500, 30600}}, false},
+		{"whole range", []PortRange{{30000, 31000}}, false},
+		{"reversed", []PortRange{{30600, 30500}}, true},
+		{"below start", []PortRange{{29990, 30010}}, true},
+		{"above end", []PortRange{{30990, 31010}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.ScanPortStart, cfg.ScanPortEnd = 30000, 31000
+			cfg.ScanExcludeRanges = tt.ranges
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ScanPortGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []PortRange
+		exclude []PortRange
+		wantErr bool
+	}{
+		{"disjoint", []PortRange{{30000, 30999}, {40000, 40099}}, nil, false},
+		{"adjacent", []PortRange{{30000, 30099}, {30100, 30199}}, nil, false},
+		{"overlapping", []PortRange{{30000, 30999}, {30900, 31099}}, nil, true},
+		{"overlapping out of order", []PortRange{{40000, 40099}, {30000, 30999}, {40099, 40100}}, nil, true},
+		{"reversed", []PortRange{{40099, 40000}}, nil, true},
+		{"out of bounds", []PortRange{{65000, 65536}}, nil, true},
+		{"exclude inside a group", []PortRange{{30000, 30999}, {40000, 40099}}, []PortRange{{40010, 40020}}, false},
+		{"exclude in the gap", []PortRange{{30000, 30999}, {40000, 40099}}, []PortRange{{35000, 35010}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			cfg.ScanPortGroups = tt.groups
+			cfg.ScanExcludeRanges = tt.exclude
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", " 192.168.1.5 ", "::1"})
+	if err != nil || len(nets) != 3 {
+		t.Fatalf("ParseCIDRs = %v, %v", nets, err)
+	}
+	if nets[1].String() != "192.168.1.5/32" || nets[2].String() != "::1/128" {
+		t.Errorf("bare IPs should become host networks, got %v and %v", nets[1], nets[2])
+	}
+	if _, err := ParseCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
+
+func TestValidate_TrustedProxies(t *testing.T) {
+	cfg := Defaults()
+	cfg.TrustedProxies = []string{"10.0.0.0/8"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("trusted proxies without behind proxy should be rejected")
+	}
+	cfg.BehindProxy = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	cfg.TrustedProxies = []string{"proxy.local"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a hostname")
+	}
+}
+
+func TestValidate_HSTSAndRedirect(t *testing.T) {
+	if got := Defaults().HSTSMaxAge; got != DefaultHSTSMaxAge {
+		t.Errorf("default HSTSMaxAge = %s, want %s", got, DefaultHSTSMaxAge)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr bool
+	}{
+		{"redirect with tls", func(c *Config) { c.TLSEnabled = true; c.HTTPRedirectPort = 8081 }, false},
+		{"hsts disabled", func(c *Config) { c.HSTSMaxAge = 0 }, false},
+		{"negative hsts", func(c *Config) { c.HSTSMaxAge = -time.Second }, true},
+		{"redirect without tls", func(c *Config) { c.HTTPRedirectPort = 8081 }, true},
+		{"redirect port out of range", func(c *Config) { c.TLSEnabled = true; c.HTTPRedirectPort = 70000 }, true},
+		{"redirect on the listen port", func(c *Config) { c.TLSEnabled = true; c.HTTPRedirectPort = c.ListenPort }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Defaults()
+			tt.mutate(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ProbeRetries(t *testing.T) {
+	cfg := Defaults()
+	if cfg.ProbeRetries != DefaultProbeRetries || cfg.ProbeRetryDelay != DefaultProbeRetryDelay {
+		t.Errorf("defaults = %d × %s, want %d × %s", cfg.ProbeRetries, cfg.ProbeRetryDelay, DefaultProbeRetries, DefaultProbeRetryDelay)
+	}
+	cfg.ProbeRetries = 0
+	cfg.ProbeRetryDelay = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("disabling retries should be valid: %v", err)
+	}
+	cfg.ProbeRetries = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative probe retries")
+	}
+	cfg.ProbeRetries = 1
+	cfg.ProbeRetryDelay = -time.Millisecond
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative probe retry delay")
+	}
+}
+
+func TestValidate_AdminPort(t *testing.T) {
+	cfg := Defaults()
+	cfg.AdminPort = 9090
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("a separate admin port should be valid: %v", err)
+	}
+	cfg.AdminPort = cfg.ListenPort
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an admin port equal to the listen port")
+	}
+	cfg.AdminPort = 70000
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an out-of-range admin port")
+	}
+}
+
+func TestValidate_DashboardTimeout(t *testing.T) {
+	cfg := Defaults()
+	if cfg.DashboardTimeout != DefaultDashboardTimeout {
+		t.Errorf("default DashboardTimeout = %s, want %s", cfg.DashboardTimeout, DefaultDashboardTimeout)
+	}
+	cfg.DashboardTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative dashboard timeout")
+	}
+}
+
+func TestParseMDNSSubtypes(t *testing.T) {
+	subtypes, err := ParseMDNSSubtypes("2.=_v2, 3.=_v3")
+	if err != nil {
+		t.Fatalf("ParseMDNSSubtypes: %v", err)
+	}
+	if len(subtypes) != 2 || subtypes["2."] != "_v2" || subtypes["3."] != "_v3" {
+		t.Errorf("subtypes = %v", subtypes)
+	}
+	for _, bad := range []string{"2.", "=_v2", "2.="} {
+		if _, err := ParseMDNSSubtypes(bad); err == nil {
+			t.Errorf("ParseMDNSSubtypes(%q): expected error", bad)
+		}
+	}
+}
+
+func TestValidate_MDNSSubtypes(t *testing.T) {
+	cfg := Defaults()
+	cfg.MDNSSubtypes = map[string]string{"2.": "_v2"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, bad := range []string{"v2", "_", "_v2._sub"} {
+		cfg.MDNSSubtypes = map[string]string{"2.": bad}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("subtype %q: expected error", bad)
+		}
+	}
+}
+
+func TestValidate_TraceSlug(t *testing.T) {
+	cfg := Defaults()
+	cfg.TraceSlug = "proj*"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	cfg.TraceSlug = "proj["
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for a malformed trace slug pattern")
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix prefixes every environment variable read by FromEnv.
+const EnvPrefix = "OPENCODEROUTER_"
+
+// FromEnv returns Defaults overlaid with OPENCODEROUTER_* environment
+// variables. Variable names are the config file keys upper-cased, e.g.
+// OPENCODEROUTER_SCAN_INTERVAL=10s or OPENCODEROUTER_MDNS=false. List values
+// are comma-separated.
+// The result is not validated.
+func FromEnv() (Config, error) {
+	return ApplyEnv(Defaults())
+}
+
+// ApplyEnv overlays OPENCODEROUTER_* environment variables onto base.
+// Unset or empty variables leave the base value alone.
+func ApplyEnv(base Config) (Config, error) {
+	var fc fileConfig
+	v := reflect.ValueOf(&fc).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := EnvPrefix + strings.ToUpper(t.Field(i).Tag.Get("json"))
+		raw := strings.TrimSpace(os.Getenv(key))
+		if raw == "" {
+			continue
+		}
+		val, err := parseEnvValue(t.Field(i).Type.Elem(), raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", key, err)
+		}
+		v.Field(i).Set(val)
+	}
+	return fc.apply(base), nil
+}
+
+// SplitList splits a comma-separated flag or env value, dropping blanks.
+func SplitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseEnvValue parses raw into a new value of type typ and returns a
+// pointer to it, matching the pointer fields of fileConfig.
+func parseEnvValue(typ reflect.Type, raw string) (reflect.Value, error) {
+	ptr := reflect.New(typ)
+	switch typ {
+	case reflect.TypeOf(duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().SetInt(int64(d))
+		return ptr, nil
+	case reflect.TypeOf([]int(nil)):
+		ports, err := ParsePorts(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().Set(reflect.ValueOf(ports))
+		return ptr, nil
+	case reflect.TypeOf(portRanges(nil)):
+		ranges, err := ParsePortRanges(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().Set(reflect.ValueOf(portRanges(ranges)))
+		return ptr, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		ptr.Elem().SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().SetBool(b)
+	case reflect.Slice:
+		ptr.Elem().Set(reflect.ValueOf(SplitList(raw)))
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().SetInt(n)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", typ)
+	}
+	return ptr, nil
+}
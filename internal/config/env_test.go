@@ -0,0 +1,102 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "unset keeps defaults",
+			env:  map[string]string{},
+			check: func(t *testing.T, cfg Config) {
+				if d := Defaults(); cfg.ListenPort != d.ListenPort || cfg.ScanInterval != d.ScanInterval {
+					t.Errorf("expected defaults, got port %d interval %s", cfg.ListenPort, cfg.ScanInterval)
+				}
+			},
+		},
+		{
+			name: "ints and strings",
+			env: map[string]string{
+				"OPENCODEROUTER_PORT":       "9090",
+				"OPENCODEROUTER_USERNAME":   "ci",
+				"OPENCODEROUTER_SCAN_START": "40000",
+				"OPENCODEROUTER_SCAN_END":   "40100",
+				"OPENCODEROUTER_LOG_LEVEL":  "warn",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.ListenPort != 9090 || cfg.Username != "ci" || cfg.ScanPortStart != 40000 || cfg.ScanPortEnd != 40100 || cfg.LogLevel != "warn" {
+					t.Errorf("unexpected config: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "durations",
+			env: map[string]string{
+				"OPENCODEROUTER_SCAN_INTERVAL": "10s",
+				"OPENCODEROUTER_PROBE_TIMEOUT": "250ms",
+				"OPENCODEROUTER_STALE_AFTER":   "2m",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.ScanInterval != 10*time.Second || cfg.ProbeTimeout != 250*time.Millisecond || cfg.StaleAfter != 2*time.Minute {
+					t.Errorf("unexpected durations: %s %s %s", cfg.ScanInterval, cfg.ProbeTimeout, cfg.StaleAfter)
+				}
+			},
+		},
+		{
+			name: "bools and int64",
+			env: map[string]string{
+				"OPENCODEROUTER_MDNS":            "false",
+				"OPENCODEROUTER_BUFFER_REQUESTS": "1",
+				"OPENCODEROUTER_MAX_LOG_SIZE":    "2048",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.EnableMDNS || !cfg.BufferRequests || cfg.MaxLogSize != 2048 {
+					t.Errorf("unexpected config: mdns=%v buffer=%v max_log_size=%d", cfg.EnableMDNS, cfg.BufferRequests, cfg.MaxLogSize)
+				}
+			},
+		},
+		{
+			name: "port list",
+			env:  map[string]string{"OPENCODEROUTER_EXCLUDE_PORTS": "4100,4102"},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.ExcludePorts) != 2 || cfg.ExcludePorts[0] != 4100 || cfg.ExcludePorts[1] != 4102 {
+					t.Errorf("unexpected exclude ports: %v", cfg.ExcludePorts)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := FromEnv()
+			if err != nil {
+				t.Fatalf("FromEnv: %v", err)
+			}
+			tc.check(t, cfg)
+		})
+	}
+}
+
+func TestFromEnv_InvalidValue(t *testing.T) {
+	for key, value := range map[string]string{
+		"OPENCODEROUTER_PORT":          "eighty",
+		"OPENCODEROUTER_MDNS":          "maybe",
+		"OPENCODEROUTER_SCAN_INTERVAL": "10",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := FromEnv(); err == nil {
+				t.Errorf("expected error for %s=%s", key, value)
+			}
+		})
+	}
+}
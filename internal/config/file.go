@@ -0,0 +1,268 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fileConfig is the on-disk JSON shape. Keys mirror the CLI flag names; every
+// field is optional and only overrides the base config when present.
+type fileConfig struct {
+	ListenPort              *int               `json:"port"`
+	Username                *string            `json:"username"`
+	UsernameFromPath        *bool              `json:"username_from_path"`
+	UnixSocket              *string            `json:"unix"`
+	ScanPortStart           *int               `json:"scan_start"`
+	ScanPortEnd             *int               `json:"scan_end"`
+	SessionPortStart        *int               `json:"session_port_start"`
+	SessionPortEnd          *int               `json:"session_port_end"`
+	ExcludePorts            *[]int             `json:"exclude_ports"`
+	ScanPortBlocklist       *[]int             `json:"scan_blocklist"`
+	ScanPortGroups          *portRanges        `json:"scan_groups"`
+	ScanExcludeRanges       *portRanges        `json:"scan_exclude"`
+	ScanInterval            *duration          `json:"scan_interval"`
+	ScanConcurrency         *int               `json:"scan_concurrency"`
+	ScanConcurrencyAuto     *bool              `json:"scan_concurrency_auto"`
+	DryRun                  *bool              `json:"dry_run"`
+	PassiveScan             *bool              `json:"passive_scan"`
+	ProbeTimeout            *duration          `json:"probe_timeout"`
+	ProbeRetries            *int               `json:"probe_retries"`
+	ProbeRetryDelay         *duration          `json:"probe_retry_delay"`
+	StaleAfter              *duration          `json:"stale_after"`
+	DrainTimeout            *duration          `json:"drain_timeout"`
+	DrainPeriod             *duration          `json:"drain_period"`
+	HealthPath              *string            `json:"health_path"`
+	ProjectPath             *string            `json:"project_path"`
+	ProbeUserAgent          *string            `json:"probe_user_agent"`
+	ProbeTLS                *bool              `json:"probe_tls"`
+	ScanIPv6                *bool              `json:"ipv6"`
+	ProbeInsecureSkipVerify *bool              `json:"probe_insecure_skip_verify"`
+	EnableMDNS              *bool              `json:"mdns"`
+	HostSuffix              *string            `json:"host_suffix"`
+	MDNSServiceType         *string            `json:"mdns_service_type"`
+	MDNSInterfaces          *[]string          `json:"mdns_interfaces"`
+	MDNSSRVPriority         *int               `json:"mdns_srv_priority"`
+	MDNSSRVWeight           *int               `json:"mdns_srv_weight"`
+	MDNSSubtypes            *map[string]string `json:"mdns_subtypes"`
+	PeerTimeout             *duration          `json:"peer_timeout"`
+	ConsulAddr              *string            `json:"consul_addr"`
+	AccessLog               *bool              `json:"access_log"`
+	AccessLogFile           *string            `json:"access_log_file"`
+	TraceFile               *string            `json:"trace_file"`
+	TraceSlug               *string            `json:"trace_slug"`
+	TUI                     *bool              `json:"tui"`
+	TLSEnabled              *bool              `json:"tls"`
+	TLSCert                 *string            `json:"tls_cert"`
+	TLSKey                  *string            `json:"tls_key"`
+	HSTSMaxAge              *duration          `json:"hsts_max_age"`
+	HTTPRedirectPort        *int               `json:"http_redirect_port"`
+	CORSOrigins             *[]string          `json:"cors_origins"`
+	EnableCompression       *bool              `json:"compress"`
+	BehindProxy             *bool              `json:"behind_proxy"`
+	ProxyProtocol           *bool              `json:"proxy_protocol"`
+	TrustedProxies          *[]string          `json:"trusted_proxies"`
+	AllowRemote             *bool              `json:"allow_remote"`
+	AllowedClientCIDRs      *[]string          `json:"allowed_client_cidrs"`
+	BufferRequests          *bool              `json:"buffer_requests"`
+	BufferMaxSize           *int64             `json:"buffer_max_size"`
+	UseH2C                  *bool              `json:"h2c"`
+	GRPCEnabled             *bool              `json:"grpc"`
+	OTelEndpoint            *string            `json:"otel_endpoint"`
+	NoInjectHeaders         *bool              `json:"no_inject_headers"`
+	InjectRequestID         *bool              `json:"inject_request_id"`
+	StrictMode              *bool              `json:"strict"`
+	DashboardTimeout        *duration          `json:"dashboard_timeout"`
+	OpenCodeBinary          *string            `json:"opencode_bin"`
+	RestartPolicy           *string            `json:"restart_policy"`
+	Balance                 *string            `json:"balance"`
+	StickySession           *bool              `json:"sticky_session"`
+	StickyMaxAge            *duration          `json:"sticky_max_age"`
+	SlugCollision           *string            `json:"slug_collision"`
+	LogLevel                *string            `json:"log_level"`
+	LogFormat               *string            `json:"log_format"`
+	LogDir                  *string            `json:"log_dir"`
+	PinnedFile              *string            `json:"pinned_file"`
+	MaxLogSize              *int64             `json:"max_log_size"`
+	RedactConfig            *bool              `json:"redact_config"`
+	AdminToken              *string            `json:"admin_token"`
+	AdminPort               *int               `json:"admin_port"`
+	BasicAuthUser           *string            `json:"auth_user"`
+	BasicAuthPass           *string            `json:"auth_pass_hash"`
+	PortFile                *string            `json:"port_file"`
+	PortDir                 *string            `json:"port_dir"`
+}
+
+// duration decodes Go duration strings such as "5s" or "1m30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// portRanges is a []PortRange written in JSON as a list of "start-end"
+// strings, e.g. ["30500-30600"].
+type portRanges []PortRange
+
+func (p *portRanges) UnmarshalJSON(data []byte) error {
+	var raw []string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("port ranges must be a list of strings like \"30500-30600\": %w", err)
+	}
+	ranges, err := ParsePortRanges(strings.Join(raw, ","))
+	if err != nil {
+		return err
+	}
+	*p = ranges
+	return nil
+}
+
+// LoadFile reads a JSON config file and applies it on top of base.
+// Unknown keys are rejected so typos don't silently fall back to defaults.
+// The result is not validated.
+func LoadFile(path string, base Config) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	var fc fileConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return Config{}, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return fc.apply(base), nil
+}
+
+// apply overlays the fields present in fc onto base.
+func (fc fileConfig) apply(base Config) Config {
+	cfg := base
+	setIf(&cfg.ListenPort, fc.ListenPort)
+	setIf(&cfg.Username, fc.Username)
+	setIf(&cfg.UsernameFromPath, fc.UsernameFromPath)
+	setIf(&cfg.UnixSocket, fc.UnixSocket)
+	setIf(&cfg.ScanPortStart, fc.ScanPortStart)
+	setIf(&cfg.ScanPortEnd, fc.ScanPortEnd)
+	setIf(&cfg.SessionPortStart, fc.SessionPortStart)
+	setIf(&cfg.SessionPortEnd, fc.SessionPortEnd)
+	setIf(&cfg.ScanConcurrency, fc.ScanConcurrency)
+	setIf(&cfg.ScanConcurrencyAuto, fc.ScanConcurrencyAuto)
+	setIf(&cfg.DryRun, fc.DryRun)
+	setIf(&cfg.PassiveScan, fc.PassiveScan)
+	setIf(&cfg.HealthPath, fc.HealthPath)
+	setIf(&cfg.ProjectPath, fc.ProjectPath)
+	setIf(&cfg.ProbeUserAgent, fc.ProbeUserAgent)
+	setIf(&cfg.ExcludePorts, fc.ExcludePorts)
+	setIf(&cfg.ScanPortBlocklist, fc.ScanPortBlocklist)
+	if fc.ScanPortGroups != nil {
+		cfg.ScanPortGroups = []PortRange(*fc.ScanPortGroups)
+	}
+	if fc.ScanExcludeRanges != nil {
+		cfg.ScanExcludeRanges = []PortRange(*fc.ScanExcludeRanges)
+	}
+	setIf(&cfg.ProbeTLS, fc.ProbeTLS)
+	setIf(&cfg.ScanIPv6, fc.ScanIPv6)
+	setIf(&cfg.ProbeInsecureSkipVerify, fc.ProbeInsecureSkipVerify)
+	setIf(&cfg.EnableMDNS, fc.EnableMDNS)
+	setIf(&cfg.HostSuffix, fc.HostSuffix)
+	setIf(&cfg.MDNSServiceType, fc.MDNSServiceType)
+	setIf(&cfg.MDNSInterfaces, fc.MDNSInterfaces)
+	setIf(&cfg.MDNSSRVPriority, fc.MDNSSRVPriority)
+	setIf(&cfg.MDNSSRVWeight, fc.MDNSSRVWeight)
+	setIf(&cfg.MDNSSubtypes, fc.MDNSSubtypes)
+	setIf(&cfg.ConsulAddr, fc.ConsulAddr)
+	setIf(&cfg.AccessLog, fc.AccessLog)
+	setIf(&cfg.AccessLogFile, fc.AccessLogFile)
+	setIf(&cfg.TraceFile, fc.TraceFile)
+	setIf(&cfg.TraceSlug, fc.TraceSlug)
+	setIf(&cfg.TUI, fc.TUI)
+	setIf(&cfg.TLSEnabled, fc.TLSEnabled)
+	setIf(&cfg.TLSCert, fc.TLSCert)
+	setIf(&cfg.TLSKey, fc.TLSKey)
+	setDurationIf(&cfg.HSTSMaxAge, fc.HSTSMaxAge)
+	setIf(&cfg.HTTPRedirectPort, fc.HTTPRedirectPort)
+	setIf(&cfg.CORSOrigins, fc.CORSOrigins)
+	setIf(&cfg.EnableCompression, fc.EnableCompression)
+	setIf(&cfg.BehindProxy, fc.BehindProxy)
+	setIf(&cfg.ProxyProtocol, fc.ProxyProtocol)
+	setIf(&cfg.TrustedProxies, fc.TrustedProxies)
+	setIf(&cfg.AllowRemote, fc.AllowRemote)
+	setIf(&cfg.AllowedClientCIDRs, fc.AllowedClientCIDRs)
+	setIf(&cfg.BufferRequests, fc.BufferRequests)
+	setIf(&cfg.BufferMaxSize, fc.BufferMaxSize)
+	setIf(&cfg.UseH2C, fc.UseH2C)
+	setIf(&cfg.GRPCEnabled, fc.GRPCEnabled)
+	setIf(&cfg.OTelEndpoint, fc.OTelEndpoint)
+	setIf(&cfg.NoInjectHeaders, fc.NoInjectHeaders)
+	setIf(&cfg.InjectRequestID, fc.InjectRequestID)
+	setIf(&cfg.StrictMode, fc.StrictMode)
+	setDurationIf(&cfg.DashboardTimeout, fc.DashboardTimeout)
+	setIf(&cfg.OpenCodeBinary, fc.OpenCodeBinary)
+	setIf(&cfg.RestartPolicy, fc.RestartPolicy)
+	setIf(&cfg.Balance, fc.Balance)
+	setIf(&cfg.StickySession, fc.StickySession)
+	setIf(&cfg.SlugCollision, fc.SlugCollision)
+	setIf(&cfg.LogLevel, fc.LogLevel)
+	setIf(&cfg.LogFormat, fc.LogFormat)
+	setIf(&cfg.LogDir, fc.LogDir)
+	setIf(&cfg.PinnedFile, fc.PinnedFile)
+	setIf(&cfg.MaxLogSize, fc.MaxLogSize)
+	setIf(&cfg.RedactConfig, fc.RedactConfig)
+	setIf(&cfg.AdminToken, fc.AdminToken)
+	setIf(&cfg.AdminPort, fc.AdminPort)
+	setIf(&cfg.BasicAuthUser, fc.BasicAuthUser)
+	setIf(&cfg.BasicAuthPass, fc.BasicAuthPass)
+	setIf(&cfg.PortFile, fc.PortFile)
+	setIf(&cfg.PortDir, fc.PortDir)
+	setDurationIf(&cfg.ScanInterval, fc.ScanInterval)
+	setDurationIf(&cfg.ProbeTimeout, fc.ProbeTimeout)
+	setIf(&cfg.ProbeRetries, fc.ProbeRetries)
+	setDurationIf(&cfg.ProbeRetryDelay, fc.ProbeRetryDelay)
+	setDurationIf(&cfg.StaleAfter, fc.StaleAfter)
+	setDurationIf(&cfg.DrainTimeout, fc.DrainTimeout)
+	setDurationIf(&cfg.DrainPeriod, fc.DrainPeriod)
+	setDurationIf(&cfg.PeerTimeout, fc.PeerTimeout)
+	setDurationIf(&cfg.StickyMaxAge, fc.StickyMaxAge)
+	return cfg
+}
+
+func setIf[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setDurationIf(dst *time.Duration, src *duration) {
+	if src != nil {
+		*dst = time.Duration(*src)
+	}
+}
+
+// Diff returns the names of Config fields whose values differ between a and b.
+func Diff(a, b Config) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+
+	var changed []string
+	for i := 0; i < t.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, t.Field(i).Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "router.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile_OverridesOnlyPresentKeys(t *testing.T) {
+	path := writeConfigFile(t, `{"scan_interval": "10s", "scan_concurrency": 4, "mdns": false}`)
+
+	base := Defaults()
+	cfg, err := LoadFile(path, base)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.ScanInterval != 10*time.Second {
+		t.Errorf("ScanInterval = %s, want 10s", cfg.ScanInterval)
+	}
+	if cfg.ScanConcurrency != 4 {
+		t.Errorf("ScanConcurrency = %d, want 4", cfg.ScanConcurrency)
+	}
+	if cfg.EnableMDNS {
+		t.Error("expected mdns to be disabled")
+	}
+	if cfg.StaleAfter != base.StaleAfter || cfg.ListenPort != base.ListenPort {
+		t.Errorf("absent keys should keep base values, got stale_after=%s port=%d", cfg.StaleAfter, cfg.ListenPort)
+	}
+}
+
+func TestLoadFile_ScanExclude(t *testing.T) {
+	cfg, err := LoadFile(writeConfigFile(t, `{"scan_exclude": ["30500-30600", "30800-30850"]}`), Defaults())
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := []PortRange{{30500, 30600}, {30800, 30850}}
+	if !slices.Equal(cfg.ScanExcludeRanges, want) {
+		t.Errorf("ScanExcludeRanges = %v, want %v", cfg.ScanExcludeRanges, want)
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":  `{"scan_intervall": "10s"}`,
+		"bad duration": `{"stale_after": "soon"}`,
+		"not a string": `{"probe_timeout": 800}`,
+		"bad range":    `{"scan_exclude": ["30500-high"]}`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadFile(writeConfigFile(t, body), Defaults()); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json"), Defaults()); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := Defaults()
+	b := a
+	if changed := Diff(a, b); len(changed) != 0 {
+		t.Fatalf("expected no changes, got %v", changed)
+	}
+
+	b.ScanInterval = time.Minute
+	b.RateLimits = map[string]RateLimit{"*": {RequestsPerSecond: 1, Burst: 1}}
+	changed := Diff(a, b)
+	if len(changed) != 2 || changed[0] != "ScanInterval" || changed[1] != "RateLimits" {
+		t.Errorf("Diff = %v, want [ScanInterval RateLimits]", changed)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// UnixSocketMode is the permission applied to a freshly created unix socket.
+const UnixSocketMode os.FileMode = 0o660
+
+// Listen opens the router's listener: a unix domain socket when UnixSocket is
+// set, otherwise TCP on ListenAddr. A stale socket file left behind by a
+// previous run is removed first. For TCP, ListenPort and ListenAddr are
+// updated to the bound address, so a port of 0 is replaced by the one the
+// OS assigned.
+func (c *Config) Listen() (net.Listener, error) {
+	if c.UnixSocket == "" {
+		ln, err := net.Listen("tcp", c.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+		if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+			c.ListenPort = addr.Port
+			if host, _, err := net.SplitHostPort(c.ListenAddr); err == nil {
+				c.ListenAddr = net.JoinHostPort(host, strconv.Itoa(addr.Port))
+			}
+		}
+		return ln, nil
+	}
+
+	if info, err := os.Lstat(c.UnixSocket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket path %q exists and is not a socket", c.UnixSocket)
+		}
+		if err := os.Remove(c.UnixSocket); err != nil {
+			return nil, fmt.Errorf("remove stale unix socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", c.UnixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(c.UnixSocket, UnixSocketMode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("chmod unix socket: %w", err)
+	}
+	return ln, nil
+}
+
+// ListenDisplay returns the listen address as shown in logs.
+func (c *Config) ListenDisplay() string {
+	if c.UnixSocket != "" {
+		return "unix:" + c.UnixSocket
+	}
+	return c.ListenAddr
+}
+
+// WritePortFile writes ListenPort to PortFile as a decimal string. The file
+// is replaced atomically, so a reader never sees a partial port. It does
+// nothing when PortFile is unset.
+func (c *Config) WritePortFile() error {
+	if c.PortFile == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.PortFile), "."+filepath.Base(c.PortFile)+".*")
+	if err != nil {
+		return fmt.Errorf("write port file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.Itoa(c.ListenPort)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write port file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write port file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write port file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.PortFile); err != nil {
+		return fmt.Errorf("write port file: %w", err)
+	}
+	return nil
+}
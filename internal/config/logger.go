@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// ParseLogLevel maps a --log-level value to a slog level. Empty means debug.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch level {
+	case "", "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("log level must be debug, info, warn or error, got %q", level)
+	}
+}
+
+// NewLogger builds the application logger writing to w, honouring
+// LogLevel and LogFormat. JSON output uses RFC3339Nano timestamps. Invalid
+// settings fall back to debug/text; Validate reports them.
+func NewLogger(cfg Config, w io.Writer) *slog.Logger {
+	level, err := ParseLogLevel(cfg.LogLevel)
+	if err != nil {
+		level = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.LogFormat == "json" {
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+				return slog.String(slog.TimeKey, a.Value.Time().Format(time.RFC3339Nano))
+			}
+			return a
+		}
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
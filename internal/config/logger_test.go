@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogger_JSON(t *testing.T) {
+	cfg := Defaults()
+	cfg.LogFormat = "json"
+	var buf bytes.Buffer
+	NewLogger(cfg, &buf).Info("hello", "port", 4096)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "hello" || entry["port"] != float64(4096) {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	ts, _ := entry["time"].(string)
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		t.Errorf("expected RFC3339Nano timestamp, got %q", ts)
+	}
+}
+
+func TestNewLogger_Level(t *testing.T) {
+	for _, tc := range []struct {
+		level     string
+		wantDebug bool
+	}{
+		{"debug", true},
+		{"info", false},
+		{"error", false},
+	} {
+		cfg := Defaults()
+		cfg.LogLevel = tc.level
+		var buf bytes.Buffer
+		NewLogger(cfg, &buf).Debug("probe detail")
+		if got := strings.Contains(buf.String(), "probe detail"); got != tc.wantDebug {
+			t.Errorf("level %s: debug logged = %v, want %v", tc.level, got, tc.wantDebug)
+		}
+	}
+}
+
+func TestValidate_LogSettings(t *testing.T) {
+	cfg := Defaults()
+	cfg.LogLevel = "verbose"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown log level")
+	}
+	cfg = Defaults()
+	cfg.LogFormat = "xml"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown log format")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultScanPortStart = 30000
+	defaultScanPortEnd   = 31000
+	// sessionPortOffset separates the managed session range from the scan range.
+	sessionPortOffset = 100
+	// minUnprivilegedPort is the lowest port AutoPortRange will pick.
+	minUnprivilegedPort = 1024
+)
+
+// Indirections so tests can fake the OS without touching /proc or sysctl.
+var (
+	goos     = runtime.GOOS
+	readFile = os.ReadFile
+	sysctl   = func(name string) (string, error) {
+		out, err := exec.Command("sysctl", "-n", name).Output()
+		return string(out), err
+	}
+)
+
+// AutoPortRange returns a scan range the size of the default one that does
+// not overlap the OS ephemeral port range, so the scanner doesn't probe
+// short-lived outbound connections. The session range above the scan range
+// is kept clear as well. The default range is returned unchanged when it is
+// already safe; otherwise the range is moved just below the ephemeral block,
+// or just above it if there is no room below.
+func AutoPortRange() (start, end int, err error) {
+	lo, hi, err := ephemeralPortRange()
+	if err != nil {
+		return 0, 0, err
+	}
+	return safePortRange(lo, hi)
+}
+
+// safePortRange places the default-sized scan range (plus session headroom)
+// outside [lo, hi].
+func safePortRange(lo, hi int) (int, int, error) {
+	span := defaultScanPortEnd - defaultScanPortStart
+	start, end := defaultScanPortStart, defaultScanPortEnd
+
+	overlaps := func(s, e int) bool { return s <= hi && e+sessionPortOffset >= lo }
+	if !overlaps(start, end) {
+		return start, end, nil
+	}
+
+	// Below the ephemeral range.
+	end = lo - 1 - sessionPortOffset
+	start = end - span
+	if start >= minUnprivilegedPort {
+		return start, end, nil
+	}
+
+	// Above it.
+	start = hi + 1
+	end = start + span
+	if end+sessionPortOffset <= 65535 {
+		return start, end, nil
+	}
+	return 0, 0, fmt.Errorf("no room for a %d-port scan range outside ephemeral range %d-%d", span+1, lo, hi)
+}
+
+// ephemeralPortRange reads the OS ephemeral port range.
+func ephemeralPortRange() (int, int, error) {
+	switch goos {
+	case "linux":
+		data, err := readFile("/proc/sys/net/ipv4/ip_local_port_range")
+		if err != nil {
+			return 0, 0, err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return 0, 0, fmt.Errorf("unexpected ip_local_port_range contents %q", string(data))
+		}
+		return parsePortPair(fields[0], fields[1])
+	case "darwin", "freebsd":
+		first, err := sysctl("net.inet.ip.portrange.first")
+		if err != nil {
+			return 0, 0, fmt.Errorf("sysctl portrange.first: %w", err)
+		}
+		last, err := sysctl("net.inet.ip.portrange.last")
+		if err != nil {
+			return 0, 0, fmt.Errorf("sysctl portrange.last: %w", err)
+		}
+		return parsePortPair(first, last)
+	default:
+		return 0, 0, fmt.Errorf("ephemeral port range detection not supported on %s", goos)
+	}
+}
+
+func parsePortPair(a, b string) (int, int, error) {
+	lo, err := strconv.Atoi(strings.TrimSpace(a))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q: %w", a, err)
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(b))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q: %w", b, err)
+	}
+	if lo < 1 || hi > 65535 || lo > hi {
+		return 0, 0, fmt.Errorf("invalid ephemeral port range %d-%d", lo, hi)
+	}
+	return lo, hi, nil
+}
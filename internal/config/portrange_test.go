@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeOS swaps the OS indirections for the duration of a test.
+func fakeOS(t *testing.T, os string, file string, fileErr error, sysctlValues map[string]string) {
+	t.Helper()
+	origGOOS, origRead, origSysctl := goos, readFile, sysctl
+	t.Cleanup(func() { goos, readFile, sysctl = origGOOS, origRead, origSysctl })
+
+	goos = os
+	readFile = func(string) ([]byte, error) { return []byte(file), fileErr }
+	sysctl = func(name string) (string, error) {
+		v, ok := sysctlValues[name]
+		if !ok {
+			return "", errors.New("unknown sysctl")
+		}
+		return v, nil
+	}
+}
+
+func avoidsRange(start, end, lo, hi int) bool {
+	return end+sessionPortOffset < lo || start > hi
+}
+
+func TestAutoPortRange_LinuxDefaultIsAlreadySafe(t *testing.T) {
+	fakeOS(t, "linux", "32768\t60999\n", nil, nil)
+
+	start, end, err := AutoPortRange()
+	if err != nil {
+		t.Fatalf("AutoPortRange: %v", err)
+	}
+	if start != defaultScanPortStart || end != defaultScanPortEnd {
+		t.Errorf("got %d-%d, want default %d-%d", start, end, defaultScanPortStart, defaultScanPortEnd)
+	}
+}
+
+func TestAutoPortRange_LinuxMovesBelowEphemeral(t *testing.T) {
+	fakeOS(t, "linux", "15000 60999\n", nil, nil)
+
+	start, end, err := AutoPortRange()
+	if err != nil {
+		t.Fatalf("AutoPortRange: %v", err)
+	}
+	if !avoidsRange(start, end, 15000, 60999) {
+		t.Errorf("range %d-%d (plus session ports) overlaps ephemeral 15000-60999", start, end)
+	}
+	if end-start != defaultScanPortEnd-defaultScanPortStart {
+		t.Errorf("range size changed: %d-%d", start, end)
+	}
+	if end >= 15000 {
+		t.Errorf("expected range below the ephemeral block, got %d-%d", start, end)
+	}
+}
+
+func TestAutoPortRange_LinuxMovesAboveEphemeral(t *testing.T) {
+	fakeOS(t, "linux", "1500 40000", nil, nil)
+
+	start, end, err := AutoPortRange()
+	if err != nil {
+		t.Fatalf("AutoPortRange: %v", err)
+	}
+	if start != 40001 || !avoidsRange(start, end, 1500, 40000) {
+		t.Errorf("expected range starting just above 40000, got %d-%d", start, end)
+	}
+}
+
+func TestAutoPortRange_NoRoom(t *testing.T) {
+	fakeOS(t, "linux", "2000 65000", nil, nil)
+
+	if _, _, err := AutoPortRange(); err == nil {
+		t.Error("expected error when no range fits outside the ephemeral block")
+	}
+}
+
+func TestAutoPortRange_Darwin(t *testing.T) {
+	fakeOS(t, "darwin", "", nil, map[string]string{
+		"net.inet.ip.portrange.first": "29000\n",
+		"net.inet.ip.portrange.last":  "65535\n",
+	})
+
+	start, end, err := AutoPortRange()
+	if err != nil {
+		t.Fatalf("AutoPortRange: %v", err)
+	}
+	if !avoidsRange(start, end, 29000, 65535) {
+		t.Errorf("range %d-%d overlaps ephemeral 29000-65535", start, end)
+	}
+}
+
+func TestAutoPortRange_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		os      string
+		file    string
+		fileErr error
+	}{
+		{"unreadable", "linux", "", errors.New("permission denied")},
+		{"garbage", "linux", "not ports", nil},
+		{"inverted", "linux", "60000 32768", nil},
+		{"unsupported os", "windows", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeOS(t, tt.os, tt.file, tt.fileErr, nil)
+			if _, _, err := AutoPortRange(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSelfSignedCert_SANs(t *testing.T) {
+	extra := net.ParseIP("192.168.1.50")
+	cert, err := GenerateSelfSignedCert(extra)
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCert: %v", err)
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		t.Fatal("expected Leaf to be populated")
+	}
+	if err := leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("cert should cover localhost: %v", err)
+	}
+	if err := leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("cert should cover 127.0.0.1: %v", err)
+	}
+	if err := leaf.VerifyHostname("192.168.1.50"); err != nil {
+		t.Errorf("cert should cover the extra IP: %v", err)
+	}
+}
+
+func TestGenerateSelfSignedCert_ServesHTTPS(t *testing.T) {
+	cert, err := GenerateSelfSignedCert()
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCert: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "secure" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestCertFingerprint(t *testing.T) {
+	cert, err := GenerateSelfSignedCert()
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCert: %v", err)
+	}
+
+	fp := CertFingerprint(cert)
+	if parts := strings.Split(fp, ":"); len(parts) != 32 {
+		t.Fatalf("expected 32 hex pairs, got %d (%q)", len(parts), fp)
+	}
+	if fp != strings.ToUpper(fp) {
+		t.Errorf("fingerprint should be uppercase: %q", fp)
+	}
+	if CertFingerprint(tls.Certificate{}) != "" {
+		t.Error("empty certificate should have an empty fingerprint")
+	}
+}
@@ -0,0 +1,177 @@
+// Package consul registers discovered backends as Consul services, for
+// networks where mDNS does not reach (other subnets, cloud VPCs).
+package consul
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/registry"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Registrar publishes backends to a service catalog.
+type Registrar interface {
+	Register(b *registry.Backend) error
+	Deregister(slug string) error
+}
+
+// Health check timing for registered services. The Consul agent runs the
+// check itself against 127.0.0.1, so it must share a host with the backends.
+const (
+	checkInterval = "10s"
+	checkTimeout  = "2s"
+	// deregisterAfter removes services whose check stays critical, e.g.
+	// when the router exits without deregistering.
+	deregisterAfter = "5m"
+)
+
+// Client registers backends with a Consul agent over its HTTP API. Each
+// backend becomes a service whose ID is its slug.
+type Client struct {
+	cfg        config.Config
+	agent      *api.Agent
+	address    string
+	registered map[string]string // slug → Backend.Fingerprint at registration
+	mu         sync.Mutex
+	logger     *slog.Logger
+}
+
+var _ Registrar = (*Client)(nil)
+
+// New creates a Client for the agent at cfg.ConsulAddr. No request is made
+// until the first Register or Sync.
+func New(cfg config.Config, logger *slog.Logger) (*Client, error) {
+	cc := api.DefaultConfig()
+	cc.Address = cfg.ConsulAddr
+	client, err := api.NewClient(cc)
+	if err != nil {
+		return nil, fmt.Errorf("consul client for %q: %w", cfg.ConsulAddr, err)
+	}
+	return &Client{
+		cfg:        cfg,
+		agent:      client.Agent(),
+		address:    config.GetOutboundIP().String(),
+		registered: make(map[string]string),
+		logger:     logger,
+	}, nil
+}
+
+// Register creates or replaces the Consul service for b.
+func (c *Client) Register(b *registry.Backend) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.register(b)
+}
+
+// Deregister removes the Consul service for slug.
+func (c *Client) Deregister(slug string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deregister(slug)
+}
+
+// Sync reconciles Consul registrations with the current registry state, the
+// same way discovery.Advertiser.Sync does for mDNS: new backends are
+// registered, removed ones deregistered, and changed ones re-registered.
+func (c *Client) Sync(backends []*registry.Backend) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[string]struct{}, len(backends))
+	for _, b := range backends {
+		current[b.Slug] = struct{}{}
+	}
+	for slug := range c.registered {
+		if _, ok := current[slug]; !ok {
+			if err := c.deregister(slug); err != nil {
+				c.logger.Error("consul deregistration failed", "slug", slug, "error", err)
+			}
+		}
+	}
+
+	// Only the first instance of a slug is registered; the service ID must be
+	// unique per agent.
+	seen := make(map[string]struct{}, len(backends))
+	for _, b := range backends {
+		if _, dup := seen[b.Slug]; dup {
+			continue
+		}
+		seen[b.Slug] = struct{}{}
+		if fp, ok := c.registered[b.Slug]; ok && fp == b.Fingerprint() {
+			continue
+		}
+		if err := c.register(b); err != nil {
+			c.logger.Error("consul registration failed", "slug", b.Slug, "error", err)
+		}
+	}
+}
+
+// Shutdown deregisters every service this client registered.
+func (c *Client) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for slug := range c.registered {
+		if err := c.deregister(slug); err != nil {
+			c.logger.Warn("consul deregistration failed", "slug", slug, "error", err)
+		}
+	}
+	c.logger.Info("all consul services deregistered")
+}
+
+// register sends the service definition for b. Caller must hold c.mu.
+func (c *Client) register(b *registry.Backend) error {
+	if err := c.agent.ServiceRegister(c.serviceFor(b)); err != nil {
+		return fmt.Errorf("consul ServiceRegister: %w", err)
+	}
+	c.registered[b.Slug] = b.Fingerprint()
+	c.logger.Info("consul service registered", "slug", b.Slug, "port", b.Port)
+	return nil
+}
+
+// deregister removes slug's service. Caller must hold c.mu.
+func (c *Client) deregister(slug string) error {
+	if err := c.agent.ServiceDeregister(slug); err != nil {
+		return fmt.Errorf("consul ServiceDeregister: %w", err)
+	}
+	delete(c.registered, slug)
+	c.logger.Info("consul service deregistered", "slug", slug)
+	return nil
+}
+
+// serviceFor builds the Consul service for b. Clients reach it through the
+// router, like mDNS: Address and Port are the router's, and the "host" meta
+// value is the Host header that selects this backend.
+func (c *Client) serviceFor(b *registry.Backend) *api.AgentServiceRegistration {
+	scheme := "http"
+	if b.TLS {
+		scheme = "https"
+	}
+	meta := map[string]string{
+		"project": b.ProjectName,
+		"path":    b.ProjectPath,
+		"backend": b.Addr(),
+		"host":    c.cfg.DomainForUser(b.Slug, b.Username),
+	}
+	if b.Version != "" {
+		meta["version"] = b.Version
+	}
+	return &api.AgentServiceRegistration{
+		ID:      b.Slug,
+		Name:    b.Slug,
+		Tags:    []string{"opencode", "username:" + c.cfg.Username},
+		Address: c.address,
+		Port:    c.cfg.ListenPort,
+		Meta:    meta,
+		Check: &api.AgentServiceCheck{
+			HTTP:                           scheme + "://" + b.Addr() + c.cfg.HealthPath,
+			TLSSkipVerify:                  b.TLS,
+			Interval:                       checkInterval,
+			Timeout:                        checkTimeout,
+			DeregisterCriticalServiceAfter: deregisterAfter,
+		},
+	}
+}
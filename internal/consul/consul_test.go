@@ -0,0 +1,175 @@
+package consul
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/registry"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+// fakeAgent records the service register/deregister calls of the Consul
+// agent HTTP API.
+type fakeAgent struct {
+	mu           sync.Mutex
+	services     map[string]api.AgentServiceRegistration
+	registers    int
+	deregistered []string
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *httptest.Server) {
+	t.Helper()
+	fa := &fakeAgent{services: make(map[string]api.AgentServiceRegistration)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fa.mu.Lock()
+		defer fa.mu.Unlock()
+		switch {
+		case r.URL.Path == "/v1/agent/service/register":
+			var reg api.AgentServiceRegistration
+			if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fa.services[reg.ID] = reg
+			fa.registers++
+		case strings.HasPrefix(r.URL.Path, "/v1/agent/service/deregister/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/")
+			if _, ok := fa.services[id]; !ok {
+				http.Error(w, "Unknown service ID", http.StatusNotFound)
+				return
+			}
+			delete(fa.services, id)
+			fa.deregistered = append(fa.deregistered, id)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return fa, srv
+}
+
+func (fa *fakeAgent) service(id string) (api.AgentServiceRegistration, bool) {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	s, ok := fa.services[id]
+	return s, ok
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.Username = "alice"
+	cfg.ConsulAddr = strings.TrimPrefix(srv.URL, "http://")
+	c, err := New(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestRegister_Service(t *testing.T) {
+	fa, srv := newFakeAgent(t)
+	c := newTestClient(t, srv)
+
+	b := &registry.Backend{Port: 4096, Slug: "my-app", ProjectName: "my-app", ProjectPath: "/home/alice/my-app", Version: "1.0"}
+	if err := c.Register(b); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	s, ok := fa.service("my-app")
+	if !ok {
+		t.Fatal("expected service my-app to be registered")
+	}
+	if s.Name != "my-app" || s.Port != c.cfg.ListenPort {
+		t.Errorf("unexpected service: name=%q port=%d", s.Name, s.Port)
+	}
+	if want := []string{"opencode", "username:alice"}; !slices.Equal(s.Tags, want) {
+		t.Errorf("tags = %v, want %v", s.Tags, want)
+	}
+	if s.Meta["backend"] != "127.0.0.1:4096" || s.Meta["version"] != "1.0" {
+		t.Errorf("unexpected meta: %v", s.Meta)
+	}
+	if s.Check == nil || s.Check.HTTP != "http://127.0.0.1:4096/global/health" {
+		t.Errorf("unexpected health check: %+v", s.Check)
+	}
+
+	if err := c.Deregister("my-app"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+	if _, ok := fa.service("my-app"); ok {
+		t.Error("expected service to be deregistered")
+	}
+	if err := c.Deregister("my-app"); err == nil {
+		t.Error("expected error deregistering an unknown service")
+	}
+}
+
+func TestRegister_TLSBackendCheck(t *testing.T) {
+	fa, srv := newFakeAgent(t)
+	c := newTestClient(t, srv)
+
+	if err := c.Register(&registry.Backend{Port: 4443, Slug: "secure", TLS: true}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s, _ := fa.service("secure")
+	if s.Check == nil || s.Check.HTTP != "https://127.0.0.1:4443/global/health" || !s.Check.TLSSkipVerify {
+		t.Errorf("unexpected health check for TLS backend: %+v", s.Check)
+	}
+}
+
+func TestSync(t *testing.T) {
+	fa, srv := newFakeAgent(t)
+	c := newTestClient(t, srv)
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "a", "/home/alice/a", "1.0")
+	reg.Upsert(4097, "b", "/home/alice/b", "1.0")
+
+	c.Sync(reg.All())
+	if _, ok := fa.service("a"); !ok {
+		t.Error("expected a to be registered")
+	}
+	if _, ok := fa.service("b"); !ok {
+		t.Error("expected b to be registered")
+	}
+
+	// Unchanged backends are not re-registered.
+	c.Sync(reg.All())
+	if fa.registers != 2 {
+		t.Errorf("expected 2 registrations, got %d", fa.registers)
+	}
+
+	// A version change re-registers; a removed backend is deregistered.
+	reg.Upsert(4096, "a", "/home/alice/a", "1.1")
+	reg.Remove("b")
+	c.Sync(reg.All())
+	if s, _ := fa.service("a"); s.Meta["version"] != "1.1" {
+		t.Errorf("expected a to be re-registered with version 1.1, got %v", s.Meta)
+	}
+	if _, ok := fa.service("b"); ok {
+		t.Error("expected b to be deregistered")
+	}
+
+	c.Shutdown()
+	if _, ok := fa.service("a"); ok {
+		t.Error("expected Shutdown to deregister all services")
+	}
+}
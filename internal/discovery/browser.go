@@ -0,0 +1,387 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"opencoderouter/internal/config"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// RemoteEntry is a project advertised by another OpenCode Router on the LAN.
+type RemoteEntry struct {
+	Instance string    `json:"instance"`
+	Host     string    `json:"host"`
+	Port     int       `json:"port"`
+	Username string    `json:"username"`
+	Project  string    `json:"project,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Router   string    `json:"router,omitempty"` // advertising router's hostname
+	Addrs    []string  `json:"addrs,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Peer is another OpenCode Router on the LAN, derived from the projects it
+// advertises.
+type Peer struct {
+	Host     string    `json:"host"`
+	IP       string    `json:"ip"`
+	Port     int       `json:"port"`
+	Username string    `json:"username"`
+	Projects int       `json:"projects"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// URL returns the path-routed URL for the remote project.
+func (e RemoteEntry) URL() string {
+	host := strings.TrimSuffix(e.Host, ".")
+	if len(e.Addrs) > 0 {
+		host = e.Addrs[0]
+	}
+	return fmt.Sprintf("http://%s/%s/", net.JoinHostPort(host, fmt.Sprintf("%d", e.Port)), e.Instance)
+}
+
+// RemoteRegistry is a thread-safe store of entries discovered via mDNS browsing.
+// It is deliberately separate from registry.Registry: remote entries are never
+// proxied to directly and must not be re-advertised.
+type RemoteRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]RemoteEntry // host:port/instance → entry
+}
+
+// NewRemoteRegistry creates an empty RemoteRegistry.
+func NewRemoteRegistry() *RemoteRegistry {
+	return &RemoteRegistry{entries: make(map[string]RemoteEntry)}
+}
+
+// Upsert adds or refreshes a remote entry. Returns true if this is a new entry.
+func (r *RemoteRegistry) Upsert(e RemoteEntry) bool {
+	if e.LastSeen.IsZero() {
+		e.LastSeen = time.Now()
+	}
+	key := remoteKey(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, existed := r.entries[key]
+	r.entries[key] = e
+	return !existed
+}
+
+// Prune removes entries unseen for longer than maxAge and returns their instance names.
+func (r *RemoteRegistry) Prune(maxAge time.Duration) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed []string
+	for key, e := range r.entries {
+		if time.Since(e.LastSeen) > maxAge {
+			delete(r.entries, key)
+			removed = append(removed, e.Instance)
+		}
+	}
+	return removed
+}
+
+// All returns a snapshot of all remote entries sorted by host, then instance.
+func (r *RemoteRegistry) All() []RemoteEntry {
+	r.mu.RLock()
+	result := make([]RemoteEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		result = append(result, e)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Host != result[j].Host {
+			return result[i].Host < result[j].Host
+		}
+		return result[i].Instance < result[j].Instance
+	})
+	return result
+}
+
+// Peers groups the remote entries by the router that advertised them,
+// identified by address, port and owner, sorted by host then port.
+func (r *RemoteRegistry) Peers() []Peer {
+	byRouter := make(map[string]*Peer)
+	for _, e := range r.All() {
+		ip := e.Host
+		if len(e.Addrs) > 0 {
+			ip = e.Addrs[0]
+		}
+		key := fmt.Sprintf("%s:%d/%s", ip, e.Port, e.Username)
+		p, ok := byRouter[key]
+		if !ok {
+			host := e.Router
+			if host == "" {
+				host = ip
+			}
+			p = &Peer{Host: host, IP: ip, Port: e.Port, Username: e.Username}
+			byRouter[key] = p
+		}
+		p.Projects++
+		if e.LastSeen.After(p.LastSeen) {
+			p.LastSeen = e.LastSeen
+		}
+	}
+
+	peers := make([]Peer, 0, len(byRouter))
+	for _, p := range byRouter {
+		peers = append(peers, *p)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Host != peers[j].Host {
+			return peers[i].Host < peers[j].Host
+		}
+		return peers[i].Port < peers[j].Port
+	})
+	return peers
+}
+
+// Len returns the number of remote entries.
+func (r *RemoteRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+func remoteKey(e RemoteEntry) string {
+	return fmt.Sprintf("%s:%d/%s", e.Host, e.Port, e.Instance)
+}
+
+// Browser listens for mDNS advertisements from other routers and records them
+// in a RemoteRegistry.
+type Browser struct {
+	cfg        config.Config
+	outboundIP net.IP
+	remotes    *RemoteRegistry
+	interval   time.Duration
+	timeout    time.Duration   // entries unseen this long are pruned
+	ifaces     []net.Interface // nil = all interfaces
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBrowser creates a Browser that stores discoveries in remotes.
+// Each browse round lasts one scan interval; entries not re-announced within
+// cfg.PeerTimeout (three rounds if unset) are pruned.
+func NewBrowser(cfg config.Config, remotes *RemoteRegistry, logger *slog.Logger) *Browser {
+	interval := cfg.ScanInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	timeout := cfg.PeerTimeout
+	if timeout <= 0 {
+		timeout = 3 * interval
+	}
+	return &Browser{
+		cfg:        cfg,
+		outboundIP: config.GetOutboundIP(),
+		remotes:    remotes,
+		interval:   interval,
+		timeout:    timeout,
+		ifaces:     resolveInterfaces(cfg.MDNSInterfaces, logger),
+		logger:     logger,
+	}
+}
+
+// Start launches the browse loop in the background. It returns an error if the
+// browser is already running.
+func (b *Browser) Start(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cancel != nil {
+		return fmt.Errorf("browser already started")
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	go b.run(loopCtx, b.done)
+
+	b.logger.Info("mDNS browser started", "service", b.cfg.MDNSServiceType)
+	return nil
+}
+
+// Close stops the browse loop and waits for it to exit.
+func (b *Browser) Close() {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.cancel, b.done = nil, nil
+	b.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	b.logger.Info("mDNS browser stopped")
+}
+
+// SetServiceType switches the DNS-SD service type browsed, starting with the next round.
+func (b *Browser) SetServiceType(serviceType string) {
+	b.mu.Lock()
+	b.cfg.MDNSServiceType = serviceType
+	b.mu.Unlock()
+}
+
+func (b *Browser) serviceType() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cfg.MDNSServiceType
+}
+
+func (b *Browser) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		err := b.browseOnce(ctx)
+		b.expire()
+
+		// A failed round returns immediately; wait before retrying so a
+		// host without multicast doesn't spin.
+		wait := time.Duration(0)
+		if err != nil {
+			b.logger.Debug("mDNS browse round failed", "error", err)
+			wait = b.interval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// browseOnce runs a single browse round. zeroconf only reports each instance
+// once per Browse call, so rounds are bounded to refresh LastSeen periodically.
+func (b *Browser) browseOnce(ctx context.Context) error {
+	var opts []zeroconf.ClientOption
+	if len(b.ifaces) > 0 {
+		opts = append(opts, zeroconf.SelectIfaces(b.ifaces))
+	}
+	resolver, err := zeroconf.NewResolver(opts...)
+	if err != nil {
+		return fmt.Errorf("zeroconf.NewResolver: %w", err)
+	}
+
+	roundCtx, cancel := context.WithTimeout(ctx, b.interval)
+	defer cancel()
+
+	entries := make(chan *zeroconf.ServiceEntry)
+	if err := resolver.Browse(roundCtx, b.serviceType(), "local.", entries); err != nil {
+		return fmt.Errorf("zeroconf.Browse: %w", err)
+	}
+
+	for {
+		select {
+		case <-roundCtx.Done():
+			return nil
+		case se, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			b.handleEntry(se)
+		}
+	}
+}
+
+// expire drops entries that have not been re-announced within the peer timeout.
+func (b *Browser) expire() {
+	if removed := b.remotes.Prune(b.timeout); len(removed) > 0 {
+		b.logger.Info("remote entries expired", "count", len(removed), "instances", removed)
+	}
+}
+
+func (b *Browser) handleEntry(se *zeroconf.ServiceEntry) {
+	if se == nil {
+		return
+	}
+	entry := remoteEntryFromService(se)
+	if b.isSelf(entry) {
+		return
+	}
+	if b.remotes.Upsert(entry) {
+		b.logger.Info("remote router entry discovered",
+			"instance", entry.Instance,
+			"host", entry.Host,
+			"port", entry.Port,
+			"username", entry.Username,
+		)
+	}
+}
+
+// isSelf reports whether an entry was advertised by this router's own Advertiser.
+func (b *Browser) isSelf(e RemoteEntry) bool {
+	if e.Username != b.cfg.Username || e.Port != b.cfg.ListenPort {
+		return false
+	}
+	if b.outboundIP == nil {
+		return false
+	}
+	for _, addr := range e.Addrs {
+		if addr == b.outboundIP.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteEntryFromService converts a zeroconf entry into a RemoteEntry.
+func remoteEntryFromService(se *zeroconf.ServiceEntry) RemoteEntry {
+	txt := parseTXTRecords(se.Text)
+
+	addrs := make([]string, 0, len(se.AddrIPv4)+len(se.AddrIPv6))
+	for _, ip := range se.AddrIPv4 {
+		addrs = append(addrs, ip.String())
+	}
+	for _, ip := range se.AddrIPv6 {
+		addrs = append(addrs, ip.String())
+	}
+
+	return RemoteEntry{
+		Instance: se.Instance,
+		Host:     strings.TrimSuffix(se.HostName, "."),
+		Port:     se.Port,
+		Username: txt["owner"],
+		Project:  txt["project"],
+		Path:     txt["path"],
+		Version:  txt["version"],
+		Router:   txt["router"],
+		Addrs:    addrs,
+		LastSeen: time.Now(),
+	}
+}
+
+// parseTXTRecords splits "key=value" TXT strings into a map.
+// Entries without "=" are kept as keys with empty values; later keys win.
+func parseTXTRecords(records []string) map[string]string {
+	result := make(map[string]string, len(records))
+	for _, rec := range records {
+		rec = strings.TrimSpace(rec)
+		if rec == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(rec, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		result[key] = strings.TrimSpace(value)
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// ---------------------------------------------------------------------------
+// TXT record parsing
+// ---------------------------------------------------------------------------
+
+func TestParseTXTRecords(t *testing.T) {
+	got := parseTXTRecords([]string{
+		"project=alpha",
+		"path=/home/bob/alpha=beta",
+		"Owner=bob",
+		"flag",
+		"",
+		"=orphan",
+	})
+
+	want := map[string]string{
+		"project": "alpha",
+		"path":    "/home/bob/alpha=beta",
+		"owner":   "bob",
+		"flag":    "",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseTXTRecords returned %d keys, want %d (%v)", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("key %q = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// fakeServiceEntry builds the entry a router owned by owner at ip would
+// advertise for project.
+func fakeServiceEntry(project, owner, ip string, port int, txt ...string) *zeroconf.ServiceEntry {
+	se := zeroconf.NewServiceEntry(project, "_opencode._tcp", "local.")
+	se.HostName = project + "-" + owner + ".local."
+	se.Port = port
+	se.Text = append([]string{"project=" + project, "path=/home/" + owner + "/" + project, "owner=" + owner}, txt...)
+	se.AddrIPv4 = []net.IP{net.ParseIP(ip)}
+	return se
+}
+
+func TestRemoteEntryFromService(t *testing.T) {
+	se := fakeServiceEntry("alpha", "bob", "192.168.1.20", 8080, "version=1.2.3", "router=bobs-laptop")
+
+	e := remoteEntryFromService(se)
+	if e.Instance != "alpha" || e.Host != "alpha-bob.local" || e.Port != 8080 {
+		t.Fatalf("unexpected identity fields: %+v", e)
+	}
+	if e.Username != "bob" || e.Project != "alpha" || e.Path != "/home/bob/alpha" || e.Version != "1.2.3" || e.Router != "bobs-laptop" {
+		t.Fatalf("unexpected TXT-derived fields: %+v", e)
+	}
+	if len(e.Addrs) != 1 || e.Addrs[0] != "192.168.1.20" {
+		t.Fatalf("unexpected addrs: %v", e.Addrs)
+	}
+	if got := e.URL(); got != "http://192.168.1.20:8080/alpha/" {
+		t.Errorf("URL() = %q", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// RemoteRegistry
+// ---------------------------------------------------------------------------
+
+func TestRemoteRegistry_UpsertAndPrune(t *testing.T) {
+	r := NewRemoteRegistry()
+
+	if !r.Upsert(RemoteEntry{Instance: "alpha", Host: "alpha-bob.local", Port: 8080}) {
+		t.Error("expected first Upsert to report new entry")
+	}
+	if r.Upsert(RemoteEntry{Instance: "alpha", Host: "alpha-bob.local", Port: 8080}) {
+		t.Error("expected second Upsert to report update")
+	}
+	r.Upsert(RemoteEntry{Instance: "beta", Host: "beta-bob.local", Port: 8080, LastSeen: time.Now().Add(-time.Minute)})
+
+	if r.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", r.Len())
+	}
+
+	removed := r.Prune(30 * time.Second)
+	if len(removed) != 1 || removed[0] != "beta" {
+		t.Fatalf("expected beta to be pruned, got %v", removed)
+	}
+
+	all := r.All()
+	if len(all) != 1 || all[0].Instance != "alpha" {
+		t.Fatalf("unexpected entries after prune: %+v", all)
+	}
+}
+
+func TestRemoteRegistry_Peers(t *testing.T) {
+	r := NewRemoteRegistry()
+	old := time.Now().Add(-time.Minute)
+	r.Upsert(RemoteEntry{Instance: "alpha", Host: "alpha-bob.local", Port: 8080, Username: "bob", Router: "laptop", Addrs: []string{"10.0.0.9"}, LastSeen: old})
+	r.Upsert(RemoteEntry{Instance: "beta", Host: "beta-bob.local", Port: 8080, Username: "bob", Router: "laptop", Addrs: []string{"10.0.0.9"}})
+	r.Upsert(RemoteEntry{Instance: "alpha", Host: "alpha-eve.local", Port: 9090, Username: "eve", Addrs: []string{"10.0.0.7"}})
+
+	peers := r.Peers()
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 peers, got %+v", peers)
+	}
+	// eve's router sends no router TXT record, so its IP stands in for the host.
+	if p := peers[0]; p.Host != "10.0.0.7" || p.Port != 9090 || p.Username != "eve" || p.Projects != 1 {
+		t.Errorf("unexpected first peer %+v", p)
+	}
+	if p := peers[1]; p.Host != "laptop" || p.IP != "10.0.0.9" || p.Projects != 2 || !p.LastSeen.After(old) {
+		t.Errorf("unexpected second peer %+v", p)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Browser
+// ---------------------------------------------------------------------------
+
+func TestBrowser_PeerTimeout(t *testing.T) {
+	cfg := testCfg()
+	cfg.PeerTimeout = time.Minute
+	b := NewBrowser(cfg, NewRemoteRegistry(), testLogger())
+	b.outboundIP = net.ParseIP("10.0.0.5")
+
+	b.handleEntry(fakeServiceEntry("alpha", "bob", "10.0.0.9", 8080))
+	b.handleEntry(fakeServiceEntry("beta", "bob", "10.0.0.9", 8080))
+
+	// beta was last announced before the timeout; alpha is re-announced.
+	stale := b.remotes.All()[1]
+	stale.LastSeen = time.Now().Add(-cfg.PeerTimeout - time.Second)
+	b.remotes.Upsert(stale)
+	b.handleEntry(fakeServiceEntry("alpha", "bob", "10.0.0.9", 8080))
+
+	b.expire()
+	all := b.remotes.All()
+	if len(all) != 1 || all[0].Instance != "alpha" {
+		t.Fatalf("expected only alpha to survive the peer timeout, got %+v", all)
+	}
+	if peers := b.remotes.Peers(); len(peers) != 1 || peers[0].Projects != 1 {
+		t.Errorf("unexpected peers after expiry: %+v", peers)
+	}
+
+	b.expire()
+	if b.remotes.Len() != 1 {
+		t.Error("a fresh entry should not expire")
+	}
+}
+
+func TestBrowser_PeerTimeoutDefault(t *testing.T) {
+	cfg := testCfg()
+	cfg.PeerTimeout = 0
+	cfg.ScanInterval = 2 * time.Second
+	if b := NewBrowser(cfg, NewRemoteRegistry(), testLogger()); b.timeout != 6*time.Second {
+		t.Errorf("timeout = %s, want three browse rounds", b.timeout)
+	}
+}
+
+func TestBrowser_SkipsSelf(t *testing.T) {
+	cfg := testCfg()
+	b := NewBrowser(cfg, NewRemoteRegistry(), testLogger())
+	b.outboundIP = net.ParseIP("10.0.0.5")
+
+	self := zeroconf.NewServiceEntry("alpha", cfg.MDNSServiceType, "local.")
+	self.HostName = "alpha-testuser.local."
+	self.Port = cfg.ListenPort
+	self.Text = []string{"owner=testuser"}
+	self.AddrIPv4 = []net.IP{net.ParseIP("10.0.0.5")}
+	b.handleEntry(self)
+
+	peer := zeroconf.NewServiceEntry("alpha", cfg.MDNSServiceType, "local.")
+	peer.HostName = "alpha-bob.local."
+	peer.Port = cfg.ListenPort
+	peer.Text = []string{"owner=bob"}
+	peer.AddrIPv4 = []net.IP{net.ParseIP("10.0.0.9")}
+	b.handleEntry(peer)
+
+	all := b.remotes.All()
+	if len(all) != 1 || all[0].Username != "bob" {
+		t.Fatalf("expected only the peer entry, got %+v", all)
+	}
+}
+
+func TestBrowser_StartClose(t *testing.T) {
+	b := NewBrowser(testCfg(), NewRemoteRegistry(), testLogger())
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := b.Start(context.Background()); err == nil {
+		t.Error("expected second Start to fail")
+	}
+
+	b.Close()
+	b.Close() // idempotent
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"os"
 	"sync"
 
 	"opencoderouter/internal/config"
@@ -18,71 +19,105 @@ import (
 type Advertiser struct {
 	cfg        config.Config
 	outboundIP net.IP
-	servers    map[string]*zeroconf.Server // slug → mDNS server
+	hostname   string                        // advertised as the "router" TXT record
+	servers    map[string]*zeroconf.Server   // slug → mDNS server
+	subtypes   map[string][]*zeroconf.Server // slug → subtype servers, see RegisterSubtype
+	advertised map[string]*registry.Backend  // slug → backend as registered
+	ifaces     []net.Interface               // nil = all interfaces
 	mu         sync.Mutex
 	logger     *slog.Logger
+
+	registerProxy func(instance, service, domain string, port int, host string, ips, text []string, ifaces []net.Interface) (*zeroconf.Server, error)
 }
 
-// New creates a new mDNS Advertiser.
+// New creates a new mDNS Advertiser. cfg.MDNSInterfaces limits
+// advertisements to those interfaces; names that don't exist are skipped.
 func New(cfg config.Config, logger *slog.Logger) *Advertiser {
+	hostname, _ := os.Hostname()
 	return &Advertiser{
-		cfg:        cfg,
-		outboundIP: config.GetOutboundIP(),
-		servers:    make(map[string]*zeroconf.Server),
-		logger:     logger,
+		cfg:           cfg,
+		outboundIP:    config.GetOutboundIP(),
+		hostname:      hostname,
+		servers:       make(map[string]*zeroconf.Server),
+		subtypes:      make(map[string][]*zeroconf.Server),
+		advertised:    make(map[string]*registry.Backend),
+		ifaces:        resolveInterfaces(cfg.MDNSInterfaces, logger),
+		logger:        logger,
+		registerProxy: zeroconf.RegisterProxy,
 	}
 }
 
 // Sync reconciles the set of mDNS advertisements with the current registry state.
-// It registers new backends and unregisters removed ones.
+// It registers new backends, unregisters removed ones, and re-registers any
+// whose fingerprint changed so the TXT records stay current. Only the first
+// instance of a slug is advertised; the rest share its hostname.
 func (a *Advertiser) Sync(backends []*registry.Backend) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Build a set of current slugs.
-	currentSlugs := make(map[string]struct{}, len(backends))
-	for _, b := range backends {
-		currentSlugs[b.Slug] = struct{}{}
+	previous := make([]*registry.Backend, 0, len(a.advertised))
+	for _, b := range a.advertised {
+		previous = append(previous, b)
 	}
+	added, removed, updated := registry.DiffBackends(previous, backends)
 
-	// Remove advertisements for backends no longer present.
-	for slug, srv := range a.servers {
-		if _, ok := currentSlugs[slug]; !ok {
-			srv.Shutdown()
-			delete(a.servers, slug)
-			a.logger.Info("mDNS service removed", "slug", slug)
-		}
+	for _, b := range removed {
+		a.unregisterLocked(b.Slug)
+		a.logger.Info("mDNS service removed", "slug", b.Slug)
 	}
-
-	// Add advertisements for new backends.
+	for _, b := range updated {
+		a.reregister(b)
+	}
+	// SRV priority and weight come from labels, which Diff does not compare.
+	seen := make(map[string]struct{}, len(backends))
 	for _, b := range backends {
-		if _, ok := a.servers[b.Slug]; ok {
-			continue // already advertised
+		if _, dup := seen[b.Slug]; dup {
+			continue
+		}
+		seen[b.Slug] = struct{}{}
+		if old, ok := a.advertised[b.Slug]; ok && a.advertPrint(old) != a.advertPrint(b) {
+			a.reregister(b)
 		}
+	}
+	for _, b := range added {
 		if err := a.register(b); err != nil {
 			a.logger.Error("mDNS registration failed", "slug", b.Slug, "error", err)
 		}
 	}
 }
 
-// register creates an mDNS entry for a single backend.
-func (a *Advertiser) register(b *registry.Backend) error {
-	host := a.cfg.DomainFor(b.Slug)
-	ip := a.outboundIP.String()
-	txt := []string{
-		fmt.Sprintf("project=%s", b.ProjectName),
-		fmt.Sprintf("path=%s", b.ProjectPath),
-		fmt.Sprintf("backend=127.0.0.1:%d", b.Port),
-		fmt.Sprintf("owner=%s", a.cfg.Username),
+// reregister replaces the advertisement for b's slug with a fresh one.
+func (a *Advertiser) reregister(b *registry.Backend) {
+	a.unregisterLocked(b.Slug)
+	a.logger.Info("mDNS service changed, re-registering", "slug", b.Slug, "version", b.Version)
+	if err := a.register(b); err != nil {
+		a.logger.Error("mDNS registration failed", "slug", b.Slug, "error", err)
 	}
+}
 
-	if b.Version != "" {
-		txt = append(txt, fmt.Sprintf("version=%s", b.Version))
+// unregisterLocked withdraws the advertisement for slug, if any.
+func (a *Advertiser) unregisterLocked(slug string) bool {
+	a.unregisterSubtypesLocked(slug)
+	srv, ok := a.servers[slug]
+	if !ok {
+		return false
 	}
+	srv.Shutdown()
+	delete(a.servers, slug)
+	delete(a.advertised, slug)
+	return true
+}
+
+// register creates an mDNS entry for a single backend.
+func (a *Advertiser) register(b *registry.Backend) error {
+	host := a.cfg.DomainForUser(b.Slug, b.Username)
+	ip := a.outboundIP.String()
+	txt := a.advertText(b)
+	priority, weight := a.srvParams(b)
 
 	// RegisterProxy lets us set a custom hostname for the A record,
 	// so "{slug}-{username}.local" resolves to this machine's IP.
-	srv, err := zeroconf.RegisterProxy(
+	srv, err := a.registerProxy(
 		b.Slug,                // instance name
 		a.cfg.MDNSServiceType, // service type: "_opencode._tcp"
 		"local.",              // domain
@@ -90,30 +125,89 @@ func (a *Advertiser) register(b *registry.Backend) error {
 		host,                  // hostname for A record
 		[]string{ip},          // IPs
 		txt,                   // TXT records
-		nil,                   // interfaces (nil = all)
+		a.ifaces,              // interfaces (nil = all)
 	)
 	if err != nil {
 		return fmt.Errorf("zeroconf.RegisterProxy: %w", err)
 	}
 
 	a.servers[b.Slug] = srv
+	a.advertised[b.Slug] = b
 	a.logger.Info("mDNS service registered",
 		"slug", b.Slug,
 		"host", host,
 		"ip", ip,
 		"port", a.cfg.ListenPort,
+		"priority", priority,
+		"weight", weight,
 	)
+	for _, subtype := range a.subtypesFor(b) {
+		if err := a.registerSubtypeLocked(b, subtype); err != nil {
+			a.logger.Error("mDNS subtype registration failed", "slug", b.Slug, "subtype", subtype, "error", err)
+		}
+	}
 	return nil
 }
 
+// advertText returns the TXT records advertised for b.
+func (a *Advertiser) advertText(b *registry.Backend) []string {
+	txt := []string{
+		fmt.Sprintf("project=%s", b.ProjectName),
+		fmt.Sprintf("path=%s", b.ProjectPath),
+		fmt.Sprintf("backend=%s", b.Addr()),
+		fmt.Sprintf("owner=%s", a.cfg.Username),
+	}
+
+	if b.Version != "" {
+		txt = append(txt, fmt.Sprintf("version=%s", b.Version))
+	}
+	if a.hostname != "" {
+		txt = append(txt, fmt.Sprintf("router=%s", a.hostname))
+	}
+	priority, weight := a.srvParams(b)
+	return append(txt, srvText(priority, weight)...)
+}
+
+// Unregister withdraws the advertisement for slug immediately rather than
+// waiting for the next Sync.
+func (a *Advertiser) Unregister(slug string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.unregisterLocked(slug) {
+		a.logger.Info("mDNS service removed", "slug", slug)
+	}
+}
+
+// SetServiceType switches the DNS-SD service type. Existing advertisements are
+// withdrawn and re-registered under the new type on the next Sync.
+func (a *Advertiser) SetServiceType(serviceType string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if serviceType == a.cfg.MDNSServiceType {
+		return
+	}
+	for slug, srv := range a.servers {
+		a.unregisterSubtypesLocked(slug)
+		srv.Shutdown()
+	}
+	a.servers = make(map[string]*zeroconf.Server)
+	a.advertised = make(map[string]*registry.Backend)
+	a.cfg.MDNSServiceType = serviceType
+	a.logger.Info("mDNS service type changed", "service", serviceType)
+}
+
 // Shutdown stops all mDNS advertisements.
 func (a *Advertiser) Shutdown() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	for slug := range a.subtypes {
+		a.unregisterSubtypesLocked(slug)
+	}
 	for slug, srv := range a.servers {
 		srv.Shutdown()
 		a.logger.Debug("mDNS service shut down", "slug", slug)
 	}
 	a.servers = make(map[string]*zeroconf.Server)
+	a.advertised = make(map[string]*registry.Backend)
 	a.logger.Info("all mDNS services shut down")
 }
@@ -1,14 +1,19 @@
 package discovery
 
 import (
+	"errors"
 	"log/slog"
+	"net"
 	"os"
 	"runtime/debug"
+	"slices"
 	"testing"
 	"time"
 
 	"opencoderouter/internal/config"
 	"opencoderouter/internal/registry"
+
+	"github.com/grandcat/zeroconf"
 )
 
 func testLogger() *slog.Logger {
@@ -109,6 +114,37 @@ func TestSync_RemovesStale(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Sync — re-register on change
+// ---------------------------------------------------------------------------
+
+func TestSync_ReregistersOnVersionChange(t *testing.T) {
+	adv := New(testCfg(), testLogger())
+	defer adv.Shutdown()
+
+	b := &registry.Backend{Slug: "alpha", Port: 4096, ProjectName: "alpha", ProjectPath: "/alpha", Version: "1.0", LastSeen: time.Now()}
+	adv.Sync([]*registry.Backend{b})
+	adv.mu.Lock()
+	srv1 := adv.servers["alpha"]
+	adv.mu.Unlock()
+
+	updated := *b
+	updated.Version = "1.1"
+	adv.Sync([]*registry.Backend{&updated})
+	adv.mu.Lock()
+	defer adv.mu.Unlock()
+	srv2, ok := adv.servers["alpha"]
+	if !ok {
+		t.Fatal("expected 'alpha' to still be advertised")
+	}
+	if srv1 == srv2 {
+		t.Error("expected server to be replaced after version change")
+	}
+	if adv.advertPrint(adv.advertised["alpha"]) != adv.advertPrint(&updated) {
+		t.Error("expected stored backend to match the updated backend")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Sync — no-op when unchanged
 // ---------------------------------------------------------------------------
@@ -212,3 +248,100 @@ func raceDetectorEnabled() bool {
 	}
 	return false
 }
+
+// ---------------------------------------------------------------------------
+// Interfaces
+// ---------------------------------------------------------------------------
+
+func TestNew_ResolvesInterfaces(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("no loopback interface named lo: %v", err)
+	}
+
+	cfg := testCfg()
+	cfg.MDNSInterfaces = []string{"lo", "does-not-exist0"}
+	adv := New(cfg, testLogger())
+	defer adv.Shutdown()
+
+	if len(adv.ifaces) != 1 || adv.ifaces[0].Name != "lo" || adv.ifaces[0].Index != lo.Index {
+		t.Fatalf("expected only lo to resolve, got %+v", adv.ifaces)
+	}
+
+	var got []net.Interface
+	adv.registerProxy = func(instance, service, domain string, port int, host string, ips, text []string, ifaces []net.Interface) (*zeroconf.Server, error) {
+		got = ifaces
+		return nil, errors.New("not registering in tests")
+	}
+	adv.Sync([]*registry.Backend{{Slug: "alpha", Port: 4096, ProjectName: "alpha", ProjectPath: "/alpha", LastSeen: time.Now()}})
+	if len(got) != 1 || got[0].Name != "lo" {
+		t.Errorf("expected RegisterProxy to receive [lo], got %+v", got)
+	}
+}
+
+func TestNew_AllInterfacesByDefault(t *testing.T) {
+	adv := New(testCfg(), testLogger())
+	if adv.ifaces != nil {
+		t.Errorf("expected nil interfaces (all), got %+v", adv.ifaces)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// SRV priority and weight
+// ---------------------------------------------------------------------------
+
+func TestRegister_SRVPriorityAndWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+		weight   int
+		labels   map[string]string
+		want     []string
+	}{
+		{"defaults", 0, config.DefaultMDNSSRVWeight, nil, []string{"srv_priority=0", "srv_weight=100"}},
+		{"config", 5, 20, nil, []string{"srv_priority=5", "srv_weight=20"}},
+		{"label override", 5, 20, map[string]string{"mdns_priority": "1", "mdns_weight": "7"}, []string{"srv_priority=1", "srv_weight=7"}},
+		{"invalid labels ignored", 5, 20, map[string]string{"mdns_priority": "high", "mdns_weight": "70000"}, []string{"srv_priority=5", "srv_weight=20"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testCfg()
+			cfg.MDNSSRVPriority = tt.priority
+			cfg.MDNSSRVWeight = tt.weight
+			adv := New(cfg, testLogger())
+
+			var got []string
+			adv.registerProxy = func(instance, service, domain string, port int, host string, ips, text []string, ifaces []net.Interface) (*zeroconf.Server, error) {
+				got = text
+				return nil, errors.New("not registering in tests")
+			}
+			adv.Sync([]*registry.Backend{{Slug: "alpha", Port: 4096, ProjectPath: "/alpha", Labels: tt.labels, LastSeen: time.Now()}})
+			for _, want := range tt.want {
+				if !slices.Contains(got, want) {
+					t.Errorf("TXT = %v, missing %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSync_ReregistersOnPriorityLabelChange(t *testing.T) {
+	adv := New(testCfg(), testLogger())
+	defer adv.Shutdown()
+
+	b := &registry.Backend{Slug: "alpha", Port: 4096, ProjectPath: "/alpha", LastSeen: time.Now()}
+	adv.Sync([]*registry.Backend{b})
+	adv.mu.Lock()
+	srv1 := adv.servers["alpha"]
+	adv.mu.Unlock()
+
+	updated := *b
+	updated.Labels = map[string]string{"mdns_priority": "10"}
+	adv.Sync([]*registry.Backend{&updated})
+	adv.mu.Lock()
+	defer adv.mu.Unlock()
+	if srv2 := adv.servers["alpha"]; srv2 == nil || srv2 == srv1 {
+		t.Error("expected server to be replaced after the priority label changed")
+	}
+}
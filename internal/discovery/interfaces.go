@@ -0,0 +1,24 @@
+package discovery
+
+import (
+	"log/slog"
+	"net"
+)
+
+// resolveInterfaces looks up the named network interfaces for mDNS. Unknown
+// names are logged and skipped. A nil result means all interfaces.
+func resolveInterfaces(names []string, logger *slog.Logger) []net.Interface {
+	var ifaces []net.Interface
+	for _, name := range names {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			logger.Warn("mDNS interface not found; skipping", "interface", name, "error", err)
+			continue
+		}
+		ifaces = append(ifaces, *iface)
+	}
+	if len(names) > 0 && len(ifaces) == 0 {
+		logger.Warn("none of the configured mDNS interfaces exist; using all interfaces", "interfaces", names)
+	}
+	return ifaces
+}
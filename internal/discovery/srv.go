@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"fmt"
+	"strconv"
+
+	"opencoderouter/internal/registry"
+)
+
+// Backend labels that override Config.MDNSSRVPriority and
+// Config.MDNSSRVWeight for one backend.
+const (
+	priorityLabel = "mdns_priority"
+	weightLabel   = "mdns_weight"
+)
+
+// srvParams returns the DNS-SD priority and weight advertised for b.
+// Labels that are not a 16-bit unsigned integer are ignored.
+func (a *Advertiser) srvParams(b *registry.Backend) (priority, weight uint16) {
+	priority, weight = uint16(a.cfg.MDNSSRVPriority), uint16(a.cfg.MDNSSRVWeight)
+	if v, err := strconv.ParseUint(b.Labels[priorityLabel], 10, 16); err == nil {
+		priority = uint16(v)
+	}
+	if v, err := strconv.ParseUint(b.Labels[weightLabel], 10, 16); err == nil {
+		weight = uint16(v)
+	}
+	return priority, weight
+}
+
+// srvText renders the priority and weight as TXT entries. zeroconf v1.0.0
+// always answers with SRV priority and weight 0, so the TXT record is where
+// DNS-SD clients can read them.
+func srvText(priority, weight uint16) []string {
+	return []string{
+		fmt.Sprintf("srv_priority=%d", priority),
+		fmt.Sprintf("srv_weight=%d", weight),
+	}
+}
+
+// advertPrint identifies what an advertisement for b carries, so Sync
+// re-registers when either the backend or its SRV overrides change.
+func (a *Advertiser) advertPrint(b *registry.Backend) string {
+	priority, weight := a.srvParams(b)
+	return fmt.Sprintf("%s/%d/%d", b.Fingerprint(), priority, weight)
+}
@@ -0,0 +1,63 @@
+package discovery
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"opencoderouter/internal/registry"
+)
+
+// RegisterSubtype advertises b under the DNS-SD subtype subtype (e.g.
+// "_v2") of the service type, so clients browsing
+// "_v2._sub._opencode._tcp" find it without filtering every instance.
+//
+// zeroconf v1.0.0 has no subtype support, so the subtype is a separate
+// advertisement with the subtype service name and the same host, port and
+// TXT records as b's main one. It is withdrawn along with the main one.
+func (a *Advertiser) RegisterSubtype(b *registry.Backend, subtype string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.registerSubtypeLocked(b, subtype)
+}
+
+func (a *Advertiser) registerSubtypeLocked(b *registry.Backend, subtype string) error {
+	service := subtype + "._sub." + a.cfg.MDNSServiceType
+	srv, err := a.registerProxy(
+		b.Slug,
+		service,
+		"local.",
+		a.cfg.ListenPort,
+		a.cfg.DomainForUser(b.Slug, b.Username),
+		[]string{a.outboundIP.String()},
+		a.advertText(b),
+		a.ifaces,
+	)
+	if err != nil {
+		return fmt.Errorf("zeroconf.RegisterProxy %s: %w", service, err)
+	}
+	a.subtypes[b.Slug] = append(a.subtypes[b.Slug], srv)
+	a.logger.Info("mDNS subtype registered", "slug", b.Slug, "service", service)
+	return nil
+}
+
+// unregisterSubtypesLocked withdraws every subtype advertisement for slug.
+func (a *Advertiser) unregisterSubtypesLocked(slug string) {
+	for _, srv := range a.subtypes[slug] {
+		srv.Shutdown()
+	}
+	delete(a.subtypes, slug)
+}
+
+// subtypesFor returns the subtypes of config.Config.MDNSSubtypes whose
+// version prefix b.Version starts with, sorted and without duplicates.
+func (a *Advertiser) subtypesFor(b *registry.Backend) []string {
+	var subtypes []string
+	for prefix, subtype := range a.cfg.MDNSSubtypes {
+		if b.Version != "" && strings.HasPrefix(b.Version, prefix) {
+			subtypes = append(subtypes, subtype)
+		}
+	}
+	slices.Sort(subtypes)
+	return slices.Compact(subtypes)
+}
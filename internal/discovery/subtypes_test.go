@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// recordServices makes adv record the service type of every registration
+// it makes, registering for real so the servers can be shut down.
+func recordServices(adv *Advertiser) func() []string {
+	var mu sync.Mutex
+	var services []string
+	adv.registerProxy = func(instance, service, domain string, port int, host string, ips, text []string, ifaces []net.Interface) (*zeroconf.Server, error) {
+		mu.Lock()
+		services = append(services, service)
+		mu.Unlock()
+		return zeroconf.RegisterProxy(instance, service, domain, port, host, ips, text, ifaces)
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(services)
+	}
+}
+
+func TestRegister_Subtypes(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{"2.1.0", []string{"_opencode._tcp", "_v2._sub._opencode._tcp"}},
+		{"1.9", []string{"_opencode._tcp"}},
+		{"", []string{"_opencode._tcp"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			cfg := testCfg()
+			cfg.MDNSSubtypes = map[string]string{"2.": "_v2", "3.": "_v3"}
+			adv := New(cfg, testLogger())
+			defer adv.Shutdown()
+			services := recordServices(adv)
+
+			adv.Sync([]*registry.Backend{{Slug: "alpha", Port: 4096, ProjectPath: "/alpha", Version: tt.version, LastSeen: time.Now()}})
+			if got := services(); !slices.Equal(got, tt.want) {
+				t.Errorf("registered services = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterSubtype(t *testing.T) {
+	adv := New(testCfg(), testLogger())
+	defer adv.Shutdown()
+	services := recordServices(adv)
+
+	b := &registry.Backend{Slug: "alpha", Port: 4096, ProjectPath: "/alpha", Version: "1.9", LastSeen: time.Now()}
+	adv.Sync([]*registry.Backend{b})
+	if err := adv.RegisterSubtype(b, "_beta"); err != nil {
+		t.Fatalf("RegisterSubtype: %v", err)
+	}
+	if got, want := services(), []string{"_opencode._tcp", "_beta._sub._opencode._tcp"}; !slices.Equal(got, want) {
+		t.Errorf("registered services = %v, want %v", got, want)
+	}
+
+	// Withdrawing the backend withdraws its subtypes too.
+	adv.Sync(nil)
+	adv.mu.Lock()
+	n := len(adv.subtypes)
+	adv.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d backends still have subtype advertisements after removal", n)
+	}
+}
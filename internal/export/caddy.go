@@ -0,0 +1,45 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"opencoderouter/internal/registry"
+)
+
+// CaddyExporter writes a Caddyfile with one site block per domain that
+// reverse proxies to its backends on 127.0.0.1, the way the router's own
+// host-based routing does. A slug with several instances gets one upstream
+// per port, which Caddy load balances.
+type CaddyExporter struct {
+	// Domain returns the site address of a backend, e.g. from
+	// config.DomainForUser.
+	Domain func(b *registry.Backend) string
+}
+
+// ContentType implements Exporter.
+func (CaddyExporter) ContentType() string { return "text/plain; charset=utf-8" }
+
+// Export implements Exporter.
+func (e CaddyExporter) Export(backends []*registry.Backend) ([]byte, error) {
+	if e.Domain == nil {
+		return nil, fmt.Errorf("caddy export: no domain function")
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by OpenCodeRouter from the registered backends.\n")
+	var site string
+	for _, b := range routable(backends) {
+		if domain := e.Domain(b); domain != site {
+			if site != "" {
+				buf.WriteString("\n}\n")
+			}
+			site = domain
+			fmt.Fprintf(&buf, "\n%s {\n\treverse_proxy", site)
+		}
+		buf.WriteString(" " + b.Addr())
+	}
+	if site != "" {
+		buf.WriteString("\n}\n")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+
+	"opencoderouter/internal/registry"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultComposeImage is the container image ComposeExporter uses when its
+// Image is empty.
+const DefaultComposeImage = "ghcr.io/sst/opencode:latest"
+
+// composeWorkdir is where a backend's project directory is mounted.
+const composeWorkdir = "/workspace"
+
+// ComposeExporter writes a docker-compose.yml services block with one
+// service per backend. Each runs `opencode serve` on the backend's port with
+// its project directory mounted, and publishes that port on 127.0.0.1 so
+// the router finds it in the same place once the host process is stopped.
+type ComposeExporter struct {
+	// Image is the container image; empty means DefaultComposeImage.
+	Image string
+}
+
+// composeFile and composeService fix the key order of the YAML output.
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image      string            `yaml:"image"`
+	Command    []string          `yaml:"command"`
+	WorkingDir string            `yaml:"working_dir"`
+	Volumes    []string          `yaml:"volumes"`
+	Ports      []string          `yaml:"ports"`
+	Labels     map[string]string `yaml:"labels"`
+}
+
+// ContentType implements Exporter.
+func (ComposeExporter) ContentType() string { return "application/yaml" }
+
+// Export implements Exporter. Services are named opencode-{slug}, with the
+// port appended when a slug has several instances.
+func (e ComposeExporter) Export(backends []*registry.Backend) ([]byte, error) {
+	image := e.Image
+	if image == "" {
+		image = DefaultComposeImage
+	}
+	backends = routable(backends)
+	instances := make(map[string]int, len(backends))
+	for _, b := range backends {
+		instances[b.Slug]++
+	}
+
+	file := composeFile{Services: make(map[string]composeService, len(backends))}
+	for _, b := range backends {
+		name := "opencode-" + b.Slug
+		if instances[b.Slug] > 1 {
+			name += "-" + strconv.Itoa(b.Port)
+		}
+		port := strconv.Itoa(b.Port)
+		labels := map[string]string{
+			"opencoderouter.slug":    b.Slug,
+			"opencoderouter.project": b.ProjectName,
+		}
+		for k, v := range b.Labels {
+			labels["opencoderouter.label."+k] = v
+		}
+		file.Services[name] = composeService{
+			Image:      image,
+			Command:    []string{"serve", "--port", port, "--hostname", "0.0.0.0"},
+			WorkingDir: composeWorkdir,
+			Volumes:    []string{b.ProjectPath + ":" + composeWorkdir},
+			Ports:      []string{"127.0.0.1:" + port + ":" + port},
+			Labels:     labels,
+		}
+	}
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("compose export: %w", err)
+	}
+	return append([]byte("# Generated by OpenCodeRouter from the registered backends.\n"), out...), nil
+}
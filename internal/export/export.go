@@ -0,0 +1,39 @@
+// Package export renders the registry's backends as configuration for other
+// tools, such as a Caddyfile that fronts each backend under its own host name
+// or a docker-compose services block that runs them in containers.
+package export
+
+import (
+	"sort"
+
+	"opencoderouter/internal/registry"
+)
+
+// Exporter renders a list of backends as a configuration file.
+type Exporter interface {
+	Export(backends []*registry.Backend) ([]byte, error)
+	// ContentType is the media type of Export's output.
+	ContentType() string
+}
+
+// routable returns the backends that take new requests, sorted by slug,
+// username and port so the output is stable between calls. Draining backends are
+// on their way out and left out.
+func routable(backends []*registry.Backend) []*registry.Backend {
+	out := make([]*registry.Backend, 0, len(backends))
+	for _, b := range backends {
+		if !b.Draining {
+			out = append(out, b)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Slug != out[j].Slug {
+			return out[i].Slug < out[j].Slug
+		}
+		if out[i].Username != out[j].Username {
+			return out[i].Username < out[j].Username
+		}
+		return out[i].Port < out[j].Port
+	})
+	return out
+}
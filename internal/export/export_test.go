@@ -0,0 +1,112 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"opencoderouter/internal/registry"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testBackends() []*registry.Backend {
+	return []*registry.Backend{
+		{Slug: "web", Port: 4097, ProjectName: "web", ProjectPath: "/home/alice/web"},
+		{Slug: "api", Port: 4096, ProjectName: "api", ProjectPath: "/home/alice/api", Labels: map[string]string{"env": "dev"}},
+		{Slug: "web", Port: 4098, ProjectName: "web", ProjectPath: "/home/alice/web"},
+		{Slug: "old", Port: 4099, ProjectName: "old", ProjectPath: "/home/alice/old", Draining: true},
+	}
+}
+
+func TestCaddyExporter(t *testing.T) {
+	e := CaddyExporter{Domain: func(b *registry.Backend) string { return b.Slug + "-alice.local" }}
+	out, err := e.Export(testBackends())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got := string(out)
+	for _, want := range []string{
+		"api-alice.local {\n\treverse_proxy 127.0.0.1:4096\n}\n",
+		"web-alice.local {\n\treverse_proxy 127.0.0.1:4097 127.0.0.1:4098\n}\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Caddyfile missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "old-alice.local") || strings.Contains(got, "4099") {
+		t.Errorf("draining backend exported:\n%s", got)
+	}
+	if strings.Index(got, "api-alice") > strings.Index(got, "web-alice") {
+		t.Errorf("site blocks not sorted by slug:\n%s", got)
+	}
+}
+
+func TestCaddyExporter_Empty(t *testing.T) {
+	out, err := CaddyExporter{Domain: func(b *registry.Backend) string { return b.Slug }}.Export(nil)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if strings.Contains(string(out), "{") {
+		t.Errorf("expected no site blocks, got:\n%s", out)
+	}
+}
+
+func TestComposeExporter(t *testing.T) {
+	out, err := ComposeExporter{}.Export(testBackends())
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var file struct {
+		Services map[string]struct {
+			Image      string            `yaml:"image"`
+			Command    []string          `yaml:"command"`
+			WorkingDir string            `yaml:"working_dir"`
+			Volumes    []string          `yaml:"volumes"`
+			Ports      []string          `yaml:"ports"`
+			Labels     map[string]string `yaml:"labels"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(out, &file); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, out)
+	}
+	if len(file.Services) != 3 {
+		t.Fatalf("got %d services, want 3:\n%s", len(file.Services), out)
+	}
+
+	api, ok := file.Services["opencode-api"]
+	if !ok {
+		t.Fatalf("missing opencode-api service:\n%s", out)
+	}
+	if api.Image != DefaultComposeImage {
+		t.Errorf("image = %q, want %q", api.Image, DefaultComposeImage)
+	}
+	if got := strings.Join(api.Command, " "); got != "serve --port 4096 --hostname 0.0.0.0" {
+		t.Errorf("command = %q", got)
+	}
+	if len(api.Ports) != 1 || api.Ports[0] != "127.0.0.1:4096:4096" {
+		t.Errorf("ports = %v", api.Ports)
+	}
+	if len(api.Volumes) != 1 || api.Volumes[0] != "/home/alice/api:/workspace" || api.WorkingDir != "/workspace" {
+		t.Errorf("volumes = %v, working_dir = %q", api.Volumes, api.WorkingDir)
+	}
+	if api.Labels["opencoderouter.slug"] != "api" || api.Labels["opencoderouter.label.env"] != "dev" {
+		t.Errorf("labels = %v", api.Labels)
+	}
+
+	// A slug with several instances gets one service per port.
+	for _, name := range []string{"opencode-web-4097", "opencode-web-4098"} {
+		if _, ok := file.Services[name]; !ok {
+			t.Errorf("missing %s service:\n%s", name, out)
+		}
+	}
+}
+
+func TestComposeExporter_Image(t *testing.T) {
+	out, err := ComposeExporter{Image: "example/opencode:1.2"}.Export(testBackends()[:1])
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if !strings.Contains(string(out), "image: example/opencode:1.2") {
+		t.Errorf("custom image not used:\n%s", out)
+	}
+}
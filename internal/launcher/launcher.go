@@ -3,6 +3,7 @@ package launcher
 import (
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net"
 	"os"
 	"os/exec"
@@ -12,30 +13,174 @@ import (
 	"time"
 )
 
+const (
+	defaultInitialBackoff = 1 * time.Second
+	defaultMaxBackoff     = 30 * time.Second
+
+	// diedBuffer bounds undelivered exit notifications; further exits are
+	// dropped and left to the registry's stale pruning.
+	diedBuffer = 16
+)
+
+// RestartPolicy controls whether a managed process is relaunched after it exits.
+type RestartPolicy string
+
+const (
+	// RestartNever leaves exited processes stopped.
+	RestartNever RestartPolicy = "never"
+	// RestartOnFailure relaunches processes that exit with an error.
+	RestartOnFailure RestartPolicy = "on-failure"
+	// RestartAlways relaunches processes regardless of exit status.
+	RestartAlways RestartPolicy = "always"
+)
+
+func (p RestartPolicy) shouldRestart(exitErr error) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitErr != nil
+	default:
+		return false
+	}
+}
+
+// ProcessState is the lifecycle state of a managed process.
+type ProcessState string
+
+const (
+	ProcessRunning    ProcessState = "running"
+	ProcessRestarting ProcessState = "restarting"
+	ProcessStopped    ProcessState = "stopped"
+)
+
+// ProcessStatus is a point-in-time snapshot of a managed process.
+type ProcessStatus struct {
+	Path         string       `json:"path"`
+	Port         int          `json:"port"`
+	PID          int          `json:"pid,omitempty"`
+	State        ProcessState `json:"state"`
+	RestartCount int          `json:"restart_count"`
+	LastError    string       `json:"last_error,omitempty"`
+}
+
 // Launcher manages opencode serve child processes tied to the router's lifetime.
 // When the router starts with project paths, the launcher spawns opencode serve
 // instances in those directories. On shutdown, it sends SIGTERM to all children.
 type Launcher struct {
-	portStart int
-	portEnd   int
-	procs     []*managedProcess
-	mu        sync.Mutex
-	logger    *slog.Logger
+	portStart     int
+	portEnd       int
+	restartPolicy RestartPolicy
+	procs         []*managedProcess
+	mu            sync.Mutex
+	logger        *slog.Logger
+
+	stopping       bool
+	done           chan struct{}
+	died           chan int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	stopTimeout    time.Duration
+	command        func(dir string, port int) *exec.Cmd
+
+	logDir     string
+	maxLogSize int64
+	binaryPath string
+	envPort    bool
 }
 
 type managedProcess struct {
-	cmd  *exec.Cmd
-	path string
-	port int
+	cmd           *exec.Cmd
+	path          string
+	port          int
+	restartPolicy RestartPolicy
+	state         ProcessState
+	restartCount  int
+	lastErr       error
+	logFile       *rotatingFile
+	// handoff is set by Restart; supervise closes it instead of applying
+	// the restart policy when the process next exits.
+	handoff chan struct{}
+}
+
+// Option configures a Launcher.
+type Option func(*Launcher)
+
+// WithRestartPolicy sets the policy applied to every launched process.
+func WithRestartPolicy(policy RestartPolicy) Option {
+	return func(l *Launcher) {
+		l.restartPolicy = policy
+	}
+}
+
+// WithLogDir captures each child's stdout and stderr in "{dir}/{slug}.log",
+// rotating to "{slug}.log.1" once the file would exceed maxSize bytes
+// (0 disables rotation). Without it, child output is discarded.
+func WithLogDir(dir string, maxSize int64) Option {
+	return func(l *Launcher) {
+		l.logDir = dir
+		l.maxLogSize = maxSize
+	}
+}
+
+// WithBinaryPath runs the given opencode binary instead of looking up
+// "opencode" on PATH.
+func WithBinaryPath(path string) Option {
+	return func(l *Launcher) {
+		if path != "" {
+			l.binaryPath = path
+		}
+	}
+}
+
+// WithEnvPort controls whether children also receive their port as
+// OPENCODE_PORT in addition to --port. Enabled by default.
+func WithEnvPort(enabled bool) Option {
+	return func(l *Launcher) {
+		l.envPort = enabled
+	}
 }
 
 // New creates a Launcher that allocates ports from the given range.
-func New(portStart, portEnd int, logger *slog.Logger) *Launcher {
-	return &Launcher{
-		portStart: portStart,
-		portEnd:   portEnd,
-		logger:    logger,
+func New(portStart, portEnd int, logger *slog.Logger, opts ...Option) *Launcher {
+	l := &Launcher{
+		portStart:      portStart,
+		portEnd:        portEnd,
+		restartPolicy:  RestartNever,
+		logger:         logger,
+		done:           make(chan struct{}),
+		died:           make(chan int, diedBuffer),
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		stopTimeout:    defaultStopTimeout,
+		binaryPath:     "opencode",
+		envPort:        true,
+	}
+	l.command = l.opencodeServeCommand
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *Launcher) opencodeServeCommand(dir string, port int) *exec.Cmd {
+	cmd := exec.Command(l.binaryPath, "serve", "--port", fmt.Sprintf("%d", port))
+	cmd.Dir = dir
+	if l.envPort {
+		cmd.Env = append(os.Environ(), fmt.Sprintf("OPENCODE_PORT=%d", port))
 	}
+	// Don't pollute router output; opencode serve logs go to /dev/null
+	// unless WithLogDir redirects them.
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	return cmd
+}
+
+// Died delivers the port of each managed process as soon as it exits, before
+// any restart, so callers can drop the backend without waiting for it to go
+// stale. Notifications are dropped if the channel is not drained.
+func (l *Launcher) Died() <-chan int {
+	return l.died
 }
 
 // Launch starts opencode serve in each directory with an auto-assigned port.
@@ -65,41 +210,202 @@ func (l *Launcher) Launch(paths []string) error {
 			}
 		}
 
-		cmd := exec.Command("opencode", "serve", "--port", fmt.Sprintf("%d", nextPort))
-		cmd.Dir = abs
-		// Don't pollute router output; opencode serve logs go to /dev/null.
-		cmd.Stdout = nil
-		cmd.Stderr = nil
-
-		if err := cmd.Start(); err != nil {
+		mp := &managedProcess{path: abs, port: nextPort, restartPolicy: l.restartPolicy}
+		cmd, err := l.startProcess(mp)
+		if err != nil {
 			l.logger.Error("failed to start opencode serve", "path", abs, "port", nextPort, "error", err)
 			continue
 		}
 
-		mp := &managedProcess{cmd: cmd, path: abs, port: nextPort}
 		l.mu.Lock()
 		l.procs = append(l.procs, mp)
 		l.mu.Unlock()
 
-		l.logger.Info("started opencode serve", "path", abs, "port", nextPort, "pid", cmd.Process.Pid)
-
-		// Reap the process when it exits to avoid zombies.
-		go func(mp *managedProcess) {
-			if err := mp.cmd.Wait(); err != nil {
-				l.logger.Warn("opencode serve exited", "path", mp.path, "port", mp.port, "error", err)
-			} else {
-				l.logger.Info("opencode serve exited", "path", mp.path, "port", mp.port)
-			}
-		}(mp)
+		go l.supervise(mp, cmd)
 
 		nextPort++
 	}
 	return nil
 }
 
-// Shutdown sends SIGTERM to all managed opencode serve processes.
+// startProcess spawns the child for mp and records it as running.
+func (l *Launcher) startProcess(mp *managedProcess) (*exec.Cmd, error) {
+	cmd := l.command(mp.path, mp.port)
+
+	var logFile *rotatingFile
+	if l.logDir != "" {
+		if err := os.MkdirAll(l.logDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create log dir: %w", err)
+		}
+		f, err := openRotatingFile(LogPath(l.logDir, mp.path, mp.port), l.maxLogSize)
+		if err != nil {
+			return nil, fmt.Errorf("open process log: %w", err)
+		}
+		cmd.Stdout = f
+		cmd.Stderr = f
+		logFile = f
+	}
+
+	if err := cmd.Start(); err != nil {
+		if logFile != nil {
+			_ = logFile.Close()
+		}
+		return nil, err
+	}
+
+	l.mu.Lock()
+	mp.cmd = cmd
+	mp.logFile = logFile
+	mp.state = ProcessRunning
+	l.mu.Unlock()
+
+	l.logger.Info("started opencode serve", "path", mp.path, "port", mp.port, "pid", cmd.Process.Pid)
+	return cmd, nil
+}
+
+// supervise reaps the process when it exits (avoiding zombies) and relaunches
+// it according to its restart policy.
+func (l *Launcher) supervise(mp *managedProcess, cmd *exec.Cmd) {
+	for {
+		waitErr := cmd.Wait()
+		l.closeLog(mp)
+		if waitErr != nil {
+			l.logger.Warn("opencode serve exited", "path", mp.path, "port", mp.port, "error", waitErr)
+		} else {
+			l.logger.Info("opencode serve exited", "path", mp.path, "port", mp.port)
+		}
+		if l.handOff(mp) {
+			return
+		}
+		select {
+		case l.died <- mp.port:
+		default:
+		}
+
+		delay, ok := l.prepareRestart(mp, waitErr)
+		for ok {
+			select {
+			case <-time.After(delay):
+			case <-l.done:
+				l.markStopped(mp)
+				return
+			}
+
+			next, err := l.startProcess(mp)
+			if err == nil {
+				cmd = next
+				break
+			}
+			l.logger.Error("failed to restart opencode serve", "path", mp.path, "port", mp.port, "error", err)
+			delay, ok = l.prepareRestart(mp, err)
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// prepareRestart records the exit and returns the backoff before the next
+// attempt, or false if the process should stay stopped.
+func (l *Launcher) prepareRestart(mp *managedProcess, exitErr error) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	mp.lastErr = exitErr
+	if l.stopping || !mp.restartPolicy.shouldRestart(exitErr) {
+		mp.state = ProcessStopped
+		return 0, false
+	}
+
+	mp.restartCount++
+	mp.state = ProcessRestarting
+	delay := l.backoff(mp.restartCount)
+	l.logger.Info("restarting opencode serve",
+		"path", mp.path, "port", mp.port, "attempt", mp.restartCount, "delay", delay)
+	return delay, true
+}
+
+// handOff reports whether Restart is waiting for mp to exit, and wakes it
+// if so. Otherwise mp is marked as restarting until prepareRestart decides,
+// so a concurrent Restart cannot start a second copy.
+func (l *Launcher) handOff(mp *managedProcess) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	mp.state = ProcessRestarting
+	if mp.handoff == nil {
+		return false
+	}
+	close(mp.handoff)
+	mp.handoff = nil
+	return true
+}
+
+// closeLog closes the output log of mp's last run, if any.
+func (l *Launcher) closeLog(mp *managedProcess) {
+	l.mu.Lock()
+	f := mp.logFile
+	mp.logFile = nil
+	l.mu.Unlock()
+	if f != nil {
+		_ = f.Close()
+	}
+}
+
+func (l *Launcher) markStopped(mp *managedProcess) {
+	l.mu.Lock()
+	mp.state = ProcessStopped
+	l.mu.Unlock()
+}
+
+// backoff returns an exponential delay for the given attempt with up to 50%
+// added jitter, capped at maxBackoff.
+func (l *Launcher) backoff(attempt int) time.Duration {
+	delay := l.initialBackoff
+	for i := 1; i < attempt && delay < l.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > 0 {
+		delay += time.Duration(rand.Int64N(int64(delay)/2 + 1))
+	}
+	if delay > l.maxBackoff {
+		delay = l.maxBackoff
+	}
+	return delay
+}
+
+// Status returns a snapshot of every managed process.
+func (l *Launcher) Status() []ProcessStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]ProcessStatus, 0, len(l.procs))
+	for _, mp := range l.procs {
+		st := ProcessStatus{
+			Path:         mp.path,
+			Port:         mp.port,
+			State:        mp.state,
+			RestartCount: mp.restartCount,
+		}
+		if mp.state == ProcessRunning && mp.cmd != nil && mp.cmd.Process != nil {
+			st.PID = mp.cmd.Process.Pid
+		}
+		if mp.lastErr != nil {
+			st.LastError = mp.lastErr.Error()
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// Shutdown sends SIGTERM to all managed opencode serve processes and
+// cancels any pending restarts.
 func (l *Launcher) Shutdown() {
 	l.mu.Lock()
+	if !l.stopping {
+		l.stopping = true
+		close(l.done)
+	}
 	procs := l.procs
 	l.procs = nil
 	l.mu.Unlock()
@@ -110,15 +416,19 @@ func (l *Launcher) Shutdown() {
 
 	l.logger.Info("stopping managed opencode serve instances", "count", len(procs))
 	for _, mp := range procs {
-		if mp.cmd.Process == nil {
+		l.mu.Lock()
+		cmd, state := mp.cmd, mp.state
+		l.mu.Unlock()
+
+		if cmd == nil || cmd.Process == nil || state != ProcessRunning {
 			continue
 		}
-		if err := mp.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
 			l.logger.Debug("signal failed (process may have already exited)",
-				"pid", mp.cmd.Process.Pid, "error", err)
+				"pid", cmd.Process.Pid, "error", err)
 		} else {
 			l.logger.Info("sent SIGTERM to opencode serve",
-				"path", mp.path, "port", mp.port, "pid", mp.cmd.Process.Pid)
+				"path", mp.path, "port", mp.port, "pid", cmd.Process.Pid)
 		}
 	}
 }
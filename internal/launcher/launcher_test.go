@@ -0,0 +1,264 @@
+package launcher
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func newTestLauncher(policy RestartPolicy, script string) *Launcher {
+	l := New(39500, 39510, testLogger(), WithRestartPolicy(policy))
+	l.initialBackoff = 20 * time.Millisecond
+	l.maxBackoff = 80 * time.Millisecond
+	l.command = func(dir string, port int) *exec.Cmd {
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Dir = dir
+		return cmd
+	}
+	return l
+}
+
+func waitForStatus(t *testing.T, l *Launcher, cond func(ProcessStatus) bool) ProcessStatus {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if st := l.Status(); len(st) == 1 && cond(st[0]) {
+			return st[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition not met; last status %+v", l.Status())
+	return ProcessStatus{}
+}
+
+// ---------------------------------------------------------------------------
+// Restart policies
+// ---------------------------------------------------------------------------
+
+func TestLaunch_RestartOnFailure(t *testing.T) {
+	l := newTestLauncher(RestartOnFailure, "exit 1")
+	defer l.Shutdown()
+
+	if err := l.Launch([]string{t.TempDir()}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	st := waitForStatus(t, l, func(s ProcessStatus) bool { return s.RestartCount >= 3 })
+	if st.LastError == "" {
+		t.Error("expected last error to be recorded")
+	}
+}
+
+func TestLaunch_RestartNever(t *testing.T) {
+	l := newTestLauncher(RestartNever, "exit 1")
+	defer l.Shutdown()
+
+	if err := l.Launch([]string{t.TempDir()}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+	time.Sleep(100 * time.Millisecond)
+	if st := l.Status()[0]; st.RestartCount != 0 {
+		t.Errorf("expected no restarts, got %d", st.RestartCount)
+	}
+}
+
+func TestLaunch_OnFailureIgnoresCleanExit(t *testing.T) {
+	l := newTestLauncher(RestartOnFailure, "exit 0")
+	defer l.Shutdown()
+
+	if err := l.Launch([]string{t.TempDir()}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	st := waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+	if st.RestartCount != 0 || st.LastError != "" {
+		t.Errorf("clean exit should not restart: %+v", st)
+	}
+}
+
+func TestLaunch_ShutdownCancelsRestart(t *testing.T) {
+	l := newTestLauncher(RestartAlways, "exit 0")
+	l.initialBackoff = time.Hour
+	l.maxBackoff = time.Hour
+
+	if err := l.Launch([]string{t.TempDir()}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessRestarting })
+
+	l.Shutdown()
+	if len(l.Status()) != 0 {
+		t.Error("expected no managed processes after shutdown")
+	}
+}
+
+func TestLaunch_RunningStatus(t *testing.T) {
+	l := newTestLauncher(RestartNever, "sleep 5")
+	defer l.Shutdown()
+
+	if err := l.Launch([]string{t.TempDir()}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	st := l.Status()[0]
+	if st.State != ProcessRunning || st.PID == 0 {
+		t.Errorf("expected running process with PID, got %+v", st)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Process logs
+// ---------------------------------------------------------------------------
+
+func TestLaunch_CapturesOutput(t *testing.T) {
+	logDir := t.TempDir()
+	l := newTestLauncher(RestartNever, "echo hello-stdout; echo hello-stderr >&2")
+	WithLogDir(logDir, 0)(l)
+	defer l.Shutdown()
+
+	project := t.TempDir()
+	if err := l.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+
+	data, err := os.ReadFile(LogPath(logDir, project, l.Status()[0].Port))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	for _, want := range []string{"hello-stdout", "hello-stderr"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected log to contain %q, got %q", want, data)
+		}
+	}
+}
+
+func TestLaunch_SeparateLogsForSameBaseName(t *testing.T) {
+	logDir := t.TempDir()
+	l := newTestLauncher(RestartNever, "pwd")
+	WithLogDir(logDir, 0)(l)
+	defer l.Shutdown()
+
+	root := t.TempDir()
+	projects := []string{filepath.Join(root, "a", "app"), filepath.Join(root, "b", "app")}
+	for _, p := range projects {
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Launch(projects); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		stopped := 0
+		for _, s := range l.Status() {
+			if s.State == ProcessStopped {
+				stopped++
+			}
+		}
+		if stopped == len(projects) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("processes did not exit: %+v", l.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, s := range l.Status() {
+		data, err := os.ReadFile(LogPath(logDir, s.Path, s.Port))
+		if err != nil {
+			t.Fatalf("read log of %s: %v", s.Path, err)
+		}
+		if got := strings.TrimSpace(string(data)); got != s.Path {
+			t.Errorf("log of %s = %q, want only its own output", s.Path, got)
+		}
+	}
+}
+
+func TestLaunch_BinaryPathAndEnvPort(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "fake-opencode")
+	script := "#!/bin/sh\necho \"args=$* env=$OPENCODE_PORT\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake binary: %v", err)
+	}
+
+	for _, tc := range []struct {
+		envPort bool
+		want    string
+	}{
+		{true, "args=serve --port 39500 env=39500"},
+		{false, "args=serve --port 39500 env="},
+	} {
+		logDir := t.TempDir()
+		l := New(39500, 39510, testLogger(), WithBinaryPath(bin), WithEnvPort(tc.envPort), WithLogDir(logDir, 0))
+		project := t.TempDir()
+		if err := l.Launch([]string{project}); err != nil {
+			t.Fatalf("Launch: %v", err)
+		}
+		waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+		l.Shutdown()
+
+		data, err := os.ReadFile(LogPath(logDir, project, 39500))
+		if err != nil {
+			t.Fatalf("read log: %v", err)
+		}
+		if got := strings.TrimSpace(string(data)); got != tc.want {
+			t.Errorf("envPort=%v: output %q, want %q", tc.envPort, got, tc.want)
+		}
+	}
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proj.log")
+	rf, err := openRotatingFile(path, 10)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	old, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("expected rotated file: %v", err)
+	}
+	cur, _ := os.ReadFile(path)
+	if string(old) != "first\n" || string(cur) != "second\n" {
+		t.Errorf("unexpected contents: .1=%q current=%q", old, cur)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Backoff
+// ---------------------------------------------------------------------------
+
+func TestBackoff_ExponentialWithCap(t *testing.T) {
+	l := New(0, 0, testLogger())
+
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		d := l.backoff(attempt)
+		if d < base || d > base+base/2 {
+			t.Errorf("backoff(%d) = %s, want within [%s, %s]", attempt, d, base, base+base/2)
+		}
+	}
+
+	if d := l.backoff(20); d != defaultMaxBackoff {
+		t.Errorf("backoff(20) = %s, want cap %s", d, defaultMaxBackoff)
+	}
+}
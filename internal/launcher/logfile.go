@@ -0,0 +1,83 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"opencoderouter/internal/registry"
+)
+
+// LogPath returns the file that captures the output of the project at
+// projectPath served on port when logs are written to dir. The port keeps
+// projects that share a base name apart.
+func LogPath(dir, projectPath string, port int) string {
+	return filepath.Join(dir, registry.SlugifyPath(projectPath)+"-"+strconv.Itoa(port)+".log")
+}
+
+// rotatingFile is an append-only log that renames itself to "{path}.1" and
+// starts over once it would exceed maxSize bytes. A maxSize of 0 disables
+// rotation.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.f = f
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate replaces any previous "{path}.1" with the current file. Caller must
+// hold rf.mu.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.f.Close()
+}
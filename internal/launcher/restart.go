@@ -0,0 +1,141 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"syscall"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// defaultStopTimeout is how long Restart waits for a process to exit after
+// SIGTERM before killing it.
+const defaultStopTimeout = 5 * time.Second
+
+var (
+	// ErrNotManaged is returned by Restart and RestartPorts when no managed
+	// process matches.
+	ErrNotManaged = errors.New("no managed process for this slug")
+	// ErrRestartInProgress is returned by Restart while the process is
+	// already waiting to be relaunched.
+	ErrRestartInProgress = errors.New("process is already restarting")
+	// errShuttingDown is returned by Restart once Shutdown has been called.
+	errShuttingDown = errors.New("launcher is shutting down")
+)
+
+// Restart stops every managed process whose project path has the given
+// slug and starts it again on the same port in the same directory. A
+// running process gets SIGTERM and up to 5 seconds to exit before it is
+// killed. A stopped process is simply started. The restart does not count
+// against the restart policy and is not reported on Died.
+//
+// Projects in different directories with the same base name share a path
+// slug; use RestartPorts to restart only one of them.
+func (l *Launcher) Restart(slug string) error {
+	matched, err := l.matchProcesses(func(mp *managedProcess) bool {
+		return registry.SlugifyPath(mp.path) == slug
+	})
+	if err != nil {
+		return err
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("%w: %q", ErrNotManaged, slug)
+	}
+	return l.restartAll(matched)
+}
+
+// RestartPorts is Restart for the managed processes listening on ports.
+// Ports without a managed process are ignored unless none has one.
+func (l *Launcher) RestartPorts(ports ...int) error {
+	matched, err := l.matchProcesses(func(mp *managedProcess) bool {
+		return slices.Contains(ports, mp.port)
+	})
+	if err != nil {
+		return err
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("%w: ports %v", ErrNotManaged, ports)
+	}
+	return l.restartAll(matched)
+}
+
+// matchProcesses returns the managed processes for which match is true.
+func (l *Launcher) matchProcesses(match func(*managedProcess) bool) ([]*managedProcess, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping {
+		return nil, errShuttingDown
+	}
+	var matched []*managedProcess
+	for _, mp := range l.procs {
+		if match(mp) {
+			matched = append(matched, mp)
+		}
+	}
+	return matched, nil
+}
+
+// restartAll restarts every process in matched.
+func (l *Launcher) restartAll(matched []*managedProcess) error {
+	var errs []error
+	for _, mp := range matched {
+		if err := l.restartProcess(mp); err != nil {
+			errs = append(errs, fmt.Errorf("restart %s: %w", mp.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restartProcess stops mp, if running, and starts it again.
+func (l *Launcher) restartProcess(mp *managedProcess) error {
+	l.mu.Lock()
+	switch {
+	case mp.state == ProcessRestarting:
+		l.mu.Unlock()
+		return ErrRestartInProgress
+	case mp.state == ProcessRunning && mp.cmd != nil && mp.cmd.Process != nil:
+		// Ask supervise to hand the process back instead of applying the
+		// restart policy once it has exited.
+		exited := make(chan struct{})
+		mp.handoff = exited
+		mp.state = ProcessRestarting
+		proc := mp.cmd.Process
+		l.mu.Unlock()
+
+		l.logger.Info("restarting opencode serve on request", "path", mp.path, "port", mp.port, "pid", proc.Pid)
+		if err := proc.Signal(syscall.SIGTERM); err != nil {
+			l.logger.Debug("signal failed (process may have already exited)", "pid", proc.Pid, "error", err)
+		}
+		select {
+		case <-exited:
+		case <-time.After(l.stopTimeout):
+			l.logger.Warn("opencode serve ignored SIGTERM; killing it", "path", mp.path, "port", mp.port, "pid", proc.Pid)
+			_ = proc.Kill()
+			<-exited
+		}
+		l.mu.Lock()
+		stopping := l.stopping
+		if stopping {
+			mp.state = ProcessStopped
+		}
+		l.mu.Unlock()
+		if stopping {
+			return errShuttingDown
+		}
+	default:
+		l.mu.Unlock()
+	}
+
+	cmd, err := l.startProcess(mp)
+	if err != nil {
+		l.mu.Lock()
+		mp.state = ProcessStopped
+		mp.lastErr = err
+		l.mu.Unlock()
+		return err
+	}
+	go l.supervise(mp, cmd)
+	return nil
+}
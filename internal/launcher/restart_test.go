@@ -0,0 +1,179 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestRestart_RunningProcess(t *testing.T) {
+	l := newTestLauncher(RestartNever, "exec sleep 30")
+	defer l.Shutdown()
+	project := t.TempDir()
+	if err := l.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	before := waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessRunning })
+
+	if err := l.Restart(registry.SlugifyPath(project)); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+
+	after := waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessRunning })
+	if after.PID == before.PID {
+		t.Errorf("expected a new process, PID is still %d", after.PID)
+	}
+	if after.Port != before.Port || after.Path != before.Path {
+		t.Errorf("restart moved the process: %+v, was %+v", after, before)
+	}
+	if after.RestartCount != 0 {
+		t.Errorf("requested restart should not count against the policy, got %d", after.RestartCount)
+	}
+	select {
+	case port := <-l.Died():
+		t.Errorf("requested restart reported port %d on Died", port)
+	default:
+	}
+}
+
+func TestRestart_KillsProcessIgnoringSIGTERM(t *testing.T) {
+	l := newTestLauncher(RestartNever, "trap '' TERM; touch ready; exec sleep 30")
+	l.stopTimeout = 100 * time.Millisecond
+	defer l.Shutdown()
+	project := t.TempDir()
+	if err := l.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	before := waitForStatus(t, l, func(s ProcessStatus) bool {
+		_, err := os.Stat(filepath.Join(project, "ready"))
+		return s.State == ProcessRunning && err == nil
+	})
+
+	start := time.Now()
+	if err := l.Restart(registry.SlugifyPath(project)); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < l.stopTimeout {
+		t.Errorf("Restart returned after %s, before the stop timeout", elapsed)
+	}
+	after := waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessRunning })
+	if after.PID == before.PID {
+		t.Errorf("expected the stubborn process to be replaced, PID is still %d", after.PID)
+	}
+}
+
+func TestRestart_StoppedProcess(t *testing.T) {
+	l := newTestLauncher(RestartNever, "echo run >> runs")
+	defer l.Shutdown()
+	project := t.TempDir()
+	if err := l.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+
+	if err := l.Restart(registry.SlugifyPath(project)); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessStopped })
+
+	data, err := os.ReadFile(filepath.Join(project, "runs"))
+	if err != nil {
+		t.Fatalf("read runs: %v", err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 2 {
+		t.Errorf("process ran %d times, want 2", runs)
+	}
+}
+
+func TestRestart_Errors(t *testing.T) {
+	l := newTestLauncher(RestartNever, "exec sleep 30")
+	project := t.TempDir()
+	if err := l.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	waitForStatus(t, l, func(s ProcessStatus) bool { return s.State == ProcessRunning })
+
+	if err := l.Restart("unknown"); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("Restart(unknown) = %v, want ErrNotManaged", err)
+	}
+
+	l.command = func(string, int) *exec.Cmd { return exec.Command(filepath.Join(project, "missing")) }
+	if err := l.Restart(registry.SlugifyPath(project)); err == nil || errors.Is(err, ErrNotManaged) {
+		t.Errorf("Restart with a failing command = %v, want a start error", err)
+	}
+	if st := l.Status()[0]; st.State != ProcessStopped || st.LastError == "" {
+		t.Errorf("failed restart should leave the process stopped with an error, got %+v", st)
+	}
+
+	l.Shutdown()
+	if err := l.Restart(registry.SlugifyPath(project)); err == nil {
+		t.Error("Restart after Shutdown should fail")
+	}
+}
+
+func TestRestartPorts_SameBaseName(t *testing.T) {
+	l := newTestLauncher(RestartNever, "exec sleep 30")
+	defer l.Shutdown()
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a", "app"), filepath.Join(root, "b", "app")
+	for _, dir := range []string{a, b} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Launch([]string{a, b}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	before := waitForPIDs(t, l, 2)
+
+	var target ProcessStatus
+	for _, st := range l.Status() {
+		if st.Path == b {
+			target = st
+		}
+	}
+	if err := l.RestartPorts(target.Port); err != nil {
+		t.Fatalf("RestartPorts: %v", err)
+	}
+
+	after := waitForPIDs(t, l, 2)
+	for path, pid := range before {
+		switch restarted := after[path] != pid; {
+		case path == b && !restarted:
+			t.Errorf("%s was not restarted", path)
+		case path != b && restarted:
+			t.Errorf("%s was restarted along with %s", path, b)
+		}
+	}
+
+	if err := l.RestartPorts(1); !errors.Is(err, ErrNotManaged) {
+		t.Errorf("RestartPorts(1) = %v, want ErrNotManaged", err)
+	}
+}
+
+// waitForPIDs waits until n processes are running and returns their PIDs
+// by project path.
+func waitForPIDs(t *testing.T, l *Launcher, n int) map[string]int {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		pids := make(map[string]int)
+		for _, st := range l.Status() {
+			if st.State == ProcessRunning && st.PID != 0 {
+				pids[st.Path] = st.PID
+			}
+		}
+		if len(pids) == n {
+			return pids
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%d processes not running; last status %+v", n, l.Status())
+	return nil
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientAllowlist answers 403 Forbidden to requests whose client address,
+// taken from r.RemoteAddr, is outside every network in allowed. Place it
+// after RealIP so clients behind a trusted proxy are checked rather than
+// the proxy. Requests without a parseable address are refused.
+func ClientAllowlist(allowed []*net.IPNet) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				for _, n := range allowed {
+					if n.Contains(ip) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			http.Error(w, "forbidden", http.StatusForbidden)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAllowlist(t *testing.T) {
+	h := ClientAllowlist(mustCIDRs(t, "10.0.0.0/8", "::1/128"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       int
+	}{
+		{"allowed IPv4", "10.1.2.3:40000", http.StatusOK},
+		{"allowed IPv6", "[::1]:40000", http.StatusOK},
+		{"denied", "203.0.113.7:40000", http.StatusForbidden},
+		{"denied loopback not listed", "127.0.0.1:40000", http.StatusForbidden},
+		{"unparseable address", "@", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/backends", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientAllowlist_AfterRealIP(t *testing.T) {
+	h := Chain(RealIP(mustCIDRs(t, "127.0.0.0/8")), ClientAllowlist(mustCIDRs(t, "10.0.0.0/8")))(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for xff, want := range map[string]int{"10.0.0.9": http.StatusOK, "203.0.113.7": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		req.Header.Set(HeaderForwardedFor, xff)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("client %s behind a trusted proxy: status %d, want %d", xff, w.Code, want)
+		}
+	}
+}
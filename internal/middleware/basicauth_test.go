@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := BasicAuth("alice", hash, "/api/health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		user, pass string
+		noAuth     bool
+		wantStatus int
+	}{
+		{name: "correct credentials", path: "/api/backends", user: "alice", pass: "s3cret", wantStatus: http.StatusOK},
+		{name: "wrong password", path: "/api/backends", user: "alice", pass: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "wrong user", path: "/", user: "bob", pass: "s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "missing header", path: "/", noAuth: true, wantStatus: http.StatusUnauthorized},
+		{name: "exempt path", path: "/api/health", noAuth: true, wantStatus: http.StatusOK},
+		{name: "exempt match is exact", path: "/api/health/extra", noAuth: true, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if tt.wantStatus == http.StatusUnauthorized && challenge != `Basic realm="OpenCode Router"` {
+				t.Errorf("WWW-Authenticate = %q", challenge)
+			}
+			if tt.wantStatus == http.StatusOK && challenge != "" {
+				t.Errorf("unexpected challenge %q on success", challenge)
+			}
+		})
+	}
+}
+
+func TestBasicAuth_HtpasswdHash(t *testing.T) {
+	// htpasswd -nbB alice s3cret writes "$2y$" hashes; bcrypt accepts them.
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash[2] = 'y'
+	h := BasicAuth("alice", hash)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("alice", "s3cret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d with a $2y$ hash, want 200", w.Code)
+	}
+}
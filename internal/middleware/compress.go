@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressMinSize is the smallest response body Compress encodes;
+// below it the framing overhead outweighs the savings.
+const DefaultCompressMinSize = 1024
+
+// Supported content codings, in order of preference when a client accepts
+// several with the same quality.
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+var (
+	gzipPool = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zstdPool = sync.Pool{New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return enc
+	}}
+)
+
+// Compress encodes response bodies with zstd or gzip when the request's
+// Accept-Encoding allows it, setting Content-Encoding and Vary. Bodies
+// shorter than minSize, responses that already carry a Content-Encoding,
+// partial content, event streams and WebSocket upgrades are sent as is, as
+// are responses whose handler calls SkipCompression. A non-positive minSize
+// means DefaultCompressMinSize.
+func Compress(minSize int) Middleware {
+	if minSize <= 0 {
+		minSize = DefaultCompressMinSize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Values("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// SkipCompression sends the response on w uncompressed, for handlers such
+// as the reverse proxy whose bodies are already encoded or streamed. It must
+// be called before the first write and does nothing when w is not
+// (wrapping) a Compress writer.
+func SkipCompression(w http.ResponseWriter) {
+	for w != nil {
+		if cw, ok := w.(*compressWriter); ok {
+			cw.skip = true
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// negotiateEncoding picks the coding to use from Accept-Encoding values, or
+// "" for none. Codings with q=0 are refused; "*" stands for any coding not
+// listed explicitly.
+func negotiateEncoding(values []string) string {
+	q := map[string]float64{}
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			weight := 1.0
+			for _, p := range strings.Split(params, ";") {
+				if k, val, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.TrimSpace(k) == "q" {
+					if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+						weight = f
+					}
+				}
+			}
+			q[name] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range []string{encodingZstd, encodingGzip} {
+		weight, ok := q[enc]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = enc, weight
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body is worth compressing, then either streams it through an encoder or
+// passes it along untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	skip     bool // see SkipCompression
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser // nil after deciding means uncompressed
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status != 0 {
+		return // superfluous; the first status wins
+	}
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status) // informational, e.g. 103
+		return
+	}
+	cw.status = status
+	if !cw.compressible() {
+		cw.decide(false)
+		return
+	}
+	// A declared length below the threshold settles it without buffering.
+	if n, err := strconv.Atoi(cw.Header().Get("Content-Length")); err == nil && n < cw.minSize {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided && cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends what has been written so far. A response flushed before it
+// reached the size threshold is a stream and stays uncompressed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(false)
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
+	}
+	cw.decided = true
+	return hj.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible reports whether the headers set so far allow encoding.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	switch {
+	case cw.skip,
+		cw.status == http.StatusNoContent,
+		cw.status == http.StatusNotModified,
+		cw.status == http.StatusPartialContent,
+		h.Get("Content-Encoding") != "",
+		h.Get("Content-Range") != "",
+		strings.HasPrefix(h.Get("Content-Type"), "text/event-stream"):
+		return false
+	}
+	return true
+}
+
+// decide writes the header and buffered body, through an encoder when
+// compress is set and the response still allows it.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if compress && cw.compressible() {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		cw.enc = cw.newEncoder()
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// finish runs after the handler returns: it sends a response that never
+// reached the threshold and closes the encoder.
+func (cw *compressWriter) finish() {
+	if !cw.decided && cw.status != 0 {
+		cw.decide(false)
+	}
+	if cw.enc != nil {
+		_ = cw.enc.Close()
+	}
+}
+
+func (cw *compressWriter) newEncoder() io.WriteCloser {
+	if cw.encoding == encodingZstd {
+		enc := zstdPool.Get().(*zstd.Encoder)
+		enc.Reset(cw.ResponseWriter)
+		return &pooledEncoder{WriteCloser: enc, release: func() { zstdPool.Put(enc) }}
+	}
+	gz := gzipPool.Get().(*gzip.Writer)
+	gz.Reset(cw.ResponseWriter)
+	return &pooledEncoder{WriteCloser: gz, release: func() { gzipPool.Put(gz) }}
+}
+
+// pooledEncoder returns its encoder to a pool once closed.
+type pooledEncoder struct {
+	io.WriteCloser
+	release func()
+}
+
+func (p *pooledEncoder) Flush() error {
+	if f, ok := p.WriteCloser.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (p *pooledEncoder) Close() error {
+	err := p.WriteCloser.Close()
+	p.release()
+	return err
+}
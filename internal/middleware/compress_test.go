@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var largeBody = strings.Repeat(`{"slug":"alpha","port":4096},`, 100)
+
+// compressServe runs one request with the given Accept-Encoding through
+// Compress around h.
+func compressServe(h http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/backends", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	Compress(0)(h).ServeHTTP(w, req)
+	return w
+}
+
+func writeBody(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Write in two parts so the threshold is crossed mid-response.
+		_, _ = io.WriteString(w, body[:len(body)/2])
+		_, _ = io.WriteString(w, body[len(body)/2:])
+	}
+}
+
+func TestCompress_Decodable(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+		decode         func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", "gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"zstd", "zstd", "zstd", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+		{"prefers zstd on a tie", "gzip, deflate, br, zstd", "zstd", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+		{"honours quality", "zstd;q=0.5, gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compressServe(writeBody(largeBody), tt.acceptEncoding)
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if w.Body.Len() >= len(largeBody) {
+				t.Errorf("compressed body is %d bytes, original %d", w.Body.Len(), len(largeBody))
+			}
+			r, err := tt.decode(bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("new decoder: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if string(got) != largeBody {
+				t.Errorf("decoded body differs from the original (%d bytes vs %d)", len(got), len(largeBody))
+			}
+		})
+	}
+}
+
+func TestCompress_PassesThrough(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+		wantBody       string
+	}{
+		{"no Accept-Encoding", "", writeBody(largeBody), largeBody},
+		{"unsupported coding", "br", writeBody(largeBody), largeBody},
+		{"refused with q=0", "gzip;q=0, *;q=0", writeBody(largeBody), largeBody},
+		{"under 1KB", "gzip", writeBody(`{"slug":"alpha"}`), `{"slug":"alpha"}`},
+		{"already encoded", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, largeBody)
+		}, largeBody},
+		{"event stream", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, largeBody)
+		}, largeBody},
+		{"skipped by handler", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			SkipCompression(w)
+			_, _ = io.WriteString(w, largeBody)
+		}, largeBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compressServe(tt.handler, tt.acceptEncoding)
+			if enc := w.Header().Get("Content-Encoding"); enc == "gzip" || enc == "zstd" {
+				t.Errorf("unexpected Content-Encoding %q", enc)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body altered: got %d bytes, want %d", w.Body.Len(), len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestCompress_StatusAndLength(t *testing.T) {
+	w := compressServe(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "3000")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, largeBody)
+	}, "gzip")
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", w.Code)
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("Content-Length of the uncompressed body must be dropped")
+	}
+
+	w = compressServe(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, "gzip")
+	if w.Code != http.StatusNoContent || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("204 = %d with Content-Encoding %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompress_FlushBeforeThresholdStreams(t *testing.T) {
+	w := compressServe(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		if w.(*compressWriter).enc != nil {
+			t.Error("a flushed short response should not be compressed")
+		}
+		_, _ = io.WriteString(w, largeBody)
+	}, "gzip")
+	if w.Header().Get("Content-Encoding") != "" || !strings.HasPrefix(w.Body.String(), "data: 1") {
+		t.Errorf("streamed response was altered: encoding %q", w.Header().Get("Content-Encoding"))
+	}
+}
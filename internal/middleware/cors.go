@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORS response headers.
+const (
+	HeaderAllowOrigin  = "Access-Control-Allow-Origin"
+	HeaderAllowMethods = "Access-Control-Allow-Methods"
+	HeaderAllowHeaders = "Access-Control-Allow-Headers"
+)
+
+// Values advertised to allowed origins. Requested headers are echoed back;
+// corsDefaultHeaders is used when a preflight names none.
+const (
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsDefaultHeaders = "Content-Type, Authorization"
+	corsMaxAge         = "600"
+)
+
+// CORS adds cross-origin headers for requests whose Origin is allowed.
+// origins lists allowed origins such as "https://app.example.com"; "*"
+// allows any. originsFor, if non-nil, may return origins for a particular
+// request that replace the global list, e.g. per backend.
+//
+// Preflight requests (OPTIONS with Access-Control-Request-Method) are
+// answered with 204 and never reach the wrapped handler; a preflight from
+// an origin that is not allowed gets 403. Other requests from such origins
+// are passed on without CORS headers, so the browser blocks the response.
+// Requests without an Origin header, with no origins configured for them,
+// or already handled by an outer CORS middleware are passed through
+// untouched.
+func CORS(origins []string, originsFor func(*http.Request) []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			// An outer CORS middleware has already answered for this request.
+			if origin == "" || w.Header().Get(HeaderAllowOrigin) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed := origins
+			if originsFor != nil {
+				if o := originsFor(r); len(o) > 0 {
+					allowed = o
+				}
+			}
+			if len(allowed) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			allowOrigin, ok := matchOrigin(allowed, origin)
+			if !ok {
+				if preflight {
+					http.Error(w, "origin not allowed", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(HeaderAllowOrigin, allowOrigin)
+			if allowOrigin != "*" {
+				h.Add("Vary", "Origin")
+			}
+			h.Set(HeaderAllowMethods, corsAllowedMethods)
+			headers := r.Header.Get("Access-Control-Request-Headers")
+			if headers == "" {
+				headers = corsDefaultHeaders
+			}
+			h.Set(HeaderAllowHeaders, headers)
+
+			if preflight {
+				h.Set("Access-Control-Max-Age", corsMaxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin, or
+// false if allowed does not include it. An explicit match wins over "*".
+func matchOrigin(allowed []string, origin string) (string, bool) {
+	wildcard := false
+	for _, a := range allowed {
+		if a == "*" {
+			wildcard = true
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return origin, true
+		}
+	}
+	if wildcard {
+		return "*", true
+	}
+	return "", false
+}
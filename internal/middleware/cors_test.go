@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// corsServe runs one request through CORS and reports whether the wrapped
+// handler was reached.
+func corsServe(mw Middleware, method, origin string, header http.Header) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, "/api/x", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w, reached
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	mw := CORS([]string{"https://app.example.com"}, nil)
+	w, reached := corsServe(mw, http.MethodOptions, "https://app.example.com", http.Header{
+		"Access-Control-Request-Method":  {"PUT"},
+		"Access-Control-Request-Headers": {"X-Custom"},
+	})
+	if reached {
+		t.Error("preflight should not reach the wrapped handler")
+	}
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("preflight = %d with %d body bytes, want 204 and no body", w.Code, w.Body.Len())
+	}
+	if got := w.Header().Get(HeaderAllowOrigin); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get(HeaderAllowMethods); got != corsAllowedMethods {
+		t.Errorf("Allow-Methods = %q", got)
+	}
+	if got := w.Header().Get(HeaderAllowHeaders); got != "X-Custom" {
+		t.Errorf("Allow-Headers = %q, want requested headers echoed", got)
+	}
+}
+
+func TestCORS_PlainOptionsIsNotPreflight(t *testing.T) {
+	_, reached := corsServe(CORS([]string{"*"}, nil), http.MethodOptions, "https://a.example", nil)
+	if !reached {
+		t.Error("OPTIONS without Access-Control-Request-Method should reach the handler")
+	}
+}
+
+func TestCORS_Origins(t *testing.T) {
+	tests := []struct {
+		name       string
+		origins    []string
+		origin     string
+		wantOrigin string
+	}{
+		{"wildcard", []string{"*"}, "https://any.example", "*"},
+		{"allowlisted", []string{"https://a.example", "https://b.example"}, "https://b.example", "https://b.example"},
+		{"case and trailing slash", []string{"HTTPS://A.example/"}, "https://a.example", "https://a.example"},
+		{"explicit beats wildcard", []string{"*", "https://a.example"}, "https://a.example", "https://a.example"},
+		{"rejected", []string{"https://a.example"}, "https://evil.example", ""},
+		{"none configured", nil, "https://a.example", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, reached := corsServe(CORS(tt.origins, nil), http.MethodGet, tt.origin, nil)
+			if !reached || w.Code != http.StatusOK {
+				t.Fatalf("simple request should reach the handler, got %d", w.Code)
+			}
+			if got := w.Header().Get(HeaderAllowOrigin); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin == "" && w.Header().Get(HeaderAllowMethods) != "" {
+				t.Error("rejected origin should get no CORS headers")
+			}
+		})
+	}
+}
+
+func TestCORS_RejectedPreflight(t *testing.T) {
+	w, reached := corsServe(CORS([]string{"https://a.example"}, nil), http.MethodOptions, "https://evil.example",
+		http.Header{"Access-Control-Request-Method": {"POST"}})
+	if reached || w.Code != http.StatusForbidden {
+		t.Errorf("rejected preflight = %d (reached %v), want 403", w.Code, reached)
+	}
+	if w.Header().Get(HeaderAllowOrigin) != "" {
+		t.Error("rejected preflight should not carry Allow-Origin")
+	}
+}
+
+func TestCORS_NoOriginPassesThrough(t *testing.T) {
+	w, reached := corsServe(CORS([]string{"*"}, nil), http.MethodGet, "", nil)
+	if !reached || w.Header().Get(HeaderAllowOrigin) != "" {
+		t.Errorf("same-origin request should pass through untouched, headers %v", w.Header())
+	}
+}
+
+func TestCORS_PerRequestOriginsTakePrecedence(t *testing.T) {
+	mw := CORS([]string{"https://global.example"}, func(r *http.Request) []string {
+		if r.Header.Get("X-Slug") == "special" {
+			return []string{"https://special.example"}
+		}
+		return nil
+	})
+
+	w, _ := corsServe(mw, http.MethodGet, "https://special.example", http.Header{"X-Slug": {"special"}})
+	if got := w.Header().Get(HeaderAllowOrigin); got != "https://special.example" {
+		t.Errorf("per-request origin: Allow-Origin = %q", got)
+	}
+	w, _ = corsServe(mw, http.MethodGet, "https://global.example", http.Header{"X-Slug": {"special"}})
+	if got := w.Header().Get(HeaderAllowOrigin); got != "" {
+		t.Errorf("per-request list should replace the global one, got %q", got)
+	}
+	w, _ = corsServe(mw, http.MethodGet, "https://global.example", nil)
+	if got := w.Header().Get(HeaderAllowOrigin); got != "https://global.example" {
+		t.Errorf("fallback to global: Allow-Origin = %q", got)
+	}
+}
+
+func TestCORS_OuterWins(t *testing.T) {
+	outer := CORS([]string{"https://a.example"}, nil)
+	inner := CORS([]string{"*"}, nil)
+	w, reached := corsServe(Chain(outer, inner), http.MethodGet, "https://a.example", nil)
+	if !reached {
+		t.Fatal("request should reach the handler")
+	}
+	if got := w.Header().Values(HeaderAllowOrigin); len(got) != 1 || got[0] != "https://a.example" {
+		t.Errorf("Allow-Origin = %v, want the outer middleware's value only", got)
+	}
+	if got := w.Header().Values("Vary"); len(got) != 1 {
+		t.Errorf("Vary = %v, want a single entry", got)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HeaderHSTS tells browsers to reach the host only over HTTPS.
+const HeaderHSTS = "Strict-Transport-Security"
+
+// HSTS sets Strict-Transport-Security with maxAge, in whole seconds, and
+// includeSubDomains on every response. It belongs in front of handlers
+// served over TLS only; browsers ignore the header on plain HTTP.
+func HSTS(maxAge time.Duration) Middleware {
+	value := fmt.Sprintf("max-age=%d; includeSubDomains", int64(maxAge/time.Second))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(HeaderHSTS, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
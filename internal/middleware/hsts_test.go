@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHSTS(t *testing.T) {
+	h := HSTS(365 * 24 * time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if got, want := w.Header().Get(HeaderHSTS), "max-age=31536000; includeSubDomains"; got != want {
+		t.Errorf("%s = %q, want %q", HeaderHSTS, got, want)
+	}
+}
+
+func TestHSTS_WholeSeconds(t *testing.T) {
+	h := HSTS(90*time.Second + 500*time.Millisecond)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got, want := w.Header().Get(HeaderHSTS), "max-age=90; includeSubDomains"; got != want {
+		t.Errorf("%s = %q, want %q", HeaderHSTS, got, want)
+	}
+}
@@ -0,0 +1,22 @@
+// Package middleware provides a composable http.Handler wrapper type and
+// middlewares built on it.
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with extra behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain combines middlewares into one. The first middleware is the
+// outermost: Chain(a, b, c)(h) handles a request as a(b(c(h))), so a runs
+// first on the way in and last on the way out. Nil entries are skipped.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				h = middlewares[i](h)
+			}
+		}
+		return h
+	}
+}
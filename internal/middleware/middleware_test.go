@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+type traceKey struct{}
+
+// tracing returns a middleware that appends "name>" before and "<name" after
+// calling next, to the trace slice carried in the request context.
+func tracing(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace := r.Context().Value(traceKey{}).(*[]string)
+			*trace = append(*trace, name+">")
+			next.ServeHTTP(w, r)
+			*trace = append(*trace, "<"+name)
+		})
+	}
+}
+
+func serveTraced(h http.Handler) []string {
+	var trace []string
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), traceKey{}, &trace))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return trace
+}
+
+func TestChain_Order(t *testing.T) {
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace := r.Context().Value(traceKey{}).(*[]string)
+		*trace = append(*trace, "handler")
+	})
+
+	got := serveTraced(Chain(tracing("a"), tracing("b"), nil, tracing("c"))(final))
+	want := []string{"a>", "b>", "c>", "handler", "<c", "<b", "<a"}
+	if !slices.Equal(got, want) {
+		t.Errorf("execution order = %v, want %v", got, want)
+	}
+
+	// Chains nest: the outer chain's middlewares run around the inner one's.
+	nested := Chain(tracing("outer"), Chain(tracing("a"), tracing("b")))(final)
+	want = []string{"outer>", "a>", "b>", "handler", "<b", "<a", "<outer"}
+	if got := serveTraced(nested); !slices.Equal(got, want) {
+		t.Errorf("nested execution order = %v, want %v", got, want)
+	}
+}
+
+func TestChain_Empty(t *testing.T) {
+	called := false
+	h := Chain()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !called {
+		t.Error("empty chain should call the handler directly")
+	}
+}
+
+func TestChain_ShortCircuit(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		})
+	}
+	h := Chain(deny)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not run after a middleware rejects the request")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
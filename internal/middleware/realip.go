@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Client address headers set by reverse proxies.
+const (
+	HeaderForwardedFor = "X-Forwarded-For"
+	HeaderRealIP       = "X-Real-IP"
+)
+
+// RealIP replaces r.RemoteAddr with the client's address when the request
+// comes from one of the trusted proxies, so logging and per-IP rate limits
+// see the user rather than the proxy.
+//
+// X-Forwarded-For is read right to left, skipping trusted hops; the first
+// untrusted address is the client, and the entries it consumed are removed
+// so the header forwarded to the backend is not duplicated. Without
+// X-Forwarded-For, X-Real-IP is used. Requests from untrusted addresses, or
+// without a usable header, are passed on unchanged.
+func RealIP(trusted []*net.IPNet) Middleware {
+	isTrusted := func(ip net.IP) bool {
+		for _, n := range trusted {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			peer := net.ParseIP(host)
+			if peer == nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, rest := forwardedClient(r.Header.Values(HeaderForwardedFor), isTrusted)
+			if client == nil {
+				client = net.ParseIP(strings.TrimSpace(r.Header.Get(HeaderRealIP)))
+				rest = r.Header.Values(HeaderForwardedFor)
+			}
+			if client == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = r.WithContext(r.Context())
+			r.Header = r.Header.Clone()
+			r.RemoteAddr = net.JoinHostPort(client.String(), "0")
+			if len(rest) > 0 {
+				r.Header.Set(HeaderForwardedFor, strings.Join(rest, ", "))
+			} else {
+				r.Header.Del(HeaderForwardedFor)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient walks X-Forwarded-For values right to left and returns the
+// first address not in a trusted network, or the leftmost one if every hop
+// is trusted, along with the entries before it. It returns nil when the
+// header is absent or holds an invalid address before a client is found.
+func forwardedClient(values []string, isTrusted func(net.IP) bool) (net.IP, []string) {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return nil, nil
+		}
+		if i == 0 || !isTrusted(ip) {
+			return ip, hops[:i]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func TestRealIP(t *testing.T) {
+	trusted := mustCIDRs(t, "10.0.0.0/8", "::1/128")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		wantAddr   string
+		wantXFF    string
+	}{
+		{
+			name:       "trusted proxy with X-Forwarded-For",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.7"}},
+			wantAddr:   "203.0.113.7:0",
+		},
+		{
+			name:       "skips trusted hops from the right",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7, 10.0.0.9"}},
+			wantAddr:   "203.0.113.7:0",
+			wantXFF:    "198.51.100.1",
+		},
+		{
+			name:       "multiple header lines",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1", "203.0.113.7"}},
+			wantAddr:   "203.0.113.7:0",
+			wantXFF:    "198.51.100.1",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": {"10.1.1.1, 10.0.0.9"}},
+			wantAddr:   "10.1.1.1:0",
+		},
+		{
+			name:       "X-Real-IP",
+			remoteAddr: "[::1]:51234",
+			header:     http.Header{"X-Real-Ip": {"2001:db8::5"}},
+			wantAddr:   "[2001:db8::5]:0",
+		},
+		{
+			name:       "untrusted source is left alone",
+			remoteAddr: "192.0.2.10:40000",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.7"}, "X-Real-Ip": {"203.0.113.7"}},
+			wantAddr:   "192.0.2.10:40000",
+			wantXFF:    "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{},
+			wantAddr:   "10.0.0.2:51234",
+		},
+		{
+			name:       "garbage X-Forwarded-For",
+			remoteAddr: "10.0.0.2:51234",
+			header:     http.Header{"X-Forwarded-For": {"not-an-ip"}},
+			wantAddr:   "10.0.0.2:51234",
+			wantXFF:    "not-an-ip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddr, gotXFF string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAddr = r.RemoteAddr
+				gotXFF = r.Header.Get(HeaderForwardedFor)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header = tt.header
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotAddr != tt.wantAddr {
+				t.Errorf("RemoteAddr = %q, want %q", gotAddr, tt.wantAddr)
+			}
+			if gotXFF != tt.wantXFF {
+				t.Errorf("X-Forwarded-For = %q, want %q", gotXFF, tt.wantXFF)
+			}
+			if req.RemoteAddr != tt.remoteAddr {
+				t.Error("the caller's request should not be modified")
+			}
+		})
+	}
+}
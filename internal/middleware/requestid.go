@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HeaderRequestID carries the ID that correlates a request across the router
+// and the backend it is proxied to.
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID gives every request an ID, sets it as the X-Request-ID request
+// header, so it is forwarded to the backend, and on the response. An ID
+// already sent by the client, or already set on the response by an outer
+// middleware, is kept; otherwise a random UUID is generated. Handlers can
+// read the ID with RequestIDFromContext.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := strings.TrimSpace(r.Header.Get(HeaderRequestID))
+			if id == "" {
+				id = w.Header().Get(HeaderRequestID)
+			}
+			if id == "" {
+				id = NewUUID()
+			}
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+			r.Header = r.Header.Clone()
+			if r.Header == nil {
+				r.Header = http.Header{}
+			}
+			r.Header.Set(HeaderRequestID, id)
+			w.Header().Set(HeaderRequestID, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestIDFromContext returns the ID assigned by RequestID, or "" if the
+// request did not pass through it.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewUUID returns a random (version 4) UUID in its canonical string form.
+func NewUUID() string {
+	var b [16]byte
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// requestIDServe runs req through RequestID and returns the response along
+// with the header and context IDs the wrapped handler saw.
+func requestIDServe(req *http.Request) (w *httptest.ResponseRecorder, header, fromCtx string) {
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(HeaderRequestID)
+		fromCtx = RequestIDFromContext(r.Context())
+	}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w, header, fromCtx
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/alpha/x", nil)
+	w, header, fromCtx := requestIDServe(req)
+	if !uuidPattern.MatchString(header) {
+		t.Fatalf("generated ID %q is not a v4 UUID", header)
+	}
+	if fromCtx != header {
+		t.Errorf("context ID = %q, header ID = %q", fromCtx, header)
+	}
+	if req.Header.Get(HeaderRequestID) != "" {
+		t.Error("the caller's request headers should not be modified")
+	}
+
+	_, second, _ := requestIDServe(httptest.NewRequest(http.MethodGet, "/alpha/x", nil))
+	if second == header {
+		t.Errorf("two requests got the same ID %q", header)
+	}
+	if got := w.Header().Get(HeaderRequestID); got != header {
+		t.Errorf("response ID = %q, want %q", got, header)
+	}
+}
+
+func TestRequestID_PreservesIncoming(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/alpha/x", nil)
+	req.Header.Set(HeaderRequestID, "client-id-123")
+	_, header, fromCtx := requestIDServe(req)
+	if header != "client-id-123" || fromCtx != "client-id-123" {
+		t.Errorf("incoming ID not preserved: header %q, context %q", header, fromCtx)
+	}
+}
+
+func TestRequestID_ResponseHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/alpha/x", nil)
+	req.Header.Set(HeaderRequestID, "client-id-123")
+	w, _, _ := requestIDServe(req)
+	if got := w.Header().Get(HeaderRequestID); got != "client-id-123" {
+		t.Errorf("response %s = %q, want client-id-123", HeaderRequestID, got)
+	}
+}
+
+func TestRequestID_ReusesOuterResponseID(t *testing.T) {
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(HeaderRequestID); got != "outer" {
+			t.Errorf("forwarded ID = %q, want outer", got)
+		}
+	}))
+	w := httptest.NewRecorder()
+	w.Header().Set(HeaderRequestID, "outer")
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get(HeaderRequestID); got != "outer" {
+		t.Errorf("response ID = %q, want outer", got)
+	}
+}
@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// Tuning for GET /api/backends/{slug}/config.
+const (
+	backendConfigPath    = "/global/config"
+	backendConfigTTL     = 30 * time.Second
+	backendConfigTimeout = 5 * time.Second
+	maxBackendConfigSize = 1 << 20
+)
+
+// cachedConfig is a backend's /global/config response, kept for
+// backendConfigTTL.
+type cachedConfig struct {
+	port        int
+	body        []byte
+	contentType string
+	fetched     time.Time
+}
+
+// handleAPIBackendConfig returns a backend's own configuration from its
+// /global/config endpoint. Successful responses are cached for
+// backendConfigTTL; ?refresh=true fetches a fresh copy. Other statuses are
+// passed through uncached, and an unreachable backend is a 502.
+//
+//	GET /api/backends/{slug}/config?refresh=true
+func (rt *Router) handleAPIBackendConfig(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backend, ok := rt.registry.Lookup(slug)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+
+	if r.URL.Query().Get("refresh") != "true" {
+		if c, ok := rt.cachedBackendConfig(slug, backend.Port); ok {
+			rt.writeBackendConfig(w, http.StatusOK, c.contentType, c.body)
+			return
+		}
+	}
+
+	status, contentType, body, err := rt.fetchBackendConfig(r.Context(), backend)
+	if err != nil {
+		rt.logger.Warn("backend config fetch failed", "slug", slug, "port", backend.Port, "error", err)
+		http.Error(w, "backend unreachable", http.StatusBadGateway)
+		return
+	}
+	if status == http.StatusOK {
+		rt.configMu.Lock()
+		rt.configCache[slug] = cachedConfig{port: backend.Port, body: body, contentType: contentType, fetched: time.Now()}
+		rt.configMu.Unlock()
+	}
+	rt.writeBackendConfig(w, status, contentType, body)
+}
+
+// cachedBackendConfig returns the cached config of slug if it is younger
+// than backendConfigTTL and still belongs to the backend on port.
+func (rt *Router) cachedBackendConfig(slug string, port int) (cachedConfig, bool) {
+	rt.configMu.Lock()
+	defer rt.configMu.Unlock()
+	c, ok := rt.configCache[slug]
+	if !ok || c.port != port || time.Since(c.fetched) >= backendConfigTTL {
+		return cachedConfig{}, false
+	}
+	return c, true
+}
+
+// fetchBackendConfig requests backendConfigPath from backend.
+func (rt *Router) fetchBackendConfig(ctx context.Context, backend *registry.Backend) (int, string, []byte, error) {
+	scheme := "http"
+	if backend.TLS {
+		scheme = "https"
+	}
+	ctx, cancel := context.WithTimeout(ctx, backendConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		scheme+"://"+backend.Addr()+backendConfigPath, nil)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Transport: rt.transportFor(backend)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendConfigSize))
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return resp.StatusCode, resp.Header.Get("Content-Type"), body, nil
+}
+
+func (rt *Router) writeBackendConfig(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		rt.logger.Debug("failed to write backend config", "error", err)
+	}
+}
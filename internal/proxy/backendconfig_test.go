@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestAPIBackendConfig(t *testing.T) {
+	var hits atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/global/config" {
+			http.NotFound(w, r)
+			return
+		}
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"anthropic/claude","theme":"dark"}`))
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "alpha", "/home/user/alpha", "1.0")
+	rt := newTestRouter(reg)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/api/backends/alpha/config")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET config = %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != `{"model":"anthropic/claude","theme":"dark"}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	if w := get("/api/backends/alpha/config"); w.Code != http.StatusOK || hits.Load() != 1 {
+		t.Errorf("second GET = %d with %d backend hits, want a cached 200", w.Code, hits.Load())
+	}
+	if w := get("/api/backends/alpha/config?refresh=true"); w.Code != http.StatusOK || hits.Load() != 2 {
+		t.Errorf("refresh GET = %d with %d backend hits, want a fresh fetch", w.Code, hits.Load())
+	}
+
+	if w := get("/api/backends/missing/config"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown slug = %d, want 404", w.Code)
+	}
+}
+
+func TestAPIBackendConfig_ForwardsStatus(t *testing.T) {
+	var hits atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "config unavailable", http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "alpha", "/home/user/alpha", "1.0")
+	rt := newTestRouter(reg)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backends/alpha/config", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("GET config = %d, want the backend's 503", w.Code)
+		}
+	}
+	if hits.Load() != 2 {
+		t.Errorf("errors should not be cached: %d backend hits, want 2", hits.Load())
+	}
+}
+
+func TestAPIBackendConfig_Unreachable(t *testing.T) {
+	backend := httptest.NewServer(http.NotFoundHandler())
+	port := mustPort(t, backend.URL)
+	backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(port, "alpha", "/home/user/alpha", "1.0")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backends/alpha/config", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("GET config = %d, want 502", w.Code)
+	}
+}
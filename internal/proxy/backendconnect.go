@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+
+	"opencoderouter/internal/middleware"
+)
+
+// handleAPIBackendConnect opens a WebSocket tunnel to the root of a
+// backend's primary instance, for tools that want a raw connection to one
+// backend by slug rather than going through the proxied routes. The
+// request's query is passed on. 503 when the backend cannot be reached.
+//
+//	GET /api/backends/{slug}/connect
+func (rt *Router) handleAPIBackendConnect(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	backend, ok := rt.registry.Lookup(slug)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+	middleware.SkipCompression(w)
+
+	scheme := "ws"
+	if backend.TLS {
+		scheme = "wss"
+	}
+	target := &url.URL{Scheme: scheme, Host: backend.Addr()}
+
+	connID := rt.trackWSConnection(slug)
+	defer rt.untrackWSConnection(connID)
+
+	tunnelWebSocket(w, webSocketRequest(r, "/"), target, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestAPIBackendConnect(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	backend := wsEchoBackend(t, requests)
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "myproj", "/home/test/myproj", "1.0")
+	srv := httptest.NewServer(newTestRouter(reg))
+	defer srv.Close()
+
+	conn, reader := wsHandshake(t, strings.TrimPrefix(srv.URL, "http://"), "/api/backends/myproj/connect?client=cli")
+	assertWSEcho(t, conn, reader, "hello", "world")
+
+	r := <-requests
+	if r.URL.RequestURI() != "/?client=cli" {
+		t.Errorf("backend request URI = %q, want /?client=cli", r.URL.RequestURI())
+	}
+}
+
+func TestAPIBackendConnect_Errors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downPort := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(downPort, "down", "/home/test/down", "1.0")
+	rt := newTestRouter(reg)
+
+	upgrade := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, upgrade("/api/backends/missing/connect"))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"not_found"`) {
+		t.Errorf("unknown slug: status = %d, body = %q; want a JSON 404", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, upgrade("/api/backends/down/connect"))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unreachable backend: status = %d, want 503", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backends/down/connect", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("without an upgrade: status = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backends/down/connect", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// handleAPIBackendEvents streams a backend's changes as server-sent events:
+// an "updated" event with the backend's info on each change, starting with
+// its current state, and a final "removed" event once its last instance is
+// gone.
+//
+//	GET /api/backends/{slug}/events
+func (rt *Router) handleAPIBackendEvents(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Watch before the lookup so a change in between is not missed.
+	updates := rt.registry.Watch(r.Context(), slug)
+	current, ok := rt.registry.Lookup(slug)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	rt.writeBackendEvent(w, "updated", rt.newBackendInfo(current))
+	flusher.Flush()
+
+	for b := range updates {
+		if b == nil {
+			rt.writeBackendEvent(w, "removed", map[string]string{"slug": slug})
+			flusher.Flush()
+			return
+		}
+		rt.writeBackendEvent(w, "updated", rt.newBackendInfo(b))
+		flusher.Flush()
+	}
+}
+
+// writeBackendEvent writes one named server-sent event with a JSON payload.
+func (rt *Router) writeBackendEvent(w http.ResponseWriter, event string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		rt.logger.Debug("failed to encode backend event", "error", err)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// sseEvents delivers each named event read from resp's body.
+func sseEvents(resp *http.Response) <-chan sseEvent {
+	ch := make(chan sseEvent, 16)
+	go func() {
+		defer close(ch)
+		var ev sseEvent
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				ev.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			case line == "":
+				ch <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return ch
+}
+
+func expectEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("stream closed early")
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event within 2s")
+		return sseEvent{}
+	}
+}
+
+func TestAPIBackendEvents(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "proj", "/home/test/proj", "1.0")
+	srv := httptest.NewServer(New(reg, testCfg(), testLogger(), nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/backends/proj/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	events := sseEvents(resp)
+
+	var info backendInfo
+	ev := expectEvent(t, events)
+	if err := json.Unmarshal([]byte(ev.data), &info); ev.name != "updated" || err != nil || info.Version != "1.0" {
+		t.Fatalf("initial event = %+v (%v), want updated at 1.0", ev, err)
+	}
+
+	reg.Upsert(4097, "other", "/home/test/other", "1.0")
+	reg.Upsert(4096, "proj", "/home/test/proj", "1.1")
+	ev = expectEvent(t, events)
+	if err := json.Unmarshal([]byte(ev.data), &info); ev.name != "updated" || err != nil || info.Version != "1.1" {
+		t.Fatalf("change event = %+v (%v), want updated at 1.1", ev, err)
+	}
+
+	reg.Remove("proj")
+	if ev = expectEvent(t, events); ev.name != "removed" || ev.data != `{"slug":"proj"}` {
+		t.Fatalf("final event = %+v, want removed", ev)
+	}
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Fatalf("stream should end after removal, got %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not end after removal")
+	}
+}
+
+func TestAPIBackendEvents_UnknownSlug(t *testing.T) {
+	rt := New(registry.New(30*time.Second, testLogger()), testCfg(), testLogger(), nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backends/missing/events", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"opencoderouter/internal/registry"
+)
+
+const (
+	// BalanceRoundRobin spreads requests across all instances of a slug.
+	BalanceRoundRobin = "round-robin"
+	// BalanceFirst always routes to the earliest registered instance.
+	BalanceFirst = "first"
+)
+
+// Selector picks one backend from the instances registered under a slug.
+type Selector interface {
+	Select(slug string, backends []*registry.Backend) *registry.Backend
+}
+
+// NewSelector returns the Selector for a --balance strategy name.
+// Unknown names fall back to round-robin.
+func NewSelector(strategy string) Selector {
+	if strategy == BalanceFirst {
+		return firstSelector{}
+	}
+	return NewRoundRobinSelector()
+}
+
+// RoundRobinSelector cycles through instances with an independent counter per slug.
+type RoundRobinSelector struct {
+	mu       sync.Mutex
+	counters map[string]*atomic.Uint64
+}
+
+// NewRoundRobinSelector creates a RoundRobinSelector.
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{counters: make(map[string]*atomic.Uint64)}
+}
+
+// Select implements Selector.
+func (s *RoundRobinSelector) Select(slug string, backends []*registry.Backend) *registry.Backend {
+	switch len(backends) {
+	case 0:
+		return nil
+	case 1:
+		return backends[0]
+	}
+
+	s.mu.Lock()
+	counter, ok := s.counters[slug]
+	if !ok {
+		counter = new(atomic.Uint64)
+		s.counters[slug] = counter
+	}
+	s.mu.Unlock()
+
+	idx := (counter.Add(1) - 1) % uint64(len(backends))
+	return backends[idx]
+}
+
+type firstSelector struct{}
+
+func (firstSelector) Select(_ string, backends []*registry.Backend) *registry.Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+	return backends[0]
+}
+
+// lookupBackend resolves slug to one of its instances using the configured
+// Selector. A non-empty user limits the choice as in routable.
+func (rt *Router) lookupBackend(slug, user string) (*registry.Backend, bool) {
+	backend := rt.selector.Select(slug, rt.routable(slug, user))
+	return backend, backend != nil
+}
+
+// routable returns the instances of slug that may take new requests, i.e.
+// those not draining. A non-empty user keeps only the instances whose
+// domain names that user, so tenants sharing a project name under
+// --username-from-path reach their own.
+func (rt *Router) routable(slug, user string) []*registry.Backend {
+	backends := rt.registry.LookupAll(slug)
+	kept := backends[:0]
+	for _, b := range backends {
+		if !b.Draining && (user == "" || rt.ownedBy(b, user)) {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
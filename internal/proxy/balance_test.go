@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestRoundRobinSelector_PerSlug(t *testing.T) {
+	sel := NewRoundRobinSelector()
+	a := []*registry.Backend{{Port: 1}, {Port: 2}, {Port: 3}}
+	b := []*registry.Backend{{Port: 10}, {Port: 20}}
+
+	var gotA, gotB []int
+	for i := 0; i < 6; i++ {
+		gotA = append(gotA, sel.Select("a", a).Port)
+		gotB = append(gotB, sel.Select("b", b).Port)
+	}
+
+	wantA := []int{1, 2, 3, 1, 2, 3}
+	wantB := []int{10, 20, 10, 20, 10, 20}
+	for i := range wantA {
+		if gotA[i] != wantA[i] || gotB[i] != wantB[i] {
+			t.Fatalf("got a=%v b=%v, want a=%v b=%v", gotA, gotB, wantA, wantB)
+		}
+	}
+
+	if sel.Select("none", nil) != nil {
+		t.Error("expected nil for empty instance list")
+	}
+}
+
+func TestServeHTTP_RoundRobinAcrossInstances(t *testing.T) {
+	const n = 3
+	reg := registry.New(30*time.Second, testLogger())
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(i)
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(id))
+		}))
+		defer backend.Close()
+		reg.Upsert(mustPort(t, backend.URL), "repo", "/home/dev"+id+"/repo", "1.0")
+	}
+
+	rt := newTestRouter(reg)
+	srv := httptest.NewServer(rt)
+	defer srv.Close()
+
+	hits := make(map[string]int)
+	for i := 0; i < n*2; i++ {
+		resp, err := http.Get(srv.URL + "/repo/session")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		hits[string(body)]++
+	}
+
+	if len(hits) != n {
+		t.Fatalf("expected requests spread over %d backends, got %v", n, hits)
+	}
+	for id, count := range hits {
+		if count != 2 {
+			t.Errorf("backend %s got %d requests, want 2", id, count)
+		}
+	}
+}
+
+func TestServeHTTP_SkipsDraining(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger(), registry.WithDrainPeriod(time.Minute))
+	for _, id := range []string{"old", "new"} {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(id))
+		}))
+		defer backend.Close()
+		reg.Upsert(mustPort(t, backend.URL), "repo", "/home/"+id+"/repo", "1.0")
+		if id == "old" {
+			reg.MarkDraining("repo")
+		}
+	}
+
+	srv := httptest.NewServer(newTestRouter(reg))
+	defer srv.Close()
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(srv.URL + "/repo/session")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "new" {
+			t.Fatalf("request %d reached %q, want only the non-draining instance", i, body)
+		}
+	}
+
+	var draining int
+	for _, b := range reg.All() {
+		if b.Draining {
+			draining++
+		}
+	}
+	if n := len(reg.All()); n != 2 || draining != 1 {
+		t.Errorf("expected 2 listed instances with 1 draining, got %d and %d", n, draining)
+	}
+
+	w := httptest.NewRecorder()
+	newTestRouter(reg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backends", nil))
+	if got := strings.Count(w.Body.String(), `"draining":true`); got != 1 {
+		t.Errorf("GET /api/backends lists %d draining backends, want 1: %s", got, w.Body.String())
+	}
+
+	// With every instance draining the slug no longer routes.
+	reg.MarkDraining("repo")
+	resp, err := http.Get(srv.URL + "/repo/session")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) == "new" || string(body) == "old" {
+		t.Errorf("a fully draining slug was proxied to %q", body)
+	}
+}
+
+func TestServeHTTP_BalanceFirst(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	for i := 0; i < 2; i++ {
+		id := strconv.Itoa(i)
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(id))
+		}))
+		defer backend.Close()
+		reg.Upsert(mustPort(t, backend.URL), "repo", "/home/dev"+id+"/repo", "1.0")
+	}
+
+	cfg := testCfg()
+	cfg.Balance = BalanceFirst
+	rt := New(reg, cfg, testLogger(), nil)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/repo/", nil))
+		if w.Body.String() != "0" {
+			t.Fatalf("expected primary instance, got %q", w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// bufferRequestBody replaces a body of unknown length with an in-memory copy
+// so the upstream request carries Content-Length instead of chunked encoding.
+// Bodies larger than the configured maximum are rejected with 413. It returns
+// false if a response has already been written.
+func (rt *Router) bufferRequestBody(w http.ResponseWriter, r *http.Request) bool {
+	max := rt.cfg.BufferMaxSize
+	if r.ContentLength > max {
+		rt.writeTooLarge(w, max)
+		return false
+	}
+	if r.ContentLength >= 0 || r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(http.MaxBytesReader(w, r.Body, max))
+	r.Body.Close()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			rt.writeTooLarge(w, max)
+		} else {
+			http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		}
+		return false
+	}
+
+	r.Body = io.NopCloser(&buf)
+	r.ContentLength = int64(buf.Len())
+	r.TransferEncoding = nil
+	r.Header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	return true
+}
+
+func (rt *Router) writeTooLarge(w http.ResponseWriter, max int64) {
+	http.Error(w, fmt.Sprintf("request body exceeds %d bytes", max), http.StatusRequestEntityTooLarge)
+}
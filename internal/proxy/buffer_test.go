@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// contentLengthBackend reports the Content-Length the backend received, or
+// "chunked" when the body arrived without one.
+func contentLengthBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Length") == "" {
+			_, _ = io.WriteString(w, "chunked:"+string(body))
+			return
+		}
+		_, _ = io.WriteString(w, r.Header.Get("Content-Length")+":"+string(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func chunkedRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/upload/message", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1
+	return req
+}
+
+func TestServeHTTP_BufferRequestsSetsContentLength(t *testing.T) {
+	backend := contentLengthBackend(t)
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "upload", "/home/test/upload", "1.0")
+
+	cfg := testCfg()
+	cfg.BufferRequests = true
+	cfg.BufferMaxSize = 1024
+	rt := New(reg, cfg, testLogger(), nil)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, chunkedRequest("hello world"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Body.String(); got != "11:hello world" {
+		t.Errorf("backend saw %q, want Content-Length 11", got)
+	}
+}
+
+func TestServeHTTP_BufferRequestsDisabledStaysChunked(t *testing.T) {
+	backend := contentLengthBackend(t)
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "upload", "/home/test/upload", "1.0")
+
+	rt := New(reg, testCfg(), testLogger(), nil)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, chunkedRequest("hello world"))
+
+	if got := w.Body.String(); got != "chunked:hello world" {
+		t.Errorf("backend saw %q, want chunked body", got)
+	}
+}
+
+func TestServeHTTP_BufferRequestsTooLarge(t *testing.T) {
+	backend := contentLengthBackend(t)
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "upload", "/home/test/upload", "1.0")
+
+	cfg := testCfg()
+	cfg.BufferRequests = true
+	cfg.BufferMaxSize = 4
+	rt := New(reg, cfg, testLogger(), nil)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, chunkedRequest("hello world"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked: expected 413, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload/message", strings.NewReader("hello world")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("known length: expected 413, got %d", w.Code)
+	}
+}
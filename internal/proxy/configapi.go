@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"net/http"
+)
+
+// redacted replaces private values in GET /api/config when Config.RedactConfig is set.
+const redacted = "<redacted>"
+
+// configInfo is the API representation of the effective configuration.
+// Keys match the config file. Durations are Go duration strings.
+type configInfo struct {
+	ListenAddr          string            `json:"listen_addr"`
+	ListenPort          int               `json:"port"`
+	UnixSocket          string            `json:"unix,omitempty"`
+	Username            string            `json:"username"`
+	UsernameFromPath    bool              `json:"username_from_path"`
+	AuthUser            string            `json:"auth_user,omitempty"`
+	ScanPortStart       int               `json:"scan_start"`
+	ScanPortEnd         int               `json:"scan_end"`
+	ExcludePorts        []int             `json:"exclude_ports"`
+	ScanBlocklist       []int             `json:"scan_blocklist"`
+	ScanGroups          []string          `json:"scan_groups"`
+	ScanExclude         []string          `json:"scan_exclude"`
+	SessionPortStart    int               `json:"session_port_start"`
+	SessionPortEnd      int               `json:"session_port_end"`
+	ScanInterval        string            `json:"scan_interval"`
+	ScanConcurrency     int               `json:"scan_concurrency"`
+	ScanConcurrencyAuto bool              `json:"scan_concurrency_auto"`
+	DryRun              bool              `json:"dry_run"`
+	PassiveScan         bool              `json:"passive_scan"`
+	ProbeTimeout        string            `json:"probe_timeout"`
+	ProbeRetries        int               `json:"probe_retries"`
+	ProbeRetryDelay     string            `json:"probe_retry_delay"`
+	StaleAfter          string            `json:"stale_after"`
+	DrainTimeout        string            `json:"drain_timeout"`
+	DrainPeriod         string            `json:"drain_period"`
+	HealthPath          string            `json:"health_path"`
+	ProjectPath         string            `json:"project_path"`
+	ProbeUserAgent      string            `json:"probe_user_agent"`
+	ProbeTLS            bool              `json:"probe_tls"`
+	ScanIPv6            bool              `json:"ipv6"`
+	EnableMDNS          bool              `json:"mdns"`
+	HostSuffix          string            `json:"host_suffix"`
+	MDNSServiceType     string            `json:"mdns_service_type"`
+	MDNSInterfaces      []string          `json:"mdns_interfaces"`
+	MDNSSRVPriority     int               `json:"mdns_srv_priority"`
+	MDNSSRVWeight       int               `json:"mdns_srv_weight"`
+	MDNSSubtypes        map[string]string `json:"mdns_subtypes"`
+	PeerTimeout         string            `json:"peer_timeout"`
+	TLSEnabled          bool              `json:"tls"`
+	TLSCert             string            `json:"tls_cert,omitempty"`
+	TLSKey              string            `json:"tls_key,omitempty"`
+	HSTSMaxAge          string            `json:"hsts_max_age"`
+	HTTPRedirectPort    int               `json:"http_redirect_port"`
+	AdminPort           int               `json:"admin_port"`
+	CORSOrigins         []string          `json:"cors_origins"`
+	EnableCompression   bool              `json:"compress"`
+	BehindProxy         bool              `json:"behind_proxy"`
+	ProxyProtocol       bool              `json:"proxy_protocol"`
+	TrustedProxies      []string          `json:"trusted_proxies"`
+	AllowRemote         bool              `json:"allow_remote"`
+	AllowedClientCIDRs  []string          `json:"allowed_client_cidrs"`
+	UseH2C              bool              `json:"h2c"`
+	GRPCEnabled         bool              `json:"grpc"`
+	StrictMode          bool              `json:"strict"`
+	DashboardTimeout    string            `json:"dashboard_timeout"`
+	RestartPolicy       string            `json:"restart_policy"`
+	Balance             string            `json:"balance"`
+	StickySession       bool              `json:"sticky_session"`
+	StickyMaxAge        string            `json:"sticky_max_age"`
+	SlugCollision       string            `json:"slug_collision"`
+	BufferRequests      bool              `json:"buffer_requests"`
+	BufferMaxSize       int64             `json:"buffer_max_size"`
+	AccessLog           bool              `json:"access_log"`
+	TraceFile           string            `json:"trace_file,omitempty"`
+	TraceSlug           string            `json:"trace_slug,omitempty"`
+	InjectRequestID     bool              `json:"inject_request_id"`
+	LogLevel            string            `json:"log_level"`
+	LogFormat           string            `json:"log_format"`
+	LogDir              string            `json:"log_dir,omitempty"`
+	PinnedFile          string            `json:"pinned_file,omitempty"`
+	OTelEndpoint        string            `json:"otel_endpoint,omitempty"`
+	ConfigFile          string            `json:"config_file,omitempty"`
+}
+
+// newConfigInfo snapshots the router's config. The scan interval and stale
+// window come from the live scanner and registry, since SIGHUP reloads
+// change them without touching the router's copy.
+func (rt *Router) newConfigInfo() configInfo {
+	c := rt.cfg
+	scanInterval := c.ScanInterval
+	if rt.scanner != nil {
+		scanInterval = rt.scanner.Interval()
+	}
+	info := configInfo{
+		ListenAddr:          c.ListenAddr,
+		ListenPort:          c.ListenPort,
+		UnixSocket:          c.UnixSocket,
+		Username:            c.Username,
+		UsernameFromPath:    c.UsernameFromPath,
+		AuthUser:            c.BasicAuthUser,
+		ScanPortStart:       c.ScanPortStart,
+		ScanPortEnd:         c.ScanPortEnd,
+		ExcludePorts:        c.ScanExcludedPorts(),
+		ScanBlocklist:       c.ScanPortBlocklist,
+		SessionPortStart:    c.SessionPortStart,
+		SessionPortEnd:      c.SessionPortEnd,
+		ScanInterval:        scanInterval.String(),
+		ScanConcurrency:     c.ScanConcurrency,
+		ScanConcurrencyAuto: c.ScanConcurrencyAuto,
+		DryRun:              c.DryRun,
+		PassiveScan:         c.PassiveScan,
+		ProbeTimeout:        c.ProbeTimeout.String(),
+		ProbeRetries:        c.ProbeRetries,
+		ProbeRetryDelay:     c.ProbeRetryDelay.String(),
+		StaleAfter:          rt.registry.StaleAfter().String(),
+		DrainTimeout:        c.DrainTimeout.String(),
+		DrainPeriod:         c.DrainPeriod.String(),
+		HealthPath:          c.HealthPath,
+		ProjectPath:         c.ProjectPath,
+		ProbeUserAgent:      c.ProbeUserAgent,
+		ProbeTLS:            c.ProbeTLS,
+		ScanIPv6:            c.ScanIPv6,
+		EnableMDNS:          c.EnableMDNS,
+		HostSuffix:          c.HostSuffix,
+		MDNSServiceType:     c.MDNSServiceType,
+		MDNSInterfaces:      c.MDNSInterfaces,
+		MDNSSRVPriority:     c.MDNSSRVPriority,
+		MDNSSRVWeight:       c.MDNSSRVWeight,
+		MDNSSubtypes:        c.MDNSSubtypes,
+		PeerTimeout:         c.PeerTimeout.String(),
+		TLSEnabled:          c.TLSEnabled,
+		TLSCert:             c.TLSCert,
+		TLSKey:              c.TLSKey,
+		HSTSMaxAge:          c.HSTSMaxAge.String(),
+		HTTPRedirectPort:    c.HTTPRedirectPort,
+		AdminPort:           c.AdminPort,
+		CORSOrigins:         c.CORSOrigins,
+		EnableCompression:   c.EnableCompression,
+		BehindProxy:         c.BehindProxy,
+		ProxyProtocol:       c.ProxyProtocol,
+		TrustedProxies:      c.TrustedProxies,
+		AllowRemote:         c.AllowRemote,
+		AllowedClientCIDRs:  c.AllowedClientCIDRs,
+		UseH2C:              c.UseH2C,
+		GRPCEnabled:         c.GRPCEnabled,
+		StrictMode:          c.StrictMode,
+		DashboardTimeout:    c.DashboardTimeout.String(),
+		RestartPolicy:       c.RestartPolicy,
+		Balance:             c.Balance,
+		StickySession:       c.StickySession,
+		StickyMaxAge:        c.StickyMaxAge.String(),
+		SlugCollision:       c.SlugCollision,
+		BufferRequests:      c.BufferRequests,
+		BufferMaxSize:       c.BufferMaxSize,
+		AccessLog:           c.AccessLog,
+		TraceFile:           c.TraceFile,
+		TraceSlug:           c.TraceSlug,
+		InjectRequestID:     c.InjectRequestID,
+		LogLevel:            c.LogLevel,
+		LogFormat:           c.LogFormat,
+		LogDir:              c.LogDir,
+		PinnedFile:          c.PinnedFile,
+		OTelEndpoint:        c.OTelEndpoint,
+		ConfigFile:          c.ConfigFile,
+	}
+	if info.ExcludePorts == nil {
+		info.ExcludePorts = []int{}
+	}
+	if info.ScanBlocklist == nil {
+		info.ScanBlocklist = []int{}
+	}
+	info.ScanGroups = []string{}
+	for _, r := range c.ScanPortGroups {
+		info.ScanGroups = append(info.ScanGroups, r.String())
+	}
+	info.ScanExclude = []string{}
+	for _, r := range c.ScanExcludeRanges {
+		info.ScanExclude = append(info.ScanExclude, r.String())
+	}
+	if info.MDNSInterfaces == nil {
+		info.MDNSInterfaces = []string{}
+	}
+	if info.CORSOrigins == nil {
+		info.CORSOrigins = []string{}
+	}
+	if info.TrustedProxies == nil {
+		info.TrustedProxies = []string{}
+	}
+	if info.AllowedClientCIDRs == nil {
+		info.AllowedClientCIDRs = []string{}
+	}
+	if c.RedactConfig {
+		for _, field := range []*string{
+			&info.Username, &info.AuthUser, &info.TLSCert, &info.TLSKey, &info.LogDir, &info.PinnedFile, &info.OTelEndpoint, &info.ConfigFile,
+		} {
+			if *field != "" {
+				*field = redacted
+			}
+		}
+	}
+	return info
+}
+
+// handleAPIConfig returns the router's effective configuration.
+//
+//	GET /api/config
+func (rt *Router) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, rt.newConfigInfo())
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func getConfig(t *testing.T, rt *Router) map[string]interface{} {
+	t.Helper()
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return got
+}
+
+func TestAPIConfig(t *testing.T) {
+	reg := registry.New(45*time.Second, testLogger())
+	cfg := testCfg()
+	cfg.ListenAddr = "127.0.0.1:8080"
+	cfg.ScanPortStart, cfg.ScanPortEnd = 4000, 4100
+	rt := New(reg, cfg, testLogger(), http.NotFoundHandler())
+
+	got := getConfig(t, rt)
+	for _, key := range []string{
+		"listen_addr", "port", "username", "scan_start", "scan_end", "scan_interval",
+		"probe_timeout", "stale_after", "mdns", "mdns_service_type", "mdns_interfaces",
+	} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing key %q in %v", key, got)
+		}
+	}
+	if got["listen_addr"] != "127.0.0.1:8080" || got["scan_start"] != float64(4000) || got["scan_end"] != float64(4100) {
+		t.Errorf("unexpected listen/scan settings: %v", got)
+	}
+	if got["stale_after"] != "45s" || got["username"] != "testuser" {
+		t.Errorf("unexpected stale_after/username: %v %v", got["stale_after"], got["username"])
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/config", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", w.Code)
+	}
+}
+
+func TestAPIConfig_Redacted(t *testing.T) {
+	cfg := testCfg()
+	cfg.RedactConfig = true
+	cfg.ConfigFile = "/etc/opencode-router.json"
+	rt := New(registry.New(30*time.Second, testLogger()), cfg, testLogger(), http.NotFoundHandler())
+
+	got := getConfig(t, rt)
+	for _, key := range []string{"username", "config_file"} {
+		if got[key] != redacted {
+			t.Errorf("%s = %v, want %q", key, got[key], redacted)
+		}
+	}
+	if _, ok := got["otel_endpoint"]; ok {
+		t.Errorf("unset otel_endpoint should be omitted, got %v", got["otel_endpoint"])
+	}
+	if got["scan_start"] != float64(cfg.ScanPortStart) {
+		t.Errorf("non-sensitive fields must not be redacted: %v", got["scan_start"])
+	}
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"opencoderouter/internal/auth"
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/middleware"
+)
+
+// corsOriginLabel is the backend label holding comma-separated origins that
+// replace Config.CORSOrigins for that backend.
+const corsOriginLabel = "cors_origin"
+
+// newCORS builds the router's CORS middleware. Config.CORSOrigins is the
+// global allowlist; without it the auth package's OCR_CORS_ALLOW_ORIGINS
+// list (default "*") applies, as it did before the router handled CORS.
+func (rt *Router) newCORS(authCfg auth.Config) middleware.Middleware {
+	origins := rt.cfg.CORSOrigins
+	if len(origins) == 0 {
+		origins = authCfg.CORSAllowedOrigins
+	}
+	return middleware.CORS(origins, rt.corsOriginsFor)
+}
+
+// CORS returns the middleware the router applies for cross-origin requests.
+// Handlers placed in front of the router, such as the session API, should
+// be wrapped with it so preflights for proxied backends are answered with
+// the same policy.
+func (rt *Router) CORS() middleware.Middleware {
+	return rt.cors
+}
+
+// corsOriginsFor returns the cors_origin label of the backend r is routed
+// to, or nil to use Config.CORSOrigins.
+func (rt *Router) corsOriginsFor(r *http.Request) []string {
+	slug := rt.slugFromHost(r.Host)
+	if slug == "" {
+		slug, _ = rt.slugFromPath(r.URL.Path)
+	}
+	if slug == "" {
+		return nil
+	}
+	backend, ok := rt.registry.Lookup(slug)
+	if !ok {
+		return nil
+	}
+	return config.SplitList(backend.Labels[corsOriginLabel])
+}
+
+// stripBackendCORS drops a backend's own CORS headers once the router's CORS
+// middleware has answered for the request, so browsers never see both.
+func stripBackendCORS(w http.ResponseWriter, resp *http.Response) {
+	if w.Header().Get(middleware.HeaderAllowOrigin) == "" {
+		return
+	}
+	for k := range resp.Header {
+		if strings.HasPrefix(k, "Access-Control-") {
+			resp.Header.Del(k)
+		}
+	}
+}
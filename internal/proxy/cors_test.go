@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestCORS_RouterIntegration(t *testing.T) {
+	var backendHits int
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendHits++
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Backend")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+	reg.Upsert(4097, "special", "/home/test/special", "1.0")
+	if err := reg.SetLabels("special", map[string]string{"cors_origin": "https://special.example"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testCfg()
+	cfg.CORSOrigins = []string{"https://app.example"}
+	rt := New(reg, cfg, testLogger(), nil)
+
+	serve := func(method, target, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodOptions, "/proj/session", "https://app.example")
+	if w.Code != http.StatusNoContent || backendHits != 0 {
+		t.Fatalf("preflight = %d with %d backend hits, want 204 answered by the router", w.Code, backendHits)
+	}
+
+	w = serve(http.MethodGet, "/proj/session", "https://app.example")
+	if got := w.Header().Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "https://app.example" {
+		t.Errorf("Allow-Origin = %v, want only the router's value; %v", got, w.Header())
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "" {
+		t.Errorf("backend CORS header leaked: %q", got)
+	}
+
+	w = serve(http.MethodGet, "/proj/session", "https://evil.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("rejected origin should see the backend's own headers, got %q", got)
+	}
+
+	w = serve(http.MethodOptions, "/special/session", "https://special.example")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://special.example" {
+		t.Errorf("cors_origin label: preflight = %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	w = serve(http.MethodOptions, "/special/session", "https://app.example")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("cors_origin label should replace the global list, got %d", w.Code)
+	}
+
+	w = serve(http.MethodOptions, "/api/backends", "https://app.example")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("API preflight = %d, want 204", w.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+)
+
+// drainPollInterval is how often Drain rechecks the in-flight counter.
+const drainPollInterval = 5 * time.Millisecond
+
+// trackInFlight reports whether r counts towards InFlight. WebSocket
+// connections are excluded: they stay open indefinitely and would hold
+// every drain to its full timeout.
+func trackInFlight(r *http.Request) bool {
+	return !headerHasToken(r.Header.Get("Upgrade"), "websocket")
+}
+
+// countInFlight is the outermost middleware of every Router and of Guard, so
+// InFlight covers the whole request including auth and any WithMiddleware
+// layers, and handlers in front of the router as well.
+func (rt *Router) countInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if trackInFlight(r) {
+			rt.inFlight.Add(1)
+			defer rt.inFlight.Add(-1)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// InFlight returns the number of requests the router is currently serving.
+func (rt *Router) InFlight() int64 {
+	return rt.inFlight.Load()
+}
+
+// Drain blocks until no requests are in flight or timeout elapses. It
+// returns false if requests were still running at the deadline.
+func (rt *Router) Drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for rt.inFlight.Load() > 0 {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
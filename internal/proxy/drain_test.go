@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func slowBackend(t *testing.T, delay time.Duration) (*registry.Registry, func()) {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		_, _ = io.WriteString(w, "done")
+	}))
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "slow", "/home/test/slow", "1.0")
+	return reg, backend.Close
+}
+
+func waitInFlight(t *testing.T, rt *Router, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for rt.InFlight() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("in-flight = %d, want %d", rt.InFlight(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDrain_WaitsForInFlightRequest(t *testing.T) {
+	reg, closeBackend := slowBackend(t, 50*time.Millisecond)
+	defer closeBackend()
+	rt := newTestRouter(reg)
+	front := httptest.NewServer(rt)
+	defer front.Close()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(front.URL + "/slow/")
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		done <- result{resp.StatusCode, string(body), err}
+	}()
+
+	waitInFlight(t, rt, 1)
+	if !rt.Drain(time.Second) {
+		t.Fatal("expected drain to finish before the timeout")
+	}
+	// Shutdown would proceed here; the request must already have completed.
+	front.Close()
+
+	res := <-done
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Errorf("expected completed response, got %+v", res)
+	}
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	reg, closeBackend := slowBackend(t, 300*time.Millisecond)
+	defer closeBackend()
+	rt := newTestRouter(reg)
+
+	go rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow/", nil))
+	waitInFlight(t, rt, 1)
+
+	start := time.Now()
+	if rt.Drain(20 * time.Millisecond) {
+		t.Error("expected drain to time out with a request still running")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("drain overran its timeout: %s", elapsed)
+	}
+	waitInFlight(t, rt, 0)
+}
+
+func TestDrain_IgnoresWebSockets(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Upgrade", "websocket")
+	if trackInFlight(req) {
+		t.Error("WebSocket upgrades should not count as in-flight")
+	}
+	if !trackInFlight(httptest.NewRequest("GET", "/slow/", nil)) {
+		t.Error("plain requests should count as in-flight")
+	}
+}
+
+func TestGuard_CountsHandlersInFrontOfRouter(t *testing.T) {
+	reg, closeBackend := slowBackend(t, 50*time.Millisecond)
+	defer closeBackend()
+	rt := newTestRouter(reg)
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sessions", func(w http.ResponseWriter, r *http.Request) { <-release })
+	mux.Handle("/", rt)
+	front := httptest.NewServer(rt.Guard()(mux))
+	defer front.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if resp, err := http.Get(front.URL + "/api/sessions"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitInFlight(t, rt, 1)
+	if rt.Drain(20 * time.Millisecond) {
+		t.Error("Drain returned true while a session API request was running")
+	}
+	close(release)
+	<-done
+	waitInFlight(t, rt, 0)
+
+	// A request falling through to the router is counted once, not twice.
+	go func() {
+		if resp, err := http.Get(front.URL + "/slow/"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitInFlight(t, rt, 1)
+	time.Sleep(10 * time.Millisecond)
+	if n := rt.InFlight(); n != 1 {
+		t.Errorf("in-flight = %d for one proxied request, want 1", n)
+	}
+	if !rt.Drain(2 * time.Second) {
+		t.Error("Drain timed out")
+	}
+}
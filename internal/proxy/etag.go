@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uptimeETagPeriod is how long GET /api/backends keeps an ETag while only
+// uptime_ms and uptime_percent_24h move, so a revalidating poller sees them
+// at most this stale.
+const uptimeETagPeriod = time.Minute
+
+// backendsETag is the quoted ETag for GET /api/backends: the registry's
+// ETag, folded with the current uptimeETagPeriod and the query string,
+// because uptime, filters and sort order change the body.
+func backendsETag(registryTag, rawQuery string, now time.Time) string {
+	period := now.Unix() / int64(uptimeETagPeriod/time.Second)
+	h := fnv.New64a()
+	h.Write([]byte(registryTag + "@" + strconv.FormatInt(period, 10) + "?" + rawQuery))
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value names etag,
+// using the weak comparison RFC 9110 prescribes for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"net/http"
+
+	"opencoderouter/internal/export"
+	"opencoderouter/internal/registry"
+)
+
+// handleAPIExportCaddy returns a Caddyfile fronting every routable backend
+// under its host-based routing domain.
+//
+//	GET /api/export/caddy
+func (rt *Router) handleAPIExportCaddy(w http.ResponseWriter, r *http.Request) {
+	rt.serveExport(w, r, export.CaddyExporter{Domain: func(b *registry.Backend) string {
+		return rt.cfg.DomainForUser(b.Slug, b.Username)
+	}})
+}
+
+// handleAPIExportCompose returns a docker-compose services block running
+// every routable backend in a container. ?image= overrides the image.
+//
+//	GET /api/export/compose
+func (rt *Router) handleAPIExportCompose(w http.ResponseWriter, r *http.Request) {
+	rt.serveExport(w, r, export.ComposeExporter{Image: r.URL.Query().Get("image")})
+}
+
+func (rt *Router) serveExport(w http.ResponseWriter, r *http.Request, e export.Exporter) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	out, err := e.Export(rt.registry.All())
+	if err != nil {
+		rt.logger.Error("export failed", "path", r.URL.Path, "error", err)
+		http.Error(w, "export failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", e.ContentType())
+	if _, err := w.Write(out); err != nil {
+		rt.logger.Debug("failed to write export", "error", err)
+	}
+}
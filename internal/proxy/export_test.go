@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestAPIExport(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	rt := newTestRouter(reg)
+
+	for path, want := range map[string][]string{
+		"/api/export/caddy":   {"alpha-testuser.local {", "reverse_proxy 127.0.0.1:4096"},
+		"/api/export/compose": {"services:", "opencode-alpha:", "127.0.0.1:4096:4096", "/home/user/alpha:/workspace"},
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, w.Code, w.Body.String())
+		}
+		for _, s := range want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("GET %s missing %q:\n%s", path, s, w.Body.String())
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/export/compose", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("compose Content-Type = %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/export/caddy", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/export/caddy = %d, want 405", w.Code)
+	}
+}
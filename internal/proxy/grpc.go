@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	"opencoderouter/internal/registry"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// isGRPCRequest reports whether r is a gRPC call (application/grpc,
+// application/grpc+proto, ...).
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// AcceptH2C wraps h so it also accepts cleartext HTTP/2 with prior
+// knowledge, which is how gRPC clients connect without TLS. HTTP/1.1
+// requests, including WebSocket upgrades, pass through unchanged.
+func AcceptH2C(h http.Handler) http.Handler {
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
+// grpcTransportFor returns an HTTP/2 transport for forwarding gRPC to
+// backend: h2c for plain backends, or HTTP/2 over TLS without certificate
+// verification for HTTPS backends. gRPC needs HTTP/2 end to end for
+// trailers and bidirectional streams, so the h2c probe result is ignored.
+func (rt *Router) grpcTransportFor(backend *registry.Backend) http.RoundTripper {
+	if backend.TLS {
+		rt.grpcTLSTransportOnce.Do(func() {
+			rt.grpcTLSTransport = &http2.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			}
+		})
+		return rt.grpcTLSTransport
+	}
+	return rt.sharedH2CTransport(backend.Port)
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPC_UnaryAndStreaming(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	backend := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(backend, hs)
+	go func() { _ = backend.Serve(lis) }()
+	defer backend.Stop()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(lis.Addr().(*net.TCPAddr).Port, "proj", "/home/test/proj", "1.0")
+	cfg := testCfg()
+	cfg.GRPCEnabled = true
+	rt := New(reg, cfg, testLogger(), nil)
+
+	front := httptest.NewServer(AcceptH2C(rt))
+	defer front.Close()
+
+	conn, err := grpc.NewClient(front.Listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithAuthority(cfg.DomainFor("proj")),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("unary Check through proxy: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check status = %v, want SERVING", resp.GetStatus())
+	}
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch through proxy: %v", err)
+	}
+	first, err := stream.Recv()
+	if err != nil || first.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("first Watch update = %v, %v", first, err)
+	}
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	second, err := stream.Recv()
+	if err != nil || second.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("second Watch update = %v, %v", second, err)
+	}
+}
+
+func TestGRPC_DisabledByDefault(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	backend := grpc.NewServer()
+	healthpb.RegisterHealthServer(backend, health.NewServer())
+	go func() { _ = backend.Serve(lis) }()
+	defer backend.Stop()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(lis.Addr().(*net.TCPAddr).Port, "proj", "/home/test/proj", "1.0")
+	cfg := testCfg()
+	rt := New(reg, cfg, testLogger(), nil)
+
+	front := httptest.NewServer(AcceptH2C(rt))
+	defer front.Close()
+
+	conn, err := grpc.NewClient(front.Listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithAuthority(cfg.DomainFor("proj")),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+		t.Error("expected gRPC call to fail without GRPCEnabled")
+	}
+}
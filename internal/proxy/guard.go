@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"opencoderouter/internal/middleware"
+)
+
+// guardKey marks a request context once the request has passed Guard.
+type guardKey struct{}
+
+// Guard returns the router's outer layers: in-flight counting for Drain,
+// RealIP, the client allowlist, CORS and Basic Auth. Handlers served in
+// front of the router, such as the session API with the router as its
+// fallback, must be wrapped with it, or their routes would skip the access
+// checks and be ignored by drain-on-shutdown. Requests that reach the
+// router through Guard are not checked or counted a second time.
+func (rt *Router) Guard() middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		mark := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), guardKey{}, true)))
+		})
+		return rt.guard(mark)
+	}
+}
+
+// passedGuard reports whether r has been through Guard.
+func passedGuard(r *http.Request) bool {
+	ok, _ := r.Context().Value(guardKey{}).(bool)
+	return ok
+}
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"opencoderouter/internal/registry"
+
+	"golang.org/x/net/http2"
+)
+
+// transportFor returns the upstream transport for backend. Backends that
+// advertised h2c support get a dedicated cleartext HTTP/2 transport, kept
+// for the life of the router so concurrent requests multiplex over one
+// connection. HTTPS backends share a transport that accepts their
+// self-signed certificates. Everything else uses the default HTTP/1.1
+// transport (nil).
+func (rt *Router) transportFor(backend *registry.Backend) http.RoundTripper {
+	if backend.TLS {
+		rt.tlsTransportOnce.Do(func() {
+			rt.tlsTransport = newInsecureTLSTransport()
+		})
+		return rt.tlsTransport
+	}
+	if !rt.cfg.UseH2C || !backend.SupportsH2C {
+		return nil
+	}
+	return rt.sharedH2CTransport(backend.Port)
+}
+
+// sharedH2CTransport returns the h2c transport for port, creating it on
+// first use.
+func (rt *Router) sharedH2CTransport(port int) *http2.Transport {
+	rt.transportMu.Lock()
+	defer rt.transportMu.Unlock()
+
+	if t, ok := rt.h2cTransports[port]; ok {
+		return t
+	}
+	t := newH2CTransport()
+	rt.h2cTransports[port] = t
+	return t
+}
+
+// newInsecureTLSTransport is the default transport without certificate
+// verification. Upstreams are always on 127.0.0.1, where self-signed
+// certificates are expected.
+func newInsecureTLSTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return t
+}
+
+// newH2CTransport speaks HTTP/2 with prior knowledge over plain TCP.
+func newH2CTransport() *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestServeHTTP_H2CMultiplexesOneConnection(t *testing.T) {
+	var (
+		conns   atomic.Int32
+		arrived sync.WaitGroup
+	)
+	arrived.Add(2)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hold both requests open until each has arrived, so they are
+		// genuinely concurrent on the wire.
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	backend := httptest.NewUnstartedServer(h2c.NewHandler(handler, &http2.Server{}))
+	backend.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	port := mustPort(t, backend.URL)
+	reg.Upsert(port, "h2", "/home/test/h2", "1.0")
+	reg.SetSupportsH2C(port, true)
+
+	cfg := testCfg()
+	cfg.UseH2C = true
+	rt := New(reg, cfg, testLogger(), nil)
+
+	var wg sync.WaitGroup
+	protos := make([]string, 2)
+	for i := range protos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/h2/stream", nil))
+			protos[i] = w.Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, proto := range protos {
+		if proto != "HTTP/2.0" {
+			t.Errorf("request %d reached backend over %q, want HTTP/2.0", i, proto)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("expected both requests on one connection, got %d connections", n)
+	}
+}
+
+func TestServeHTTP_H2CRequiresBackendSupport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "h1", "/home/test/h1", "1.0")
+
+	cfg := testCfg()
+	cfg.UseH2C = true
+	rt := New(reg, cfg, testLogger(), nil)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/h1/", nil))
+	if got := w.Body.String(); got != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1 for backend without h2c, got %q", got)
+	}
+}
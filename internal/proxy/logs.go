@@ -0,0 +1,261 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/registry"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Limits for GET /api/backends/{slug}/logs?lines=N.
+const (
+	defaultLogLines = 100
+	maxLogLines     = 10000
+)
+
+// logPath returns the log file of the backend at slug. Backends that have
+// gone away may still have a log, found through the launcher by project
+// name as long as only one managed process has it.
+func (rt *Router) logPath(slug string) (string, bool) {
+	if b, ok := rt.registry.Lookup(slug); ok {
+		return launcher.LogPath(rt.cfg.LogDir, b.ProjectPath, b.Port), true
+	}
+	if rt.launcher == nil {
+		return "", false
+	}
+	var path string
+	for _, p := range rt.launcher.Status() {
+		if registry.SlugifyPath(p.Path) != slug {
+			continue
+		}
+		if path != "" {
+			return "", false
+		}
+		path = launcher.LogPath(rt.cfg.LogDir, p.Path, p.Port)
+	}
+	return path, path != ""
+}
+
+// logTailChunk is how much of the file tailLines reads per step backwards.
+const logTailChunk = 32 << 10
+
+// handleAPIBackendLogs streams a launched backend's log file as SSE: the
+// last N lines first, then every line appended while the client stays
+// connected. Each line is one "data:" event.
+//
+//	GET /api/backends/{slug}/logs?lines=100
+func (rt *Router) handleAPIBackendLogs(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lines := defaultLogLines
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLogLines {
+			http.Error(w, fmt.Sprintf("lines must be 1-%d", maxLogLines), http.StatusBadRequest)
+			return
+		}
+		lines = n
+	}
+	if rt.cfg.LogDir == "" {
+		writeLogsNotFound(w, slug, "log capture is disabled")
+		return
+	}
+
+	path, ok := rt.logPath(slug)
+	if !ok {
+		writeLogsNotFound(w, slug, "no log file for this backend")
+		return
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeLogsNotFound(w, slug, "no log file for this backend")
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to open log file", http.StatusInternalServerError)
+		return
+	}
+	follower := &logFollower{path: path, f: f}
+	defer func() { _ = follower.f.Close() }()
+
+	// Watch before reading so writes made while the tail is sent are not
+	// missed. The directory is watched, not the file, so rotation shows up
+	// as a Create.
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		http.Error(w, "failed to watch log file", http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = watcher.Close() }()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		http.Error(w, "failed to watch log file", http.StatusInternalServerError)
+		return
+	}
+
+	tail, offset, err := tailLines(f, lines)
+	if err != nil {
+		http.Error(w, "failed to read log file", http.StatusInternalServerError)
+		return
+	}
+	follower.offset = offset
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range tail {
+		writeSSELine(w, line)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case err, ok := <-watcher.Errors:
+			if ok {
+				rt.logger.Debug("log watcher failed", "slug", slug, "error", err)
+			}
+			return
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Name != path || !ev.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			newLines, err := follower.next()
+			if err != nil {
+				rt.logger.Debug("log read failed", "slug", slug, "error", err)
+				return
+			}
+			if len(newLines) == 0 {
+				continue
+			}
+			for _, line := range newLines {
+				writeSSELine(w, line)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogsNotFound(w http.ResponseWriter, slug, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	writeJSONResponse(w, map[string]interface{}{
+		"error":  "not_found",
+		"query":  slug,
+		"detail": detail,
+	})
+}
+
+func writeSSELine(w io.Writer, line string) {
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", strings.TrimSuffix(line, "\r"))
+}
+
+// tailLines returns up to the last n complete lines of f and the offset just
+// past them. A trailing line without a newline is left for the follower.
+func tailLines(f *os.File, n int) ([]string, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	pos := info.Size()
+	var buf []byte
+	for pos > 0 && bytes.Count(buf, []byte{'\n'}) <= n {
+		chunk := min(int64(logTailChunk), pos)
+		pos -= chunk
+		b := make([]byte, chunk)
+		if _, err := f.ReadAt(b, pos); err != nil {
+			return nil, 0, err
+		}
+		buf = append(b, buf...)
+	}
+	last := bytes.LastIndexByte(buf, '\n')
+	if last < 0 {
+		return nil, 0, nil
+	}
+	// Reading stopped early only once buf held more than n lines, so the
+	// possibly partial first line is always trimmed here.
+	lines := strings.Split(string(buf[:last]), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, pos + int64(last) + 1, nil
+}
+
+// logFollower reads lines appended to a log file, reopening it when the
+// launcher rotates or truncates it.
+type logFollower struct {
+	path    string
+	f       *os.File
+	offset  int64
+	pending []byte // bytes after the last newline
+}
+
+// next returns the complete lines written since the previous call.
+func (lf *logFollower) next() ([]string, error) {
+	if err := lf.reopenIfReplaced(); err != nil {
+		return nil, err
+	}
+	if _, err := lf.f.Seek(lf.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(lf.f)
+	if err != nil {
+		return nil, err
+	}
+	lf.offset += int64(len(data))
+	lf.pending = append(lf.pending, data...)
+
+	last := bytes.LastIndexByte(lf.pending, '\n')
+	if last < 0 {
+		return nil, nil
+	}
+	lines := strings.Split(string(lf.pending[:last]), "\n")
+	lf.pending = append(lf.pending[:0], lf.pending[last+1:]...)
+	return lines, nil
+}
+
+func (lf *logFollower) reopenIfReplaced() error {
+	cur, err := lf.f.Stat()
+	if err != nil {
+		return err
+	}
+	disk, err := os.Stat(lf.path)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(cur, disk) {
+		if disk.Size() < lf.offset {
+			lf.offset, lf.pending = 0, nil
+		}
+		return nil
+	}
+	f, err := os.Open(lf.path)
+	if err != nil {
+		return err
+	}
+	_ = lf.f.Close()
+	lf.f, lf.offset, lf.pending = f, 0, nil
+	return nil
+}
@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func newLogsTestRouter(t *testing.T, logDir string) *Router {
+	t.Helper()
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "proj", "/home/test/proj", "1.0")
+	cfg := testCfg()
+	cfg.LogDir = logDir
+	return New(reg, cfg, testLogger(), nil)
+}
+
+// sseLines delivers the payload of each "data:" event read from body.
+func sseLines(t *testing.T, resp *http.Response) <-chan string {
+	t.Helper()
+	ch := make(chan string, 64)
+	go func() {
+		defer close(ch)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+				ch <- data
+			}
+		}
+	}()
+	return ch
+}
+
+func expectLine(t *testing.T, lines <-chan string, want string, within time.Duration) {
+	t.Helper()
+	select {
+	case got, ok := <-lines:
+		if !ok {
+			t.Fatalf("stream closed, want %q", want)
+		}
+		if got != want {
+			t.Fatalf("line = %q, want %q", got, want)
+		}
+	case <-time.After(within):
+		t.Fatalf("no line within %s, want %q", within, want)
+	}
+}
+
+func TestAPIBackendLogs_TailAndFollow(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "proj-4096.log")
+	var initial strings.Builder
+	for i := 1; i <= 150; i++ {
+		fmt.Fprintf(&initial, "line %d\n", i)
+	}
+	initial.WriteString("partial")
+	if err := os.WriteFile(logPath, []byte(initial.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(newLogsTestRouter(t, dir))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/backends/proj/logs?lines=3")
+	if err != nil {
+		t.Fatalf("GET logs: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	lines := sseLines(t, resp)
+	for _, want := range []string{"line 148", "line 149", "line 150"} {
+		expectLine(t, lines, want, time.Second)
+	}
+
+	go func() {
+		f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		_, _ = f.WriteString(" done\n")
+		time.Sleep(20 * time.Millisecond)
+		_, _ = f.WriteString("fresh 1\nfresh 2\n")
+	}()
+	expectLine(t, lines, "partial done", 500*time.Millisecond)
+	expectLine(t, lines, "fresh 1", 500*time.Millisecond)
+	expectLine(t, lines, "fresh 2", 500*time.Millisecond)
+}
+
+func TestAPIBackendLogs_SameBaseName(t *testing.T) {
+	dir := t.TempDir()
+	reg := registry.New(30*time.Second, testLogger(), registry.WithSlugCollision(registry.CollisionPort))
+	reg.Upsert(4096, "app", "/a/app", "1.0")
+	reg.Upsert(4097, "app", "/b/app", "1.0")
+	for port, line := range map[int]string{4096: "from a", 4097: "from b"} {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("app-%d.log", port)), []byte(line+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := testCfg()
+	cfg.LogDir = dir
+	srv := httptest.NewServer(New(reg, cfg, testLogger(), nil))
+	defer srv.Close()
+
+	for slug, want := range map[string]string{"app": "from a", "app-4097": "from b"} {
+		resp, err := http.Get(srv.URL + "/api/backends/" + slug + "/logs")
+		if err != nil {
+			t.Fatalf("GET logs: %v", err)
+		}
+		expectLine(t, sseLines(t, resp), want, time.Second)
+		resp.Body.Close()
+	}
+}
+
+func TestAPIBackendLogs_FollowsRotation(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "proj-4096.log")
+	if err := os.WriteFile(logPath, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(newLogsTestRouter(t, dir))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/api/backends/proj/logs")
+	if err != nil {
+		t.Fatalf("GET logs: %v", err)
+	}
+	defer resp.Body.Close()
+	lines := sseLines(t, resp)
+	expectLine(t, lines, "old", time.Second)
+
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("rotated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, "rotated", 500*time.Millisecond)
+}
+
+func TestAPIBackendLogs_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "proj-4096.log"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		logDir string
+		method string
+		target string
+		want   int
+	}{
+		{"capture disabled", "", "GET", "/api/backends/proj/logs", http.StatusNotFound},
+		{"no log file", dir, "GET", "/api/backends/other/logs", http.StatusNotFound},
+		{"bad lines", dir, "GET", "/api/backends/proj/logs?lines=zero", http.StatusBadRequest},
+		{"too many lines", dir, "GET", "/api/backends/proj/logs?lines=1000000", http.StatusBadRequest},
+		{"wrong method", dir, "POST", "/api/backends/proj/logs", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newLogsTestRouter(t, tt.logDir).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    []string
+		offset  int64
+	}{
+		{"empty", "", 5, nil, 0},
+		{"fewer than n", "a\nb\n", 5, []string{"a", "b"}, 4},
+		{"last n", "a\nb\nc\n", 2, []string{"b", "c"}, 6},
+		{"partial last line", "a\nb", 5, []string{"a"}, 2},
+		{"no newline", "abc", 5, nil, 0},
+		{"long file", strings.Repeat(strings.Repeat("x", 999)+"\n", 200) + "end\n", 2, []string{strings.Repeat("x", 999), "end"}, 200*1000 + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "f.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, offset, err := tailLines(f, tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || offset != tt.offset {
+				t.Errorf("tailLines = %q, %d; want %q, %d", got, offset, tt.want, tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"opencoderouter/internal/scanner"
+)
+
+// defaultPingTimeout bounds GET /api/ping/{slug} when no ?timeout= is given.
+const defaultPingTimeout = 5 * time.Second
+
+// pingResult is the JSON body of GET /api/ping/{slug}.
+type pingResult struct {
+	Slug      string  `json:"slug"`
+	Port      int     `json:"port"`
+	Healthy   bool    `json:"healthy"`
+	Version   string  `json:"version,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// handleAPIPing probes a backend's health endpoint on demand. It answers 200
+// when the backend reports healthy and 503 otherwise, so scripts can poll it
+// until a backend is up.
+//
+//	GET /api/ping/{slug}?timeout=5s
+func (rt *Router) handleAPIPing(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	timeout := defaultPingTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid timeout %q: want a positive duration like 5s", raw), http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+
+	backend, ok := rt.registry.Lookup(slug)
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+
+	scheme := "http"
+	if backend.TLS {
+		scheme = "https"
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	client := &http.Client{Transport: rt.transportFor(backend)}
+
+	start := time.Now()
+	health, err := scanner.CheckHealth(ctx, client, scheme+"://"+backend.Addr(), rt.cfg.HealthPath)
+	result := pingResult{
+		Slug:      backend.Slug,
+		Port:      backend.Port,
+		LatencyMs: durationMs(time.Since(start)),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Healthy = health.Healthy
+		result.Version = health.Version
+	}
+	if !result.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSONResponse(w, result)
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func pingBackend(t *testing.T, healthy bool, delay time.Duration) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/global/health" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"healthy": healthy, "version": "1.2.3"})
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+func TestAPIPing(t *testing.T) {
+	up := pingBackend(t, true, 0)
+	down := pingBackend(t, false, 0)
+	slow := pingBackend(t, true, 200*time.Millisecond)
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, up.URL), "up", "/home/test/up", "1.2.3")
+	reg.Upsert(mustPort(t, down.URL), "down", "/home/test/down", "1.2.3")
+	reg.Upsert(mustPort(t, slow.URL), "slow", "/home/test/slow", "1.2.3")
+	reg.Upsert(1, "gone", "/home/test/gone", "1.2.3") // nothing listens on port 1
+	rt := newTestRouter(reg)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    int
+		healthy bool
+	}{
+		{"healthy", "/api/ping/up", http.StatusOK, true},
+		{"reports unhealthy", "/api/ping/down", http.StatusServiceUnavailable, false},
+		{"not listening", "/api/ping/gone", http.StatusServiceUnavailable, false},
+		{"timeout", "/api/ping/slow?timeout=20ms", http.StatusServiceUnavailable, false},
+		{"slow within timeout", "/api/ping/slow?timeout=5s", http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			var res pingResult
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if res.Healthy != tt.healthy {
+				t.Errorf("healthy = %v, want %v (%+v)", res.Healthy, tt.healthy, res)
+			}
+			if tt.healthy && (res.Version != "1.2.3" || res.LatencyMs <= 0) {
+				t.Errorf("unexpected result: %+v", res)
+			}
+		})
+	}
+}
+
+func TestAPIPing_Errors(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "proj", "/home/test/proj", "1.0")
+	rt := newTestRouter(reg)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/api/ping/nope", http.StatusNotFound},
+		{"GET", "/api/ping/proj?timeout=soon", http.StatusBadRequest},
+		{"GET", "/api/ping/proj?timeout=-1s", http.StatusBadRequest},
+		{"POST", "/api/ping/proj", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+	}
+}
@@ -1,24 +1,41 @@
 package proxy
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"opencoderouter/internal/auth"
 	"opencoderouter/internal/config"
+	"opencoderouter/internal/discovery"
+	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/middleware"
 	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+	"opencoderouter/internal/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http2"
 )
 
 // Router is the HTTP handler that proxies requests to discovered OpenCode backends.
 // It supports two routing modes:
-//  1. Host-based: "{slug}-{username}.local" → backend
+//  1. Host-based: "{slug}-{username}.local" (or the --host-suffix) → backend
 //  2. Path-based: "/{slug}/..." → backend (prefix stripped)
 //
 // Unmatched requests get the dashboard.
@@ -26,8 +43,40 @@ type Router struct {
 	registry  *registry.Registry
 	cfg       config.Config
 	logger    *slog.Logger
-	handler   http.Handler
+	handler   http.Handler          // route wrapped in the middleware chain
+	guard     middleware.Middleware // see Guard
+	guarded   http.Handler          // handler without the Guard layers
+	extra     []middleware.Middleware
+	cors      middleware.Middleware
 	uiHandler http.Handler
+	dashboard *template.Template // root page; see WithDashboardTemplate
+	remotes   *discovery.RemoteRegistry
+	accessLog *slog.Logger
+	trace     *requestTracer // see WithTrace
+	limiter   *slugRateLimiter
+	launcher  *launcher.Launcher
+	scanner   *scanner.Scanner
+	selector  Selector
+	adv       *discovery.Advertiser
+	tracer    trace.Tracer
+	now       func() time.Time // clock for the backends ETag's uptime period
+
+	statsMu sync.Mutex
+	stats   map[string]*BackendStats // slug → proxying statistics
+
+	configMu    sync.Mutex
+	configCache map[string]cachedConfig // slug → backend /global/config
+
+	inFlight atomic.Int64 // requests in ServeHTTP; see Drain
+
+	transportMu   sync.Mutex
+	h2cTransports map[int]*http2.Transport // backend port → shared h2c transport
+
+	tlsTransportOnce sync.Once
+	tlsTransport     *http.Transport
+
+	grpcTLSTransportOnce sync.Once
+	grpcTLSTransport     *http2.Transport
 
 	wsMu           sync.Mutex
 	wsConnections  map[string]string
@@ -41,8 +90,65 @@ func writeJSONResponse(w http.ResponseWriter, payload any) {
 	}
 }
 
+// Option configures optional Router dependencies.
+type Option func(*Router)
+
+// WithRemotes exposes entries discovered from other routers via GET
+// /api/remotes and the routers themselves via GET /api/peers.
+func WithRemotes(remotes *discovery.RemoteRegistry) Option {
+	return func(rt *Router) {
+		rt.remotes = remotes
+	}
+}
+
+// WithAccessLog emits one structured record per proxied request to logger.
+// Passing nil disables access logging.
+func WithAccessLog(logger *slog.Logger) Option {
+	return func(rt *Router) {
+		rt.accessLog = logger
+	}
+}
+
+// WithLauncher exposes managed process state via GET /api/processes.
+func WithLauncher(l *launcher.Launcher) Option {
+	return func(rt *Router) {
+		rt.launcher = l
+	}
+}
+
+// WithScanner enables POST /api/scan for on-demand scans.
+func WithScanner(sc *scanner.Scanner) Option {
+	return func(rt *Router) {
+		rt.scanner = sc
+	}
+}
+
+// WithAdvertiser withdraws mDNS advertisements as soon as a backend is
+// deleted through DELETE /api/backends/{slug}.
+func WithAdvertiser(adv *discovery.Advertiser) Option {
+	return func(rt *Router) {
+		rt.adv = adv
+	}
+}
+
+// WithTracerProvider sets where proxy spans are recorded. By default the
+// global OpenTelemetry provider is used, which is a no-op unless configured.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(rt *Router) {
+		rt.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithMiddleware wraps the router's routing in mws, inside its built-in
+// in-flight counting and auth. The first middleware runs first.
+func WithMiddleware(mws ...middleware.Middleware) Option {
+	return func(rt *Router) {
+		rt.extra = append(rt.extra, mws...)
+	}
+}
+
 // New creates a new Router.
-func New(reg *registry.Registry, cfg config.Config, logger *slog.Logger, uiHandler http.Handler) *Router {
+func New(reg *registry.Registry, cfg config.Config, logger *slog.Logger, uiHandler http.Handler, opts ...Option) *Router {
 	rt := &Router{
 		registry:       reg,
 		cfg:            cfg,
@@ -50,20 +156,80 @@ func New(reg *registry.Registry, cfg config.Config, logger *slog.Logger, uiHandl
 		wsConnections:  make(map[string]string),
 		wsPingInterval: defaultWSPingInterval,
 		uiHandler:      uiHandler,
+		limiter:        newSlugRateLimiter(cfg.RateLimits),
+		selector:       NewSelector(cfg.Balance),
+		tracer:         otel.Tracer(tracerName),
+		h2cTransports:  make(map[int]*http2.Transport),
+		stats:          make(map[string]*BackendStats),
+		configCache:    make(map[string]cachedConfig),
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	reg.SetRequestCounter(rt.requestCount)
+	authCfg := auth.LoadFromEnv()
+	rt.cors = rt.newCORS(authCfg)
+	authCfg.DisableCORS = true
+	var compress, realIP, allowlist, requestID, basicAuth middleware.Middleware
+	if cfg.EnableCompression {
+		compress = middleware.Compress(middleware.DefaultCompressMinSize)
 	}
-	rt.handler = auth.Middleware(http.HandlerFunc(rt.routeRequest), auth.LoadFromEnv())
+	if cfg.BehindProxy {
+		trusted := cfg.TrustedProxies
+		if len(trusted) == 0 {
+			trusted = config.DefaultTrustedProxies
+		}
+		// Validate has already rejected malformed CIDRs.
+		nets, _ := config.ParseCIDRs(trusted)
+		realIP = middleware.RealIP(nets)
+	}
+	if len(cfg.AllowedClientCIDRs) > 0 {
+		nets, _ := config.ParseCIDRs(cfg.AllowedClientCIDRs)
+		allowlist = middleware.ClientAllowlist(nets)
+	}
+	if cfg.InjectRequestID {
+		requestID = middleware.RequestID()
+	}
+	if cfg.BasicAuthUser != "" {
+		// The admin endpoints carry their own bearer token in Authorization.
+		basicAuth = middleware.BasicAuth(cfg.BasicAuthUser, []byte(cfg.BasicAuthPass),
+			"/api/health", "/api/snapshot", "/api/restore")
+	}
+	authMiddleware := func(next http.Handler) http.Handler { return auth.Middleware(next, authCfg) }
+	chain := append([]middleware.Middleware{
+		rt.countInFlight,
+		compress,
+		realIP,
+		allowlist,
+		requestID,
+		// CORS runs before auth: browsers send preflights without credentials.
+		rt.cors,
+		basicAuth,
+		authMiddleware,
+	}, rt.extra...)
+	rt.handler = middleware.Chain(chain...)(http.HandlerFunc(rt.route))
+	rt.guard = middleware.Chain(rt.countInFlight, realIP, allowlist, rt.cors, basicAuth)
+	rest := append([]middleware.Middleware{compress, requestID, authMiddleware}, rt.extra...)
+	rt.guarded = middleware.Chain(rest...)(http.HandlerFunc(rt.route))
 	return rt
 }
 
 // ServeHTTP implements http.Handler.
 func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if passedGuard(r) {
+		rt.guarded.ServeHTTP(w, r)
+		return
+	}
 	rt.handler.ServeHTTP(w, r)
 }
 
-func (rt *Router) routeRequest(w http.ResponseWriter, r *http.Request) {
+// route dispatches a request to a backend, the API or the dashboard. It is
+// the innermost handler of the middleware chain.
+func (rt *Router) route(w http.ResponseWriter, r *http.Request) {
 	// Try host-based routing first.
-	if slug := rt.slugFromHost(r.Host); slug != "" {
-		if backend, ok := rt.registry.Lookup(slug); ok {
+	if slug, user := rt.hostRoute(r.Host); slug != "" {
+		if backend, ok := rt.selectBackend(w, r, slug, user, "/"); ok {
 			rt.proxyTo(backend, w, r, "")
 			return
 		}
@@ -76,16 +242,73 @@ func (rt *Router) routeRequest(w http.ResponseWriter, r *http.Request) {
 
 	// Try path-based routing: /{slug}/...
 	if slug, remainder := rt.slugFromPath(r.URL.Path); slug != "" {
-		if backend, ok := rt.registry.Lookup(slug); ok {
+		if backend, ok := rt.selectBackend(w, r, slug, "", "/"+slug); ok {
 			rt.proxyTo(backend, w, r, remainder)
 			return
 		}
 	}
 
 	// API endpoints.
+	if r.URL.Path == "/api/scan/metrics" {
+		rt.handleAPIScanMetrics(w, r)
+		return
+	}
+	if id, ok := strings.CutPrefix(r.URL.Path, "/api/scan/"); ok && id != "" {
+		rt.handleAPIScanStatus(w, r, id)
+		return
+	}
+	if slug, ok := strings.CutPrefix(r.URL.Path, "/api/ping/"); ok && slug != "" {
+		rt.handleAPIPing(w, r, slug)
+		return
+	}
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/api/backends/"); ok && rest != "" {
+		if slug, ok := strings.CutSuffix(rest, "/history"); ok && slug != "" {
+			rt.handleAPIBackendHistory(w, r, slug)
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/proxy-stats"); ok && slug != "" {
+			rt.handleAPIBackendProxyStats(w, r, slug)
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/logs"); ok && slug != "" {
+			rt.handleAPIBackendLogs(w, r, slug)
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/rename"); ok && slug != "" {
+			if !rt.movedToAdminPort(w, r) {
+				rt.handleAPIBackendRename(w, r, slug)
+			}
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/restart"); ok && slug != "" {
+			if !rt.movedToAdminPort(w, r) {
+				rt.handleAPIBackendRestart(w, r, slug)
+			}
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/config"); ok && slug != "" {
+			rt.handleAPIBackendConfig(w, r, slug)
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/events"); ok && slug != "" {
+			rt.handleAPIBackendEvents(w, r, slug)
+			return
+		}
+		if slug, ok := strings.CutSuffix(rest, "/connect"); ok && slug != "" {
+			rt.handleAPIBackendConnect(w, r, slug)
+			return
+		}
+		if !rt.movedToAdminPort(w, r) {
+			rt.handleAPIBackend(w, r, rest)
+		}
+		return
+	}
 	switch r.URL.Path {
 	case "/api/backends":
-		rt.handleAPIBackends(w, r)
+		// Listing stays public; pinning a backend is privileged.
+		if r.Method != http.MethodPost || !rt.movedToAdminPort(w, r) {
+			rt.handleAPIBackends(w, r)
+		}
 		return
 	case "/api/health":
 		rt.handleAPIHealth(w, r)
@@ -93,38 +316,113 @@ func (rt *Router) routeRequest(w http.ResponseWriter, r *http.Request) {
 	case "/api/resolve":
 		rt.handleAPIResolve(w, r)
 		return
+	case "/api/remotes":
+		rt.handleAPIRemotes(w, r)
+		return
+	case "/api/peers":
+		rt.handleAPIPeers(w, r)
+		return
+	case "/api/processes":
+		rt.handleAPIProcesses(w, r)
+		return
+	case "/api/register":
+		rt.handleAPISelfRegister(w, r)
+		return
+	case "/api/scan":
+		if !rt.movedToAdminPort(w, r) {
+			rt.handleAPIScan(w, r)
+		}
+		return
+	case "/api/config":
+		rt.handleAPIConfig(w, r)
+		return
+	case "/api/snapshot":
+		if !rt.movedToAdminPort(w, r) {
+			rt.handleAPISnapshot(w, r)
+		}
+		return
+	case "/api/restore":
+		if !rt.movedToAdminPort(w, r) {
+			rt.handleAPIRestore(w, r)
+		}
+		return
+	case "/api/export/caddy":
+		rt.handleAPIExportCaddy(w, r)
+		return
+	case "/api/export/compose":
+		rt.handleAPIExportCompose(w, r)
+		return
 	}
 
-	// Dashboard.
+	// Dashboard. In strict mode an unknown slug is an error rather than a
+	// silent fall-through to the dashboard.
+	if rt.cfg.StrictMode {
+		if slug, _ := rt.slugFromPath(r.URL.Path); slug != "" && slug != "api" {
+			rt.serveStrictFallback(w, r, slug)
+			return
+		}
+	}
 	rt.handleDashboard(w, r)
 }
 
 // slugFromHost extracts the project slug from the Host header.
-// Expected format: "{slug}-{username}.local" or "{slug}-{username}.local:port"
+// Expected format: "{slug}-{username}{suffix}" or "{slug}-{username}{suffix}:port",
+// where suffix is cfg.HostSuffix (default ".local").
 func (rt *Router) slugFromHost(host string) string {
+	slug, _ := rt.hostRoute(host)
+	return slug
+}
+
+// hostRoute is slugFromHost that, with cfg.UsernameFromPath, also returns
+// the username the host names. Any username is accepted then, but since
+// slugs and usernames may both contain hyphens, the split is the one,
+// longest slug first, that a registered backend answers to. Otherwise the
+// username must be cfg.Username and user is "".
+func (rt *Router) hostRoute(host string) (slug, user string) {
 	// Strip port if present.
 	hostname := host
 	if idx := strings.LastIndex(host, ":"); idx != -1 {
 		hostname = host[:idx]
 	}
 
-	// Check for ".local" suffix.
-	if !strings.HasSuffix(hostname, ".local") {
-		return ""
+	// Check for the host suffix.
+	if !strings.HasSuffix(hostname, rt.cfg.HostSuffix) {
+		return "", ""
+	}
+	hostname = strings.TrimSuffix(hostname, rt.cfg.HostSuffix)
+
+	if rt.cfg.UsernameFromPath {
+		for i := strings.LastIndex(hostname, "-"); i > 0; i = strings.LastIndex(hostname[:i], "-") {
+			slug, user := hostname[:i], hostname[i+1:]
+			for _, b := range rt.registry.LookupAll(slug) {
+				if user != "" && rt.ownedBy(b, user) {
+					return slug, user
+				}
+			}
+		}
+		return "", ""
 	}
-	hostname = strings.TrimSuffix(hostname, ".local")
 
 	// Check for "-{username}" suffix.
 	suffix := "-" + rt.cfg.Username
 	if !strings.HasSuffix(hostname, suffix) {
-		return ""
+		return "", ""
 	}
 
-	slug := strings.TrimSuffix(hostname, suffix)
+	slug = strings.TrimSuffix(hostname, suffix)
 	if slug == "" {
-		return ""
+		return "", ""
 	}
-	return slug
+	return slug, ""
+}
+
+// ownedBy reports whether b's domain names user: its own Username when the
+// registry derived one, otherwise cfg.Username.
+func (rt *Router) ownedBy(b *registry.Backend, user string) bool {
+	if b.Username != "" {
+		return b.Username == user
+	}
+	return rt.cfg.Username == user
 }
 
 // slugFromPath extracts "slug" from "/{slug}/..." and returns the remainder path.
@@ -147,12 +445,87 @@ func (rt *Router) slugFromPath(path string) (slug, remainder string) {
 
 // proxyTo forwards the request to the given backend.
 func (rt *Router) proxyTo(backend *registry.Backend, w http.ResponseWriter, r *http.Request, pathOverride string) {
-	target, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", backend.Port))
+	// The backend chooses its own encoding; don't compress it twice.
+	middleware.SkipCompression(w)
+	if !rt.checkRateLimit(w, r, backend.Slug) {
+		return
+	}
+	grpc := rt.cfg.GRPCEnabled && isGRPCRequest(r)
+	// Buffering would stall client-streaming RPCs until the stream ends.
+	if rt.cfg.BufferRequests && !grpc && !rt.bufferRequestBody(w, r) {
+		return
+	}
+
+	scheme := "http"
+	if backend.TLS {
+		scheme = "https"
+	}
+	target, err := url.Parse(scheme + "://" + backend.Addr())
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
+	var (
+		handler http.Handler
+		tr      *traceRecord
+	)
+	if isWebSocketUpgrade(r) {
+		// Upgrades are tunneled directly; see WebSocketProxy.
+		tunnel := WebSocketProxy(target)
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tunnel.ServeHTTP(w, webSocketRequest(r, pathOverride))
+		})
+	} else {
+		if rt.trace.traces(backend.Slug) {
+			tr = rt.trace.start(backend.Slug, r)
+			defer rt.trace.finish(tr)
+		}
+		handler = rt.newReverseProxy(backend, w, target, pathOverride, grpc, tr)
+	}
+
+	rt.logger.Debug("proxying request",
+		"slug", backend.Slug,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"target", fmt.Sprintf("%s%s", target.String(), pathOverride),
+	)
+
+	ctx, span := rt.startProxySpan(r, backend.Slug)
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	body := &countingBody{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = body
+	}
+
+	start := time.Now()
+	rec := newResponseRecorder(w)
+	handler.ServeHTTP(rec, r)
+	elapsed := time.Since(start)
+	endProxySpan(span, rec.Status())
+	rt.statsFor(backend.Slug).Record(rec.Status(), body.n, rec.BytesWritten(), elapsed)
+
+	if rt.accessLog == nil {
+		return
+	}
+	rt.accessLog.Info("access",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"slug", backend.Slug,
+		"status", rec.Status(),
+		"bytes", rec.BytesWritten(),
+		"duration_ms", durationMs(elapsed),
+		"remote_addr", r.RemoteAddr,
+		"request_id", middleware.RequestIDFromContext(r.Context()),
+	)
+}
+
+// newReverseProxy returns the ReverseProxy proxyTo uses for requests other
+// than WebSocket upgrades. w is the client's writer, whose CORS headers
+// take precedence over the backend's. A non-nil tr records the response.
+func (rt *Router) newReverseProxy(backend *registry.Backend, w http.ResponseWriter, target *url.URL, pathOverride string, grpc bool, tr *traceRecord) *httputil.ReverseProxy {
 	proxy := &httputil.ReverseProxy{
 		Rewrite: func(pr *httputil.ProxyRequest) {
 			pr.SetURL(target)
@@ -162,6 +535,18 @@ func (rt *Router) proxyTo(backend *registry.Backend, w http.ResponseWriter, r *h
 				pr.Out.URL.RawPath = ""
 			}
 			pr.Out.Host = target.Host
+			traceContext.Inject(pr.Out.Context(), propagation.HeaderCarrier(pr.Out.Header))
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			stripBackendCORS(w, resp)
+			if !rt.cfg.NoInjectHeaders {
+				resp.Header.Set("X-OpenCode-Slug", backend.Slug)
+				resp.Header.Set("X-OpenCode-Router-Version", version.Version)
+			}
+			if tr != nil {
+				tr.response(resp)
+			}
+			return nil
 		},
 		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
 			rt.logger.Error("proxy error",
@@ -174,63 +559,452 @@ func (rt *Router) proxyTo(backend *registry.Backend, w http.ResponseWriter, r *h
 		// Flush immediately for SSE/streaming.
 		FlushInterval: -1,
 	}
+	if grpc {
+		proxy.Transport = rt.grpcTransportFor(backend)
+	} else if t := rt.transportFor(backend); t != nil {
+		proxy.Transport = t
+	}
+	return proxy
+}
 
-	rt.logger.Debug("proxying request",
-		"slug", backend.Slug,
-		"method", r.Method,
-		"path", r.URL.Path,
-		"target", fmt.Sprintf("%s%s", target.String(), pathOverride),
-	)
+// backendInfo is the API representation of a registered backend.
+type backendInfo struct {
+	Slug        string            `json:"slug"`
+	ProjectName string            `json:"project_name"`
+	ProjectPath string            `json:"project_path"`
+	Port        int               `json:"port"`
+	BindIP      string            `json:"bind_ip,omitempty"`
+	Version     string            `json:"version"`
+	Domain      string            `json:"domain"`
+	PathPrefix  string            `json:"path_prefix"`
+	URL         string            `json:"url"`
+	LastSeen    time.Time         `json:"last_seen"`
+	Manual      bool              `json:"manual,omitempty"`
+	Draining    bool              `json:"draining,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	// Reliability, from the registry's sightings of the backend.
+	UptimeSince     time.Time `json:"uptime_since"`
+	UptimeMs        int64     `json:"uptime_ms"`
+	TotalDowntimeMs int64     `json:"total_downtime_ms"`
+	UptimePercent   float64   `json:"uptime_percent_24h"`
+}
+
+// uptimeWindow is the period uptime_percent_24h covers.
+const uptimeWindow = 24 * time.Hour
 
-	proxy.ServeHTTP(w, r)
+func (rt *Router) newBackendInfo(b *registry.Backend) backendInfo {
+	return backendInfo{
+		Slug:        b.Slug,
+		ProjectName: b.ProjectName,
+		ProjectPath: b.ProjectPath,
+		Port:        b.Port,
+		BindIP:      b.BindIP,
+		Version:     b.Version,
+		Domain:      rt.cfg.DomainForUser(b.Slug, b.Username),
+		PathPrefix:  fmt.Sprintf("/%s/", b.Slug),
+		URL:         fmt.Sprintf("%s://localhost:%d/%s/", rt.cfg.Scheme(), rt.cfg.ListenPort, b.Slug),
+		LastSeen:    b.LastSeen,
+		Manual:      b.Manual,
+		Draining:    b.Draining,
+		Username:    b.Username,
+		Labels:      b.Labels,
+		Tags:        b.Tags,
+
+		UptimeSince:     b.UptimeSince,
+		UptimeMs:        b.Uptime().Milliseconds(),
+		TotalDowntimeMs: b.TotalDowntime.Milliseconds(),
+		UptimePercent:   b.UptimePercentage(uptimeWindow),
+	}
+}
+
+// backendSorters are the ?sort= keys accepted by GET /api/backends. Ties
+// fall back to slug, then port.
+var backendSorters = map[string]func(a, b *registry.Backend) int{
+	"slug":      func(a, b *registry.Backend) int { return strings.Compare(a.Slug, b.Slug) },
+	"port":      func(a, b *registry.Backend) int { return cmp.Compare(a.Port, b.Port) },
+	"last_seen": func(a, b *registry.Backend) int { return a.LastSeen.Compare(b.LastSeen) },
+	"version":   func(a, b *registry.Backend) int { return strings.Compare(a.Version, b.Version) },
 }
 
-// handleAPIBackends returns a JSON list of all backends.
+// queryBackends applies the sort, order, healthy, label, tag and prefix query
+// parameters of GET /api/backends. The default is sort=slug&order=asc; repeated
+// label=key:value and tag parameters must all match.
+func (rt *Router) queryBackends(q url.Values) ([]*registry.Backend, error) {
+	key := q.Get("sort")
+	if key == "" {
+		key = "slug"
+	}
+	byKey, ok := backendSorters[key]
+	if !ok {
+		return nil, fmt.Errorf("sort must be one of slug, port, last_seen, version")
+	}
+	desc := false
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+	healthyOnly := false
+	if raw := q.Get("healthy"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("healthy must be true or false")
+		}
+		healthyOnly = v
+	}
+
+	labels := make(map[string]string)
+	for _, raw := range q["label"] {
+		k, v, ok := strings.Cut(raw, ":")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("label must be key:value, got %q", raw)
+		}
+		labels[k] = v
+	}
+
+	backends := rt.registry.LookupPrefix(q.Get("prefix"))
+	if len(labels) > 0 {
+		backends = slices.DeleteFunc(backends, func(b *registry.Backend) bool {
+			for k, v := range labels {
+				if b.Labels[k] != v {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	if tags := q["tag"]; len(tags) > 0 {
+		backends = slices.DeleteFunc(backends, func(b *registry.Backend) bool {
+			for _, tag := range tags {
+				if !slices.Contains(b.Tags, tag) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	if healthyOnly {
+		staleAfter := rt.registry.StaleAfter()
+		backends = slices.DeleteFunc(backends, func(b *registry.Backend) bool {
+			return !b.Healthy(staleAfter)
+		})
+	}
+	slices.SortFunc(backends, func(a, b *registry.Backend) int {
+		c := byKey(a, b)
+		if c == 0 {
+			c = cmp.Or(strings.Compare(a.Slug, b.Slug), cmp.Compare(a.Port, b.Port))
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return backends, nil
+}
+
+// handleAPIBackends lists backends (GET) or pins a manual backend (POST).
+//
+//	GET  /api/backends?sort=port&order=desc&healthy=true
+//	POST /api/backends {"port":4200,"project_name":"my-app","project_path":"/home/user/my-app","version":"manual"}
 func (rt *Router) handleAPIBackends(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		etag := backendsETag(rt.registry.ETag(), r.URL.RawQuery, rt.now())
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		backends, err := rt.queryBackends(r.URL.Query())
+		if err != nil {
+			w.Header().Del("ETag")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		items := make([]backendInfo, 0, len(backends))
+		for _, b := range backends {
+			items = append(items, rt.newBackendInfo(b))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		writeJSONResponse(w, items)
+	case http.MethodPost:
+		rt.handleAPIRegisterBackend(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (rt *Router) handleAPIRegisterBackend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Port        int               `json:"port"`
+		ProjectName string            `json:"project_name"`
+		ProjectPath string            `json:"project_path"`
+		Version     string            `json:"version"`
+		Labels      map[string]string `json:"labels"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	req.ProjectPath = strings.TrimSpace(req.ProjectPath)
+	if req.Port < 1 || req.Port > 65535 {
+		http.Error(w, "port must be 1-65535", http.StatusBadRequest)
+		return
+	}
+	if req.ProjectPath == "" {
+		http.Error(w, `missing "project_path"`, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.ProjectName) == "" {
+		req.ProjectName = filepath.Base(req.ProjectPath)
+	}
+	if req.Version == "" {
+		req.Version = "manual"
+	}
+
+	isNew := rt.registry.UpsertManual(req.Port, req.ProjectName, req.ProjectPath, req.Version)
+	backend, ok := rt.registry.LookupByPort(req.Port)
+	if !ok {
+		http.Error(w, "backend registration failed", http.StatusInternalServerError)
+		return
+	}
+	if len(req.Labels) > 0 {
+		if err := rt.registry.SetLabels(backend.Slug, req.Labels); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		backend, _ = rt.registry.LookupByPort(req.Port)
+	}
+
+	status := http.StatusOK
+	if isNew {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSONResponse(w, rt.newBackendInfo(backend))
+}
+
+// handleAPIBackend removes every instance of a slug.
+//
+//	DELETE /api/backends/{slug}
+func (rt *Router) handleAPIBackend(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !rt.registry.Remove(slug) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+	if rt.adv != nil {
+		rt.adv.Unregister(slug)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxLabelsSize caps the body accepted by PUT /api/backends/{slug}/labels.
+const maxLabelsSize = 1 << 16
+
+// handleAPIBackendLabels merges labels into a slug's instances; an empty
+// value deletes that key. It returns the resulting labels. Only AdminRoutes
+// serves it.
+//
+//	PUT /api/backends/{slug}/labels {"team":"infra","old":""}
+func (rt *Router) handleAPIBackendLabels(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var labels map[string]string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLabelsSize)).Decode(&labels); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := rt.registry.SetLabels(slug, labels); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	}
+	current := map[string]string{}
+	if b, ok := rt.registry.Lookup(slug); ok && b.Labels != nil {
+		current = b.Labels
+	}
+	writeJSONResponse(w, map[string]interface{}{
+		"slug":   slug,
+		"labels": current,
+	})
+}
+
+// handleAPIBackendHistory returns the recorded health checks for a slug,
+// oldest first.
+func (rt *Router) handleAPIBackendHistory(w http.ResponseWriter, r *http.Request, slug string) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	backends := rt.registry.All()
-	type backendInfo struct {
-		Slug        string    `json:"slug"`
-		ProjectName string    `json:"project_name"`
-		ProjectPath string    `json:"project_path"`
-		Port        int       `json:"port"`
-		Version     string    `json:"version"`
-		Domain      string    `json:"domain"`
-		PathPrefix  string    `json:"path_prefix"`
-		URL         string    `json:"url"`
-		LastSeen    time.Time `json:"last_seen"`
-	}
-
-	items := make([]backendInfo, 0, len(backends))
-	for _, b := range backends {
-		items = append(items, backendInfo{
-			Slug:        b.Slug,
-			ProjectName: b.ProjectName,
-			ProjectPath: b.ProjectPath,
-			Port:        b.Port,
-			Version:     b.Version,
-			Domain:      rt.cfg.DomainFor(b.Slug),
-			PathPrefix:  fmt.Sprintf("/%s/", b.Slug),
-			URL:         fmt.Sprintf("http://localhost:%d/%s/", rt.cfg.ListenPort, b.Slug),
-			LastSeen:    b.LastSeen,
+	records, ok := rt.registry.History(slug)
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
 		})
+		return
+	}
+	writeJSONResponse(w, records)
+}
+
+// handleAPIBackendRename moves a backend to a new slug without waiting for
+// the old one to go stale.
+//
+//	POST /api/backends/{slug}/rename {"new_slug":"my-app"}
+func (rt *Router) handleAPIBackendRename(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		NewSlug string `json:"new_slug"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	req.NewSlug = strings.TrimSpace(req.NewSlug)
+	if req.NewSlug == "" {
+		http.Error(w, `missing "new_slug"`, http.StatusBadRequest)
+		return
+	}
+
+	err := rt.registry.Rename(slug, req.NewSlug)
+	switch {
+	case errors.Is(err, registry.ErrSlugNotFound):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  slug,
+			"detail": "no backend registered under this slug",
+		})
+		return
+	case errors.Is(err, registry.ErrSlugTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rt.adv != nil && slug != req.NewSlug {
+		rt.adv.Unregister(slug)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	writeJSONResponse(w, items)
+	if backend, ok := rt.registry.Lookup(req.NewSlug); ok {
+		writeJSONResponse(w, rt.newBackendInfo(backend))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent) // removed again right after the rename
+}
+
+// handleAPIBackendRestart restarts the launcher-managed process behind a
+// slug on its current port.
+//
+//	POST /api/backends/{slug}/restart
+func (rt *Router) handleAPIBackendRestart(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if rt.launcher == nil {
+		writeRestartNotFound(w, slug)
+		return
+	}
+	// Restart the slug's instances by port: another project with the same
+	// directory name may be registered under a different slug. A process
+	// that has exited is no longer registered, so fall back to the slug of
+	// its project path.
+	var ports []int
+	for _, b := range rt.registry.LookupAll(slug) {
+		ports = append(ports, b.Port)
+	}
+	var err error
+	if len(ports) > 0 {
+		err = rt.launcher.RestartPorts(ports...)
+	} else {
+		err = rt.launcher.Restart(slug)
+	}
+	switch {
+	case errors.Is(err, launcher.ErrNotManaged):
+		writeRestartNotFound(w, slug)
+		return
+	case errors.Is(err, launcher.ErrRestartInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		rt.logger.Error("backend restart failed", "slug", slug, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Keep the backend registered while the new process starts up.
+	processes := []launcher.ProcessStatus{}
+	for _, p := range rt.launcher.Status() {
+		if len(ports) > 0 && slices.Contains(ports, p.Port) || len(ports) == 0 && registry.SlugifyPath(p.Path) == slug {
+			rt.registry.Touch(p.Port)
+			processes = append(processes, p)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, map[string]interface{}{
+		"restarted": true,
+		"slug":      slug,
+		"processes": processes,
+	})
+}
+
+func writeRestartNotFound(w http.ResponseWriter, slug string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	writeJSONResponse(w, map[string]interface{}{
+		"error":  "not_found",
+		"query":  slug,
+		"detail": "no launcher-managed process for this slug",
+	})
 }
 
 // handleAPIHealth returns the router's own health status.
 func (rt *Router) handleAPIHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	writeJSONResponse(w, map[string]interface{}{
-		"healthy":  true,
-		"username": rt.cfg.Username,
-		"backends": rt.registry.Len(),
+		"healthy":        true,
+		"username":       rt.cfg.Username,
+		"backends":       rt.registry.Len(),
+		"router_version": version.Version,
+		"commit":         version.Commit,
+		"build_time":     version.BuildTime,
 	})
 }
 
@@ -239,6 +1013,7 @@ func (rt *Router) handleAPIHealth(w http.ResponseWriter, r *http.Request) {
 //
 //	GET /api/resolve?path=/home/alice/myproject
 //	GET /api/resolve?name=myproject
+//	GET /api/resolve?name=my&fuzzy=true
 func (rt *Router) handleAPIResolve(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -253,6 +1028,11 @@ func (rt *Router) handleAPIResolve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("fuzzy") == "true" {
+		rt.handleAPIResolveFuzzy(w, r, projectName, projectPath)
+		return
+	}
+
 	var backend *registry.Backend
 	var ok bool
 
@@ -260,7 +1040,7 @@ func (rt *Router) handleAPIResolve(w http.ResponseWriter, r *http.Request) {
 		backend, ok = rt.registry.LookupByPath(projectPath)
 	} else {
 		// Bare name lookup: slugify and look up directly.
-		backend, ok = rt.registry.Lookup(registry.Slugify(projectName))
+		backend, ok = rt.registry.Lookup(registry.SlugifyPath(projectName))
 	}
 
 	if !ok {
@@ -279,21 +1059,186 @@ func (rt *Router) handleAPIResolve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	writeJSONResponse(w, map[string]interface{}{
+	writeJSONResponse(w, rt.resolveInfo(backend))
+}
+
+// defaultFuzzyLimit caps fuzzy resolve results when no limit is given.
+const defaultFuzzyLimit = 10
+
+// handleAPIResolveFuzzy returns every backend whose slug starts with or
+// contains the query, best match first. The response is always an array.
+//
+//	GET /api/resolve?name=my&fuzzy=true[&limit=5]
+func (rt *Router) handleAPIResolveFuzzy(w http.ResponseWriter, r *http.Request, projectName, projectPath string) {
+	query := projectName
+	if query == "" {
+		query = projectPath
+	}
+
+	limit := defaultFuzzyLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			http.Error(w, `"limit" must be a positive integer`, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	matches := rt.registry.FuzzyLookup(query, limit)
+	items := make([]map[string]interface{}, 0, len(matches))
+	for _, b := range matches {
+		items = append(items, rt.resolveInfo(b))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, items)
+}
+
+func (rt *Router) resolveInfo(backend *registry.Backend) map[string]interface{} {
+	return map[string]interface{}{
 		"slug":         backend.Slug,
 		"project_name": backend.ProjectName,
 		"project_path": backend.ProjectPath,
 		"port":         backend.Port,
 		"version":      backend.Version,
-		"domain":       rt.cfg.DomainFor(backend.Slug),
+		"domain":       rt.cfg.DomainForUser(backend.Slug, backend.Username),
 		"path_prefix":  fmt.Sprintf("/%s/", backend.Slug),
-		"url":          fmt.Sprintf("http://localhost:%d/%s/", rt.cfg.ListenPort, backend.Slug),
+		"url":          fmt.Sprintf("%s://localhost:%d/%s/", rt.cfg.Scheme(), rt.cfg.ListenPort, backend.Slug),
 		"last_seen":    backend.LastSeen,
+	}
+}
+
+// handleAPIRemotes returns projects advertised by other routers on the LAN.
+// The list is empty when mDNS browsing is disabled.
+func (rt *Router) handleAPIRemotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type remoteInfo struct {
+		discovery.RemoteEntry
+		URL string `json:"url"`
+	}
+
+	items := []remoteInfo{}
+	if rt.remotes != nil {
+		for _, e := range rt.remotes.All() {
+			items = append(items, remoteInfo{RemoteEntry: e, URL: e.URL()})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, items)
+}
+
+// handleAPIPeers lists the other routers discovered on the LAN.
+func (rt *Router) handleAPIPeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	peers := []discovery.Peer{}
+	if rt.remotes != nil {
+		peers = rt.remotes.Peers()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, peers)
+}
+
+// handleAPIScan starts an on-demand scan and returns its ID without waiting
+// for it to finish.
+func (rt *Router) handleAPIScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if rt.scanner == nil {
+		http.Error(w, "scanner not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := rt.scanner.StartScan(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSONResponse(w, map[string]interface{}{
+		"triggered": true,
+		"scan_id":   id,
 	})
 }
 
-// handleDashboard serves the dashboard UI.
+// handleAPIScanStatus reports the progress and outcome of a recent scan.
+func (rt *Router) handleAPIScanStatus(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		rec scanner.ScanRecord
+		ok  bool
+	)
+	if rt.scanner != nil {
+		rec, ok = rt.scanner.ScanStatus(id)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSONResponse(w, map[string]interface{}{
+			"error":  "not_found",
+			"query":  id,
+			"detail": "no recent scan with this ID",
+		})
+		return
+	}
+	writeJSONResponse(w, rec)
+}
+
+// handleAPIScanMetrics reports ports probed, healthy backends found and
+// duration of the most recent completed scan.
+//
+//	GET /api/scan/metrics
+func (rt *Router) handleAPIScanMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if rt.scanner == nil {
+		http.Error(w, "scanner not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, rt.scanner.LastMetrics())
+}
+
+// handleAPIProcesses returns the state of launcher-managed processes.
+// The list is empty when the router was started without project paths.
+func (rt *Router) handleAPIProcesses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	items := []launcher.ProcessStatus{}
+	if rt.launcher != nil {
+		items = rt.launcher.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, items)
+}
+
+// handleDashboard serves the dashboard UI. The root page is rendered from
+// the dashboard template, when one is set, with the theme from ?theme= or
+// the theme cookie.
 func (rt *Router) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if rt.dashboard != nil && r.URL.Path == "/" {
+		rt.serveDashboardPage(w, r)
+		return
+	}
 	if rt.uiHandler != nil {
 		rt.uiHandler.ServeHTTP(w, r)
 	} else {
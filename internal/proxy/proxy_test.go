@@ -2,6 +2,9 @@ package proxy
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,7 +20,13 @@ import (
 	"time"
 
 	"opencoderouter/internal/config"
+	"opencoderouter/internal/discovery"
+	"opencoderouter/internal/middleware"
 	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+	"opencoderouter/internal/version"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func testCfg() config.Config {
@@ -96,6 +105,36 @@ func TestSlugFromHost(t *testing.T) {
 	}
 }
 
+func TestSlugFromHost_CustomSuffix(t *testing.T) {
+	tests := []struct {
+		suffix string
+		host   string
+		want   string
+	}{
+		{".internal", "myproject-testuser.internal", "myproject"},
+		{".internal", "myproject-testuser.internal:8080", "myproject"},
+		{".internal", "myproject-testuser.local", ""},
+		{".internal", "myproject-otheruser.internal", ""},
+		{".dev", "my-cool-project-testuser.dev", "my-cool-project"},
+		{".dev", "myproject-testuser.internal", ""},
+		{".dev", "-testuser.dev", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.suffix+"/"+tt.host, func(t *testing.T) {
+			cfg := testCfg()
+			cfg.HostSuffix = tt.suffix
+			rt := New(registry.New(30*time.Second, testLogger()), cfg, testLogger(), nil)
+			if got := rt.slugFromHost(tt.host); got != tt.want {
+				t.Errorf("slugFromHost(%q) with suffix %q = %q, want %q", tt.host, tt.suffix, got, tt.want)
+			}
+			if got := rt.slugFromHost(cfg.DomainFor("alpha")); got != "alpha" {
+				t.Errorf("slugFromHost(DomainFor(alpha)) = %q, want alpha", got)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // slugFromPath
 // ---------------------------------------------------------------------------
@@ -222,6 +261,52 @@ func TestServeHTTP_PathRouting(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_InjectsRoutingHeaders(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	w := httptest.NewRecorder()
+	newTestRouter(reg).ServeHTTP(w, httptest.NewRequest("GET", "/proj/", nil))
+	if got := w.Header().Get("X-OpenCode-Slug"); got != "proj" {
+		t.Errorf("X-OpenCode-Slug = %q, want proj", got)
+	}
+	if got := w.Header().Get("X-OpenCode-Router-Version"); got != version.Version {
+		t.Errorf("X-OpenCode-Router-Version = %q, want %q", got, version.Version)
+	}
+
+	cfg := testCfg()
+	cfg.NoInjectHeaders = true
+	rt := New(reg, cfg, testLogger(), http.NotFoundHandler())
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/proj/", nil))
+	if w.Header().Get("X-OpenCode-Slug") != "" || w.Header().Get("X-OpenCode-Router-Version") != "" {
+		t.Errorf("expected no injected headers, got %v", w.Header())
+	}
+}
+
+func TestServeHTTP_TLSBackend(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	port := mustPort(t, backend.URL)
+	reg.Upsert(port, "proj", "/home/test/proj", "1.0")
+	reg.SetTLS(port, true)
+
+	w := httptest.NewRecorder()
+	newTestRouter(reg).ServeHTTP(w, httptest.NewRequest("GET", "/proj/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "secure" {
+		t.Errorf("expected proxied HTTPS response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
 func TestWSRouteParsing(t *testing.T) {
 	rt := newTestRouter(registry.New(30*time.Second, testLogger()))
 
@@ -491,6 +576,15 @@ func TestAPIHealth(t *testing.T) {
 	if resp["username"] != "testuser" {
 		t.Errorf("expected username=testuser, got %v", resp["username"])
 	}
+	for key, want := range map[string]string{
+		"router_version": version.Version,
+		"commit":         version.Commit,
+		"build_time":     version.BuildTime,
+	} {
+		if resp[key] != want {
+			t.Errorf("expected %s=%q, got %v", key, want, resp[key])
+		}
+	}
 	// JSON numbers are float64.
 	if resp["backends"].(float64) != 2 {
 		t.Errorf("expected backends=2, got %v", resp["backends"])
@@ -552,12 +646,174 @@ func TestAPIBackends_WithEntries(t *testing.T) {
 	}
 }
 
+func TestAPIBackends_SortAndFilter(t *testing.T) {
+	reg := registry.New(time.Hour, testLogger())
+	reg.Upsert(4300, "charlie", "/home/test/charlie", "1.2")
+	time.Sleep(5 * time.Millisecond)
+	reg.Upsert(4100, "alpha", "/home/test/alpha", "2.0")
+	time.Sleep(5 * time.Millisecond)
+	reg.Upsert(4200, "bravo", "/home/test/bravo", "1.0")
+	rt := newTestRouter(reg)
+
+	list := func(t *testing.T, query string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d (%s)", query, w.Code, w.Body.String())
+		}
+		var items []backendInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		slugs := make([]string, 0, len(items))
+		for _, it := range items {
+			slugs = append(slugs, it.Slug)
+		}
+		return slugs
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "alpha,bravo,charlie"},
+		{"?sort=slug&order=desc", "charlie,bravo,alpha"},
+		{"?sort=port", "alpha,bravo,charlie"},
+		{"?sort=port&order=desc", "charlie,bravo,alpha"},
+		{"?sort=last_seen", "charlie,alpha,bravo"},
+		{"?sort=last_seen&order=desc", "bravo,alpha,charlie"},
+		{"?sort=version", "bravo,charlie,alpha"},
+		{"?sort=version&order=desc", "alpha,charlie,bravo"},
+		{"?prefix=BR", "bravo"},
+		{"?prefix=a&sort=port&order=desc", "alpha"},
+		{"?prefix=zulu", ""},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(list(t, tc.query), ","); got != tc.want {
+			t.Errorf("GET /api/backends%s = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+
+	// With a short stale window only the most recent registration is healthy.
+	reg.SetStaleAfter(50 * time.Millisecond)
+	time.Sleep(80 * time.Millisecond)
+	reg.Upsert(4100, "alpha", "/home/test/alpha", "2.0")
+	if got := strings.Join(list(t, "?healthy=true"), ","); got != "alpha" {
+		t.Errorf("healthy filter = %s, want alpha", got)
+	}
+	reg.Upsert(4300, "charlie", "/home/test/charlie", "1.2")
+	if got := strings.Join(list(t, "?healthy=true&sort=port&order=desc"), ","); got != "charlie,alpha" {
+		t.Errorf("healthy + sort = %s, want charlie,alpha", got)
+	}
+	if got := len(list(t, "?healthy=false")); got != 3 {
+		t.Errorf("healthy=false should not filter, got %d backends", got)
+	}
+}
+
+func TestAPIBackends_LabelFilter(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	rt := newTestRouter(reg)
+
+	for _, body := range []string{
+		`{"port":4100,"project_path":"/home/test/alpha","labels":{"env":"dev","team":"core"}}`,
+		`{"port":4200,"project_path":"/home/test/bravo","labels":{"env":"prod","team":"core"}}`,
+		`{"port":4300,"project_path":"/home/test/charlie"}`,
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/backends", strings.NewReader(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("register %s: expected 201, got %d (%s)", body, w.Code, w.Body.String())
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"?label=env:dev", "alpha"},
+		{"?label=team:core", "alpha,bravo"},
+		{"?label=team:core&label=env:prod", "bravo"},
+		{"?label=env:staging", ""},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends"+tc.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", tc.query, w.Code)
+		}
+		var items []backendInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		slugs := make([]string, 0, len(items))
+		for _, it := range items {
+			slugs = append(slugs, it.Slug)
+		}
+		if got := strings.Join(slugs, ","); got != tc.want {
+			t.Errorf("GET /api/backends%s = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestAPIBackends_TagFilter(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4100, "alpha", "/home/test/alpha", "1.0")
+	reg.Upsert(4200, "bravo", "/home/test/bravo", "1.0")
+	reg.Upsert(4300, "charlie", "/home/test/charlie", "1.0")
+	reg.SetTags(4100, []string{"experimental", "gpu"})
+	reg.SetTags(4200, []string{"experimental"})
+	rt := newTestRouter(reg)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"?tag=experimental", "alpha,bravo"},
+		{"?tag=experimental&tag=gpu", "alpha"},
+		{"?tag=deprecated", ""},
+		{"?tag=experimental&prefix=b", "bravo"},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends"+tc.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", tc.query, w.Code)
+		}
+		var items []backendInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		slugs := make([]string, 0, len(items))
+		for _, it := range items {
+			slugs = append(slugs, it.Slug)
+		}
+		if got := strings.Join(slugs, ","); got != tc.want {
+			t.Errorf("GET /api/backends%s = %s, want %s", tc.query, got, tc.want)
+		}
+		if tc.query == "?tag=experimental&tag=gpu" && len(items) == 1 && strings.Join(items[0].Tags, ",") != "experimental,gpu" {
+			t.Errorf("expected tags in response, got %v", items[0].Tags)
+		}
+	}
+}
+
+func TestAPIBackends_InvalidQuery(t *testing.T) {
+	rt := newTestRouter(registry.New(30*time.Second, testLogger()))
+	for _, query := range []string{"?sort=name", "?order=sideways", "?healthy=maybe", "?label=env"} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/backends%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
 func TestAPIBackends_MethodNotAllowed(t *testing.T) {
 	reg := registry.New(30*time.Second, testLogger())
 	rt := newTestRouter(reg)
 
 	w := httptest.NewRecorder()
-	req := httptest.NewRequest("POST", "/api/backends", nil)
+	req := httptest.NewRequest("PUT", "/api/backends", nil)
 	rt.ServeHTTP(w, req)
 
 	if w.Code != http.StatusMethodNotAllowed {
@@ -565,6 +821,326 @@ func TestAPIBackends_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestAPIBackends_Register(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	rt := newTestRouter(reg)
+
+	body := `{"port":4200,"project_name":"my-app","project_path":"/home/user/my-app","version":"manual"}`
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/backends", strings.NewReader(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var item map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &item); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if item["slug"] != "my-app" || item["manual"] != true {
+		t.Errorf("unexpected response: %v", item)
+	}
+
+	b, ok := reg.Lookup("my-app")
+	if !ok || b.Port != 4200 || !b.Manual {
+		t.Fatalf("expected manual backend in registry, got %+v, %v", b, ok)
+	}
+
+	// Re-posting the same backend updates it in place.
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/backends", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 on re-register, got %d", w.Code)
+	}
+}
+
+func TestAPIBackends_RegisterInvalid(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	rt := newTestRouter(reg)
+
+	for _, body := range []string{
+		`not json`,
+		`{"port":0,"project_path":"/home/user/my-app"}`,
+		`{"port":4200}`,
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/backends", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if reg.Len() != 0 {
+		t.Errorf("expected nothing registered, got %d", reg.Len())
+	}
+}
+
+func TestAPIBackends_Delete(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.UpsertManual(4200, "my-app", "/home/user/my-app", "manual")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/backends/my-app", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if _, ok := reg.Lookup("my-app"); ok {
+		t.Error("expected backend to be removed")
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/backends/my-app", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown slug, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/my-app", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET on a single backend, got %d", w.Code)
+	}
+}
+
+func TestAPIBackendHistory(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "my-app", "/home/user/my-app", "1.0")
+	reg.Upsert(4096, "my-app", "/home/user/my-app", "1.1")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/my-app/history", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var records []registry.HealthRecord
+	if err := json.NewDecoder(w.Body).Decode(&records); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(records) != 2 || records[1].Version != "1.1" || !records[1].Healthy {
+		t.Errorf("unexpected history: %+v", records)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/nope/history", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown slug, got %d", w.Code)
+	}
+}
+
+func TestAPIBackendRename(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "old-name", "/home/user/old-name", "1.0")
+	reg.Upsert(4097, "other", "/home/user/other", "1.0")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/backends/old-name/rename", strings.NewReader(`{"new_slug":"new-name"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var info backendInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.Slug != "new-name" || info.Port != 4096 {
+		t.Errorf("unexpected backend info: %+v", info)
+	}
+	if _, ok := reg.Lookup("old-name"); ok {
+		t.Error("expected old slug to be gone")
+	}
+
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"unknown slug", "/api/backends/old-name/rename", `{"new_slug":"x"}`, http.StatusNotFound},
+		{"slug taken", "/api/backends/new-name/rename", `{"new_slug":"other"}`, http.StatusConflict},
+		{"invalid slug", "/api/backends/new-name/rename", `{"new_slug":"Not A Slug"}`, http.StatusBadRequest},
+		{"missing slug", "/api/backends/new-name/rename", `{}`, http.StatusBadRequest},
+		{"bad json", "/api/backends/new-name/rename", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/new-name/rename", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", w.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// API: /api/scan
+// ---------------------------------------------------------------------------
+
+func TestAPIScan(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, map[string]interface{}{"healthy": true, "version": "1.0"})
+	})
+	mux.HandleFunc("/project/current", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, map[string]interface{}{"name": "scanned", "path": "/home/test/scanned"})
+	})
+	backend := httptest.NewServer(mux)
+	defer backend.Close()
+	port := mustPort(t, backend.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := scanner.New(reg, port, port, time.Minute, 1, time.Second, testLogger())
+	rt := New(reg, testCfg(), testLogger(), http.NotFoundHandler(), WithScanner(sc))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/api/scan", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+	var started struct {
+		Triggered bool   `json:"triggered"`
+		ScanID    string `json:"scan_id"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&started); err != nil || !started.Triggered || started.ScanID == "" {
+		t.Fatalf("unexpected response %+v (err %v)", started, err)
+	}
+
+	var rec scanner.ScanRecord
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		w = httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/scan/"+started.ScanID, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if err := json.NewDecoder(w.Body).Decode(&rec); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if rec.Status == scanner.ScanComplete {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec.Status != scanner.ScanComplete || rec.Added != 1 {
+		t.Fatalf("expected completed scan adding 1 backend, got %+v", rec)
+	}
+	if _, ok := reg.Lookup("scanned"); !ok {
+		t.Error("expected scanned backend in registry")
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/scan/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown scan, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/scan", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET /api/scan, got %d", w.Code)
+	}
+}
+
+func TestAPIScan_NoScanner(t *testing.T) {
+	rt := newTestRouter(registry.New(30*time.Second, testLogger()))
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/scan", nil),
+		httptest.NewRequest("GET", "/api/scan/metrics", nil),
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503 without a scanner, got %d", req.Method, req.URL.Path, w.Code)
+		}
+	}
+}
+
+func TestAPIScanMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, map[string]interface{}{"healthy": true, "version": "1.0"})
+	})
+	backend := httptest.NewServer(mux)
+	defer backend.Close()
+	port := mustPort(t, backend.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := scanner.New(reg, port, port, time.Minute, 1, time.Second, testLogger())
+	sc.ScanOnce(context.Background())
+	rt := New(reg, testCfg(), testLogger(), http.NotFoundHandler(), WithScanner(sc))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/scan/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var m scanner.ScanMetrics
+	if err := json.NewDecoder(w.Body).Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if m.PortsScanned != 1 || m.BackendsFound != 1 || m.ScanDurationMs <= 0 || m.LastScanTime.IsZero() {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// API: /api/resolve
+// ---------------------------------------------------------------------------
+
+func TestAPIResolve_ExactNameMiss(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "myproject", "/home/test/myproject", "1.0")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/resolve?name=my", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for partial name without fuzzy, got %d", w.Code)
+	}
+}
+
+func TestAPIResolve_Fuzzy(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "myproject", "/home/test/myproject", "1.0")
+	reg.Upsert(4097, "my", "/home/test/my", "1.0")
+	reg.Upsert(4098, "other", "/home/test/other", "1.0")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/resolve?name=my&fuzzy=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("unmarshal fuzzy resolve response: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(items))
+	}
+	if items[0]["slug"] != "my" || items[1]["slug"] != "myproject" {
+		t.Errorf("expected exact match first, got %v, %v", items[0]["slug"], items[1]["slug"])
+	}
+	if items[1]["url"] != "http://localhost:8080/myproject/" {
+		t.Errorf("unexpected url %v", items[1]["url"])
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/resolve?name=zzz&fuzzy=true", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected empty array for no matches, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/resolve?name=my&fuzzy=true&limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid limit, got %d", w.Code)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Backend unavailable → 502
 // ---------------------------------------------------------------------------
@@ -609,3 +1185,473 @@ func TestServeHTTP_UnknownSlug(t *testing.T) {
 		t.Error("expected HTML dashboard for unknown slug")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// API: /api/remotes
+// ---------------------------------------------------------------------------
+
+func TestAPIRemotes(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	remotes := discovery.NewRemoteRegistry()
+	remotes.Upsert(discovery.RemoteEntry{
+		Instance: "alpha",
+		Host:     "alpha-bob.local",
+		Port:     8080,
+		Username: "bob",
+		Addrs:    []string{"192.168.1.20"},
+	})
+
+	rt := New(reg, testCfg(), testLogger(), nil, WithRemotes(remotes))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/remotes", nil)
+	rt.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("unmarshal remotes response: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if items[0]["username"] != "bob" {
+		t.Errorf("expected username 'bob', got %v", items[0]["username"])
+	}
+	if items[0]["url"] != "http://192.168.1.20:8080/alpha/" {
+		t.Errorf("unexpected url %v", items[0]["url"])
+	}
+}
+
+func TestAPIRemotes_Disabled(t *testing.T) {
+	rt := newTestRouter(registry.New(30*time.Second, testLogger()))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/remotes", nil)
+	rt.ServeHTTP(w, req)
+
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected empty list, got %q", w.Body.String())
+	}
+}
+
+func TestAPIPeers(t *testing.T) {
+	remotes := discovery.NewRemoteRegistry()
+	for _, project := range []string{"alpha", "beta"} {
+		remotes.Upsert(discovery.RemoteEntry{
+			Instance: project,
+			Host:     project + "-bob.local",
+			Port:     8080,
+			Username: "bob",
+			Router:   "bobs-laptop",
+			Addrs:    []string{"192.168.1.20"},
+		})
+	}
+	rt := New(registry.New(30*time.Second, testLogger()), testCfg(), testLogger(), nil, WithRemotes(remotes))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/peers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var peers []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &peers); err != nil {
+		t.Fatalf("unmarshal peers response: %v", err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("expected the two projects to collapse into 1 peer, got %v", peers)
+	}
+	p := peers[0]
+	if p["host"] != "bobs-laptop" || p["ip"] != "192.168.1.20" || p["port"] != float64(8080) || p["username"] != "bob" {
+		t.Errorf("unexpected peer %v", p)
+	}
+
+	w = httptest.NewRecorder()
+	newTestRouter(registry.New(30*time.Second, testLogger())).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/peers", nil))
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected empty list without mDNS, got %q", w.Body.String())
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Access log
+// ---------------------------------------------------------------------------
+
+func TestServeHTTP_AccessLog(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	var buf bytes.Buffer
+	accessLog := slog.New(slog.NewJSONHandler(&buf, nil))
+	rt := New(reg, testCfg(), testLogger(), nil, WithAccessLog(accessLog))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/proj/session", nil)
+	rt.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("access log is not JSON: %v (%q)", err, buf.String())
+	}
+	for _, field := range []string{"method", "path", "slug", "status", "bytes", "duration_ms", "remote_addr", "request_id"} {
+		if _, ok := record[field]; !ok {
+			t.Errorf("access log missing field %q: %v", field, record)
+		}
+	}
+	if record["slug"] != "proj" || record["method"] != "POST" {
+		t.Errorf("unexpected slug/method in %v", record)
+	}
+	if record["status"].(float64) != http.StatusCreated {
+		t.Errorf("expected status 201, got %v", record["status"])
+	}
+	if record["bytes"].(float64) != 5 {
+		t.Errorf("expected 5 bytes, got %v", record["bytes"])
+	}
+	if id := w.Header().Get("X-Request-ID"); id == "" || record["request_id"] != id {
+		t.Errorf("access log request_id = %v, response X-Request-ID = %q", record["request_id"], id)
+	}
+}
+
+func TestServeHTTP_Compression(t *testing.T) {
+	body := strings.Repeat("backend payload ", 200)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+	for i := 0; i < 20; i++ {
+		reg.Upsert(5000+i, fmt.Sprintf("proj%02d", i), fmt.Sprintf("/home/test/proj%02d", i), "1.0")
+	}
+
+	get := func(rt *Router, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(newTestRouter(reg), "/api/backends")
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("GET /api/backends Content-Encoding = %q, want gzip", w.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	var items []backendInfo
+	if err := json.NewDecoder(zr).Decode(&items); err != nil || len(items) != 21 {
+		t.Fatalf("decoded %d backends, err %v", len(items), err)
+	}
+
+	if w := get(newTestRouter(reg), "/proj/file"); w.Header().Get("Content-Encoding") != "" || w.Body.String() != body {
+		t.Errorf("proxied response was re-encoded (Content-Encoding %q)", w.Header().Get("Content-Encoding"))
+	}
+
+	cfg := testCfg()
+	cfg.EnableCompression = false
+	if w := get(New(reg, cfg, testLogger(), nil), "/api/backends"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("compression disabled but Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestServeHTTP_BehindProxy(t *testing.T) {
+	var forwarded string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Forwarded-For")
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	var buf bytes.Buffer
+	cfg := testCfg()
+	cfg.BehindProxy = true
+	rt := New(reg, cfg, testLogger(), nil, WithAccessLog(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	req := httptest.NewRequest(http.MethodGet, "/proj/session", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	rt.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("access log is not JSON: %v (%q)", err, buf.String())
+	}
+	if record["remote_addr"] != "203.0.113.7:0" {
+		t.Errorf("access log remote_addr = %v, want the client behind the proxy", record["remote_addr"])
+	}
+	if forwarded != "203.0.113.7" {
+		t.Errorf("backend X-Forwarded-For = %q, want the client listed once", forwarded)
+	}
+}
+
+func TestAPIBackends_ETag(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	rt := newTestRouter(reg)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rt.now = func() time.Time { return now }
+
+	get := func(query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/backends"+query, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("first call: status %d, ETag %q", first.Code, etag)
+	}
+
+	second := get("", etag)
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Fatalf("matching If-None-Match: status %d, body %q, want empty 304", second.Code, second.Body.String())
+	}
+	if got := get("", `"stale", W/`+etag).Code; got != http.StatusNotModified {
+		t.Errorf("weak match in a list: status %d, want 304", got)
+	}
+	if got := get("?sort=port", etag).Code; got != http.StatusOK {
+		t.Errorf("a different query should not match: status %d", got)
+	}
+
+	reg.Upsert(4097, "beta", "/home/user/beta", "1.0")
+	third := get("", etag)
+	if third.Code != http.StatusOK || !strings.Contains(third.Body.String(), `"beta"`) {
+		t.Fatalf("after a registry change: status %d, body %q", third.Code, third.Body.String())
+	}
+	if third.Header().Get("ETag") == etag {
+		t.Error("ETag did not change with the registry")
+	}
+
+	// Uptime moves with the clock, so the ETag does too, once per period.
+	etag = third.Header().Get("ETag")
+	now = now.Add(uptimeETagPeriod / 2) // still 03:04, as the clock started at 03:04:05
+	if got := get("", etag).Code; got != http.StatusNotModified {
+		t.Errorf("within the uptime period: status %d, want 304", got)
+	}
+	now = now.Add(uptimeETagPeriod)
+	if got := get("", etag).Code; got != http.StatusOK {
+		t.Errorf("after the uptime period: status %d, want 200", got)
+	}
+}
+
+func TestServeHTTP_BasicAuth(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testCfg()
+	cfg.BasicAuthUser, cfg.BasicAuthPass = "alice", string(hash)
+	rt := New(reg, cfg, testLogger(), http.NotFoundHandler())
+
+	for path, want := range map[string]int{
+		"/api/health":   http.StatusOK,
+		"/api/backends": http.StatusUnauthorized,
+		"/proj/session": http.StatusUnauthorized,
+		"/":             http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s without credentials = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/proj/session", nil)
+	req.SetBasicAuth("alice", "s3cret")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /proj/session with credentials = %d, want 200", w.Code)
+	}
+}
+
+func TestServeHTTP_RequestIDForwarded(t *testing.T) {
+	var seen string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Request-ID")
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	t.Run("generated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newTestRouter(reg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proj/session", nil))
+		if seen == "" || w.Header().Get("X-Request-ID") != seen {
+			t.Errorf("backend saw %q, response carried %q", seen, w.Header().Get("X-Request-ID"))
+		}
+	})
+	t.Run("preserved", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/proj/session", nil)
+		req.Header.Set("X-Request-ID", "trace-42")
+		w := httptest.NewRecorder()
+		newTestRouter(reg).ServeHTTP(w, req)
+		if seen != "trace-42" || w.Header().Get("X-Request-ID") != "trace-42" {
+			t.Errorf("backend saw %q, response carried %q, want trace-42", seen, w.Header().Get("X-Request-ID"))
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		seen = ""
+		cfg := testCfg()
+		cfg.InjectRequestID = false
+		New(reg, cfg, testLogger(), nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/proj/session", nil))
+		if seen != "" {
+			t.Errorf("backend saw X-Request-ID %q with injection disabled", seen)
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// API: /api/processes
+// ---------------------------------------------------------------------------
+
+func TestAPIProcesses_NoLauncher(t *testing.T) {
+	rt := newTestRouter(registry.New(30*time.Second, testLogger()))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/processes", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("expected empty list, got %q", w.Body.String())
+	}
+}
+
+// ---------------------------------------------------------------------------
+// WithMiddleware
+// ---------------------------------------------------------------------------
+
+func TestWithMiddleware_OrderAndCounting(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	var rt *Router
+	var order []string
+	record := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if rt.InFlight() != 1 {
+					t.Errorf("%s: expected the request to be counted in flight, got %d", name, rt.InFlight())
+				}
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	rt = New(reg, testCfg(), testLogger(), nil, WithMiddleware(record("first"), record("second")))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("middleware order = %v, want [first second]", order)
+	}
+	if rt.InFlight() != 0 {
+		t.Errorf("expected no requests in flight afterwards, got %d", rt.InFlight())
+	}
+}
+
+func TestServeHTTP_UsernameFromPath(t *testing.T) {
+	newBackend := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	alice, bob := newBackend("alice"), newBackend("bob")
+	defer alice.Close()
+	defer bob.Close()
+
+	reg := registry.New(30*time.Second, testLogger(), registry.WithUsernameFromPath(true))
+	reg.Upsert(mustPort(t, alice.URL), "my-proj", "/home/alice/my-proj", "1.0")
+	reg.Upsert(mustPort(t, bob.URL), "my-proj", "/home/bob/my-proj", "1.0")
+
+	cfg := testCfg()
+	cfg.Username = "root"
+	cfg.UsernameFromPath = true
+	rt := New(reg, cfg, testLogger(), http.NotFoundHandler())
+
+	for host, want := range map[string]string{
+		"my-proj-alice.local":    "alice",
+		"my-proj-bob.local:8080": "bob",
+		cfg.DomainFor("my-proj"): "",
+		"my-proj-carol.local":    "",
+		"proj-alice.local":       "",
+	} {
+		for i := 0; i < 3; i++ { // the tenants share a slug; balancing must not mix them
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = host
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, req)
+			if want == "" {
+				if w.Code != http.StatusNotFound {
+					t.Errorf("Host %s: got %d %q, want the dashboard's 404", host, w.Code, w.Body.String())
+				}
+				break
+			}
+			if w.Body.String() != want {
+				t.Errorf("Host %s reached %q, want %q", host, w.Body.String(), want)
+			}
+		}
+	}
+
+	if got := rt.slugFromHost("my-proj-alice.local"); got != "my-proj" {
+		t.Errorf("slugFromHost = %q, want my-proj", got)
+	}
+}
+
+func TestServeHTTP_IPv6Backend(t *testing.T) {
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("v6 " + r.URL.Path))
+	}))
+	backend.Listener.Close()
+	backend.Listener = ln
+	backend.Start()
+	defer backend.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(port, "v6proj", "/home/test/v6proj", "1.0")
+	reg.SetBindIP(port, registry.IPv6BindIP)
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v6proj/global/health", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "v6 /global/health" {
+		t.Fatalf("got %d %q, want the IPv6 backend's response", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,18 @@
+package proxy
+
+import (
+	"net"
+
+	"github.com/pires/go-proxyproto"
+)
+
+// ProxyProtocolListener wraps ln so that connections starting with a PROXY
+// protocol header (v1 or v2), as sent by HAProxy or an AWS NLB, report the
+// client address from the header as their remote address, which handlers
+// then see in r.RemoteAddr. Connections without a header are served as is.
+//
+// Any client that can reach ln can claim an arbitrary address this way, so
+// it is meant for listeners that only the load balancer connects to.
+func ProxyProtocolListener(ln net.Listener) net.Listener {
+	return &proxyproto.Listener{Listener: ln}
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// recordWriter hands each access log record to the test goroutine.
+type recordWriter chan []byte
+
+func (w recordWriter) Write(p []byte) (int, error) {
+	w <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+// proxyProtocolRemoteAddr sends a request for proj through a router behind
+// ProxyProtocolListener, prefixed with header, and returns the remote_addr
+// of its access log record.
+func proxyProtocolRemoteAddr(t *testing.T, header string) string {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	records := make(recordWriter, 1)
+	srv := httptest.NewUnstartedServer(New(reg, testCfg(), testLogger(), nil,
+		WithAccessLog(slog.New(slog.NewJSONHandler(records, nil)))))
+	srv.Listener = ProxyProtocolListener(srv.Listener)
+	srv.Start()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintf(conn, "%sGET /proj/session HTTP/1.1\r\nHost: localhost\r\n\r\n", header)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	select {
+	case line := <-records:
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("access log is not JSON: %v (%q)", err, line)
+		}
+		addr, _ := record["remote_addr"].(string)
+		return addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("no access log record")
+		return ""
+	}
+}
+
+func TestProxyProtocolListener_V1Header(t *testing.T) {
+	got := proxyProtocolRemoteAddr(t, "PROXY TCP4 203.0.113.7 10.0.0.1 40123 8080\r\n")
+	if got != "203.0.113.7:40123" {
+		t.Errorf("access log remote_addr = %q, want the client from the PROXY header", got)
+	}
+}
+
+func TestProxyProtocolListener_NoHeader(t *testing.T) {
+	got := proxyProtocolRemoteAddr(t, "")
+	if host, _, _ := net.SplitHostPort(got); host != "127.0.0.1" {
+		t.Errorf("access log remote_addr = %q, want the connection's own address", got)
+	}
+}
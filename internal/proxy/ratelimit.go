@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"opencoderouter/internal/config"
+)
+
+// maxIdleBuckets bounds per-client bucket growth; full (idle) buckets are
+// dropped once the map exceeds this size.
+const maxIdleBuckets = 4096
+
+// tokenBucket is a minimal token bucket. It is not safe for concurrent use;
+// slugRateLimiter serializes access.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+func newTokenBucket(limit config.RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens: float64(limit.Burst),
+		last:   now,
+		rate:   limit.RequestsPerSecond,
+		burst:  float64(limit.Burst),
+	}
+}
+
+// take consumes one token. When the bucket is empty it returns false and the
+// time until the next token becomes available.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / b.rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// slugRateLimiter holds one token bucket per slug, or per slug+client IP.
+type slugRateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]config.RateLimit
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+func newSlugRateLimiter(limits map[string]config.RateLimit) *slugRateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	copied := make(map[string]config.RateLimit, len(limits))
+	for k, v := range limits {
+		copied[k] = v
+	}
+	return &slugRateLimiter{
+		limits:  copied,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow reports whether a request for slug from clientIP may proceed.
+func (l *slugRateLimiter) allow(slug, clientIP string) (bool, time.Duration) {
+	limit, ok := l.limits[slug]
+	if !ok {
+		limit, ok = l.limits[config.RateLimitDefaultKey]
+		if !ok {
+			return true, 0
+		}
+	}
+
+	key := slug
+	if limit.PerClientIP {
+		key = slug + "|" + clientIP
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.evictFullLocked(now)
+		}
+		b = newTokenBucket(limit, now)
+		l.buckets[key] = b
+	}
+	return b.take(now)
+}
+
+func (l *slugRateLimiter) evictFullLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// checkRateLimit writes a 429 and returns false when slug is over its limit.
+func (rt *Router) checkRateLimit(w http.ResponseWriter, r *http.Request, slug string) bool {
+	if rt.limiter == nil {
+		return true
+	}
+
+	ok, retryAfter := rt.limiter.allow(slug, clientIP(r))
+	if ok {
+		return true
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	rt.logger.Debug("rate limited", "slug", slug, "remote_addr", r.RemoteAddr, "retry_after", retryAfter)
+	http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	return false
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
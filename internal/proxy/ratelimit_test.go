@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/registry"
+)
+
+func TestTokenBucket_Refill(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	b := newTokenBucket(config.RateLimit{RequestsPerSecond: 2, Burst: 2}, start)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.take(start); !ok {
+			t.Fatalf("request %d within burst should be allowed", i)
+		}
+	}
+
+	ok, wait := b.take(start)
+	if ok {
+		t.Fatal("request beyond burst should be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected 500ms until next token, got %s", wait)
+	}
+
+	if ok, _ := b.take(start.Add(500 * time.Millisecond)); !ok {
+		t.Error("expected a token after refill interval")
+	}
+	if ok, _ := b.take(start.Add(500 * time.Millisecond)); ok {
+		t.Error("expected bucket to be empty again")
+	}
+}
+
+func TestSlugRateLimiter_DefaultAndPerClient(t *testing.T) {
+	l := newSlugRateLimiter(map[string]config.RateLimit{
+		"strict":                   {RequestsPerSecond: 1, Burst: 1, PerClientIP: true},
+		config.RateLimitDefaultKey: {RequestsPerSecond: 1, Burst: 2},
+	})
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+
+	if ok, _ := l.allow("strict", "10.0.0.1"); !ok {
+		t.Fatal("first request from client A should pass")
+	}
+	if ok, _ := l.allow("strict", "10.0.0.1"); ok {
+		t.Fatal("second request from client A should be limited")
+	}
+	if ok, _ := l.allow("strict", "10.0.0.2"); !ok {
+		t.Fatal("client B has its own bucket")
+	}
+
+	// Default applies to unlisted slugs, shared across clients.
+	l.allow("other", "10.0.0.1")
+	l.allow("other", "10.0.0.2")
+	if ok, _ := l.allow("other", "10.0.0.3"); ok {
+		t.Fatal("default limit should be shared across clients")
+	}
+}
+
+func TestNewSlugRateLimiter_NoLimits(t *testing.T) {
+	if newSlugRateLimiter(nil) != nil {
+		t.Error("expected nil limiter when no limits configured")
+	}
+}
+
+func TestServeHTTP_RateLimited(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+
+	cfg := testCfg()
+	cfg.RateLimits = map[string]config.RateLimit{"proj": {RequestsPerSecond: 0.5, Burst: 3}}
+	rt := New(reg, cfg, testLogger(), nil)
+
+	var statuses []int
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proj/session", nil))
+		statuses = append(statuses, w.Code)
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Error("429 response should include Retry-After")
+		}
+	}
+
+	want := []int{200, 200, 200, 429, 429}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", statuses, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort int
+		host      string
+		target    string
+		want      string
+	}{
+		{"custom port", 8443, "alpha.local:8080", "/alpha/x?y=1", "https://alpha.local:8443/alpha/x?y=1"},
+		{"default port", 443, "alpha.local", "/", "https://alpha.local/"},
+		{"ipv6", 8443, "[::1]:8080", "/a", "https://[::1]:8443/a"},
+		{"ipv6 default port", 443, "[::1]", "/a", "https://[::1]/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			HTTPSRedirect(tt.httpsPort).ServeHTTP(w, req)
+
+			if w.Code != http.StatusMovedPermanently {
+				t.Errorf("status = %d, want 301", w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"opencoderouter/internal/scanner"
+)
+
+// handleAPISelfRegister lets a backend announce itself in passive mode. The
+// port is probed once and registered with the project and version the
+// backend reports there, as a scan would; project_name and version in the
+// body are informational and logged when they disagree. Registering again
+// refreshes the entry. 201 for a new backend, 200 for a known one and 422
+// when nothing healthy answers on the port. It stays on the main listener
+// with --admin-port: backends need to reach it, and it can only add what a
+// scan would find.
+//
+//	POST /api/register {"port":4096,"project_name":"myproj","version":"1.2.0"}
+func (rt *Router) handleAPISelfRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !rt.cfg.PassiveScan {
+		http.Error(w, "not found: self-registration needs --passive", http.StatusNotFound)
+		return
+	}
+	if rt.scanner == nil {
+		http.Error(w, "scanner not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Port        int    `json:"port"`
+		ProjectName string `json:"project_name"`
+		Version     string `json:"version"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		http.Error(w, "port must be 1-65535", http.StatusBadRequest)
+		return
+	}
+
+	_, known := rt.registry.LookupByPort(req.Port)
+	backend, err := rt.scanner.Register(r.Context(), req.Port)
+	switch {
+	case errors.Is(err, scanner.ErrUnreachable):
+		http.Error(w, "registration rejected: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, "registration rejected: "+err.Error(), http.StatusConflict)
+		return
+	}
+	if (req.ProjectName != "" && req.ProjectName != backend.ProjectName) || (req.Version != "" && req.Version != backend.Version) {
+		rt.logger.Debug("self-registration details differ from the probe",
+			"port", req.Port,
+			"claimed_project", req.ProjectName, "project", backend.ProjectName,
+			"claimed_version", req.Version, "version", backend.Version,
+		)
+	}
+
+	status := http.StatusOK
+	if !known {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSONResponse(w, rt.newBackendInfo(backend))
+}
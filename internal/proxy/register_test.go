@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+)
+
+// selfRegisteringBackend is an OpenCode stand-in whose reported version can
+// change between probes.
+func selfRegisteringBackend(t *testing.T, version *atomic.Value) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, map[string]interface{}{"healthy": true, "version": version.Load()})
+	})
+	mux.HandleFunc("/project/current", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, map[string]interface{}{"name": "myproj", "path": "/home/test/myproj"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newPassiveRouter(reg *registry.Registry, passive bool) *Router {
+	cfg := testCfg()
+	cfg.PassiveScan = passive
+	sc := scanner.New(reg, 1, 1, time.Minute, 1, time.Second, testLogger(),
+		scanner.WithPassive(passive), scanner.WithProbeRetries(0, 0))
+	return New(reg, cfg, testLogger(), http.NotFoundHandler(), WithScanner(sc))
+}
+
+func postRegister(rt *Router, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(body)))
+	return w
+}
+
+func TestAPISelfRegister(t *testing.T) {
+	var version atomic.Value
+	version.Store("1.0.0")
+	backend := selfRegisteringBackend(t, &version)
+	port := mustPort(t, backend.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	rt := newPassiveRouter(reg, true)
+
+	w := postRegister(rt, fmt.Sprintf(`{"port":%d,"project_name":"myproj","version":"1.0.0"}`, port))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d (%s), want 201", w.Code, w.Body.String())
+	}
+	var info backendInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Slug != "myproj" || info.Port != port || info.Version != "1.0.0" {
+		t.Errorf("response = %+v", info)
+	}
+	if b, ok := reg.Lookup("myproj"); !ok || b.Manual {
+		t.Fatalf("registry entry = %+v, %v; want a scanned backend", b, ok)
+	}
+
+	version.Store("1.1.0")
+	w = postRegister(rt, fmt.Sprintf(`{"port":%d,"project_name":"myproj","version":"1.1.0"}`, port))
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-registration status = %d (%s), want 200", w.Code, w.Body.String())
+	}
+	if b, _ := reg.Lookup("myproj"); b.Version != "1.1.0" {
+		t.Errorf("version after re-registration = %q, want 1.1.0", b.Version)
+	}
+	if n := len(reg.LookupAll("myproj")); n != 1 {
+		t.Errorf("re-registration left %d instances, want 1", n)
+	}
+}
+
+func TestAPISelfRegister_Unresponsive(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	w := postRegister(newPassiveRouter(reg, true), fmt.Sprintf(`{"port":%d,"project_name":"ghost"}`, port))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("an unreachable backend was registered: %d backends", reg.Len())
+	}
+}
+
+func TestAPISelfRegister_Errors(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	passive := newPassiveRouter(reg, true)
+
+	if w := postRegister(newPassiveRouter(reg, false), `{"port":4096}`); w.Code != http.StatusNotFound {
+		t.Errorf("without passive mode: status = %d, want 404", w.Code)
+	}
+	if w := postRegister(passive, `{"port":0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid port: status = %d, want 400", w.Code)
+	}
+	if w := postRegister(passive, `not json`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: status = %d, want 400", w.Code)
+	}
+	w := httptest.NewRecorder()
+	passive.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/register", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", w.Code)
+	}
+}
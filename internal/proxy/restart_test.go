@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/registry"
+)
+
+// newRestartTestRouter launches a fake opencode binary for one project and
+// registers it the way the scanner would.
+func newRestartTestRouter(t *testing.T) (*Router, *registry.Registry, string, launcher.ProcessStatus) {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "fake-opencode")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatalf("write fake binary: %v", err)
+	}
+	lnch := launcher.New(39600, 39610, testLogger(), launcher.WithBinaryPath(bin))
+	t.Cleanup(lnch.Shutdown)
+	project := filepath.Join(t.TempDir(), "restartable")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := lnch.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	st := lnch.Status()
+	if len(st) != 1 || st[0].State != launcher.ProcessRunning {
+		t.Fatalf("fake backend not running: %+v", st)
+	}
+
+	reg := registry.New(time.Minute, testLogger())
+	reg.Upsert(st[0].Port, "restartable", project, "1.0")
+	rt := New(reg, testCfg(), testLogger(), nil, WithLauncher(lnch))
+	return rt, reg, bin, st[0]
+}
+
+func TestAPIBackendRestart(t *testing.T) {
+	rt, reg, _, before := newRestartTestRouter(t)
+	seen, _ := reg.Lookup("restartable")
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backends/restartable/restart", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST restart = %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Restarted bool                     `json:"restarted"`
+		Processes []launcher.ProcessStatus `json:"processes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Restarted || len(resp.Processes) != 1 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if p := resp.Processes[0]; p.Port != before.Port || p.PID == before.PID || p.State != launcher.ProcessRunning {
+		t.Errorf("process after restart = %+v, was %+v", p, before)
+	}
+	if b, ok := reg.Lookup("restartable"); !ok || !b.LastSeen.After(seen.LastSeen) {
+		t.Errorf("restart should refresh LastSeen, got %+v", b)
+	}
+}
+
+func TestAPIBackendRestart_Errors(t *testing.T) {
+	rt, _, bin, _ := newRestartTestRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		slug   string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "restartable", http.StatusMethodNotAllowed},
+		{"unknown slug", http.MethodPost, "nope", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/backends/"+tt.slug+"/restart", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("no launcher", func(t *testing.T) {
+		reg := registry.New(time.Minute, testLogger())
+		reg.Upsert(4096, "proj", "/home/test/proj", "1.0")
+		w := httptest.NewRecorder()
+		New(reg, testCfg(), testLogger(), nil).ServeHTTP(w,
+			httptest.NewRequest(http.MethodPost, "/api/backends/proj/restart", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("restart failure", func(t *testing.T) {
+		if err := os.Remove(bin); err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backends/restartable/restart", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500 (%s)", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestAPIBackendRestart_SameBaseName(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "fake-opencode")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatalf("write fake binary: %v", err)
+	}
+	lnch := launcher.New(39620, 39630, testLogger(), launcher.WithBinaryPath(bin))
+	t.Cleanup(lnch.Shutdown)
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a", "app"), filepath.Join(root, "b", "app")
+	for _, dir := range []string{a, b} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lnch.Launch([]string{a, b}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	reg := registry.New(time.Minute, testLogger(), registry.WithSlugCollision(registry.CollisionPort))
+	before := make(map[string]launcher.ProcessStatus)
+	for _, st := range lnch.Status() {
+		reg.Upsert(st.Port, "app", st.Path, "1.0")
+		before[st.Path] = st
+	}
+	second, ok := reg.LookupByPath(b)
+	if !ok || second.Slug == "app" {
+		t.Fatalf("second project should get its own slug, got %+v", second)
+	}
+	rt := New(reg, testCfg(), testLogger(), nil, WithLauncher(lnch))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backends/"+second.Slug+"/restart", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST restart = %d: %s", w.Code, w.Body.String())
+	}
+	for _, st := range lnch.Status() {
+		restarted := st.PID != before[st.Path].PID
+		if restarted != (st.Path == b) {
+			t.Errorf("%s restarted = %v, want %v", st.Path, restarted, st.Path == b)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSnapshotSize caps the body accepted by POST /api/restore.
+const maxSnapshotSize = 16 << 20
+
+// checkAdminToken reports whether r carries the configured admin bearer
+// token, writing the error response if not. Without a configured token the
+// admin endpoints are disabled.
+func (rt *Router) checkAdminToken(w http.ResponseWriter, r *http.Request) bool {
+	if rt.cfg.AdminToken == "" {
+		http.Error(w, "admin API disabled: set --admin-token", http.StatusForbidden)
+		return false
+	}
+	if !HasBearerToken(r, rt.cfg.AdminToken) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="opencoderouter-admin"`)
+		http.Error(w, "invalid or missing admin token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// HasBearerToken reports whether r's Authorization header carries token as a
+// bearer token.
+func HasBearerToken(r *http.Request, token string) bool {
+	scheme, got, _ := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	return strings.EqualFold(scheme, "Bearer") &&
+		subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(token)) == 1
+}
+
+// movedToAdminPort answers a privileged request with 404 when --admin-port
+// serves those endpoints instead, reporting whether it did.
+func (rt *Router) movedToAdminPort(w http.ResponseWriter, _ *http.Request) bool {
+	if rt.cfg.AdminPort == 0 {
+		return false
+	}
+	http.Error(w, "not found: served on the admin port", http.StatusNotFound)
+	return true
+}
+
+// AdminRoutes serves the privileged endpoints for the admin port:
+//
+//	POST   /api/backends
+//	DELETE /api/backends/{slug}
+//	PUT    /api/backends/{slug}/labels
+//	POST   /api/backends/{slug}/rename
+//	POST   /api/backends/{slug}/restart
+//	POST   /api/scan
+//	GET    /api/snapshot
+//	POST   /api/restore
+//
+// Other paths get a 404. The main listener answers these with 404 when
+// --admin-port is set, and labels are only served here. Snapshot and restore
+// still require --admin-token. POST /api/register stays on the main
+// listener: it is how backends announce themselves in passive mode, and it
+// only registers a port that answers the health probe on loopback, as a
+// scan would.
+func (rt *Router) AdminRoutes() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/api/backends/"); ok && rest != "" {
+			if slug, ok := strings.CutSuffix(rest, "/labels"); ok && slug != "" {
+				rt.handleAPIBackendLabels(w, r, slug)
+				return
+			}
+			if slug, ok := strings.CutSuffix(rest, "/rename"); ok && slug != "" {
+				rt.handleAPIBackendRename(w, r, slug)
+				return
+			}
+			if slug, ok := strings.CutSuffix(rest, "/restart"); ok && slug != "" {
+				rt.handleAPIBackendRestart(w, r, slug)
+				return
+			}
+			rt.handleAPIBackend(w, r, rest)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/backends":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			rt.handleAPIRegisterBackend(w, r)
+		case "/api/scan":
+			rt.handleAPIScan(w, r)
+		case "/api/snapshot":
+			rt.handleAPISnapshot(w, r)
+		case "/api/restore":
+			rt.handleAPIRestore(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// handleAPISnapshot returns the registry state for another router instance
+// to restore, e.g. during a blue/green deployment.
+//
+//	GET /api/snapshot
+func (rt *Router) handleAPISnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !rt.checkAdminToken(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(rt.registry.Snapshot()); err != nil {
+		rt.logger.Debug("failed to write registry snapshot", "error", err)
+	}
+}
+
+// handleAPIRestore replaces the registry state with a snapshot taken by
+// GET /api/snapshot.
+//
+//	POST /api/restore
+func (rt *Router) handleAPIRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !rt.checkAdminToken(w, r) {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSnapshotSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "snapshot too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read snapshot", http.StatusBadRequest)
+		return
+	}
+	if err := rt.registry.Restore(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONResponse(w, map[string]interface{}{
+		"restored": true,
+		"backends": rt.registry.Len(),
+	})
+}
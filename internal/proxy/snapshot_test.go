@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+const testAdminToken = "s3cret"
+
+func newAdminTestRouter(reg *registry.Registry, token string) *Router {
+	cfg := testCfg()
+	cfg.AdminToken = token
+	return New(reg, cfg, testLogger(), nil)
+}
+
+func adminRequest(method, target, body, authz string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if authz != "" {
+		req.Header.Set("Authorization", authz)
+	}
+	return req
+}
+
+func TestAPISnapshotRestore_RoundTrip(t *testing.T) {
+	oldReg := registry.New(30*time.Second, testLogger())
+	oldReg.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	oldReg.UpsertManual(4097, "beta", "/home/user/beta", "manual")
+	oldRouter := newAdminTestRouter(oldReg, testAdminToken)
+
+	w := httptest.NewRecorder()
+	oldRouter.ServeHTTP(w, adminRequest(http.MethodGet, "/api/snapshot", "", "Bearer "+testAdminToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/snapshot = %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	newReg := registry.New(30*time.Second, testLogger())
+	newReg.Upsert(5000, "leftover", "/home/user/leftover", "0.1")
+	newRouter := newAdminTestRouter(newReg, testAdminToken)
+	w2 := httptest.NewRecorder()
+	newRouter.ServeHTTP(w2, adminRequest(http.MethodPost, "/api/restore", w.Body.String(), "bearer "+testAdminToken))
+	if w2.Code != http.StatusOK {
+		t.Fatalf("POST /api/restore = %d: %s", w2.Code, w2.Body.String())
+	}
+
+	if newReg.Len() != 2 {
+		t.Errorf("restored registry has %d backends, want 2", newReg.Len())
+	}
+	if _, ok := newReg.Lookup("leftover"); ok {
+		t.Error("restore should replace existing backends")
+	}
+	if b, ok := newReg.Lookup("beta"); !ok || b.Port != 4097 || !b.Manual {
+		t.Errorf("beta not restored intact: %+v", b)
+	}
+}
+
+func TestAPISnapshotRestore_AdminToken(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		target string
+		authz  string
+		want   int
+	}{
+		{"snapshot without token", testAdminToken, "GET", "/api/snapshot", "", http.StatusUnauthorized},
+		{"restore without token", testAdminToken, "POST", "/api/restore", "", http.StatusUnauthorized},
+		{"wrong token", testAdminToken, "GET", "/api/snapshot", "Bearer nope", http.StatusUnauthorized},
+		{"basic scheme", testAdminToken, "GET", "/api/snapshot", "Basic " + testAdminToken, http.StatusUnauthorized},
+		{"admin API disabled", "", "GET", "/api/snapshot", "Bearer " + testAdminToken, http.StatusForbidden},
+		{"wrong method", testAdminToken, "POST", "/api/snapshot", "Bearer " + testAdminToken, http.StatusMethodNotAllowed},
+		{"invalid snapshot", testAdminToken, "POST", "/api/restore", "Bearer " + testAdminToken, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newAdminTestRouter(reg, tt.token).ServeHTTP(w, adminRequest(tt.method, tt.target, "{", tt.authz))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 should carry WWW-Authenticate")
+			}
+		})
+	}
+	if _, ok := reg.Lookup("alpha"); !ok {
+		t.Error("rejected requests must not change the registry")
+	}
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many recent request latencies each backend keeps for
+// the p99 estimate.
+const latencyWindow = 1024
+
+// BackendStats accumulates proxying statistics for one slug. It is safe for
+// concurrent use.
+type BackendStats struct {
+	mu           sync.Mutex
+	requests     int64
+	errors       int64
+	bytesIn      int64
+	bytesOut     int64
+	totalLatency time.Duration
+	latencies    []time.Duration // ring of the last latencyWindow samples
+	next         int
+}
+
+// ProxyStats is the JSON snapshot served by GET /api/backends/{slug}/proxy-stats.
+type ProxyStats struct {
+	RequestsTotal int64   `json:"requests_total"`
+	ErrorsTotal   int64   `json:"errors_total"`
+	BytesIn       int64   `json:"bytes_in"`
+	BytesOut      int64   `json:"bytes_out"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
+	P99LatencyMs  float64 `json:"p99_latency_ms"`
+}
+
+// Record adds one proxied request. Responses with a 5xx status count as errors.
+func (s *BackendStats) Record(status int, bytesIn, bytesOut int64, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if status >= http.StatusInternalServerError {
+		s.errors++
+	}
+	s.bytesIn += bytesIn
+	s.bytesOut += bytesOut
+	s.totalLatency += latency
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, latency)
+		return
+	}
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % latencyWindow
+}
+
+// Snapshot returns the current counters.
+func (s *BackendStats) Snapshot() ProxyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := ProxyStats{
+		RequestsTotal: s.requests,
+		ErrorsTotal:   s.errors,
+		BytesIn:       s.bytesIn,
+		BytesOut:      s.bytesOut,
+	}
+	if s.requests == 0 {
+		return snap
+	}
+	snap.AvgLatencyMs = durationMs(s.totalLatency / time.Duration(s.requests))
+
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+	idx := (len(sorted)*99 + 99) / 100 // ceil(0.99 * n), 1-based
+	snap.P99LatencyMs = durationMs(sorted[idx-1])
+	return snap
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requestCount returns how many requests have been proxied to slug, for
+// registry.Registry.TopN.
+func (rt *Router) requestCount(slug string) int64 {
+	rt.statsMu.Lock()
+	s, ok := rt.stats[slug]
+	rt.statsMu.Unlock()
+	if !ok {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// statsFor returns the stats for slug, creating them on first use.
+func (rt *Router) statsFor(slug string) *BackendStats {
+	rt.statsMu.Lock()
+	defer rt.statsMu.Unlock()
+
+	s, ok := rt.stats[slug]
+	if !ok {
+		s = &BackendStats{}
+		rt.stats[slug] = s
+	}
+	return s
+}
+
+// countingBody counts request body bytes read by the reverse proxy.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// handleAPIBackendProxyStats returns the proxying statistics for a slug.
+//
+//	GET /api/backends/{slug}/proxy-stats
+func (rt *Router) handleAPIBackendProxyStats(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rt.statsMu.Lock()
+	s, ok := rt.stats[slug]
+	rt.statsMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		if _, registered := rt.registry.Lookup(slug); !registered {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSONResponse(w, map[string]interface{}{
+				"error":  "not_found",
+				"query":  slug,
+				"detail": "no backend registered under this slug",
+			})
+			return
+		}
+		writeJSONResponse(w, ProxyStats{})
+		return
+	}
+	writeJSONResponse(w, s.Snapshot())
+}
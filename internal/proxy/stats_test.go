@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestBackendStats_Percentile(t *testing.T) {
+	var s BackendStats
+	for i := 1; i <= 100; i++ {
+		s.Record(http.StatusOK, 0, 0, time.Duration(i)*time.Millisecond)
+	}
+	snap := s.Snapshot()
+	if snap.P99LatencyMs != 99 {
+		t.Errorf("p99 = %v, want 99", snap.P99LatencyMs)
+	}
+	if snap.AvgLatencyMs != 50.5 {
+		t.Errorf("avg = %v, want 50.5", snap.AvgLatencyMs)
+	}
+}
+
+func TestAPIBackendProxyStats(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+	rt := newTestRouter(reg)
+
+	const n = 5
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("POST", "/proj/echo", strings.NewReader("abc")))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/proj/fail", nil))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/proj/proxy-stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var stats ProxyStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := ProxyStats{RequestsTotal: n + 1, ErrorsTotal: 1, BytesIn: 3 * n, BytesOut: 5 * (n + 1)}
+	if stats.RequestsTotal != want.RequestsTotal || stats.ErrorsTotal != want.ErrorsTotal ||
+		stats.BytesIn != want.BytesIn || stats.BytesOut != want.BytesOut {
+		t.Errorf("stats = %+v, want counters %+v", stats, want)
+	}
+	if stats.AvgLatencyMs <= 0 || stats.P99LatencyMs < stats.AvgLatencyMs {
+		t.Errorf("unexpected latencies: %+v", stats)
+	}
+}
+
+func TestAPIBackendProxyStats_NoTraffic(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "idle", "/home/test/idle", "1.0")
+	rt := newTestRouter(reg)
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/idle/proxy-stats", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"requests_total":0`) {
+		t.Errorf("expected zeroed stats, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/backends/nope/proxy-stats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown slug, got %d", w.Code)
+	}
+}
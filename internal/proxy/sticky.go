@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// stickyCookie holds the backend port a client is pinned to when
+// Config.StickySession is set.
+const stickyCookie = "X-OCR-Sticky"
+
+// selectBackend is lookupBackend with sticky sessions. A request whose
+// sticky cookie names a port still routable under slug goes back to that
+// instance; any other request is balanced as usual and gets a cookie for the
+// instance it landed on. cookiePath scopes the cookie to the route, so
+// path-based clients keep a separate pin per slug. A non-empty user limits
+// the choice to that user's instances, see routable.
+func (rt *Router) selectBackend(w http.ResponseWriter, r *http.Request, slug, user, cookiePath string) (*registry.Backend, bool) {
+	if !rt.cfg.StickySession {
+		return rt.lookupBackend(slug, user)
+	}
+
+	backends := rt.routable(slug, user)
+	if c, err := r.Cookie(stickyCookie); err == nil {
+		if port, err := strconv.Atoi(c.Value); err == nil {
+			for _, b := range backends {
+				if b.Port == port {
+					return b, true
+				}
+			}
+		}
+	}
+
+	backend := rt.selector.Select(slug, backends)
+	if backend == nil {
+		return nil, false
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     stickyCookie,
+		Value:    strconv.Itoa(backend.Port),
+		Path:     cookiePath,
+		MaxAge:   int(rt.cfg.StickyMaxAge / time.Second),
+		HttpOnly: true,
+		Secure:   rt.cfg.TLSEnabled,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return backend, true
+}
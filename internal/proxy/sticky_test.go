@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// stickyGet sends GET /repo/ with the given cookies and returns the body
+// (the serving backend's port) and any sticky cookie set in the response.
+func stickyGet(t *testing.T, rt *Router, cookies ...*http.Cookie) (string, *http.Cookie) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/repo/", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == stickyCookie {
+			return w.Body.String(), c
+		}
+	}
+	return w.Body.String(), nil
+}
+
+func newStickyRouter(t *testing.T, sticky bool) (*Router, *registry.Registry) {
+	t.Helper()
+	reg := registry.New(30*time.Second, testLogger())
+	for i := 0; i < 3; i++ {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, port, _ := net.SplitHostPort(r.Host)
+			_, _ = w.Write([]byte(port))
+		}))
+		t.Cleanup(backend.Close)
+		reg.Upsert(mustPort(t, backend.URL), "repo", "/home/dev"+strconv.Itoa(i)+"/repo", "1.0")
+	}
+	cfg := testCfg()
+	cfg.StickySession = sticky
+	cfg.StickyMaxAge = time.Minute
+	return New(reg, cfg, testLogger(), nil), reg
+}
+
+func TestSticky_RoutesToSameBackend(t *testing.T) {
+	rt, _ := newStickyRouter(t, true)
+
+	first, cookie := stickyGet(t, rt)
+	if cookie == nil {
+		t.Fatal("expected a sticky cookie on the first request")
+	}
+	if cookie.Value != first || cookie.MaxAge != 60 || cookie.Path != "/repo" || !cookie.HttpOnly {
+		t.Errorf("unexpected cookie: %+v (served by %s)", cookie, first)
+	}
+
+	for i := 0; i < 6; i++ {
+		got, again := stickyGet(t, rt, cookie)
+		if got != first {
+			t.Fatalf("request %d: routed to %s, want sticky backend %s", i, got, first)
+		}
+		if again != nil {
+			t.Errorf("request %d: cookie should not be reissued while the backend is up", i)
+		}
+	}
+}
+
+func TestSticky_FallsBackWhenBackendGone(t *testing.T) {
+	rt, reg := newStickyRouter(t, true)
+
+	first, cookie := stickyGet(t, rt)
+	port, _ := strconv.Atoi(first)
+	if !reg.RemoveByPort(port) {
+		t.Fatalf("failed to remove backend %d", port)
+	}
+
+	got, fresh := stickyGet(t, rt, cookie)
+	if got == first {
+		t.Fatalf("request went to removed backend %s", first)
+	}
+	if fresh == nil || fresh.Value != got {
+		t.Fatalf("expected a new sticky cookie for %s, got %+v", got, fresh)
+	}
+	if again, _ := stickyGet(t, rt, fresh); again != got {
+		t.Errorf("expected new pin %s to hold, routed to %s", got, again)
+	}
+}
+
+func TestSticky_Disabled(t *testing.T) {
+	rt, _ := newStickyRouter(t, false)
+
+	hits := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		body, cookie := stickyGet(t, rt, &http.Cookie{Name: stickyCookie, Value: "1"})
+		if cookie != nil {
+			t.Error("no sticky cookie expected without StickySession")
+		}
+		hits[body] = true
+	}
+	if len(hits) != 3 {
+		t.Errorf("expected round-robin across 3 instances, got %v", hits)
+	}
+}
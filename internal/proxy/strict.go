@@ -0,0 +1,55 @@
+package proxy
+
+import "net/http"
+
+// serveStrictFallback serves a path whose first segment matched no backend.
+// Real dashboard assets (e.g. /js/app.js) are still served; anything the
+// dashboard would 404 on gets a JSON unknown_backend error instead, so typos
+// in a slug are obvious.
+func (rt *Router) serveStrictFallback(w http.ResponseWriter, r *http.Request, slug string) {
+	if rt.uiHandler != nil {
+		nf := &notFoundInterceptor{ResponseWriter: w}
+		rt.uiHandler.ServeHTTP(nf, r)
+		if !nf.notFound {
+			return
+		}
+	}
+
+	w.Header().Del("X-Content-Type-Options")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	writeJSONResponse(w, map[string]interface{}{
+		"error": "unknown_backend",
+		"slug":  slug,
+	})
+}
+
+// notFoundInterceptor passes a response through unless its status is 404,
+// in which case the status and body are swallowed for the caller to replace.
+type notFoundInterceptor struct {
+	http.ResponseWriter
+	wroteHeader bool
+	notFound    bool
+}
+
+func (nf *notFoundInterceptor) WriteHeader(status int) {
+	if nf.wroteHeader {
+		return
+	}
+	nf.wroteHeader = true
+	if status == http.StatusNotFound {
+		nf.notFound = true
+		return
+	}
+	nf.ResponseWriter.WriteHeader(status)
+}
+
+func (nf *notFoundInterceptor) Write(p []byte) (int, error) {
+	if !nf.wroteHeader {
+		nf.WriteHeader(http.StatusOK)
+	}
+	if nf.notFound {
+		return len(p), nil
+	}
+	return nf.ResponseWriter.Write(p)
+}
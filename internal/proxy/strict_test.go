@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func newStrictTestRouter(strict bool) *Router {
+	ui := http.FileServer(http.FS(fstest.MapFS{
+		"index.html": {Data: []byte("<html>OpenCode Router</html>")},
+		"js/app.js":  {Data: []byte("console.log('ok')")},
+	}))
+	cfg := testCfg()
+	cfg.StrictMode = strict
+	return New(registry.New(30*time.Second, testLogger()), cfg, testLogger(), ui)
+}
+
+func TestStrictMode_UnknownSlug(t *testing.T) {
+	w := httptest.NewRecorder()
+	newStrictTestRouter(true).ServeHTTP(w, httptest.NewRequest("GET", "/wrongslug/api/v1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON body: %v", err)
+	}
+	if body["error"] != "unknown_backend" || body["slug"] != "wrongslug" {
+		t.Errorf("unexpected body: %v", body)
+	}
+}
+
+func TestStrictMode_NonStrictFallsThrough(t *testing.T) {
+	w := httptest.NewRecorder()
+	newStrictTestRouter(false).ServeHTTP(w, httptest.NewRequest("GET", "/wrongslug/api/v1", nil))
+	if ct := w.Header().Get("Content-Type"); ct == "application/json" {
+		t.Errorf("expected dashboard handler response, got JSON: %s", w.Body.String())
+	}
+}
+
+func TestStrictMode_DashboardStillServed(t *testing.T) {
+	rt := newStrictTestRouter(true)
+	for _, path := range []string{"/", "/js/app.js"} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200 in strict mode, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/api/unknown", nil))
+	if w.Code == http.StatusNotFound && w.Header().Get("Content-Type") == "application/json" {
+		t.Errorf("expected /api/* to bypass strict mode, got %s", w.Body.String())
+	}
+}
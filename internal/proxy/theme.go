@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/version"
+)
+
+// Dashboard themes. The dashboard template renders the chosen one as
+// <body data-theme="...">, and styles.css keys its colour variables off it.
+const (
+	themeDark    = "dark"
+	themeLight   = "light"
+	defaultTheme = themeDark
+)
+
+// themeCookie remembers the theme last picked with ?theme=.
+const (
+	themeCookie = "ocr-theme"
+	themeMaxAge = 365 * 24 * time.Hour
+)
+
+// dashboardData is what the dashboard template is executed with.
+type dashboardData struct {
+	Theme     string
+	Version   string
+	Commit    string
+	BuildTime string
+}
+
+// WithDashboardTemplate renders the dashboard root page from tmpl instead
+// of passing it to the UI handler, so the page can carry the theme. Other
+// dashboard assets are still served by the UI handler.
+func WithDashboardTemplate(tmpl *template.Template) Option {
+	return func(rt *Router) {
+		rt.dashboard = tmpl
+	}
+}
+
+func validTheme(theme string) bool {
+	return theme == themeDark || theme == themeLight
+}
+
+// resolveTheme picks the dashboard theme for r: a valid ?theme= wins and is
+// stored in the theme cookie, then the cookie, then the default.
+func (rt *Router) resolveTheme(w http.ResponseWriter, r *http.Request) string {
+	if theme := r.URL.Query().Get("theme"); validTheme(theme) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     themeCookie,
+			Value:    theme,
+			Path:     "/",
+			MaxAge:   int(themeMaxAge / time.Second),
+			Secure:   rt.cfg.TLSEnabled,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return theme
+	}
+	if c, err := r.Cookie(themeCookie); err == nil && validTheme(c.Value) {
+		return c.Value
+	}
+	return defaultTheme
+}
+
+// serveDashboardPage renders the dashboard template with the resolved theme.
+func (rt *Router) serveDashboardPage(w http.ResponseWriter, r *http.Request) {
+	data := dashboardData{
+		Theme:     rt.resolveTheme(w, r),
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildTime: version.BuildTime,
+	}
+
+	buf, err := rt.renderDashboard(data)
+	if errors.Is(err, errDashboardTimeout) {
+		rt.logger.Warn("dashboard render timed out; serving plain-text fallback", "timeout", rt.cfg.DashboardTimeout)
+		rt.serveDashboardFallback(w, r)
+		return
+	}
+	if err != nil {
+		rt.logger.Error("failed to render dashboard", "error", err)
+		http.Error(w, "failed to render dashboard", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf)
+	}
+}
+
+// errDashboardTimeout is returned by renderDashboard past DashboardTimeout.
+var errDashboardTimeout = errors.New("dashboard render timed out")
+
+// renderDashboard executes the dashboard template with data, giving up
+// after DashboardTimeout. A template that outlives the timeout keeps
+// running in the background until it returns; its output is dropped.
+func (rt *Router) renderDashboard(data dashboardData) ([]byte, error) {
+	type result struct {
+		out []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var buf bytes.Buffer
+		err := rt.dashboard.Execute(&buf, data)
+		done <- result{buf.Bytes(), err}
+	}()
+
+	var timeout <-chan time.Time
+	if rt.cfg.DashboardTimeout > 0 {
+		timer := time.NewTimer(rt.cfg.DashboardTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-timeout:
+		return nil, errDashboardTimeout
+	}
+}
+
+// serveDashboardFallback lists the registered backends as plain text, for
+// when the dashboard template cannot be rendered in time.
+func (rt *Router) serveDashboardFallback(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	buf.WriteString("OpenCodeRouter " + version.Version + "\n\n")
+	backends := rt.registry.TopN(0, registry.SortByName)
+	if len(backends) == 0 {
+		buf.WriteString("No backends registered.\n")
+	}
+	for _, b := range backends {
+		fmt.Fprintf(&buf, "%s\t/%s/\tport %d\t%s\n", b.Slug, b.Slug, b.Port, b.ProjectPath)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
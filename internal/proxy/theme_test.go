@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func newThemeTestRouter() *Router {
+	ui := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("asset " + r.URL.Path))
+	})
+	tmpl := template.Must(template.New("index").Parse(`{{.Theme}}`))
+	return New(registry.New(30*time.Second, testLogger()), testCfg(), testLogger(), ui,
+		WithDashboardTemplate(tmpl))
+}
+
+func themeCookieFrom(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == themeCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestDashboardTheme_QueryParamSetsCookie(t *testing.T) {
+	rt := newThemeTestRouter()
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/?theme=light", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Body.String(); got != "light" {
+		t.Errorf("template Theme = %q, want light", got)
+	}
+	c := themeCookieFrom(w)
+	if c == nil {
+		t.Fatal("expected theme cookie to be set")
+	}
+	if c.Value != "light" || c.Path != "/" || c.MaxAge != int(themeMaxAge/time.Second) {
+		t.Errorf("unexpected cookie: %+v", c)
+	}
+}
+
+func TestDashboardTheme_Resolution(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		cookie string
+		want   string
+		setsIt bool
+	}{
+		{"default", "/", "", "dark", false},
+		{"cookie", "/", "light", "light", false},
+		{"query overrides cookie", "/?theme=dark", "light", "dark", true},
+		{"invalid query keeps cookie", "/?theme=neon", "light", "light", false},
+		{"invalid cookie", "/", "neon", "dark", false},
+	}
+
+	rt := newThemeTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: themeCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Theme = %q, want %q", got, tt.want)
+			}
+			if set := themeCookieFrom(w) != nil; set != tt.setsIt {
+				t.Errorf("cookie set = %v, want %v", set, tt.setsIt)
+			}
+		})
+	}
+}
+
+func TestDashboardTheme_AssetsUseUIHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	newThemeTestRouter().ServeHTTP(w, httptest.NewRequest("GET", "/styles.css?theme=light", nil))
+	if !strings.HasPrefix(w.Body.String(), "asset /styles.css") {
+		t.Errorf("expected asset from UI handler, got %q", w.Body.String())
+	}
+	if themeCookieFrom(w) != nil {
+		t.Error("assets should not set the theme cookie")
+	}
+}
+
+func TestDashboard_RenderTimeoutFallsBackToPlainText(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	tmpl := template.Must(template.New("index").Funcs(template.FuncMap{
+		"slow": func() string {
+			select {
+			case <-release:
+			case <-time.After(10 * time.Second):
+			}
+			return ""
+		},
+	}).Parse(`{{slow}}{{.Theme}}`))
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(4096, "proj", "/home/test/proj", "1.0")
+	reg.Upsert(4097, "alpha", "/home/test/alpha", "1.0")
+	cfg := testCfg()
+	cfg.DashboardTimeout = 50 * time.Millisecond
+	rt := New(reg, cfg, testLogger(), nil, WithDashboardTemplate(tmpl))
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("fallback took %s, want about the 50ms timeout", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "proj\t/proj/\tport 4096") {
+		t.Errorf("fallback should list the backends, got %q", body)
+	} else if strings.Index(body, "alpha\t") > strings.Index(body, "proj\t") {
+		t.Errorf("fallback should list the backends by name, got %q", body)
+	}
+}
+
+func TestDashboard_RendersWithinTimeout(t *testing.T) {
+	cfg := testCfg()
+	cfg.DashboardTimeout = time.Second
+	tmpl := template.Must(template.New("index").Parse(`<p>{{.Theme}}</p>`))
+	rt := New(registry.New(30*time.Second, testLogger()), cfg, testLogger(), nil, WithDashboardTemplate(tmpl))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") || w.Body.String() != "<p>dark</p>" {
+		t.Errorf("got %q (%s), want the rendered template", w.Body.String(), ct)
+	}
+}
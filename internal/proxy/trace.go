@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// traceBodyLimit caps how much of each body a trace records, so a
+// long-lived event stream cannot grow a transaction without bound.
+const traceBodyLimit = 1 << 20
+
+// WithTrace appends a dump of every proxied request and response for slugs
+// matching pattern (path.Match syntax, empty matches all) to w. Each
+// transaction is written in one piece once the response has been proxied:
+//
+//	---REQ--- 2026-01-02T15:04:05.000Z myproject
+//	POST /session HTTP/1.1
+//	Host: myproject-alice.local
+//	Content-Type: application/json
+//
+//	{"title":"x"}
+//	---RESP--- 2026-01-02T15:04:05.120Z myproject
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	{"id":"ses_1"}
+//
+// Bodies are cut at 1 MiB. WebSocket tunnels are not traced. Passing a nil
+// w disables tracing.
+func WithTrace(w io.Writer, pattern string) Option {
+	return func(rt *Router) {
+		if w == nil {
+			rt.trace = nil
+			return
+		}
+		rt.trace = &requestTracer{w: w, pattern: pattern}
+	}
+}
+
+// requestTracer writes transactions for WithTrace.
+type requestTracer struct {
+	mu      sync.Mutex // serializes whole transactions in w
+	w       io.Writer
+	pattern string
+}
+
+// traces reports whether requests to slug are traced.
+func (t *requestTracer) traces(slug string) bool {
+	if t == nil {
+		return false
+	}
+	if t.pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(t.pattern, slug)
+	return ok
+}
+
+// start begins the trace of r, teeing its body as it is sent upstream.
+func (t *requestTracer) start(slug string, r *http.Request) *traceRecord {
+	tr := &traceRecord{slug: slug, started: time.Now()}
+	fmt.Fprintf(&tr.head, "%s %s %s\r\n", r.Method, r.URL.RequestURI(), r.Proto)
+	fmt.Fprintf(&tr.head, "Host: %s\r\n", r.Host)
+	r.Header.Write(&tr.head)
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, &tr.reqBody), Closer: r.Body}
+	}
+	return tr
+}
+
+// finish writes tr to the trace file.
+func (t *requestTracer) finish(tr *traceRecord) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "---REQ--- %s %s\n", tr.started.UTC().Format(traceTimeFormat), tr.slug)
+	buf.Write(tr.head.Bytes())
+	buf.WriteString("\r\n")
+	tr.reqBody.writeTo(&buf)
+	if tr.responded {
+		fmt.Fprintf(&buf, "---RESP--- %s %s\n", tr.respondedAt.UTC().Format(traceTimeFormat), tr.slug)
+		buf.Write(tr.respHead.Bytes())
+		buf.WriteString("\r\n")
+		tr.respBody.writeTo(&buf)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.w.Write(buf.Bytes())
+}
+
+const traceTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// traceRecord collects one request and its response.
+type traceRecord struct {
+	slug    string
+	started time.Time
+	head    bytes.Buffer
+	reqBody traceBody
+
+	responded   bool
+	respondedAt time.Time
+	respHead    bytes.Buffer
+	respBody    traceBody
+}
+
+// response records resp's head and tees its body as it is proxied.
+func (tr *traceRecord) response(resp *http.Response) {
+	tr.responded = true
+	tr.respondedAt = time.Now()
+	fmt.Fprintf(&tr.respHead, "%s %s\r\n", resp.Proto, resp.Status)
+	resp.Header.Write(&tr.respHead)
+	resp.Body = teeReadCloser{Reader: io.TeeReader(resp.Body, &tr.respBody), Closer: resp.Body}
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// traceBody keeps the first traceBodyLimit bytes written to it. The
+// transport may still be reading a request body when the response ends,
+// hence the lock.
+type traceBody struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *traceBody) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := traceBodyLimit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// writeTo appends the body to buf, ending it with a newline.
+func (b *traceBody) writeTo(buf *bytes.Buffer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	buf.Write(b.buf.Bytes())
+	if b.truncated {
+		fmt.Fprintf(buf, "\n[truncated at %d bytes]", traceBodyLimit)
+	}
+	if b.buf.Len() > 0 || b.truncated {
+		buf.WriteString("\n")
+	}
+}
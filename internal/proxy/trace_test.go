@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// newTraceRouter returns a router tracing pattern into a file, with
+// backends "proj" and "other" that answer with their request body.
+func newTraceRouter(t *testing.T, pattern string) (*Router, string) {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Backend", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"got":` + string(body) + `}`))
+	}))
+	t.Cleanup(backend.Close)
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+	other := httptest.NewServer(backend.Config.Handler)
+	t.Cleanup(other.Close)
+	reg.Upsert(mustPort(t, other.URL), "other", "/home/test/other", "1.0")
+
+	path := filepath.Join(t.TempDir(), "trace.http")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return New(reg, testCfg(), testLogger(), http.NotFoundHandler(), WithTrace(f, pattern)), path
+}
+
+func readTrace(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTrace_RequestAndResponse(t *testing.T) {
+	rt, path := newTraceRouter(t, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/proj/session?x=1", strings.NewReader(`{"title":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent", "tester")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+
+	trace := readTrace(t, path)
+	for _, want := range []string{
+		"---REQ--- ",
+		"POST /proj/session?x=1 HTTP/1.1\r\n",
+		"X-Agent: tester\r\n",
+		`{"title":"hello"}`,
+		"---RESP--- ",
+		"HTTP/1.1 201 Created\r\n",
+		"X-Backend: yes\r\n",
+		`{"got":{"title":"hello"}}`,
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace is missing %q:\n%s", want, trace)
+		}
+	}
+	if strings.Index(trace, "---REQ---") > strings.Index(trace, "---RESP---") {
+		t.Errorf("response written before request:\n%s", trace)
+	}
+}
+
+func TestTrace_SlugPattern(t *testing.T) {
+	rt, path := newTraceRouter(t, "pro*")
+
+	for _, p := range []string{"/other/a", "/proj/b"} {
+		rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	trace := readTrace(t, path)
+	if !strings.Contains(trace, "GET /proj/b HTTP/1.1") {
+		t.Errorf("trace is missing the matching slug:\n%s", trace)
+	}
+	if strings.Contains(trace, "/other/a") {
+		t.Errorf("trace contains a slug that does not match:\n%s", trace)
+	}
+}
+
+func TestTrace_TruncatesBodies(t *testing.T) {
+	rt, path := newTraceRouter(t, "")
+
+	body := strings.Repeat("a", traceBodyLimit+10)
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/proj/", strings.NewReader(body)))
+
+	trace := readTrace(t, path)
+	if strings.Contains(trace, body) {
+		t.Error("trace holds the whole oversized body")
+	}
+	if !strings.Contains(trace, "[truncated at 1048576 bytes]") {
+		t.Error("trace does not mark the truncated body")
+	}
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by the proxy.
+const tracerName = "opencoderouter/internal/proxy"
+
+// traceContext carries W3C traceparent/tracestate between the client, the
+// router and the upstream backend.
+var traceContext = propagation.TraceContext{}
+
+// startProxySpan starts a server span for a proxied request, continuing any
+// trace the client sent in its traceparent header.
+func (rt *Router) startProxySpan(r *http.Request, slug string) (context.Context, trace.Span) {
+	ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return rt.tracer.Start(ctx, "proxy "+slug,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("slug", slug),
+			attribute.String("method", r.Method),
+			attribute.String("path", r.URL.Path),
+		),
+	)
+}
+
+// endProxySpan records the response status on span. 5xx responses mark the
+// span as failed.
+func endProxySpan(span trace.Span, status int) {
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
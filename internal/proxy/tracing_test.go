@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestServeHTTP_TracingSpanAndPropagation(t *testing.T) {
+	var gotTraceparent string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "traced", "/home/test/traced", "1.0")
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(t.Context()) }()
+
+	rt := New(reg, testCfg(), testLogger(), nil, WithTracerProvider(tp))
+
+	const clientTrace = "0af7651916cd43dd8448eb211c80319c"
+	req := httptest.NewRequest(http.MethodGet, "/traced/session", nil)
+	req.Header.Set("traceparent", "00-"+clientTrace+"-b7ad6b7169203331-01")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if got := span.SpanContext.TraceID().String(); got != clientTrace {
+		t.Errorf("span trace ID = %s, want client trace %s", got, clientTrace)
+	}
+	want := map[attribute.Key]attribute.Value{
+		"slug":             attribute.StringValue("traced"),
+		"method":           attribute.StringValue(http.MethodGet),
+		"path":             attribute.StringValue("/traced/session"),
+		"http.status_code": attribute.IntValue(http.StatusTeapot),
+	}
+	for _, kv := range span.Attributes {
+		if v, ok := want[kv.Key]; ok {
+			if kv.Value != v {
+				t.Errorf("attribute %s = %v, want %v", kv.Key, kv.Value.Emit(), v.Emit())
+			}
+			delete(want, kv.Key)
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("missing span attributes: %v", want)
+	}
+
+	wantParent := "00-" + clientTrace + "-" + span.SpanContext.SpanID().String() + "-01"
+	if gotTraceparent != wantParent {
+		t.Errorf("upstream traceparent = %q, want %q", gotTraceparent, wantParent)
+	}
+}
+
+func TestServeHTTP_TracingMarksBackendErrors(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(1, "down", "/home/test/down", "1.0")
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(t.Context()) }()
+
+	rt := New(reg, testCfg(), testLogger(), nil, WithTracerProvider(tp))
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/down/", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("expected error status for 502, got %v", spans[0].Status.Code)
+	}
+}
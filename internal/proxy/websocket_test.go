@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+const wsTestKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// wsAccept computes Sec-WebSocket-Accept for key (RFC 6455 section 4.2.2).
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// writeWSFrame writes a final text frame; payloads stay under 126 bytes.
+func writeWSFrame(w io.Writer, payload string, masked bool) error {
+	frame := []byte{0x81, byte(len(payload))}
+	data := []byte(payload)
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		frame[1] |= 0x80
+		frame = append(frame, mask...)
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+// readWSFrame reads a frame written by writeWSFrame and returns its payload.
+func readWSFrame(r io.Reader) (string, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return "", err
+	}
+	var mask [4]byte
+	if head[1]&0x80 != 0 {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return "", err
+		}
+	}
+	data := make([]byte, head[1]&0x7f)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	if head[1]&0x80 != 0 {
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	return string(data), nil
+}
+
+// wsEchoBackend completes the handshake and echoes every text frame, after
+// reporting the request it received.
+func wsEchoBackend(t *testing.T, requests chan<- *http.Request) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			wsAccept(r.Header.Get("Sec-WebSocket-Key")))
+		rw.Flush()
+		for {
+			msg, err := readWSFrame(rw)
+			if err != nil {
+				return
+			}
+			if err := writeWSFrame(rw, "echo: "+msg, false); err != nil {
+				return
+			}
+			rw.Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// wsHandshake dials addr, sends an upgrade request for path and returns the
+// connection once a 101 has been read.
+func wsHandshake(t *testing.T, addr, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: %s\r\n\r\n",
+		path, addr, wsTestKey)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != wsAccept(wsTestKey) {
+		t.Fatalf("Sec-WebSocket-Accept = %q, want %q", got, wsAccept(wsTestKey))
+	}
+	return conn, reader
+}
+
+func assertWSEcho(t *testing.T, conn net.Conn, reader *bufio.Reader, msgs ...string) {
+	t.Helper()
+	for _, msg := range msgs {
+		if err := writeWSFrame(conn, msg, true); err != nil {
+			t.Fatalf("write frame: %v", err)
+		}
+		got, err := readWSFrame(reader)
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		if got != "echo: "+msg {
+			t.Fatalf("echo = %q, want %q", got, "echo: "+msg)
+		}
+	}
+}
+
+func TestWebSocketProxy_Echo(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	backend := wsEchoBackend(t, requests)
+	target, _ := url.Parse(backend.URL)
+
+	srv := httptest.NewServer(WebSocketProxy(target))
+	defer srv.Close()
+
+	conn, reader := wsHandshake(t, strings.TrimPrefix(srv.URL, "http://"), "/chat?room=1")
+	assertWSEcho(t, conn, reader, "hello", "again")
+
+	r := <-requests
+	if r.URL.RequestURI() != "/chat?room=1" {
+		t.Errorf("backend request URI = %q, want /chat?room=1", r.URL.RequestURI())
+	}
+	if r.Host != target.Host {
+		t.Errorf("backend Host = %q, want %q", r.Host, target.Host)
+	}
+}
+
+func TestWebSocketProxy_RequiresUpgrade(t *testing.T) {
+	target, _ := url.Parse("http://127.0.0.1:1")
+	w := httptest.NewRecorder()
+	WebSocketProxy(target).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestWebSocketProxy_BackendDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	target, _ := url.Parse("http://" + addr)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	WebSocketProxy(target).ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", w.Code)
+	}
+}
+
+func TestServeHTTP_WebSocketOnPathRoute(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	backend := wsEchoBackend(t, requests)
+
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(mustPort(t, backend.URL), "proj", "/home/test/proj", "1.0")
+	srv := httptest.NewServer(newTestRouter(reg))
+	defer srv.Close()
+
+	conn, reader := wsHandshake(t, strings.TrimPrefix(srv.URL, "http://"), "/proj/events")
+	assertWSEcho(t, conn, reader, "ping")
+
+	r := <-requests
+	if r.URL.Path != "/events" {
+		t.Errorf("backend path = %q, want /events", r.URL.Path)
+	}
+	if r.Header.Get("X-Forwarded-For") != "127.0.0.1" || r.Header.Get("X-Forwarded-Proto") != "http" {
+		t.Errorf("X-Forwarded headers = %v", r.Header)
+	}
+}
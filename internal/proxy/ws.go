@@ -55,7 +55,7 @@ func (rt *Router) handleWSProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	backend, found := rt.registry.Lookup(slug)
+	backend, found := rt.selectBackend(w, r, slug, "", "/ws/"+slug)
 	if !found {
 		http.Error(w, fmt.Sprintf("backend %q not found", slug), http.StatusNotFound)
 		return
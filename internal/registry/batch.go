@@ -0,0 +1,33 @@
+package registry
+
+// UpsertEntry is one backend to register with BatchUpsert.
+type UpsertEntry struct {
+	Port        int
+	ProjectName string
+	ProjectPath string
+	Version     string
+}
+
+// BatchUpsert registers entries like Upsert, in order, under a single
+// acquisition of the write lock. Subscribers get one EventBatch holding all
+// the resulting changes rather than an event per entry, or nothing if
+// there were none. The result reports, per entry, whether it was new.
+func (r *Registry) BatchUpsert(entries []UpsertEntry) []bool {
+	isNew := make([]bool, len(entries))
+	if len(entries) == 0 {
+		return isNew
+	}
+
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	start := len(r.pending)
+	for i, e := range entries {
+		isNew[i] = r.upsertLocked(e.Port, e.ProjectName, e.ProjectPath, e.Version, false)
+	}
+	if changes := r.pending[start:]; len(changes) > 0 {
+		batch := RegistryEvent{Type: EventBatch, Events: append([]RegistryEvent(nil), changes...)}
+		r.pending = append(r.pending[:start], batch)
+	}
+	return isNew
+}
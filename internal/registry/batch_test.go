@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchUpsert(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	events, cancel := r.Subscribe()
+	defer cancel()
+
+	isNew := r.BatchUpsert([]UpsertEntry{
+		{Port: 4096, ProjectName: "alpha", ProjectPath: "/home/user/alpha", Version: "1.1"},
+		{Port: 4097, ProjectName: "beta", ProjectPath: "/home/user/beta", Version: "1.0"},
+		{Port: 4098, ProjectName: "gamma", ProjectPath: "/home/user/gamma", Version: "1.0"},
+	})
+	if len(isNew) != 3 || isNew[0] || !isNew[1] || !isNew[2] {
+		t.Fatalf("BatchUpsert = %v, want [false true true]", isNew)
+	}
+	if b, _ := r.Lookup("alpha"); b.Version != "1.1" {
+		t.Errorf("existing backend not updated: version %q", b.Version)
+	}
+	if r.Len() != 3 {
+		t.Errorf("Len = %d, want 3", r.Len())
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != EventBatch || len(ev.Events) != 3 {
+			t.Fatalf("got %s event with %d changes, want one batch of 3", ev.Type, len(ev.Events))
+		}
+		for i, want := range []struct{ typ, slug string }{
+			{EventUpdated, "alpha"}, {EventAdded, "beta"}, {EventAdded, "gamma"},
+		} {
+			if got := ev.Events[i]; got.Type != want.typ || got.Slug != want.slug {
+				t.Errorf("change %d = %s %s, want %s %s", i, got.Type, got.Slug, want.typ, want.slug)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected second event: %s %s", ev.Type, ev.Slug)
+	default:
+	}
+}
+
+func TestBatchUpsert_Empty(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	events, cancel := r.Subscribe()
+	defer cancel()
+
+	if got := r.BatchUpsert(nil); len(got) != 0 {
+		t.Errorf("BatchUpsert(nil) = %v", got)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("empty batch published %s", ev.Type)
+	default:
+	}
+}
+
+// scanners and portsPerScanner shape the upsert benchmarks: each of the
+// concurrent scanners registers its own block of ports.
+const (
+	scanners        = 100
+	portsPerScanner = 10
+)
+
+func benchEntries(scanner int) []UpsertEntry {
+	entries := make([]UpsertEntry, portsPerScanner)
+	for i := range entries {
+		port := 10000 + scanner*portsPerScanner + i
+		entries[i] = UpsertEntry{
+			Port:        port,
+			ProjectName: fmt.Sprintf("p%d", port),
+			ProjectPath: fmt.Sprintf("/bench/p%d", port),
+			Version:     "1.0",
+		}
+	}
+	return entries
+}
+
+func runScanners(b *testing.B, upsert func(r *Registry, entries []UpsertEntry)) {
+	r := New(30*time.Second, testLogger())
+	all := make([][]UpsertEntry, scanners)
+	for i := range all {
+		all[i] = benchEntries(i)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var wg sync.WaitGroup
+		for _, entries := range all {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				upsert(r, entries)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkUpsert_PerEntry(b *testing.B) {
+	runScanners(b, func(r *Registry, entries []UpsertEntry) {
+		for _, e := range entries {
+			r.Upsert(e.Port, e.ProjectName, e.ProjectPath, e.Version)
+		}
+	})
+}
+
+func BenchmarkUpsert_Batch(b *testing.B) {
+	runScanners(b, func(r *Registry, entries []UpsertEntry) {
+		r.BatchUpsert(entries)
+	})
+}
@@ -0,0 +1,48 @@
+package registry
+
+// Diff compares a previously seen list of backends against the registry's
+// current state. added holds backends registered since, removed those no
+// longer registered, and updated those whose port, version or project path
+// changed; added and updated are the registry's current copies. Backends are
+// matched by slug, and only the first instance of a slug in each list is
+// considered (All lists a slug's instances in registration order).
+func (r *Registry) Diff(previous []*Backend) (added, removed, updated []*Backend) {
+	return DiffBackends(previous, r.All())
+}
+
+// DiffBackends reports how current differs from previous, as described for
+// Registry.Diff. The result slices are nil when there is nothing to report
+// and follow the order of the list they were taken from.
+func DiffBackends(previous, current []*Backend) (added, removed, updated []*Backend) {
+	before := firstBySlug(previous)
+	after := firstBySlug(current)
+
+	for _, b := range current {
+		old, ok := before[b.Slug]
+		switch {
+		case after[b.Slug] != b:
+			// A later instance of a slug; only the first counts.
+		case !ok:
+			added = append(added, b)
+		case old.Port != b.Port || old.Version != b.Version || old.ProjectPath != b.ProjectPath:
+			updated = append(updated, b)
+		}
+	}
+	for _, b := range previous {
+		if _, ok := after[b.Slug]; !ok && before[b.Slug] == b {
+			removed = append(removed, b)
+		}
+	}
+	return added, removed, updated
+}
+
+// firstBySlug indexes backends by slug, keeping the first of each.
+func firstBySlug(backends []*Backend) map[string]*Backend {
+	m := make(map[string]*Backend, len(backends))
+	for _, b := range backends {
+		if _, ok := m[b.Slug]; !ok {
+			m[b.Slug] = b
+		}
+	}
+	return m
+}
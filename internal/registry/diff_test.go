@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func slugsOf(backends []*Backend) []string {
+	slugs := make([]string, 0, len(backends))
+	for _, b := range backends {
+		slugs = append(slugs, b.Slug)
+	}
+	return slugs
+}
+
+func TestDiff(t *testing.T) {
+	alpha := &Backend{Slug: "alpha", Port: 4096, ProjectPath: "/home/user/alpha", Version: "1.0"}
+	beta := &Backend{Slug: "beta", Port: 4097, ProjectPath: "/home/user/beta", Version: "1.0"}
+
+	tests := []struct {
+		name        string
+		previous    []*Backend
+		setup       func(r *Registry)
+		wantAdded   []string
+		wantRemoved []string
+		wantUpdated []string
+	}{
+		{
+			name:     "no change",
+			previous: []*Backend{alpha, beta},
+			setup: func(r *Registry) {
+				r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+				r.Upsert(4097, "beta", "/home/user/beta", "1.0")
+			},
+		},
+		{
+			name:     "pure add",
+			previous: []*Backend{alpha},
+			setup: func(r *Registry) {
+				r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+				r.Upsert(4097, "beta", "/home/user/beta", "1.0")
+			},
+			wantAdded: []string{"beta"},
+		},
+		{
+			name:     "pure remove",
+			previous: []*Backend{alpha, beta},
+			setup: func(r *Registry) {
+				r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+			},
+			wantRemoved: []string{"beta"},
+		},
+		{
+			name:     "pure update",
+			previous: []*Backend{alpha, beta},
+			setup: func(r *Registry) {
+				r.Upsert(4096, "alpha", "/home/user/alpha", "1.1") // version
+				r.Upsert(4098, "beta", "/home/user/beta", "1.0")   // port
+			},
+			wantUpdated: []string{"alpha", "beta"},
+		},
+		{
+			name:     "mixed",
+			previous: []*Backend{alpha, beta},
+			setup: func(r *Registry) {
+				r.Upsert(4096, "alpha", "/home/user/alpha", "2.0")
+				r.Upsert(4099, "gamma", "/home/user/gamma", "1.0")
+			},
+			wantAdded:   []string{"gamma"},
+			wantRemoved: []string{"beta"},
+			wantUpdated: []string{"alpha"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(time.Minute, testLogger())
+			tt.setup(r)
+			added, removed, updated := r.Diff(tt.previous)
+			for _, c := range []struct {
+				kind      string
+				got, want []string
+			}{
+				{"added", slugsOf(added), tt.wantAdded},
+				{"removed", slugsOf(removed), tt.wantRemoved},
+				{"updated", slugsOf(updated), tt.wantUpdated},
+			} {
+				if !sameSet(c.got, c.want) {
+					t.Errorf("%s = %v, want %v", c.kind, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffBackends_ProjectPathAndDuplicates(t *testing.T) {
+	previous := []*Backend{{Slug: "alpha", Port: 4096, ProjectPath: "/a"}}
+	current := []*Backend{
+		{Slug: "alpha", Port: 4096, ProjectPath: "/b"},
+		{Slug: "alpha", Port: 4100, ProjectPath: "/c"}, // second instance, ignored
+	}
+	added, removed, updated := DiffBackends(previous, current)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("added %v, removed %v, want none", slugsOf(added), slugsOf(removed))
+	}
+	if len(updated) != 1 || updated[0] != current[0] {
+		t.Errorf("updated = %+v, want the first alpha instance", updated)
+	}
+
+	if a, r, u := DiffBackends(nil, nil); a != nil || r != nil || u != nil {
+		t.Errorf("empty inputs should give nil slices, got %v %v %v", a, r, u)
+	}
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package registry
+
+import "time"
+
+// WithDrainPeriod makes Prune and MarkDraining keep a backend listed, but
+// draining, for d before removing it, so requests already proxied to it can
+// finish. Zero removes stale backends at once.
+func WithDrainPeriod(d time.Duration) Option {
+	return func(r *Registry) {
+		r.drainFor = d
+	}
+}
+
+// MarkDraining takes every instance of slug out of rotation: each stays in
+// All with Draining set, and is removed once the drain period ends unless a
+// scan sees it again in the meantime. Without a drain period the instances
+// are removed at once. Returns false if the slug is unknown.
+func (r *Registry) MarkDraining(slug string) bool {
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	group, ok := r.backends[slug]
+	if !ok {
+		return false
+	}
+	for _, b := range group {
+		if r.drainFor <= 0 {
+			r.removeLocked(slug, b.Port)
+		} else if !b.Draining {
+			r.drainLocked(b)
+		}
+	}
+	r.logger.Info("backend draining", "slug", slug, "instances", len(group), "drain_period", r.drainFor)
+	return true
+}
+
+// drainLocked marks b draining and schedules its removal after the drain
+// period.
+func (r *Registry) drainLocked(b *Backend) {
+	b.Draining = true
+	r.emitLocked(EventUpdated, b)
+	time.AfterFunc(r.drainFor, func() { r.finishDrain(b) })
+}
+
+// finishDrain removes b if it is still registered and still draining.
+func (r *Registry) finishDrain(b *Backend) {
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	for _, cur := range r.backends[b.Slug] {
+		if cur == b && b.Draining {
+			r.removeLocked(b.Slug, b.Port)
+			r.logger.Info("backend removed (drained)", "slug", b.Slug, "port", b.Port)
+			return
+		}
+	}
+}
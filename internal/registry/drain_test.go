@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkDraining(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithDrainPeriod(50*time.Millisecond))
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	r.Upsert(4097, "beta", "/home/user/beta", "1.0")
+
+	if r.MarkDraining("missing") {
+		t.Error("MarkDraining should report an unknown slug")
+	}
+	if !r.MarkDraining("alpha") {
+		t.Fatal("MarkDraining(alpha) = false")
+	}
+
+	var draining, listed int
+	for _, b := range r.All() {
+		listed++
+		if b.Draining {
+			draining++
+			if b.Slug != "alpha" {
+				t.Errorf("unexpected draining backend %q", b.Slug)
+			}
+		}
+	}
+	if listed != 2 || draining != 1 {
+		t.Fatalf("expected both backends listed and alpha draining, got %d listed, %d draining", listed, draining)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := r.Lookup("alpha"); ok {
+		t.Error("alpha should be removed once the drain period ends")
+	}
+	if _, ok := r.Lookup("beta"); !ok {
+		t.Error("beta should be untouched")
+	}
+}
+
+func TestPrune_Drains(t *testing.T) {
+	r := New(time.Millisecond, testLogger(), WithDrainPeriod(50*time.Millisecond))
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	r.Upsert(4097, "beta", "/home/user/beta", "1.0")
+	time.Sleep(5 * time.Millisecond)
+
+	if removed := r.Prune(); len(removed) != 2 {
+		t.Fatalf("expected both stale backends to start draining, got %v", removed)
+	}
+	if removed := r.Prune(); len(removed) != 0 {
+		t.Errorf("draining backends should not be reported twice, got %v", removed)
+	}
+	if b, ok := r.Lookup("alpha"); !ok || !b.Draining {
+		t.Fatalf("expected alpha listed and draining, got %+v, %v", b, ok)
+	}
+
+	// Seen again by a scan: back in rotation, and not removed later.
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	if b, _ := r.Lookup("alpha"); b.Draining {
+		t.Error("an upsert should clear Draining")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := r.Lookup("alpha"); !ok {
+		t.Error("alpha came back before the drain period ended and should stay")
+	}
+	if _, ok := r.Lookup("beta"); ok {
+		t.Error("beta should be removed once the drain period ends")
+	}
+}
+
+func TestMarkDraining_NoPeriod(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	r.MarkDraining("alpha")
+	if r.Len() != 0 {
+		t.Errorf("without a drain period the backend should be removed at once, got %d", r.Len())
+	}
+}
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ETag returns a hash of what clients see of the registered backends:
+// slug, port, bind address, TLS, version, draining state, labels, tags,
+// uptime start and total downtime, independent of registration order. It changes whenever one of those
+// does, but not when a scan only refreshes LastSeen, so pollers can
+// revalidate cheaply. The value is cached until the next change.
+func (r *Registry) ETag() string {
+	if tag := r.etag.Load(); tag != nil {
+		return *tag
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	lines := make([]string, 0, len(r.byPort))
+	for _, group := range r.backends {
+		for _, b := range group {
+			labels := make([]string, 0, len(b.Labels))
+			for k, v := range b.Labels {
+				labels = append(labels, k+"="+v)
+			}
+			sort.Strings(labels)
+			lines = append(lines, fmt.Sprintf("%s\x00%d\x00%s\x00%t\x00%s\x00%t\x00%s\x00%s\x00%d\x00%d",
+				b.Slug, b.Port, b.BindIP, b.TLS, b.Version, b.Draining, strings.Join(labels, ","), strings.Join(b.Tags, ","),
+				b.UptimeSince.UnixNano(), b.TotalDowntime))
+		}
+	}
+	slices.Sort(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	tag := hex.EncodeToString(sum[:8])
+	// Changes need the write lock, so none can land between hashing and
+	// caching while the read lock is held.
+	r.etag.Store(&tag)
+	return tag
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	empty := r.ETag()
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	r.Upsert(4097, "beta", "/home/user/beta", "1.0")
+	tag := r.ETag()
+	if tag == empty {
+		t.Fatal("ETag did not change after registering backends")
+	}
+	if r.ETag() != tag {
+		t.Error("ETag is not stable without changes")
+	}
+
+	// A rescan that only refreshes LastSeen keeps the tag.
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	if r.ETag() != tag {
+		t.Error("a LastSeen refresh should not change the ETag")
+	}
+
+	// Registration order does not matter.
+	other := New(30*time.Second, testLogger())
+	other.Upsert(4097, "beta", "/home/user/beta", "1.0")
+	other.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	for slug, group := range other.backends {
+		group[0].UptimeSince = r.backends[slug][0].UptimeSince // registered later
+	}
+	if other.ETag() != tag {
+		t.Error("ETag depends on registration order")
+	}
+
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.1")
+	bumped := r.ETag()
+	if bumped == tag {
+		t.Error("a version change should change the ETag")
+	}
+	if err := r.SetLabels("beta", map[string]string{"env": "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.ETag() == bumped {
+		t.Error("a label change should change the ETag")
+	}
+}
+
+func TestETag_UptimeSince(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	tag := r.ETag()
+
+	r.backends["alpha"][0].UptimeSince = time.Now().Add(time.Hour)
+	r.etag.Store(nil)
+	if r.ETag() == tag {
+		t.Error("a new uptime start should change the ETag")
+	}
+}
+
+func TestETag_BindIPAndTLS(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	r.SetBindIP(4096, "127.0.0.1")
+	tag := r.ETag()
+
+	r.SetBindIP(4096, "127.0.0.1")
+	if r.ETag() != tag {
+		t.Error("setting the same bind address should keep the ETag")
+	}
+	r.SetBindIP(4096, "::1")
+	moved := r.ETag()
+	if moved == tag {
+		t.Error("a bind address change should change the ETag")
+	}
+	r.SetTLS(4096, true)
+	if r.ETag() == moved {
+		t.Error("a TLS change should change the ETag")
+	}
+}
+
+func TestETag_Prune(t *testing.T) {
+	r := New(time.Millisecond, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	tag := r.ETag()
+	time.Sleep(5 * time.Millisecond)
+	r.Prune()
+	if r.ETag() == tag {
+		t.Error("pruning a backend should change the ETag")
+	}
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"sync"
+)
+
+// Registry event types.
+const (
+	EventAdded   = "added"
+	EventUpdated = "updated"
+	EventRemoved = "removed"
+	// EventBatch carries the changes of one BatchUpsert in Events.
+	EventBatch = "batch"
+)
+
+// subscriberBuffer is the channel capacity per subscriber. Events that do
+// not fit are dropped rather than blocking the registry.
+const subscriberBuffer = 64
+
+// RegistryEvent describes one change to the set of registered backends.
+type RegistryEvent struct {
+	Type    string
+	Slug    string
+	Backend Backend
+	// Events holds the individual changes of an EventBatch, in order;
+	// Slug and Backend are unset then.
+	Events []RegistryEvent
+}
+
+// subscribers holds the event channels, under a lock separate from the
+// registry's so delivery never happens while r.mu is held.
+type subscribers struct {
+	mu    sync.RWMutex
+	chans []chan RegistryEvent
+}
+
+// Subscribe returns a channel of registry changes and a func that cancels
+// the subscription and closes the channel. Slow consumers miss events
+// instead of stalling registry updates.
+func (r *Registry) Subscribe() (<-chan RegistryEvent, func()) {
+	ch := make(chan RegistryEvent, subscriberBuffer)
+	r.subs.mu.Lock()
+	r.subs.chans = append(r.subs.chans, ch)
+	r.subs.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			r.subs.mu.Lock()
+			defer r.subs.mu.Unlock()
+			for i, c := range r.subs.chans {
+				if c == ch {
+					r.subs.chans = append(r.subs.chans[:i], r.subs.chans[i+1:]...)
+					break
+				}
+			}
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// emitLocked queues an event for b, delivered once the lock is released by
+// unlockAndPublish, and drops the cached ETag. Caller must hold r.mu.
+func (r *Registry) emitLocked(eventType string, b *Backend) {
+	r.etag.Store(nil)
+	r.pending = append(r.pending, RegistryEvent{Type: eventType, Slug: b.Slug, Backend: *b.clone()})
+}
+
+// unlockAndPublish releases r.mu and then delivers any queued events.
+func (r *Registry) unlockAndPublish() {
+	events := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+
+	r.subs.mu.RLock()
+	defer r.subs.mu.RUnlock()
+	for _, ev := range events {
+		for _, ch := range r.subs.chans {
+			select {
+			case ch <- ev:
+			default:
+				r.logger.Debug("registry event dropped for slow subscriber", "type", ev.Type, "slug", ev.Slug)
+			}
+		}
+	}
+}
+
+// WaitForBackend returns the primary backend for slug, blocking until one is
+// registered or ctx is done, in which case it returns ctx.Err(). It is
+// meant for scripts and tests that would otherwise poll the resolve API.
+func (r *Registry) WaitForBackend(ctx context.Context, slug string) (*Backend, error) {
+	// Subscribe before the first lookup so an add in between is not missed.
+	events, cancel := r.Subscribe()
+	defer cancel()
+
+	if b, ok := r.Lookup(slug); ok {
+		return b, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case ev := <-events:
+			if ev.Type == EventRemoved {
+				continue
+			}
+			// Look up on any event, not only ones for slug, in case ours was
+			// dropped because the buffer was full.
+			if b, ok := r.Lookup(slug); ok {
+				return b, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, ch <-chan RegistryEvent) RegistryEvent {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for registry event")
+		return RegistryEvent{}
+	}
+}
+
+func TestSubscribe_UpsertAndPrune(t *testing.T) {
+	r := New(50*time.Millisecond, testLogger())
+	ch, cancel := r.Subscribe()
+	defer cancel()
+
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	if ev := nextEvent(t, ch); ev.Type != EventAdded || ev.Slug != "repo" || ev.Backend.Port != 4096 {
+		t.Errorf("expected added event, got %+v", ev)
+	}
+	r.Upsert(4096, "repo", "/home/user/repo", "1.1")
+	if ev := nextEvent(t, ch); ev.Type != EventUpdated || ev.Backend.Version != "1.1" {
+		t.Errorf("expected updated event, got %+v", ev)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	r.Prune()
+	if ev := nextEvent(t, ch); ev.Type != EventRemoved || ev.Slug != "repo" {
+		t.Errorf("expected removed event, got %+v", ev)
+	}
+}
+
+func TestSubscribe_MultipleSubscribers(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	const subscribers = 5
+	const upserts = 20
+
+	var wg sync.WaitGroup
+	counts := make([]int, subscribers)
+	for i := 0; i < subscribers; i++ {
+		ch, cancel := r.Subscribe()
+		defer cancel()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < upserts; j++ {
+				nextEvent(t, ch)
+				counts[i]++
+			}
+		}(i)
+	}
+
+	for i := 0; i < upserts; i++ {
+		r.Upsert(4096+i, "p", "/home/user/p"+string(rune('a'+i)), "1.0")
+	}
+	wg.Wait()
+	for i, n := range counts {
+		if n != upserts {
+			t.Errorf("subscriber %d got %d events, want %d", i, n, upserts)
+		}
+	}
+}
+
+func TestSubscribe_SlowSubscriberDoesNotBlock(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	_, cancel := r.Subscribe() // never read
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer*2; i++ {
+			r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("registry blocked on a full subscriber channel")
+	}
+}
+
+func TestSubscribe_Cancel(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	ch, cancel := r.Subscribe()
+	cancel()
+	cancel() // idempotent
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel closed after cancel")
+	}
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0") // must not panic
+}
+
+func subscriberCount(r *Registry) int {
+	r.subs.mu.RLock()
+	defer r.subs.mu.RUnlock()
+	return len(r.subs.chans)
+}
+
+func TestWaitForBackend_AlreadyRegistered(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "ready", "/home/user/ready", "1.0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	b, err := r.WaitForBackend(ctx, "ready")
+	if err != nil {
+		t.Fatalf("WaitForBackend: %v", err)
+	}
+	if b.Port != 4096 {
+		t.Errorf("got port %d, want 4096", b.Port)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("registered backend took %s to return", elapsed)
+	}
+	if n := subscriberCount(r); n != 0 {
+		t.Errorf("WaitForBackend left %d subscribers behind", n)
+	}
+}
+
+func TestWaitForBackend_AddedLater(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		r.Upsert(4000, "other", "/home/user/other", "1.0")
+		r.Upsert(4096, "late", "/home/user/late", "1.0")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	b, err := r.WaitForBackend(ctx, "late")
+	if err != nil {
+		t.Fatalf("WaitForBackend: %v", err)
+	}
+	if b.Slug != "late" || b.Port != 4096 {
+		t.Errorf("got %+v, want late on 4096", b)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned after %s, before the backend was added", elapsed)
+	}
+	if n := subscriberCount(r); n != 0 {
+		t.Errorf("WaitForBackend left %d subscribers behind", n)
+	}
+}
+
+func TestWaitForBackend_ContextCancelled(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	b, err := r.WaitForBackend(ctx, "never")
+	if !errors.Is(err, context.DeadlineExceeded) || b != nil {
+		t.Fatalf("WaitForBackend = %+v, %v; want nil, context.DeadlineExceeded", b, err)
+	}
+	if n := subscriberCount(r); n != 0 {
+		t.Errorf("WaitForBackend left %d subscribers behind", n)
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// Match quality for FuzzyLookup, best first.
+const (
+	matchExact = iota
+	matchPrefix
+	matchSubstring
+)
+
+// FuzzyLookup returns the primary backend of every slug that starts with or
+// contains query (after slugifying it), best matches first: exact, then
+// prefix, then substring, each ordered by Levenshtein distance to the query.
+// At most limit results are returned; limit <= 0 means no limit.
+func (r *Registry) FuzzyLookup(query string, limit int) []*Backend {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	q := SlugifyPath(query)
+
+	type candidate struct {
+		backend  *Backend
+		quality  int
+		distance int
+	}
+
+	r.mu.RLock()
+	var candidates []candidate
+	for slug, group := range r.backends {
+		if len(group) == 0 || !strings.Contains(slug, q) {
+			continue
+		}
+		quality := matchSubstring
+		switch {
+		case slug == q:
+			quality = matchExact
+		case strings.HasPrefix(slug, q):
+			quality = matchPrefix
+		}
+		candidates = append(candidates, candidate{backend: group[0].clone(), quality: quality, distance: levenshtein(slug, q)})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.quality != b.quality {
+			return a.quality < b.quality
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		return a.backend.Slug < b.backend.Slug
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	result := make([]*Backend, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, c.backend)
+	}
+	return result
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
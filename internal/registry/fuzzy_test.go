@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func fuzzySlugs(backends []*Backend) []string {
+	slugs := make([]string, 0, len(backends))
+	for _, b := range backends {
+		slugs = append(slugs, b.Slug)
+	}
+	return slugs
+}
+
+func newFuzzyRegistry() *Registry {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "my", "/home/alice/my", "1.0")
+	r.Upsert(4097, "myproject", "/home/alice/myproject", "1.0")
+	r.Upsert(4098, "my-app", "/home/alice/my-app", "1.0")
+	r.Upsert(4099, "dummy", "/home/alice/dummy", "1.0")
+	r.Upsert(4100, "other", "/home/alice/other", "1.0")
+	return r
+}
+
+func TestFuzzyLookup_Ordering(t *testing.T) {
+	r := newFuzzyRegistry()
+
+	got := fuzzySlugs(r.FuzzyLookup("my", 0))
+	want := []string{"my", "my-app", "myproject", "dummy"}
+	if len(got) != len(want) {
+		t.Fatalf("FuzzyLookup(my) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("FuzzyLookup(my) = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFuzzyLookup_PrefixAndSubstring(t *testing.T) {
+	r := newFuzzyRegistry()
+
+	if got := fuzzySlugs(r.FuzzyLookup("myp", 0)); len(got) != 1 || got[0] != "myproject" {
+		t.Errorf("prefix match: got %v, want [myproject]", got)
+	}
+	if got := fuzzySlugs(r.FuzzyLookup("PROJ", 0)); len(got) != 1 || got[0] != "myproject" {
+		t.Errorf("case-insensitive substring match: got %v, want [myproject]", got)
+	}
+	if got := r.FuzzyLookup("zzz", 0); len(got) != 0 {
+		t.Errorf("expected no matches, got %v", fuzzySlugs(got))
+	}
+	if got := r.FuzzyLookup("  ", 0); len(got) != 0 {
+		t.Errorf("blank query should match nothing, got %v", fuzzySlugs(got))
+	}
+}
+
+func TestFuzzyLookup_Limit(t *testing.T) {
+	r := newFuzzyRegistry()
+
+	got := fuzzySlugs(r.FuzzyLookup("my", 2))
+	if len(got) != 2 || got[0] != "my" || got[1] != "my-app" {
+		t.Errorf("limited lookup = %v, want [my my-app]", got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"my", "my-app", 4},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"sort"
+	"time"
+)
+
+// HealthHistoryCapacity is the number of health records kept per backend.
+const HealthHistoryCapacity = 100
+
+// HealthRecord is the outcome of one health observation of a backend.
+type HealthRecord struct {
+	Time    time.Time `json:"time"`
+	Port    int       `json:"port"`
+	Healthy bool      `json:"healthy"`
+	Version string    `json:"version,omitempty"`
+}
+
+// HealthHistory is a fixed-size ring buffer of health records; once full,
+// each new record overwrites the oldest. It is not safe for concurrent use on
+// its own; the Registry guards it with its mutex.
+type HealthHistory struct {
+	records []HealthRecord
+	next    int
+	full    bool
+}
+
+// NewHealthHistory creates a ring buffer holding up to capacity records.
+func NewHealthHistory(capacity int) *HealthHistory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &HealthHistory{records: make([]HealthRecord, capacity)}
+}
+
+// Add appends rec, overwriting the oldest record when the buffer is full.
+func (h *HealthHistory) Add(rec HealthRecord) {
+	h.records[h.next] = rec
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Records returns the buffered records, oldest first.
+func (h *HealthHistory) Records() []HealthRecord {
+	if !h.full {
+		return append([]HealthRecord(nil), h.records[:h.next]...)
+	}
+	out := make([]HealthRecord, 0, len(h.records))
+	out = append(out, h.records[h.next:]...)
+	return append(out, h.records[:h.next]...)
+}
+
+// Len returns the number of buffered records.
+func (h *HealthHistory) Len() int {
+	if h.full {
+		return len(h.records)
+	}
+	return h.next
+}
+
+// History returns the health records of every instance of slug, oldest
+// first. Returns false if the slug is unknown.
+func (r *Registry) History(slug string) ([]HealthRecord, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.backends[slug]
+	if !ok {
+		return nil, false
+	}
+	records := []HealthRecord{}
+	for _, b := range group {
+		if b.history != nil {
+			records = append(records, b.history.Records()...)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, true
+}
+
+// RecordUnhealthy notes a failed health check for the backend on port, if
+// one is registered. It does not refresh LastSeen.
+func (r *Registry) RecordUnhealthy(port int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			b.recordHealth(false)
+			return
+		}
+	}
+}
+
+// recordHealth appends an observation to b's history. Caller must hold the
+// registry lock.
+func (b *Backend) recordHealth(healthy bool) {
+	if b.history == nil {
+		b.history = NewHealthHistory(HealthHistoryCapacity)
+	}
+	b.history.Add(HealthRecord{Time: time.Now(), Port: b.Port, Healthy: healthy, Version: b.Version})
+}
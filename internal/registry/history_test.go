@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthHistory_Overwrite(t *testing.T) {
+	h := NewHealthHistory(3)
+	for i := 1; i <= 5; i++ {
+		h.Add(HealthRecord{Port: i})
+	}
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", h.Len())
+	}
+	recs := h.Records()
+	for i, want := range []int{3, 4, 5} {
+		if recs[i].Port != want {
+			t.Errorf("record %d: expected port %d, got %d", i, want, recs[i].Port)
+		}
+	}
+}
+
+func TestHealthHistory_Partial(t *testing.T) {
+	h := NewHealthHistory(3)
+	h.Add(HealthRecord{Port: 1})
+	if recs := h.Records(); len(recs) != 1 || recs[0].Port != 1 {
+		t.Errorf("expected one record, got %+v", recs)
+	}
+}
+
+func TestHistory_RecordsUpserts(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	for i := 0; i < HealthHistoryCapacity+10; i++ {
+		r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	}
+	r.Upsert(4096, "repo", "/home/user/repo", "1.1")
+	r.RecordUnhealthy(4096)
+	r.RecordUnhealthy(5000) // unknown port is ignored
+
+	recs, ok := r.History("repo")
+	if !ok {
+		t.Fatal("expected history for repo")
+	}
+	if len(recs) != HealthHistoryCapacity {
+		t.Fatalf("expected %d records, got %d", HealthHistoryCapacity, len(recs))
+	}
+	last := recs[len(recs)-1]
+	if last.Healthy || last.Version != "1.1" {
+		t.Errorf("expected last record unhealthy at 1.1, got %+v", last)
+	}
+	if prev := recs[len(recs)-2]; !prev.Healthy || prev.Version != "1.1" {
+		t.Errorf("expected healthy 1.1 record before failure, got %+v", prev)
+	}
+}
+
+func TestHistory_DiscardedOnPrune(t *testing.T) {
+	r := New(50*time.Millisecond, testLogger())
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	time.Sleep(100 * time.Millisecond)
+	r.Prune()
+
+	if _, ok := r.History("repo"); ok {
+		t.Fatal("expected no history after prune")
+	}
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	if recs, _ := r.History("repo"); len(recs) != 1 {
+		t.Errorf("expected fresh history after re-registration, got %d records", len(recs))
+	}
+}
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// SetLabels merges labels into every instance registered under slug. An
+// empty value deletes that key. Labels survive later Upserts of the same
+// backend.
+func (r *Registry) SetLabels(slug string, labels map[string]string) error {
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	group, ok := r.backends[slug]
+	if !ok {
+		return fmt.Errorf("no backend registered under slug %q", slug)
+	}
+	for _, b := range group {
+		for k, v := range labels {
+			if v == "" {
+				delete(b.Labels, k)
+				continue
+			}
+			if b.Labels == nil {
+				b.Labels = make(map[string]string, len(labels))
+			}
+			b.Labels[k] = v
+		}
+		r.emitLocked(EventUpdated, b)
+	}
+	return nil
+}
+
+// clone returns a copy of b that shares no mutable state with the registry
+// except the registry-guarded health history.
+func (b *Backend) clone() *Backend {
+	c := *b
+	c.Labels = maps.Clone(b.Labels)
+	c.Tags = slices.Clone(b.Tags)
+	c.outages = slices.Clone(b.outages)
+	return &c
+}
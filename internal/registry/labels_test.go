@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLabels_MergeAndDelete(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+
+	if err := r.SetLabels("repo", map[string]string{"env": "dev", "team": "core"}); err != nil {
+		t.Fatalf("SetLabels: %v", err)
+	}
+	if err := r.SetLabels("repo", map[string]string{"env": "prod", "team": ""}); err != nil {
+		t.Fatalf("SetLabels: %v", err)
+	}
+
+	b, _ := r.Lookup("repo")
+	if len(b.Labels) != 1 || b.Labels["env"] != "prod" {
+		t.Errorf("expected labels {env:prod}, got %v", b.Labels)
+	}
+
+	// The returned copy must not alias registry state.
+	b.Labels["env"] = "mutated"
+	if b, _ := r.Lookup("repo"); b.Labels["env"] != "prod" {
+		t.Errorf("lookup copy aliased registry labels: %v", b.Labels)
+	}
+}
+
+func TestSetLabels_SurviveUpsert(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	if err := r.SetLabels("repo", map[string]string{"env": "dev"}); err != nil {
+		t.Fatalf("SetLabels: %v", err)
+	}
+
+	r.Upsert(4096, "repo", "/home/user/repo", "1.1")
+	if b, _ := r.Lookup("repo"); b.Labels["env"] != "dev" {
+		t.Errorf("expected labels to survive upsert, got %v", b.Labels)
+	}
+}
+
+func TestSetLabels_UnknownSlug(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	if err := r.SetLabels("missing", map[string]string{"env": "dev"}); err == nil {
+		t.Error("expected error for unknown slug")
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// pinnedEntry is one backend declared in a pinned file.
+type pinnedEntry struct {
+	Port        int    `json:"port"`
+	ProjectName string `json:"project_name"`
+	ProjectPath string `json:"project_path"`
+	Version     string `json:"version"`
+	// Manual defaults to true; false registers the entry like a scan
+	// result, so it expires unless the scanner keeps seeing it.
+	Manual *bool `json:"manual"`
+}
+
+// ImportPinned registers the backends declared in the JSON file at path, a
+// list of {"port","project_name","project_path","version","manual"} objects.
+// Entries are pinned as manual backends that Prune never expires unless
+// they set "manual": false. The whole file is checked before anything is
+// registered: malformed JSON, an invalid port or path, or a port listed
+// twice is an error. A missing file is logged and imported as empty.
+// Importing again updates the entries in place, so it is safe to repeat
+// after the file changes, and removes the backends of entries dropped from
+// the file since the last import unless their port has been taken over by
+// another project.
+func (r *Registry) ImportPinned(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.logger.Warn("pinned backends file not found; nothing imported", "file", path)
+		r.replacePinned(path, nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read pinned file: %w", err)
+	}
+
+	var entries []pinnedEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse pinned file %s: %w", path, err)
+	}
+	seen := make(map[int]string, len(entries))
+	for i, e := range entries {
+		switch {
+		case e.Port < 1 || e.Port > 65535:
+			return fmt.Errorf("pinned file %s: entry %d: port %d out of range", path, i, e.Port)
+		case strings.TrimSpace(e.ProjectPath) == "":
+			return fmt.Errorf("pinned file %s: entry %d: project_path is required", path, i)
+		case seen[e.Port] != "":
+			return fmt.Errorf("pinned file %s: entry %d: port %d listed twice", path, i, e.Port)
+		}
+		seen[e.Port] = e.ProjectPath
+	}
+
+	for _, e := range entries {
+		if e.Manual == nil || *e.Manual {
+			r.UpsertManual(e.Port, e.ProjectName, e.ProjectPath, e.Version)
+		} else {
+			r.Upsert(e.Port, e.ProjectName, e.ProjectPath, e.Version)
+		}
+	}
+	r.replacePinned(path, seen)
+	r.logger.Info("pinned backends imported", "file", path, "count", len(entries))
+	return nil
+}
+
+// replacePinned records ports as the entries of the pinned file at path and
+// removes the backends of the entries it no longer lists.
+func (r *Registry) replacePinned(path string, ports map[int]string) {
+	r.mu.Lock()
+	prev := r.pinned[path]
+	r.pinned[path] = ports
+	r.mu.Unlock()
+
+	for port, projectPath := range prev {
+		if _, ok := ports[port]; ok {
+			continue
+		}
+		if b, ok := r.LookupByPort(port); ok && b.ProjectPath == projectPath {
+			r.RemoveByPort(port)
+		}
+	}
+}
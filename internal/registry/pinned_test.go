@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePinned(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pinned.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportPinned(t *testing.T) {
+	path := writePinned(t, `[
+		{"port":4200,"project_name":"app","project_path":"/opt/app","version":"1.0","manual":true},
+		{"port":4201,"project_name":"tool","project_path":"/opt/tool","version":"2.0"},
+		{"port":4202,"project_name":"scan","project_path":"/opt/scan","version":"3.0","manual":false}
+	]`)
+	reg := New(time.Millisecond, testLogger())
+	if err := reg.ImportPinned(path); err != nil {
+		t.Fatalf("ImportPinned: %v", err)
+	}
+
+	b, ok := reg.Lookup("app")
+	if !ok || b.Port != 4200 || b.ProjectPath != "/opt/app" || b.Version != "1.0" || !b.Manual {
+		t.Fatalf("unexpected app backend: %+v, %v", b, ok)
+	}
+	if b, ok := reg.Lookup("tool"); !ok || !b.Manual {
+		t.Errorf("entries without \"manual\" should be pinned: %+v, %v", b, ok)
+	}
+	if b, ok := reg.Lookup("scan"); !ok || b.Manual {
+		t.Errorf("\"manual\": false should register an unpinned backend: %+v, %v", b, ok)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if removed := reg.Prune(); len(removed) != 1 || removed[0] != "scan" {
+		t.Errorf("Prune removed %v, want only the unpinned entry", removed)
+	}
+
+	// Importing again after an edit updates in place.
+	if err := os.WriteFile(path, []byte(`[{"port":4200,"project_name":"app","project_path":"/opt/app","version":"1.1"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.ImportPinned(path); err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if b, _ := reg.Lookup("app"); b.Version != "1.1" {
+		t.Errorf("re-import did not update the version: %q", b.Version)
+	}
+	if n := len(reg.LookupAll("app")); n != 1 {
+		t.Errorf("re-import duplicated the backend: %d instances", n)
+	}
+}
+
+func TestImportPinned_Malformed(t *testing.T) {
+	for name, content := range map[string]string{
+		"bad json":     `[{"port":4200,`,
+		"not a list":   `{"port":4200,"project_path":"/opt/app"}`,
+		"bad port":     `[{"port":70000,"project_path":"/opt/app"}]`,
+		"missing path": `[{"port":4200,"project_name":"app"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := New(30*time.Second, testLogger())
+			if err := reg.ImportPinned(writePinned(t, content)); err == nil {
+				t.Fatal("expected an error")
+			}
+			if reg.Len() != 0 {
+				t.Errorf("nothing should be registered from an invalid file, got %d", reg.Len())
+			}
+		})
+	}
+}
+
+func TestImportPinned_MissingFile(t *testing.T) {
+	reg := New(30*time.Second, testLogger())
+	if err := reg.ImportPinned(filepath.Join(t.TempDir(), "absent.json")); err != nil {
+		t.Fatalf("a missing file should only warn, got %v", err)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("expected an empty registry, got %d", reg.Len())
+	}
+}
+
+func TestImportPinned_DuplicatePort(t *testing.T) {
+	reg := New(30*time.Second, testLogger())
+	err := reg.ImportPinned(writePinned(t, `[
+		{"port":4200,"project_name":"app","project_path":"/opt/app"},
+		{"port":4200,"project_name":"other","project_path":"/opt/other"}
+	]`))
+	if err == nil || !strings.Contains(err.Error(), "listed twice") {
+		t.Fatalf("expected a duplicate port error, got %v", err)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("nothing should be registered, got %d", reg.Len())
+	}
+}
+
+func TestImportPinned_RemovesDroppedEntries(t *testing.T) {
+	path := writePinned(t, `[
+		{"port":4200,"project_name":"app","project_path":"/opt/app"},
+		{"port":4201,"project_name":"tool","project_path":"/opt/tool"},
+		{"port":4202,"project_name":"moved","project_path":"/opt/moved"}
+	]`)
+	reg := New(time.Minute, testLogger())
+	if err := reg.ImportPinned(path); err != nil {
+		t.Fatalf("ImportPinned: %v", err)
+	}
+	reg.Upsert(4300, "scanned", "/opt/scanned", "1.0")
+	// Port 4202 is taken over by another project after the import.
+	reg.RemoveByPort(4202)
+	reg.UpsertManual(4202, "other", "/opt/other", "1.0")
+
+	if err := os.WriteFile(path, []byte(`[{"port":4200,"project_name":"app","project_path":"/opt/app"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.ImportPinned(path); err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if _, ok := reg.Lookup("app"); !ok {
+		t.Error("entry still in the file was removed")
+	}
+	if _, ok := reg.Lookup("tool"); ok {
+		t.Error("entry dropped from the file is still registered")
+	}
+	if _, ok := reg.Lookup("scanned"); !ok {
+		t.Error("backend not from the file was removed")
+	}
+	if _, ok := reg.Lookup("other"); !ok {
+		t.Error("project that took over a dropped entry's port was removed")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.ImportPinned(path); err != nil {
+		t.Fatalf("import of a missing file: %v", err)
+	}
+	if _, ok := reg.Lookup("app"); ok {
+		t.Error("deleting the file should drop its entries")
+	}
+}
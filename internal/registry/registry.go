@@ -1,12 +1,17 @@
 package registry
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"net"
+	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,6 +23,44 @@ type Backend struct {
 	Slug        string    `json:"slug"`
 	Version     string    `json:"version"`
 	LastSeen    time.Time `json:"last_seen"`
+	// Manual marks backends pinned through the API. They are never probed
+	// into freshness, so Prune leaves them alone.
+	Manual bool `json:"manual,omitempty"`
+	// SupportsH2C is set when the backend accepted an h2c upgrade during probing.
+	SupportsH2C bool `json:"supports_h2c,omitempty"`
+	// TLS is set when the backend answered its health probe over HTTPS.
+	TLS bool `json:"tls,omitempty"`
+	// BindIP is the loopback address the backend answered on, DefaultBindIP
+	// or IPv6BindIP; empty means DefaultBindIP. See Backend.Addr.
+	BindIP string `json:"bind_ip,omitempty"`
+	// Labels are operator-assigned tags such as env=dev. See Registry.SetLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Tags are free-form markers such as "experimental", read from the
+	// backend's /project/tags endpoint. See Registry.SetTags.
+	Tags []string `json:"tags,omitempty"`
+	// Draining marks a backend on its way out: it stays listed but the
+	// proxy sends it no new requests. See Registry.MarkDraining.
+	Draining bool `json:"draining,omitempty"`
+	// Username owns the backend's domain when the registry derives it from
+	// the project path; empty means the router's own. See
+	// WithUsernameFromPath.
+	Username string `json:"username,omitempty"`
+	// UptimeSince is when the backend was first seen after being registered
+	// or after its last outage, a gap between sightings longer than the
+	// stale-after period. See Backend.Uptime.
+	UptimeSince time.Time `json:"uptime_since"`
+	// TotalDowntime is the sum of the backend's outages since it was
+	// registered.
+	TotalDowntime time.Duration `json:"total_downtime"`
+
+	// firstSeen is when the backend was registered and outages its most
+	// recent downtime; see Backend.UptimePercentage.
+	firstSeen time.Time
+	outages   []outage
+
+	// history is shared by copies returned from lookups; only the registry
+	// reads or writes it, under its lock. See Registry.History.
+	history *HealthHistory
 }
 
 // Healthy returns true if the backend was seen recently.
@@ -25,46 +68,174 @@ func (b *Backend) Healthy(staleAfter time.Duration) bool {
 	return time.Since(b.LastSeen) < staleAfter
 }
 
+// Fingerprint returns a deterministic hash of the fields advertised over
+// mDNS (port, version, project path), so callers can detect when an
+// advertisement has gone stale.
+func (b *Backend) Fingerprint() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%s", b.Port, b.Version, b.ProjectPath)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Loopback addresses a backend may be bound to.
+const (
+	DefaultBindIP = "127.0.0.1"
+	IPv6BindIP    = "::1"
+)
+
+// Addr returns the host:port the backend is reached on, e.g.
+// "127.0.0.1:4096" or "[::1]:4096".
+func (b *Backend) Addr() string {
+	ip := b.BindIP
+	if ip == "" {
+		ip = DefaultBindIP
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(b.Port))
+}
+
 // Registry is a thread-safe store of discovered OpenCode backends.
+// Several instances may share one slug (e.g. two checkouts of the same repo);
+// they are kept together so the proxy can balance across them.
 type Registry struct {
 	mu         sync.RWMutex
-	backends   map[string]*Backend // slug → backend
-	byPort     map[int]string      // port → slug (for fast dedup)
+	backends   map[string][]*Backend // slug → instances, in registration order
+	byPort     map[int]string        // port → slug (for fast dedup)
 	sessions   map[string]map[string]SessionMetadata
 	staleAfter time.Duration
+	collision  string
+	dryRun     bool
+	drainFor   time.Duration             // see WithDrainPeriod
+	userByPath bool                      // see WithUsernameFromPath
+	requests   func(slug string) int64   // see SetRequestCounter
+	pinned     map[string]map[int]string // pinned file → port → project path; see ImportPinned
 	logger     *slog.Logger
+
+	pending []RegistryEvent // queued under mu, published on unlock
+	subs    subscribers
+	etag    atomic.Pointer[string] // cached ETag, cleared on every change
+}
+
+// Slug collision strategies, applied when two different project paths
+// produce the same SlugifyPath output.
+const (
+	// CollisionGroup registers both under the shared slug so the proxy can
+	// balance across them.
+	CollisionGroup = "group"
+	// CollisionPort gives the newcomer a "{slug}-{port}" slug.
+	CollisionPort = "port"
+	// CollisionPathSuffix qualifies every colliding project with its parent
+	// directory, e.g. /alice/proj → "proj-alice".
+	CollisionPathSuffix = "path-suffix"
+	// CollisionError refuses to register the newcomer.
+	CollisionError = "error"
+)
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithSlugCollision selects how colliding slugs are resolved. The default is
+// CollisionGroup.
+func WithSlugCollision(strategy string) Option {
+	return func(r *Registry) {
+		if strategy != "" {
+			r.collision = strategy
+		}
+	}
+}
+
+// WithDryRun turns Prune into a no-op, so backends are never expired while
+// the scanner only reports what it finds. See scanner.WithDryRun.
+func WithDryRun(enabled bool) Option {
+	return func(r *Registry) {
+		r.dryRun = enabled
+	}
+}
+
+// WithUsernameFromPath sets each backend's Username from its project path,
+// see UsernameFromPath, so tenants sharing one OS user get their own
+// domains.
+func WithUsernameFromPath(enabled bool) Option {
+	return func(r *Registry) {
+		r.userByPath = enabled
+	}
+}
+
+// UsernameFromPath returns the name of the directory holding the project at
+// projectPath, made safe for a hostname like a slug: "alice" for
+// /home/alice/proj. It returns "" when the project sits at the filesystem
+// root.
+func UsernameFromPath(projectPath string) string {
+	parent := path.Base(path.Dir(path.Clean(toSlash(projectPath))))
+	if parent == "/" || parent == "." {
+		return ""
+	}
+	return slugifyBase(parent)
+}
+
+// usernameFor is the Username recorded for a backend at projectPath.
+func (r *Registry) usernameFor(projectPath string) string {
+	if !r.userByPath {
+		return ""
+	}
+	return UsernameFromPath(projectPath)
 }
 
 // New creates a new Registry.
-func New(staleAfter time.Duration, logger *slog.Logger) *Registry {
-	return &Registry{
-		backends:   make(map[string]*Backend),
+func New(staleAfter time.Duration, logger *slog.Logger, opts ...Option) *Registry {
+	r := &Registry{
+		backends:   make(map[string][]*Backend),
 		byPort:     make(map[int]string),
 		sessions:   make(map[string]map[string]SessionMetadata),
+		pinned:     make(map[string]map[int]string),
 		staleAfter: staleAfter,
+		collision:  CollisionGroup,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Upsert adds or updates a backend. Returns true if this is a new entry.
+// For a known backend, a gap since LastSeen longer than the stale-after
+// period is added to TotalDowntime and restarts UptimeSince.
 func (r *Registry) Upsert(port int, projectName, projectPath, version string) bool {
-	slug := Slugify(projectPath)
+	return r.upsert(port, projectName, projectPath, version, false)
+}
 
+// UpsertManual adds or updates a manually pinned backend that Prune will
+// not expire. Returns true if this is a new entry. Pinning is not a health
+// observation, so it never counts as downtime.
+func (r *Registry) UpsertManual(port int, projectName, projectPath, version string) bool {
+	return r.upsert(port, projectName, projectPath, version, true)
+}
+
+func (r *Registry) upsert(port int, projectName, projectPath, version string, manual bool) bool {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlockAndPublish()
+	return r.upsertLocked(port, projectName, projectPath, version, manual)
+}
+
+// upsertLocked is the body of upsert. Caller must hold r.mu.
+func (r *Registry) upsertLocked(port int, projectName, projectPath, version string, manual bool) bool {
+	slug, ok := r.resolveSlugLocked(port, projectPath)
+	if !ok {
+		r.logger.Warn("backend rejected: slug collision",
+			"slug", SlugifyPath(projectPath), "port", port, "path", projectPath)
+		return false
+	}
 
 	// Check if this port was previously registered under a different slug.
 	if oldSlug, ok := r.byPort[port]; ok && oldSlug != slug {
-		delete(r.backends, oldSlug)
-		delete(r.sessions, oldSlug)
+		r.removeLocked(oldSlug, port)
 		r.logger.Info("backend project changed", "port", port, "old_slug", oldSlug, "new_slug", slug)
 	}
 
-	// Update existing backend if we already have this slug from the same port,
-	// or from a different port that moved.
-	if existing, ok := r.backends[slug]; ok {
-		if existing.ProjectPath == projectPath || existing.Port == port {
-			// Same project or same port — update in place.
+	// Update an existing instance if we already have this port under the slug,
+	// or the same project has moved to a new port.
+	group := r.backends[slug]
+	for _, existing := range group {
+		if existing.Port == port || existing.ProjectPath == projectPath {
 			if existing.Port != port {
 				delete(r.byPort, existing.Port)
 			}
@@ -72,58 +243,343 @@ func (r *Registry) Upsert(port int, projectName, projectPath, version string) bo
 			existing.ProjectName = projectName
 			existing.ProjectPath = projectPath
 			existing.Version = version
-			existing.LastSeen = time.Now()
+			existing.Username = r.usernameFor(projectPath)
+			now := time.Now()
+			if !manual {
+				existing.recordSighting(now, r.staleAfter)
+			}
+			existing.LastSeen = now
+			existing.Manual = existing.Manual || manual
+			existing.Draining = false
+			existing.recordHealth(true)
 			r.byPort[port] = slug
+			r.emitLocked(EventUpdated, existing)
 			return false
 		}
-		// Slug collision: different project produces the same slug.
-		// Disambiguate by appending the port number.
-		slug = fmt.Sprintf("%s-%d", slug, port)
 	}
 
-	r.backends[slug] = &Backend{
+	// Either a brand-new slug, or (with CollisionGroup) a different project
+	// checkout that produces the same slug: add it as another instance.
+	now := time.Now()
+	b := &Backend{
 		Port:        port,
 		ProjectName: projectName,
 		ProjectPath: projectPath,
 		Slug:        slug,
 		Version:     version,
-		LastSeen:    time.Now(),
+		Username:    r.usernameFor(projectPath),
+		LastSeen:    now,
+		Manual:      manual,
+		UptimeSince: now,
+		firstSeen:   now,
 	}
+	b.recordHealth(true)
+	r.backends[slug] = append(group, b)
 	r.byPort[port] = slug
-	r.logger.Info("backend registered", "slug", slug, "port", port, "project", projectName)
+	r.emitLocked(EventAdded, b)
+	r.logger.Info("backend registered", "slug", slug, "port", port, "project", projectName, "instances", len(group)+1, "manual", manual)
 	return true
 }
 
-// MarkUnseen marks ports NOT in the seen set as potentially stale.
-// Returns slugs that were removed because they exceeded staleAfter.
-func (r *Registry) Prune() []string {
+// resolveSlugLocked picks the slug for the project at projectPath on port,
+// applying the collision strategy when another project already uses the same
+// base slug. Returns false if the strategy refuses registration.
+// Caller must hold r.mu.
+func (r *Registry) resolveSlugLocked(port int, projectPath string) (string, bool) {
+	base := SlugifyPath(projectPath)
+
+	// Known projects keep the slug they were given, whether found by port or
+	// because the same path has moved to a new port.
+	var conflicts []*Backend
+	for slug, group := range r.backends {
+		for _, b := range group {
+			if b.ProjectPath == projectPath {
+				return slug, true
+			}
+			if SlugifyPath(b.ProjectPath) == base {
+				conflicts = append(conflicts, b)
+			}
+		}
+	}
+	if len(conflicts) == 0 || r.collision == CollisionGroup {
+		return base, true
+	}
+	// The port is being taken over by a different project; its old entry is
+	// removed by the caller, so it is not a conflict.
+	if len(conflicts) == 1 && conflicts[0].Port == port {
+		return base, true
+	}
+
+	switch r.collision {
+	case CollisionError:
+		return "", false
+	case CollisionPathSuffix:
+		for _, b := range conflicts {
+			if b.Slug == base && b.Port != port {
+				r.renameLocked(b, r.freeSlugLocked(pathSuffixSlug(b.ProjectPath), b.Port))
+			}
+		}
+		return r.freeSlugLocked(pathSuffixSlug(projectPath), port), true
+	default: // CollisionPort
+		return fmt.Sprintf("%s-%d", base, port), true
+	}
+}
+
+// pathSuffixSlug qualifies a project's slug with its parent directory:
+// "/home/alice/proj" → "proj-alice".
+func pathSuffixSlug(projectPath string) string {
+	p := toSlash(projectPath)
+	return SlugifyPath(path.Base(p) + "-" + path.Base(path.Dir(p)))
+}
+
+// freeSlugLocked returns slug, or slug with a port suffix if a backend on a
+// different port already holds it. Caller must hold r.mu.
+func (r *Registry) freeSlugLocked(slug string, port int) string {
+	for _, b := range r.backends[slug] {
+		if b.Port != port {
+			return fmt.Sprintf("%s-%d", slug, port)
+		}
+	}
+	return slug
+}
+
+// renameLocked moves b to newSlug, carrying its sessions along when it was
+// the slug's only instance. Caller must hold r.mu.
+func (r *Registry) renameLocked(b *Backend, newSlug string) {
+	oldSlug := b.Slug
+	sessions := r.sessions[oldSlug]
+	r.removeLocked(oldSlug, b.Port)
+	if _, stillUsed := r.backends[oldSlug]; !stillUsed && sessions != nil {
+		r.sessions[newSlug] = sessions
+	}
+
+	b.Slug = newSlug
+	r.backends[newSlug] = append(r.backends[newSlug], b)
+	r.byPort[b.Port] = newSlug
+	r.emitLocked(EventAdded, b)
+	r.logger.Info("backend slug changed to resolve collision", "port", b.Port, "old_slug", oldSlug, "new_slug", newSlug)
+}
+
+// removeLocked drops the instance on port from slug's group, deleting the
+// slug (and its sessions) once no instances remain. Caller must hold r.mu.
+func (r *Registry) removeLocked(slug string, port int) {
+	group := r.backends[slug]
+	kept := make([]*Backend, 0, len(group))
+	for _, b := range group {
+		if b.Port != port {
+			kept = append(kept, b)
+		} else {
+			r.emitLocked(EventRemoved, b)
+		}
+	}
+	delete(r.byPort, port)
+	if len(kept) == 0 {
+		delete(r.backends, slug)
+		delete(r.sessions, slug)
+		return
+	}
+	r.backends[slug] = kept
+}
+
+// SetSupportsH2C records whether the backend on port speaks cleartext HTTP/2.
+func (r *Registry) SetSupportsH2C(port int, supported bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			b.SupportsH2C = supported
+			return
+		}
+	}
+}
+
+// SetTLS records whether the backend on port is served over HTTPS.
+func (r *Registry) SetTLS(port int, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			if b.TLS != enabled {
+				b.TLS = enabled
+				r.etag.Store(nil)
+			}
+			return
+		}
+	}
+}
+
+// SetBindIP records the loopback address the backend on port answers on.
+func (r *Registry) SetBindIP(port int, ip string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	slug, ok := r.byPort[port]
+	if !ok {
+		return
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			if b.BindIP != ip {
+				b.BindIP = ip
+				r.etag.Store(nil)
+			}
+			return
+		}
+	}
+}
+
+// Touch sets LastSeen of the backend on port to now without probing it, e.g.
+// while its process restarts, so Prune does not expire it in the meantime.
+// Returns false if no backend is registered on port.
+func (r *Registry) Touch(port int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return false
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			b.LastSeen = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+// Remove deletes every instance registered under slug, manual or not.
+// Returns false if the slug is unknown.
+func (r *Registry) Remove(slug string) bool {
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	group, ok := r.backends[slug]
+	if !ok {
+		return false
+	}
+	for _, b := range group {
+		r.removeLocked(slug, b.Port)
+	}
+	r.logger.Info("backend removed", "slug", slug, "instances", len(group))
+	return true
+}
+
+// RemoveByPort deletes the instance on port immediately, regardless of
+// staleness or whether it was registered manually. Returns false if no
+// backend is registered on port.
+func (r *Registry) RemoveByPort(port int) bool {
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return false
+	}
+	r.removeLocked(slug, port)
+	r.logger.Info("backend removed", "slug", slug, "port", port)
+	return true
+}
+
+// SetStaleAfter changes how long a backend may go unseen before Prune removes it.
+func (r *Registry) SetStaleAfter(d time.Duration) {
+	r.mu.Lock()
+	r.staleAfter = d
+	r.mu.Unlock()
+}
+
+// StaleAfter returns how long a backend may go unseen before Prune removes it.
+func (r *Registry) StaleAfter() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.staleAfter
+}
+
+// Prune removes backends that exceeded staleAfter. Manual backends are kept.
+// With a drain period (WithDrainPeriod) stale instances are marked draining
+// instead and removed once the period ends. Returns the slug of each
+// instance removed or newly draining. In dry-run mode it removes nothing.
+func (r *Registry) Prune() []string {
+	if r.dryRun {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
 	var removed []string
-	for slug, b := range r.backends {
-		if time.Since(b.LastSeen) > r.staleAfter {
-			delete(r.backends, slug)
-			delete(r.byPort, b.Port)
-			delete(r.sessions, slug)
-			r.logger.Info("backend removed (stale)", "slug", slug, "port", b.Port)
-			removed = append(removed, slug)
+	for slug, group := range r.backends {
+		for _, b := range group {
+			switch {
+			case b.Manual || b.Draining || time.Since(b.LastSeen) <= r.staleAfter:
+			case r.drainFor > 0:
+				r.drainLocked(b)
+				r.logger.Info("backend draining (stale)", "slug", slug, "port", b.Port, "drain_period", r.drainFor)
+				removed = append(removed, slug)
+			default:
+				// removeLocked builds a new slice, so group stays intact here.
+				r.removeLocked(slug, b.Port)
+				r.logger.Info("backend removed (stale)", "slug", slug, "port", b.Port)
+				removed = append(removed, slug)
+			}
 		}
 	}
 	return removed
 }
 
-// Lookup finds a backend by slug.
+// Lookup finds the primary (earliest registered) backend for a slug.
 func (r *Registry) Lookup(slug string) (*Backend, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	b, ok := r.backends[slug]
-	if !ok {
+	group, ok := r.backends[slug]
+	if !ok || len(group) == 0 {
 		return nil, false
 	}
 	// Return a copy to avoid races.
-	copy := *b
-	return &copy, true
+	return group[0].clone(), true
+}
+
+// LookupAll returns copies of every instance registered under slug.
+func (r *Registry) LookupAll(slug string) []*Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	group := r.backends[slug]
+	if len(group) == 0 {
+		return nil
+	}
+	result := make([]*Backend, 0, len(group))
+	for _, b := range group {
+		result = append(result, b.clone())
+	}
+	return result
+}
+
+// LookupPrefix returns copies of every backend whose slug starts with
+// prefix, compared case-insensitively. An empty prefix matches all backends.
+// The result is never nil.
+func (r *Registry) LookupPrefix(prefix string) []*Backend {
+	prefix = strings.ToLower(prefix)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*Backend, 0)
+	for slug, group := range r.backends {
+		if !strings.HasPrefix(strings.ToLower(slug), prefix) {
+			continue
+		}
+		for _, b := range group {
+			result = append(result, b.clone())
+		}
+	}
+	return result
 }
 
 // LookupByPort finds a backend by its port.
@@ -134,42 +590,47 @@ func (r *Registry) LookupByPort(port int) (*Backend, bool) {
 	if !ok {
 		return nil, false
 	}
-	b := r.backends[slug]
-	copy := *b
-	return &copy, true
+	for _, b := range r.backends[slug] {
+		if b.Port == port {
+			return b.clone(), true
+		}
+	}
+	return nil, false
 }
 
 // LookupByPath finds a backend whose ProjectPath matches the given path.
-// Falls back to slug-based lookup using Slugify(path).
+// Falls back to slug-based lookup using SlugifyPath(path).
 func (r *Registry) LookupByPath(projectPath string) (*Backend, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	// Exact path match first.
-	for _, b := range r.backends {
-		if b.ProjectPath == projectPath {
-			copy := *b
-			return &copy, true
+	for _, group := range r.backends {
+		for _, b := range group {
+			if b.ProjectPath == projectPath {
+				return b.clone(), true
+			}
 		}
 	}
 
 	// Fall back to slug-based lookup.
-	slug := Slugify(projectPath)
-	if b, ok := r.backends[slug]; ok {
-		copy := *b
-		return &copy, true
+	slug := SlugifyPath(projectPath)
+	if group, ok := r.backends[slug]; ok && len(group) > 0 {
+		return group[0].clone(), true
 	}
 	return nil, false
 }
 
-// All returns a snapshot of all backends.
+// All returns a snapshot of all backends, including every instance of
+// slugs with more than one.
 func (r *Registry) All() []*Backend {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	result := make([]*Backend, 0, len(r.backends))
-	for _, b := range r.backends {
-		copy := *b
-		result = append(result, &copy)
+	result := make([]*Backend, 0, len(r.byPort))
+	for _, group := range r.backends {
+		for _, b := range group {
+			result = append(result, b.clone())
+		}
 	}
 	return result
 }
@@ -185,28 +646,90 @@ func (r *Registry) Slugs() []string {
 	return result
 }
 
-// Len returns the number of registered backends.
+// Len returns the number of registered backend instances.
 func (r *Registry) Len() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return len(r.backends)
+	return len(r.byPort)
 }
 
-// Slugify converts a project path to a hostname-safe slug.
+// SlugifyPath converts a project path to a hostname-safe slug.
 // "/home/alice/projects/My Awesome Project" → "my-awesome-project"
+//
+// Backslashes are treated as separators on every host OS, so a Windows path
+// reported by a remote instance slugifies the same way on a Linux router:
+// `C:\Users\alice\my-project` → "my-project".
+//
+// Slugs are cut to MaxSlugLength, the DNS label limit.
+func SlugifyPath(projectPath string) string {
+	return SlugifyWithOptions(projectPath, SlugifyOptions{})
+}
+
+// Slugify is SlugifyPath.
+//
+// Deprecated: use SlugifyPath.
 func Slugify(projectPath string) string {
-	base := filepath.Base(projectPath)
-	slug := strings.ToLower(base)
-	slug = nonAlphaNum.ReplaceAllString(slug, "-")
-	slug = multiHyphen.ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
+	return SlugifyPath(projectPath)
+}
+
+// SlugifyOptions tweaks SlugifyWithOptions.
+type SlugifyOptions struct {
+	// PreserveVersionSuffix keeps a trailing "v2" or "v2.1" intact, so
+	// "project_v2.1" becomes "project-v2.1" instead of "project-v2-1".
+	// The dot makes such slugs unsuitable as a single DNS label; they still
+	// work for path-based routing.
+	PreserveVersionSuffix bool
+}
+
+// SlugifyWithOptions is SlugifyPath with optional behaviour; the zero options
+// match SlugifyPath.
+func SlugifyWithOptions(projectPath string, opts SlugifyOptions) string {
+	base := path.Base(toSlash(projectPath))
+	if opts.PreserveVersionSuffix {
+		// Absurdly long "versions" fall through to the plain slug.
+		if m := versionSuffix.FindStringSubmatch(base); m != nil && len(m[2]) <= MaxSlugLength/2 {
+			version := strings.ToLower(m[2])
+			if prefix := truncateSlug(slugifyBase(m[1]), MaxSlugLength-len(version)-1); prefix != "" {
+				return prefix + "-" + version
+			}
+			return version
+		}
+	}
+	slug := truncateSlug(slugifyBase(base), MaxSlugLength)
 	if slug == "" {
 		slug = "default"
 	}
 	return slug
 }
 
+// toSlash rewrites Windows separators to forward slashes regardless of the
+// host OS, unlike filepath.ToSlash which only does so on Windows.
+func toSlash(projectPath string) string {
+	return strings.ReplaceAll(projectPath, `\`, "/")
+}
+
+// MaxSlugLength is the longest slug SlugifyPath returns, so that a slug
+// fits in a single DNS label.
+const MaxSlugLength = 63
+
+// truncateSlug cuts slug to at most n bytes without leaving a trailing
+// hyphen. Slugs are ASCII, so any byte offset is a character boundary.
+func truncateSlug(slug string, n int) string {
+	if len(slug) <= n {
+		return slug
+	}
+	return strings.TrimRight(slug[:n], "-")
+}
+
+func slugifyBase(base string) string {
+	slug := strings.ToLower(base)
+	slug = nonAlphaNum.ReplaceAllString(slug, "-")
+	slug = multiHyphen.ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-")
+}
+
 var (
-	nonAlphaNum = regexp.MustCompile(`[^a-z0-9-]`)
-	multiHyphen = regexp.MustCompile(`-+`)
+	nonAlphaNum   = regexp.MustCompile(`[^a-z0-9-]`)
+	multiHyphen   = regexp.MustCompile(`-+`)
+	versionSuffix = regexp.MustCompile(`^(.*?)[-_. ]?([vV]\d+(?:\.\d+)?)$`)
 )
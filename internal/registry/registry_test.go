@@ -3,6 +3,10 @@ package registry
 import (
 	"log/slog"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -16,7 +20,90 @@ func testLogger() *slog.Logger {
 // Slugify
 // ---------------------------------------------------------------------------
 
-func TestSlugify(t *testing.T) {
+func TestSlugifyWithOptions_VersionSuffix(t *testing.T) {
+	tests := []struct {
+		path     string
+		plain    string
+		preserve string
+	}{
+		{"/home/alice/project_v1", "project-v1", "project-v1"},
+		{"/home/alice/project_v2.1", "project-v2-1", "project-v2.1"},
+		{"/home/alice/Project.V3", "project-v3", "project-v3"},
+		{"/home/alice/project-2.0.0", "project-2-0-0", "project-2-0-0"},
+		{"/home/alice/v2.1", "v2-1", "v2.1"},
+		{"/home/alice/dev", "dev", "dev"},
+	}
+
+	for _, tt := range tests {
+		if got := SlugifyWithOptions(tt.path, SlugifyOptions{}); got != tt.plain {
+			t.Errorf("SlugifyWithOptions(%q, off) = %q, want %q", tt.path, got, tt.plain)
+		}
+		if got := Slugify(tt.path); got != tt.plain {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.path, got, tt.plain)
+		}
+		if got := SlugifyWithOptions(tt.path, SlugifyOptions{PreserveVersionSuffix: true}); got != tt.preserve {
+			t.Errorf("SlugifyWithOptions(%q, preserve) = %q, want %q", tt.path, got, tt.preserve)
+		}
+	}
+}
+
+// validSlug is the shape of every slug: DNS-label characters without a
+// leading or trailing hyphen. Single-character slugs such as "c" (from a
+// Windows drive root) are valid labels too.
+var validSlug = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func FuzzSlugify(f *testing.F) {
+	for _, seed := range []string{
+		"/home/alice/myproject",
+		"/home/alice/My Awesome Project",
+		"/home/alice/проект",
+		"/",
+		".",
+		"",
+		`C:\Users\alice\My Project\`,
+		"/home/alice/---project---",
+		"/home/alice/project_v2.1",
+		"/home/alice/" + strings.Repeat("a-", 100),
+		"/home/alice/" + strings.Repeat("!@#$%", 1000),
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		slug := Slugify(path)
+		if slug == "" {
+			t.Fatalf("Slugify(%q) is empty", path)
+		}
+		if slug != "default" && !validSlug.MatchString(slug) {
+			t.Fatalf("Slugify(%q) = %q, not a valid slug", path, slug)
+		}
+		if len(slug) > MaxSlugLength {
+			t.Fatalf("Slugify(%q) = %q, %d bytes, longer than %d", path, slug, len(slug), MaxSlugLength)
+		}
+	})
+}
+
+func BenchmarkSlugify(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		path string
+	}{
+		{"simple", "/home/alice/myproject"},
+		{"spaces", "/home/alice/My Awesome Project"},
+		{"windows", `C:\Users\alice\my-project`},
+		{"unicode", "/home/alice/проект"},
+		{"long", "/home/alice/" + strings.Repeat("project-", 500)},
+		{"special chars", "/home/alice/" + strings.Repeat("!@#$%", 1000)},
+		{"deep", strings.Repeat("/dir", 1000) + "/project"},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Slugify(bm.path)
+			}
+		})
+	}
+}
+
+func TestSlugifyPath(t *testing.T) {
 	tests := []struct {
 		name string
 		path string
@@ -34,22 +121,42 @@ func TestSlugify(t *testing.T) {
 		{"empty basename", "/", "default"},
 		{"just dot", ".", "default"},
 		{"relative path", "relative/path/to/project", "project"},
-		{"windows-ish", `C:\Users\alice\my-project`, "c-users-alice-my-project"}, // backslash not a separator on Linux
+		{"windows", `C:\Users\alice\my-project`, "my-project"},
+		{"windows trailing separator", `C:\Users\alice\My Project\`, "my-project"},
+		{"windows mixed separators", `D:\code/work\api_server`, "api-server"},
+		{"windows unc", `\\fileserver\share\proj`, "proj"},
+		{"windows drive root", `C:\`, "c"},
 		{"mixed", "/opt/code/Hello World v2.1!", "hello-world-v2-1"},
 		{"numbers only", "/home/alice/12345", "12345"},
 		{"already clean", "/home/alice/clean-slug", "clean-slug"},
+		{"too long", "/home/alice/" + strings.Repeat("a", 100), strings.Repeat("a", 63)},
+		{"too long at a hyphen", "/home/alice/" + strings.Repeat("a", 62) + "_b", strings.Repeat("a", 62)},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := Slugify(tt.path)
+			got := SlugifyPath(tt.path)
 			if got != tt.want {
-				t.Errorf("Slugify(%q) = %q, want %q", tt.path, got, tt.want)
+				t.Errorf("SlugifyPath(%q) = %q, want %q", tt.path, got, tt.want)
 			}
 		})
 	}
 }
 
+// Slugify is deprecated but still exported; it must keep matching SlugifyPath.
+func TestSlugify(t *testing.T) {
+	for path, want := range map[string]string{
+		"/home/alice/My Awesome Project": "my-awesome-project",
+		`C:\Users\alice\my-project`:      "my-project",
+		`\\fileserver\share\proj`:        "proj",
+		"/":                              "default",
+	} {
+		if got := Slugify(path); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Upsert
 // ---------------------------------------------------------------------------
@@ -175,6 +282,193 @@ func TestUpsert_MultipleBackends(t *testing.T) {
 	}
 }
 
+func TestUpsert_SameSlugDifferentPathsShareSlug(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+
+	r.Upsert(4096, "repo", "/home/alice/repo", "1.0")
+	isNew := r.Upsert(4097, "repo", "/home/bob/repo", "1.0")
+	if !isNew {
+		t.Error("expected second checkout to be a new entry")
+	}
+
+	all := r.LookupAll("repo")
+	if len(all) != 2 {
+		t.Fatalf("expected 2 instances under slug 'repo', got %d", len(all))
+	}
+	if all[0].Port != 4096 || all[1].Port != 4097 {
+		t.Errorf("expected instances in registration order, got %d, %d", all[0].Port, all[1].Port)
+	}
+	if r.Len() != 2 || len(r.Slugs()) != 1 {
+		t.Errorf("expected 2 backends under 1 slug, got Len=%d Slugs=%v", r.Len(), r.Slugs())
+	}
+
+	b, _ := r.Lookup("repo")
+	if b.Port != 4096 {
+		t.Errorf("Lookup should return the primary instance, got port %d", b.Port)
+	}
+	if b, ok := r.LookupByPort(4097); !ok || b.ProjectPath != "/home/bob/repo" {
+		t.Errorf("LookupByPort(4097) = %+v, %v", b, ok)
+	}
+}
+
+func TestPrune_RemovesSingleInstance(t *testing.T) {
+	r := New(50*time.Millisecond, testLogger())
+
+	r.Upsert(4096, "repo", "/home/alice/repo", "1.0")
+	time.Sleep(100 * time.Millisecond)
+	r.Upsert(4097, "repo", "/home/bob/repo", "1.0")
+
+	removed := r.Prune()
+	if len(removed) != 1 || removed[0] != "repo" {
+		t.Fatalf("expected one 'repo' instance removed, got %v", removed)
+	}
+	all := r.LookupAll("repo")
+	if len(all) != 1 || all[0].Port != 4097 {
+		t.Fatalf("expected the fresh instance to survive, got %+v", all)
+	}
+}
+
+func TestTouch_DefersPrune(t *testing.T) {
+	r := New(50*time.Millisecond, testLogger())
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	time.Sleep(40 * time.Millisecond)
+
+	if !r.Touch(4096) {
+		t.Fatal("Touch should find the backend on 4096")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if removed := r.Prune(); len(removed) != 0 {
+		t.Errorf("touched backend was pruned: %v", removed)
+	}
+	if r.Touch(5000) {
+		t.Error("Touch on an unknown port should return false")
+	}
+}
+
+func TestPrune_DryRun(t *testing.T) {
+	r := New(time.Millisecond, testLogger(), WithDryRun(true))
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	time.Sleep(5 * time.Millisecond)
+
+	if removed := r.Prune(); len(removed) != 0 {
+		t.Fatalf("dry-run Prune removed %v", removed)
+	}
+	if _, ok := r.Lookup("alpha"); !ok {
+		t.Error("stale backend should survive a dry-run Prune")
+	}
+}
+
+func TestPrune_KeepsManualBackends(t *testing.T) {
+	r := New(50*time.Millisecond, testLogger())
+
+	r.UpsertManual(4200, "tunnel", "/home/user/tunnel", "manual")
+	r.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	time.Sleep(100 * time.Millisecond)
+
+	removed := r.Prune()
+	if len(removed) != 1 || removed[0] != "alpha" {
+		t.Fatalf("expected only 'alpha' pruned, got %v", removed)
+	}
+	b, ok := r.Lookup("tunnel")
+	if !ok || !b.Manual {
+		t.Fatalf("expected manual backend to survive prune, got %+v, %v", b, ok)
+	}
+
+	// A later probe of the same port keeps it pinned.
+	r.Upsert(4200, "tunnel", "/home/user/tunnel", "1.2")
+	if b, _ := r.Lookup("tunnel"); !b.Manual || b.Version != "1.2" {
+		t.Errorf("expected probe update to keep Manual, got %+v", b)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := &Backend{Port: 4096, ProjectPath: "/home/user/repo", Version: "1.0", LastSeen: time.Now()}
+	b := *a
+	b.LastSeen = a.LastSeen.Add(time.Minute)
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected fingerprint to ignore LastSeen")
+	}
+	b.Version = "1.1"
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("expected fingerprint to change with version")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/alice/repo", "1.0")
+	r.Upsert(4097, "repo", "/home/bob/repo", "1.0")
+
+	if !r.Remove("repo") {
+		t.Fatal("expected Remove to report success")
+	}
+	if r.Len() != 0 {
+		t.Errorf("expected all instances removed, got %d", r.Len())
+	}
+	if _, ok := r.LookupByPort(4097); ok {
+		t.Error("expected port index to be cleared")
+	}
+	if r.Remove("repo") {
+		t.Error("expected Remove of unknown slug to return false")
+	}
+}
+
+func TestRemoveByPort(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/alice/repo", "1.0")
+	r.UpsertManual(4097, "repo", "/home/bob/repo", "manual")
+
+	if !r.RemoveByPort(4097) {
+		t.Fatal("expected RemoveByPort to remove the manual instance")
+	}
+	all := r.LookupAll("repo")
+	if len(all) != 1 || all[0].Port != 4096 {
+		t.Fatalf("expected only port 4096 to remain, got %+v", all)
+	}
+	if r.RemoveByPort(4097) {
+		t.Error("expected RemoveByPort of unknown port to return false")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// LookupPrefix
+// ---------------------------------------------------------------------------
+
+func TestLookupPrefix(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "my-app", "/home/user/my-app", "1.0")
+	r.Upsert(4097, "my-service", "/home/user/my-service", "1.0")
+	r.Upsert(4098, "other-app", "/home/user/other-app", "1.0")
+
+	slugs := func(bs []*Backend) []string {
+		out := make([]string, 0, len(bs))
+		for _, b := range bs {
+			out = append(out, b.Slug)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"my-", []string{"my-app", "my-service"}},
+		{"MY-", []string{"my-app", "my-service"}},
+		{"", []string{"my-app", "my-service", "other-app"}},
+		{"nope", []string{}},
+	}
+	for _, tc := range tests {
+		got := r.LookupPrefix(tc.prefix)
+		if got == nil {
+			t.Errorf("LookupPrefix(%q) returned nil, want empty slice", tc.prefix)
+		}
+		if s := slugs(got); !reflect.DeepEqual(s, tc.want) {
+			t.Errorf("LookupPrefix(%q) = %v, want %v", tc.prefix, s, tc.want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // LookupByPort
 // ---------------------------------------------------------------------------
@@ -350,3 +644,114 @@ func TestConcurrency(t *testing.T) {
 	wg.Wait()
 	// No race detector panic = success.
 }
+
+// ---------------------------------------------------------------------------
+// Slug collision strategies
+// ---------------------------------------------------------------------------
+
+func TestUpsert_CollisionPort(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithSlugCollision(CollisionPort))
+
+	r.Upsert(4096, "proj", "/alice/proj", "1.0")
+	r.Upsert(4097, "proj", "/bob/proj", "1.0")
+
+	if b, ok := r.Lookup("proj"); !ok || b.ProjectPath != "/alice/proj" {
+		t.Errorf("first project should keep 'proj', got %+v, %v", b, ok)
+	}
+	if b, ok := r.Lookup("proj-4097"); !ok || b.ProjectPath != "/bob/proj" {
+		t.Errorf("second project should be 'proj-4097', got %+v, %v", b, ok)
+	}
+
+	// Re-probing keeps both slugs stable.
+	if r.Upsert(4097, "proj", "/bob/proj", "1.1") {
+		t.Error("re-probe should update, not add")
+	}
+	if r.Len() != 2 {
+		t.Errorf("expected 2 backends, got %d", r.Len())
+	}
+}
+
+func TestUpsert_CollisionPathSuffix(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithSlugCollision(CollisionPathSuffix))
+
+	r.Upsert(4096, "proj", "/alice/proj", "1.0")
+	r.ReplaceSessions("proj", []SessionMetadata{{ID: "s1"}})
+	r.Upsert(4097, "proj", "/bob/proj", "1.0")
+
+	if _, ok := r.Lookup("proj"); ok {
+		t.Error("bare 'proj' should no longer be registered")
+	}
+	alice, ok := r.Lookup("proj-alice")
+	if !ok || alice.Port != 4096 {
+		t.Fatalf("expected /alice/proj as 'proj-alice', got %+v, %v", alice, ok)
+	}
+	bob, ok := r.Lookup("proj-bob")
+	if !ok || bob.Port != 4097 {
+		t.Fatalf("expected /bob/proj as 'proj-bob', got %+v, %v", bob, ok)
+	}
+	if slug := r.byPort[4096]; slug != "proj-alice" {
+		t.Errorf("port index not updated on rename, got %q", slug)
+	}
+	if sessions := r.ListSessions("proj-alice"); len(sessions) != 1 {
+		t.Errorf("expected sessions to follow the renamed slug, got %v", sessions)
+	}
+
+	r.Upsert(4096, "proj", "/alice/proj", "1.1")
+	if b, _ := r.Lookup("proj-alice"); b == nil || b.Version != "1.1" {
+		t.Errorf("re-probe should update 'proj-alice' in place, got %+v", b)
+	}
+	if r.Len() != 2 {
+		t.Errorf("expected 2 backends, got %d", r.Len())
+	}
+}
+
+func TestUpsert_CollisionPathSuffix_WindowsPaths(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithSlugCollision(CollisionPathSuffix))
+
+	r.Upsert(4096, "proj", `C:\Users\alice\proj`, "1.0")
+	r.Upsert(4097, "proj", `C:\Users\bob\proj`, "1.0")
+
+	for slug, port := range map[string]int{"proj-alice": 4096, "proj-bob": 4097} {
+		b, ok := r.Lookup(slug)
+		if !ok || b.Port != port {
+			t.Errorf("Lookup(%q) = %+v, %v; want port %d", slug, b, ok, port)
+		}
+	}
+}
+
+func TestUpsert_CollisionError(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithSlugCollision(CollisionError))
+
+	r.Upsert(4096, "proj", "/alice/proj", "1.0")
+	if r.Upsert(4097, "proj", "/bob/proj", "1.0") {
+		t.Error("colliding backend should be rejected")
+	}
+
+	if r.Len() != 1 {
+		t.Fatalf("expected only the first backend, got %d", r.Len())
+	}
+	if _, ok := r.LookupByPort(4097); ok {
+		t.Error("rejected backend must not be indexed")
+	}
+
+	// The same project moving to another port is not a collision.
+	r.Upsert(4098, "proj", "/alice/proj", "1.0")
+	if b, _ := r.Lookup("proj"); b == nil || b.Port != 4098 {
+		t.Errorf("expected /alice/proj to move to 4098, got %+v", b)
+	}
+}
+
+func TestBackendAddr(t *testing.T) {
+	for _, tt := range []struct {
+		bindIP, want string
+	}{
+		{"", "127.0.0.1:4096"},
+		{DefaultBindIP, "127.0.0.1:4096"},
+		{IPv6BindIP, "[::1]:4096"},
+	} {
+		b := &Backend{Port: 4096, BindIP: tt.bindIP}
+		if got := b.Addr(); got != tt.want {
+			t.Errorf("Addr() with BindIP %q = %q, want %q", tt.bindIP, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSlugNotFound is returned by Rename when no backend uses the old slug.
+	ErrSlugNotFound = errors.New("no backend registered under this slug")
+	// ErrSlugTaken is returned by Rename when the new slug is already in use.
+	ErrSlugTaken = errors.New("slug already in use")
+	// ErrInvalidSlug is returned by Rename for slugs that are not hostname-safe.
+	ErrInvalidSlug = errors.New("invalid slug")
+)
+
+// Rename moves every instance registered under oldSlug to newSlug, keeping
+// ports, LastSeen, labels, history and sessions. The move happens under the
+// write lock, so a concurrent Lookup sees the backend under exactly one of
+// the two slugs. Later scans of the same project path keep the new slug.
+func (r *Registry) Rename(oldSlug, newSlug string) error {
+	if newSlug == "" || slugifyBase(newSlug) != newSlug {
+		return fmt.Errorf("%w %q: use lowercase letters, digits and single hyphens", ErrInvalidSlug, newSlug)
+	}
+
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	group, ok := r.backends[oldSlug]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSlugNotFound, oldSlug)
+	}
+	if oldSlug == newSlug {
+		return nil
+	}
+	if _, taken := r.backends[newSlug]; taken {
+		return fmt.Errorf("%w: %q", ErrSlugTaken, newSlug)
+	}
+
+	for _, b := range group {
+		r.emitLocked(EventRemoved, b)
+		b.Slug = newSlug
+		r.byPort[b.Port] = newSlug
+		r.emitLocked(EventAdded, b)
+	}
+	r.backends[newSlug] = group
+	delete(r.backends, oldSlug)
+	if sessions, ok := r.sessions[oldSlug]; ok {
+		r.sessions[newSlug] = sessions
+		delete(r.sessions, oldSlug)
+	}
+	r.logger.Info("backend renamed", "old_slug", oldSlug, "new_slug", newSlug, "instances", len(group))
+	return nil
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRename_MovesBackend(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "old", "/home/user/old", "1.0")
+	r.UpsertSession("old", SessionMetadata{ID: "s1"})
+	before, _ := r.Lookup("old")
+
+	if err := r.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	if _, ok := r.Lookup("old"); ok {
+		t.Error("old slug should be gone after rename")
+	}
+	b, ok := r.Lookup("new")
+	if !ok {
+		t.Fatal("new slug should be registered after rename")
+	}
+	if b.Slug != "new" || b.Port != 4096 || !b.LastSeen.Equal(before.LastSeen) {
+		t.Errorf("rename did not preserve backend: got %+v, was %+v", b, before)
+	}
+	if p, _ := r.LookupByPort(4096); p == nil || p.Slug != "new" {
+		t.Errorf("LookupByPort should see the new slug, got %+v", p)
+	}
+	if h, ok := r.History("new"); !ok || len(h) != 1 {
+		t.Errorf("expected history to move with the backend, got %v %v", h, ok)
+	}
+	if s := r.ListSessions("new"); len(s) != 1 || s[0].ID != "s1" {
+		t.Errorf("expected sessions to move with the backend, got %v", s)
+	}
+
+	// A later scan of the same project keeps the new slug.
+	r.Upsert(4096, "old", "/home/user/old", "1.1")
+	if _, ok := r.Lookup("old"); ok {
+		t.Error("rescan should not resurrect the old slug")
+	}
+	if b, _ := r.Lookup("new"); b == nil || b.Version != "1.1" {
+		t.Errorf("rescan should update the renamed backend, got %+v", b)
+	}
+}
+
+func TestRename_Errors(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "a", "/home/user/a", "1.0")
+	r.Upsert(4097, "b", "/home/user/b", "1.0")
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     error
+	}{
+		{"unknown", "nope", "c", ErrSlugNotFound},
+		{"taken", "a", "b", ErrSlugTaken},
+		{"empty", "a", "", ErrInvalidSlug},
+		{"not slug-safe", "a", "My App", ErrInvalidSlug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Rename(tt.old, tt.new); !errors.Is(err, tt.want) {
+				t.Errorf("Rename(%q, %q) = %v, want %v", tt.old, tt.new, err, tt.want)
+			}
+		})
+	}
+	if r.Len() != 2 {
+		t.Errorf("failed renames should not change the registry, got %d backends", r.Len())
+	}
+}
+
+func TestRename_ConcurrentLookup(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "old", "/home/user/old", "1.0")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var misses int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			// Check old before new: once old is gone, new must already exist.
+			if _, ok := r.Lookup("old"); ok {
+				continue
+			}
+			if _, ok := r.Lookup("new"); !ok {
+				misses++
+			}
+		}
+	}()
+
+	time.Sleep(time.Millisecond)
+	if err := r.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if misses != 0 {
+		t.Errorf("Lookup missed the backend %d times during rename", misses)
+	}
+}
@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// snapshotVersion is bumped when the snapshot format changes incompatibly.
+const snapshotVersion = 1
+
+// ErrInvalidSnapshot is returned by Restore for data that is not a usable
+// snapshot.
+var ErrInvalidSnapshot = errors.New("invalid registry snapshot")
+
+// snapshot is the JSON form of a registry's state.
+type snapshot struct {
+	Version  int                                   `json:"version"`
+	Backends []*Backend                            `json:"backends"`
+	Sessions map[string]map[string]SessionMetadata `json:"sessions,omitempty"`
+}
+
+// Snapshot encodes every backend, with all of its fields, and the sessions
+// known for each slug as JSON. Restore on another registry reproduces the
+// state; health history and the outages behind UptimePercentage are not
+// carried over, though UptimeSince and TotalDowntime are.
+func (r *Registry) Snapshot() []byte {
+	r.mu.RLock()
+	snap := snapshot{
+		Version:  snapshotVersion,
+		Backends: make([]*Backend, 0, len(r.byPort)),
+		Sessions: make(map[string]map[string]SessionMetadata, len(r.sessions)),
+	}
+	for _, group := range r.backends {
+		for _, b := range group {
+			snap.Backends = append(snap.Backends, b.clone())
+		}
+	}
+	for slug, sessions := range r.sessions {
+		copied := make(map[string]SessionMetadata, len(sessions))
+		for id, s := range sessions {
+			copied[id] = s
+		}
+		snap.Sessions[slug] = copied
+	}
+	r.mu.RUnlock()
+
+	// Backends and sessions hold only plain values, so encoding cannot fail.
+	data, _ := json.Marshal(snap)
+	return data
+}
+
+// Restore replaces every registered backend and session with the state
+// encoded by Snapshot. The data is validated before anything changes, and
+// the swap happens under the write lock, so concurrent lookups see either
+// the old state or the new one. Subscribers get a removed event for each
+// old instance and an added event for each restored one.
+func (r *Registry) Restore(data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, snap.Version)
+	}
+
+	backends := make(map[string][]*Backend)
+	byPort := make(map[int]string, len(snap.Backends))
+	for _, b := range snap.Backends {
+		switch {
+		case b == nil:
+			return fmt.Errorf("%w: null backend", ErrInvalidSnapshot)
+		case b.Port < 1 || b.Port > 65535:
+			return fmt.Errorf("%w: port %d out of range", ErrInvalidSnapshot, b.Port)
+		case b.Slug == "" || slugifyBase(b.Slug) != b.Slug:
+			return fmt.Errorf("%w: invalid slug %q on port %d", ErrInvalidSnapshot, b.Slug, b.Port)
+		}
+		if _, dup := byPort[b.Port]; dup {
+			return fmt.Errorf("%w: port %d listed twice", ErrInvalidSnapshot, b.Port)
+		}
+		b.history = nil
+		backends[b.Slug] = append(backends[b.Slug], b)
+		byPort[b.Port] = b.Slug
+	}
+	sessions := make(map[string]map[string]SessionMetadata, len(snap.Sessions))
+	for slug, s := range snap.Sessions {
+		if _, ok := backends[slug]; ok && len(s) > 0 {
+			sessions[slug] = s
+		}
+	}
+
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	for _, group := range r.backends {
+		for _, b := range group {
+			r.emitLocked(EventRemoved, b)
+		}
+	}
+	r.backends = backends
+	r.byPort = byPort
+	r.sessions = sessions
+	for _, b := range snap.Backends {
+		r.emitLocked(EventAdded, b)
+	}
+	r.logger.Info("registry restored from snapshot", "backends", len(snap.Backends), "slugs", len(backends))
+	return nil
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func sortedByPort(backends []*Backend) []*Backend {
+	sort.Slice(backends, func(i, j int) bool { return backends[i].Port < backends[j].Port })
+	for _, b := range backends {
+		b.history = nil
+		b.firstSeen, b.outages = time.Time{}, nil
+	}
+	return backends
+}
+
+func TestSnapshotRestore_RoundTrip(t *testing.T) {
+	src := New(30*time.Second, testLogger())
+	src.Upsert(4096, "alpha", "/home/user/alpha", "1.0")
+	src.UpsertManual(4097, "beta", "/home/user/beta", "manual")
+	src.Upsert(4098, "beta", "/srv/beta", "1.1")
+	src.SetTLS(4096, true)
+	src.SetSupportsH2C(4098, true)
+	src.SetLabels("alpha", map[string]string{"env": "dev"})
+	src.SetTags(4096, []string{"experimental"})
+	src.UpsertSession("alpha", SessionMetadata{ID: "s1", Title: "first"})
+
+	dst := New(30*time.Second, testLogger())
+	dst.Upsert(5000, "stale", "/home/user/stale", "0.1")
+	events, cancel := dst.Subscribe()
+	defer cancel()
+
+	if err := dst.Restore(src.Snapshot()); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	want, got := sortedByPort(src.All()), sortedByPort(dst.All())
+	if len(got) != len(want) {
+		t.Fatalf("restored %d backends, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !want[i].LastSeen.Equal(got[i].LastSeen) {
+			t.Errorf("port %d: LastSeen = %v, want %v", want[i].Port, got[i].LastSeen, want[i].LastSeen)
+		}
+		if !want[i].UptimeSince.Equal(got[i].UptimeSince) {
+			t.Errorf("port %d: UptimeSince = %v, want %v", want[i].Port, got[i].UptimeSince, want[i].UptimeSince)
+		}
+		got[i].LastSeen = want[i].LastSeen
+		got[i].UptimeSince = want[i].UptimeSince
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("restored backend = %+v, want %+v", got[i], want[i])
+		}
+	}
+	if _, ok := dst.Lookup("stale"); ok {
+		t.Error("Restore should drop backends missing from the snapshot")
+	}
+	if _, ok := dst.LookupByPort(5000); ok {
+		t.Error("Restore should drop the port index of old backends")
+	}
+	if b, ok := dst.LookupByPort(4098); !ok || b.Slug != "beta" {
+		t.Errorf("LookupByPort(4098) = %+v, %v", b, ok)
+	}
+	if s := dst.ListSessions("alpha"); len(s) != 1 || s[0].Title != "first" {
+		t.Errorf("sessions not restored, got %v", s)
+	}
+
+	if ev := nextEvent(t, events); ev.Type != EventRemoved || ev.Slug != "stale" {
+		t.Errorf("first event = %s %s, want removed stale", ev.Type, ev.Slug)
+	}
+	for range want {
+		if ev := nextEvent(t, events); ev.Type != EventAdded {
+			t.Errorf("event = %s %s, want added", ev.Type, ev.Slug)
+		}
+	}
+}
+
+func TestRestore_InvalidLeavesStateUntouched(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"wrong version", `{"version":2,"backends":[]}`},
+		{"bad port", `{"version":1,"backends":[{"port":0,"slug":"a"}]}`},
+		{"bad slug", `{"version":1,"backends":[{"port":4096,"slug":"Not A Slug"}]}`},
+		{"duplicate port", `{"version":1,"backends":[{"port":4096,"slug":"a"},{"port":4096,"slug":"b"}]}`},
+		{"null backend", `{"version":1,"backends":[null]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(30*time.Second, testLogger())
+			r.Upsert(4096, "keep", "/home/user/keep", "1.0")
+			err := r.Restore([]byte(tt.data))
+			if !errors.Is(err, ErrInvalidSnapshot) {
+				t.Fatalf("Restore error = %v, want ErrInvalidSnapshot", err)
+			}
+			if _, ok := r.Lookup("keep"); !ok || r.Len() != 1 {
+				t.Error("failed Restore should leave the registry unchanged")
+			}
+		})
+	}
+}
+
+func TestRestore_EmptySnapshotClears(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "a", "/home/user/a", "1.0")
+	if err := r.Restore(New(time.Second, testLogger()).Snapshot()); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if r.Len() != 0 || len(r.Slugs()) != 0 {
+		t.Errorf("expected an empty registry, got %d backends", r.Len())
+	}
+	// The restored registry keeps working.
+	if !r.Upsert(4096, "a", "/home/user/a", "1.0") {
+		t.Error("Upsert after Restore should add the backend")
+	}
+}
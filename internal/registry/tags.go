@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+)
+
+// SetTags replaces the tags of the backend on port. Tags are trimmed, and
+// empty or repeated ones are dropped. Does nothing if no backend is
+// registered on port.
+func (r *Registry) SetTags(port int, tags []string) {
+	var clean []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(clean, tag) {
+			clean = append(clean, tag)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.unlockAndPublish()
+
+	slug, ok := r.byPort[port]
+	if !ok {
+		return
+	}
+	for _, b := range r.backends[slug] {
+		if b.Port == port && !slices.Equal(b.Tags, clean) {
+			b.Tags = clean
+			r.emitLocked(EventUpdated, b)
+		}
+	}
+}
+
+// LookupByTag returns copies of every backend carrying tag. Tags match
+// exactly. The result is never nil.
+func (r *Registry) LookupByTag(tag string) []*Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*Backend, 0)
+	for _, group := range r.backends {
+		for _, b := range group {
+			if slices.Contains(b.Tags, tag) {
+				result = append(result, b.clone())
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLookupByTag(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "a", "/home/user/a", "1.0")
+	r.Upsert(4097, "b", "/home/user/b", "1.0")
+	r.Upsert(4098, "c", "/home/user/c", "1.0")
+	r.SetTags(4096, []string{"experimental", "gpu"})
+	r.SetTags(4097, []string{" experimental ", "", "experimental"})
+
+	slugs := func(backends []*Backend) []string {
+		out := make([]string, 0, len(backends))
+		for _, b := range backends {
+			out = append(out, b.Slug)
+		}
+		sort.Strings(out)
+		return out
+	}
+	if got := slugs(r.LookupByTag("experimental")); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("LookupByTag(experimental) = %v, want [a b]", got)
+	}
+	if got := slugs(r.LookupByTag("gpu")); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("LookupByTag(gpu) = %v, want [a]", got)
+	}
+	if got := r.LookupByTag("missing"); got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil result, got %#v", got)
+	}
+
+	if b, _ := r.Lookup("b"); !slices.Equal(b.Tags, []string{"experimental"}) {
+		t.Errorf("expected tags to be trimmed and deduplicated, got %q", b.Tags)
+	}
+
+	// Tags survive a rescan and are replaced, not merged, by the next SetTags.
+	r.Upsert(4096, "a", "/home/user/a", "1.1")
+	r.SetTags(4096, []string{"gpu"})
+	if b, _ := r.Lookup("a"); !slices.Equal(b.Tags, []string{"gpu"}) {
+		t.Errorf("expected tags [gpu], got %q", b.Tags)
+	}
+
+	// The returned copy must not alias registry state.
+	b, _ := r.Lookup("a")
+	b.Tags[0] = "mutated"
+	if b, _ := r.Lookup("a"); b.Tags[0] != "gpu" {
+		t.Errorf("lookup copy aliased registry tags: %q", b.Tags)
+	}
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Orders accepted by Registry.TopN.
+const (
+	// SortByLastSeen puts the most recently seen backends first.
+	SortByLastSeen = "last_seen"
+	// SortByUptime puts the longest-running backends first; see
+	// Backend.Uptime.
+	SortByUptime = "uptime"
+	// SortByRequests puts the backends with the most proxied requests
+	// first, as counted by the function given to SetRequestCounter.
+	SortByRequests = "requests"
+	// SortByName orders backends alphabetically by slug.
+	SortByName = "name"
+)
+
+// SetRequestCounter sets how SortByRequests counts the requests proxied to
+// a slug. Without one every backend counts zero.
+func (r *Registry) SetRequestCounter(fn func(slug string) int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = fn
+}
+
+// TopN returns the first n backends of All ordered by sortBy, one of the
+// SortBy constants; n <= 0 returns them all. Ties, and every backend for an
+// unknown sortBy, fall back to SortByName, then to port order.
+func (r *Registry) TopN(n int, sortBy string) []*Backend {
+	backends := r.All()
+	r.mu.RLock()
+	counter := r.requests
+	r.mu.RUnlock()
+
+	var requests map[string]int64
+	if sortBy == SortByRequests && counter != nil {
+		requests = make(map[string]int64)
+		for _, b := range backends {
+			if _, ok := requests[b.Slug]; !ok {
+				requests[b.Slug] = counter(b.Slug)
+			}
+		}
+	}
+
+	byName := func(a, b *Backend) int {
+		return cmp.Or(strings.Compare(a.Slug, b.Slug), cmp.Compare(a.Port, b.Port))
+	}
+	slices.SortFunc(backends, func(a, b *Backend) int {
+		var c int
+		switch sortBy {
+		case SortByLastSeen:
+			c = b.LastSeen.Compare(a.LastSeen)
+		case SortByUptime:
+			c = cmp.Compare(b.Uptime(), a.Uptime())
+		case SortByRequests:
+			c = cmp.Compare(requests[b.Slug], requests[a.Slug])
+		}
+		return cmp.Or(c, byName(a, b))
+	})
+
+	if n > 0 && n < len(backends) {
+		backends = backends[:n]
+	}
+	return backends
+}
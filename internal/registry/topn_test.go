@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+// topNRegistry registers alpha, bravo and charlie, each with a distinct
+// last sighting, uptime and request count so every order is known.
+func topNRegistry() *Registry {
+	r := New(time.Hour, testLogger())
+	now := time.Now()
+	for _, b := range []struct {
+		port    int
+		slug    string
+		seenAgo time.Duration
+		uptime  time.Duration
+	}{
+		{4096, "bravo", 2 * time.Minute, 3 * time.Hour},
+		{4097, "charlie", time.Minute, time.Hour},
+		{4098, "alpha", 3 * time.Minute, 2 * time.Hour},
+	} {
+		r.Upsert(b.port, b.slug, "/home/user/"+b.slug, "1.0")
+		r.mu.Lock()
+		be := r.backends[b.slug][0]
+		be.LastSeen = now.Add(-b.seenAgo)
+		be.UptimeSince = be.LastSeen.Add(-b.uptime)
+		r.mu.Unlock()
+	}
+	counts := map[string]int64{"alpha": 0, "bravo": 5, "charlie": 50}
+	r.SetRequestCounter(func(slug string) int64 { return counts[slug] })
+	return r
+}
+
+func slugList(backends []*Backend) []string {
+	slugs := make([]string, len(backends))
+	for i, b := range backends {
+		slugs[i] = b.Slug
+	}
+	return slugs
+}
+
+func TestTopN(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{SortByName, []string{"alpha", "bravo", "charlie"}},
+		{SortByLastSeen, []string{"charlie", "bravo", "alpha"}},
+		{SortByUptime, []string{"bravo", "alpha", "charlie"}},
+		{SortByRequests, []string{"charlie", "bravo", "alpha"}},
+		{"bogus", []string{"alpha", "bravo", "charlie"}},
+	}
+	r := topNRegistry()
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			got := slugList(r.TopN(0, tt.sortBy))
+			if len(got) != len(tt.want) {
+				t.Fatalf("TopN(0, %q) = %v, want %v", tt.sortBy, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("TopN(0, %q) = %v, want %v", tt.sortBy, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTopN_Limit(t *testing.T) {
+	r := topNRegistry()
+	if got := slugList(r.TopN(2, SortByRequests)); len(got) != 2 || got[0] != "charlie" || got[1] != "bravo" {
+		t.Errorf("TopN(2, requests) = %v, want [charlie bravo]", got)
+	}
+	if got := r.TopN(10, SortByName); len(got) != 3 {
+		t.Errorf("TopN(10, name) returned %d backends, want 3", len(got))
+	}
+}
+
+func TestTopN_RequestsWithoutCounter(t *testing.T) {
+	r := New(time.Hour, testLogger())
+	r.Upsert(4096, "bravo", "/home/user/bravo", "1.0")
+	r.Upsert(4097, "alpha", "/home/user/alpha", "1.0")
+	if got := slugList(r.TopN(0, SortByRequests)); got[0] != "alpha" || got[1] != "bravo" {
+		t.Errorf("TopN(0, requests) without a counter = %v, want name order", got)
+	}
+}
@@ -0,0 +1,79 @@
+package registry
+
+import "time"
+
+// maxOutages is the number of outages kept per backend for
+// UptimePercentage; older ones are forgotten.
+const maxOutages = HealthHistoryCapacity
+
+// outage is a gap between two sightings of a backend longer than the
+// registry's stale-after period.
+type outage struct {
+	from, to time.Time
+}
+
+// Uptime returns how long the backend had been up, without a gap longer
+// than the stale-after period, when it was last seen.
+func (b *Backend) Uptime() time.Duration {
+	if b.UptimeSince.IsZero() || b.LastSeen.Before(b.UptimeSince) {
+		return 0
+	}
+	return b.LastSeen.Sub(b.UptimeSince)
+}
+
+// UptimePercentage returns the share of the last window, from 0 to 100,
+// that the backend was up. Time before the backend was first registered
+// does not count, and a backend that has gone quiet is only charged for
+// the gap once it is seen again. Outages beyond the last maxOutages are
+// forgotten.
+func (b *Backend) UptimePercentage(window time.Duration) float64 {
+	end := time.Now()
+	start := end.Add(-window)
+	first := b.firstSeen
+	if first.IsZero() {
+		first = b.UptimeSince
+	}
+	if start.Before(first) {
+		start = first
+	}
+	span := end.Sub(start)
+	if span <= 0 {
+		return 100
+	}
+	var down time.Duration
+	for _, o := range b.outages {
+		from, to := o.from, o.to
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			down += to.Sub(from)
+		}
+	}
+	return 100 * float64(span-down) / float64(span)
+}
+
+// recordSighting notes that b was seen at now. A gap since LastSeen longer
+// than staleAfter counts as downtime and restarts the uptime clock. Caller
+// must hold the registry lock and update LastSeen afterwards.
+func (b *Backend) recordSighting(now time.Time, staleAfter time.Duration) {
+	if b.UptimeSince.IsZero() {
+		b.UptimeSince = now
+	}
+	if b.LastSeen.IsZero() {
+		return
+	}
+	gap := now.Sub(b.LastSeen)
+	if gap <= staleAfter {
+		return
+	}
+	b.TotalDowntime += gap
+	b.UptimeSince = now
+	b.outages = append(b.outages, outage{from: b.LastSeen, to: now})
+	if len(b.outages) > maxOutages {
+		b.outages = append([]outage(nil), b.outages[len(b.outages)-maxOutages:]...)
+	}
+}
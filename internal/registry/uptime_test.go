@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+// backdate moves every timestamp of the backend on slug back by d, as if
+// the registry had last seen it d earlier.
+func backdate(r *Registry, slug string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.backends[slug][0]
+	b.LastSeen = b.LastSeen.Add(-d)
+	b.UptimeSince = b.UptimeSince.Add(-d)
+	b.firstSeen = b.firstSeen.Add(-d)
+}
+
+func TestUpsert_TracksDowntime(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ := r.Lookup("repo")
+	if b.UptimeSince.IsZero() || b.TotalDowntime != 0 {
+		t.Fatalf("new backend: uptime_since %v, downtime %v", b.UptimeSince, b.TotalDowntime)
+	}
+
+	// Seen again within the stale period: still up, no downtime.
+	backdate(r, "repo", 10*time.Second)
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ = r.Lookup("repo")
+	if b.TotalDowntime != 0 {
+		t.Errorf("a gap within stale-after counted as downtime: %v", b.TotalDowntime)
+	}
+	if up := b.Uptime(); up < 10*time.Second {
+		t.Errorf("Uptime = %v, want at least 10s", up)
+	}
+
+	// Offline for an hour, then back: the gap is downtime and the uptime
+	// clock restarts.
+	backdate(r, "repo", time.Hour)
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ = r.Lookup("repo")
+	if b.TotalDowntime < time.Hour || b.TotalDowntime > time.Hour+time.Second {
+		t.Errorf("TotalDowntime = %v, want about 1h", b.TotalDowntime)
+	}
+	if up := b.Uptime(); up > time.Second {
+		t.Errorf("Uptime = %v after an outage, want about 0", up)
+	}
+
+	// A second outage accumulates.
+	backdate(r, "repo", 2*time.Hour)
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ = r.Lookup("repo")
+	if b.TotalDowntime < 3*time.Hour || b.TotalDowntime > 3*time.Hour+time.Second {
+		t.Errorf("TotalDowntime = %v, want about 3h", b.TotalDowntime)
+	}
+}
+
+func TestUptimePercentage(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ := r.Lookup("repo")
+	if p := b.UptimePercentage(time.Hour); p != 100 {
+		t.Errorf("new backend: UptimePercentage = %v, want 100", p)
+	}
+
+	// Registered four hours ago, then offline for the last hour.
+	now := time.Now()
+	r.mu.Lock()
+	stored := r.backends["repo"][0]
+	stored.firstSeen = now.Add(-4 * time.Hour)
+	stored.UptimeSince = stored.firstSeen
+	stored.LastSeen = now.Add(-time.Hour)
+	r.mu.Unlock()
+	r.Upsert(4096, "repo", "/home/user/repo", "1.0")
+	b, _ = r.Lookup("repo")
+
+	for window, want := range map[time.Duration]float64{
+		2 * time.Hour:    50, // the outage covers half of the last two hours
+		8 * time.Hour:    75, // only the four hours since registration count
+		30 * time.Minute: 0,
+	} {
+		if got := b.UptimePercentage(window); got < want-0.1 || got > want+0.1 {
+			t.Errorf("UptimePercentage(%v) = %.2f, want %.0f", window, got, want)
+		}
+	}
+}
+
+func TestUpsertManual_NoDowntime(t *testing.T) {
+	r := New(30*time.Second, testLogger())
+	r.UpsertManual(4096, "repo", "/home/user/repo", "1.0")
+	backdate(r, "repo", time.Hour)
+	r.UpsertManual(4096, "repo", "/home/user/repo", "1.0")
+	if b, _ := r.Lookup("repo"); b.TotalDowntime != 0 {
+		t.Errorf("re-pinning counted as downtime: %v", b.TotalDowntime)
+	}
+}
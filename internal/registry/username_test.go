@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsernameFromPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/home/alice/proj":           "alice",
+		"/home/alice/proj/":          "alice",
+		"/srv/tenants/Bob Smith/app": "bob-smith",
+		`C:\Users\carol\app`:         "carol",
+		"/proj":                      "",
+		"proj":                       "",
+	} {
+		if got := UsernameFromPath(path); got != want {
+			t.Errorf("UsernameFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestUpsert_UsernameFromPath(t *testing.T) {
+	r := New(30*time.Second, testLogger(), WithUsernameFromPath(true))
+	r.Upsert(4096, "proj", "/home/alice/proj", "1.0")
+	r.Upsert(4097, "proj", "/home/bob/proj", "1.0")
+
+	users := map[int]string{}
+	for _, b := range r.LookupAll("proj") {
+		users[b.Port] = b.Username
+	}
+	if users[4096] != "alice" || users[4097] != "bob" {
+		t.Errorf("usernames by port = %v, want alice on 4096 and bob on 4097", users)
+	}
+
+	plain := New(30*time.Second, testLogger())
+	plain.Upsert(4096, "proj", "/home/alice/proj", "1.0")
+	if b, _ := plain.Lookup("proj"); b.Username != "" {
+		t.Errorf("Username = %q without WithUsernameFromPath, want empty", b.Username)
+	}
+}
@@ -0,0 +1,66 @@
+package registry
+
+import "context"
+
+// Watch returns a channel that receives a copy of slug's backend each time
+// an instance of it is added, upserted or otherwise changed. When the
+// slug's last instance is removed, by Prune, Remove, a rename or a restore,
+// the channel receives nil and is closed. It is also closed, without a nil,
+// once ctx is done. A slug that is not registered yet is waited for.
+//
+// Watch filters a Subscribe subscription and shares its delivery
+// guarantees: changes that do not fit the subscription buffer are dropped.
+func (r *Registry) Watch(ctx context.Context, slug string) <-chan *Backend {
+	events, cancel := r.Subscribe()
+	out := make(chan *Backend, subscriberBuffer)
+
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		send := func(b *Backend) bool {
+			select {
+			case out <- b:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				for _, e := range flatten(ev) {
+					if e.Slug != slug {
+						continue
+					}
+					if e.Type == EventRemoved {
+						// Other instances may still serve the slug.
+						if _, ok := r.Lookup(slug); ok {
+							continue
+						}
+						send(nil)
+						return
+					}
+					if !send(e.Backend.clone()) {
+						return
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// flatten returns the individual changes in ev: those of an EventBatch, or
+// ev itself.
+func flatten(ev RegistryEvent) []RegistryEvent {
+	if ev.Type == EventBatch {
+		return ev.Events
+	}
+	return []RegistryEvent{ev}
+}
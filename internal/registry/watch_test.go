@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextBackend returns the next value from ch, failing if none arrives.
+func nextBackend(t *testing.T, ch <-chan *Backend) (*Backend, bool) {
+	t.Helper()
+	select {
+	case b, ok := <-ch:
+		return b, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("no watch event within 2s")
+		return nil, false
+	}
+}
+
+func TestWatch_OnlyWatchedSlug(t *testing.T) {
+	reg := New(30*time.Second, testLogger())
+	ch := reg.Watch(context.Background(), "a")
+
+	reg.Upsert(4200, "b", "/home/test/b", "1.0")
+	reg.Upsert(4201, "a", "/home/test/a", "1.0")
+	reg.Upsert(4200, "b", "/home/test/b", "1.1")
+	reg.Upsert(4201, "a", "/home/test/a", "1.1")
+
+	for _, want := range []string{"1.0", "1.1"} {
+		b, ok := nextBackend(t, ch)
+		if !ok || b == nil || b.Slug != "a" || b.Version != want {
+			t.Fatalf("got %+v (open %v), want a at version %s", b, ok, want)
+		}
+	}
+	select {
+	case b := <-ch:
+		t.Fatalf("unexpected event %+v", b)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestWatch_BatchUpsert(t *testing.T) {
+	reg := New(30*time.Second, testLogger())
+	ch := reg.Watch(context.Background(), "a")
+
+	reg.BatchUpsert([]UpsertEntry{
+		{Port: 4200, ProjectName: "b", ProjectPath: "/home/test/b", Version: "1.0"},
+		{Port: 4201, ProjectName: "a", ProjectPath: "/home/test/a", Version: "1.0"},
+	})
+	if b, ok := nextBackend(t, ch); !ok || b == nil || b.Slug != "a" {
+		t.Fatalf("got %+v (open %v), want a", b, ok)
+	}
+}
+
+func TestWatch_PruneSendsNilAndCloses(t *testing.T) {
+	reg := New(time.Millisecond, testLogger())
+	reg.Upsert(4200, "a", "/home/test/a", "1.0")
+	reg.Upsert(4201, "b", "/home/test/b", "1.0")
+	ch := reg.Watch(context.Background(), "a")
+
+	time.Sleep(5 * time.Millisecond)
+	reg.Prune()
+
+	b, ok := nextBackend(t, ch)
+	if !ok || b != nil {
+		t.Fatalf("got %+v (open %v), want nil before close", b, ok)
+	}
+	if b, ok := nextBackend(t, ch); ok {
+		t.Fatalf("channel should be closed after nil, got %+v", b)
+	}
+}
+
+func TestWatch_RemovingOneInstanceKeepsWatching(t *testing.T) {
+	reg := New(30*time.Second, testLogger(), WithSlugCollision(CollisionGroup))
+	reg.Upsert(4200, "a", "/home/test/a", "1.0")
+	reg.Upsert(4201, "a", "/srv/a", "1.0")
+	if n := len(reg.LookupAll("a")); n != 2 {
+		t.Fatalf("expected 2 instances of a, got %d", n)
+	}
+	ch := reg.Watch(context.Background(), "a")
+
+	reg.RemoveByPort(4201)
+	reg.Upsert(4200, "a", "/home/test/a", "1.1")
+	if b, ok := nextBackend(t, ch); !ok || b == nil || b.Version != "1.1" {
+		t.Fatalf("got %+v (open %v), want the remaining instance's update", b, ok)
+	}
+}
+
+func TestWatch_ContextCancelCloses(t *testing.T) {
+	reg := New(30*time.Second, testLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := reg.Watch(ctx, "a")
+	cancel()
+
+	if b, ok := nextBackend(t, ch); ok {
+		t.Fatalf("expected a closed channel, got %+v", b)
+	}
+}
@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Adaptive concurrency. Each scan samples CPU idle time since the previous
+// one: below cpuIdleLow the probe concurrency is halved, above cpuIdleHigh
+// it grows by one probe per CPU, never beyond autoConcurrency.
+const (
+	probesPerCPU = 4
+	cpuIdleLow   = 0.20
+	cpuIdleHigh  = 0.50
+)
+
+// WithAdaptiveConcurrency replaces the fixed concurrency with
+// min(NumCPU*4, port range size), adjusted before each scan according to
+// CPU idle time. Adjustment needs /proc/stat; elsewhere the starting value
+// is kept.
+func WithAdaptiveConcurrency(enabled bool) Option {
+	return func(s *Scanner) {
+		s.adaptive = enabled
+		if enabled {
+			s.concurrency = s.autoConcurrency()
+		}
+	}
+}
+
+// autoConcurrency is the starting value and upper bound for adaptive
+// concurrency.
+func (s *Scanner) autoConcurrency() int {
+	return max(min(runtime.NumCPU()*probesPerCPU, s.portCount()), 1)
+}
+
+// SetConcurrency sets how many ports are probed at once, from the next scan
+// on. Values below 1 are treated as 1.
+func (s *Scanner) SetConcurrency(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.concurrency = max(n, 1)
+}
+
+// Concurrency returns how many ports are probed at once.
+func (s *Scanner) Concurrency() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.concurrency
+}
+
+// adaptConcurrency applies one step of adaptive concurrency. The first call
+// only records a CPU sample.
+func (s *Scanner) adaptConcurrency() {
+	idle, total, err := s.readCPU()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	prevIdle, prevTotal := s.cpuIdle, s.cpuTotal
+	s.cpuIdle, s.cpuTotal = idle, total
+	if prevTotal == 0 || total <= prevTotal || idle < prevIdle {
+		s.mu.Unlock()
+		return
+	}
+	idleFrac := float64(idle-prevIdle) / float64(total-prevTotal)
+	cur, limit := s.concurrency, s.autoConcurrency()
+	next := cur
+	switch {
+	case idleFrac < cpuIdleLow:
+		next = max(cur/2, 1)
+	case idleFrac > cpuIdleHigh:
+		next = min(cur+runtime.NumCPU(), limit)
+	}
+	next = min(next, limit)
+	s.concurrency = next
+	s.mu.Unlock()
+
+	if next != cur {
+		s.logger.Debug("scan concurrency adjusted", "from", cur, "to", next, "cpu_idle", idleFrac)
+	}
+}
+
+// readProcStat returns the idle (including iowait) and total CPU time from
+// the aggregate line of /proc/stat, in clock ticks.
+func readProcStat() (idle, total uint64, err error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		return 0, 0, errors.New("empty /proc/stat")
+	}
+	return parseCPULine(sc.Text())
+}
+
+// parseCPULine parses "cpu  user nice system idle iowait irq softirq ...".
+func parseCPULine(line string) (idle, total uint64, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, errors.New("unexpected /proc/stat format")
+	}
+	// Only the first eight counters: guest time is already part of user.
+	for i, field := range fields[1:min(len(fields), 9)] {
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		total += v
+		if i == 3 || i == 4 { // idle, iowait
+			idle += v
+		}
+	}
+	return idle, total, nil
+}
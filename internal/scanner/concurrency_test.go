@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/registry"
+)
+
+// fakeCPU returns a readCPU func that reports the given idle fraction for
+// each successive 100-tick interval.
+func fakeCPU(idleFracs ...float64) func() (uint64, uint64, error) {
+	var idle, total uint64
+	i := 0
+	return func() (uint64, uint64, error) {
+		if i > 0 && i <= len(idleFracs) {
+			idle += uint64(idleFracs[i-1] * 100)
+		}
+		total += 100
+		i++
+		return idle, total, nil
+	}
+}
+
+func TestWithAdaptiveConcurrency_StartsAtCPUBound(t *testing.T) {
+	reg := registry.New(time.Minute, testLogger())
+	cpuBound := runtime.NumCPU() * probesPerCPU
+
+	wide := New(reg, 1, 100000, time.Minute, 20, time.Second, testLogger(), WithAdaptiveConcurrency(true))
+	if got := wide.Concurrency(); got != cpuBound {
+		t.Errorf("wide range: concurrency = %d, want NumCPU*4 = %d", got, cpuBound)
+	}
+	narrow := New(reg, 4096, 4097, time.Minute, 20, time.Second, testLogger(), WithAdaptiveConcurrency(true))
+	if got := narrow.Concurrency(); got != 2 {
+		t.Errorf("narrow range: concurrency = %d, want range size 2", got)
+	}
+	fixed := New(reg, 4096, 4097, time.Minute, 20, time.Second, testLogger(), WithAdaptiveConcurrency(false))
+	if got := fixed.Concurrency(); got != 20 {
+		t.Errorf("fixed: concurrency = %d, want 20", got)
+	}
+}
+
+func TestAdaptConcurrency(t *testing.T) {
+	reg := registry.New(time.Minute, testLogger())
+	sc := New(reg, 1, 100000, time.Minute, 20, time.Second, testLogger(), WithAdaptiveConcurrency(true))
+	limit := runtime.NumCPU() * probesPerCPU
+
+	sc.readCPU = fakeCPU(0.05, 0.05, 0.9, 0.35)
+	sc.adaptConcurrency() // first sample only
+	if got := sc.Concurrency(); got != limit {
+		t.Fatalf("first sample changed concurrency to %d", got)
+	}
+	sc.adaptConcurrency()
+	if got, want := sc.Concurrency(), max(limit/2, 1); got != want {
+		t.Errorf("busy CPU: concurrency = %d, want %d", got, want)
+	}
+	sc.adaptConcurrency()
+	if got, want := sc.Concurrency(), max(limit/4, 1); got != want {
+		t.Errorf("still busy: concurrency = %d, want %d", got, want)
+	}
+	sc.adaptConcurrency()
+	if got, want := sc.Concurrency(), min(max(limit/4, 1)+runtime.NumCPU(), limit); got != want {
+		t.Errorf("idle CPU: concurrency = %d, want %d", got, want)
+	}
+	before := sc.Concurrency()
+	sc.adaptConcurrency()
+	if got := sc.Concurrency(); got != before {
+		t.Errorf("moderate load: concurrency changed from %d to %d", before, got)
+	}
+}
+
+func TestAdaptConcurrency_NeverExceedsCPUBound(t *testing.T) {
+	reg := registry.New(time.Minute, testLogger())
+	sc := New(reg, 1, 100000, time.Minute, 20, time.Second, testLogger(), WithAdaptiveConcurrency(true))
+	limit := runtime.NumCPU() * probesPerCPU
+
+	idle := make([]float64, 50)
+	for i := range idle {
+		idle[i] = 1
+	}
+	sc.readCPU = fakeCPU(idle...)
+	sc.SetConcurrency(1)
+	for range idle {
+		sc.adaptConcurrency()
+		if got := sc.Concurrency(); got > limit {
+			t.Fatalf("concurrency %d exceeds NumCPU*4 = %d", got, limit)
+		}
+	}
+	if got := sc.Concurrency(); got != limit {
+		t.Errorf("idle CPU should grow concurrency to %d, got %d", limit, got)
+	}
+
+	// A manual value above the bound is pulled back on the next step.
+	sc.SetConcurrency(limit * 10)
+	sc.readCPU = fakeCPU(0.35, 0.35)
+	sc.adaptConcurrency()
+	sc.adaptConcurrency()
+	if got := sc.Concurrency(); got > limit {
+		t.Errorf("concurrency %d exceeds NumCPU*4 = %d", got, limit)
+	}
+}
+
+func TestSetConcurrency_Minimum(t *testing.T) {
+	sc := New(registry.New(time.Minute, testLogger()), 4096, 4097, time.Minute, 20, time.Second, testLogger())
+	sc.SetConcurrency(0)
+	if got := sc.Concurrency(); got != 1 {
+		t.Errorf("SetConcurrency(0) gave %d, want 1", got)
+	}
+}
+
+func TestReconfigure_AdaptiveConcurrency(t *testing.T) {
+	sc := New(registry.New(time.Minute, testLogger()), 4096, 4099, time.Minute, 20, time.Second, testLogger())
+	cfg := config.Defaults()
+	cfg.ScanConcurrencyAuto = true
+	sc.Reconfigure(cfg)
+	if got := sc.Concurrency(); got != min(runtime.NumCPU()*probesPerCPU, 4) {
+		t.Errorf("auto: concurrency = %d", got)
+	}
+	cfg.ScanConcurrencyAuto = false
+	cfg.ScanConcurrency = 7
+	sc.Reconfigure(cfg)
+	if got := sc.Concurrency(); got != 7 {
+		t.Errorf("fixed: concurrency = %d, want 7", got)
+	}
+}
+
+func TestParseCPULine(t *testing.T) {
+	idle, total, err := parseCPULine("cpu  100 5 50 800 20 3 2 10 40 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idle != 820 || total != 990 {
+		t.Errorf("idle, total = %d, %d; want 820, 990", idle, total)
+	}
+	for _, bad := range []string{"", "cpu0 1 2 3 4 5", "cpu 1 2 x 4 5", "cpu 1 2"} {
+		if _, _, err := parseCPULine(bad); err == nil {
+			t.Errorf("parseCPULine(%q) should fail", bad)
+		}
+	}
+}
+
+// BenchmarkScanClosedPorts scans 1000 closed ports with the default fixed
+// concurrency and with adaptive concurrency.
+func BenchmarkScanClosedPorts(b *testing.B) {
+	const n = 1000
+	listeners := consecutiveListeners(b, n)
+	base := listeners[0].Addr().(*net.TCPAddr).Port
+	for _, ln := range listeners {
+		ln.Close()
+	}
+	limit := runtime.NumCPU() * probesPerCPU
+
+	for _, tc := range []struct {
+		name     string
+		adaptive bool
+	}{
+		{"fixed", false},
+		{"adaptive", true},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			reg := registry.New(time.Minute, testLogger())
+			sc := New(reg, base, base+n-1, time.Minute, config.Defaults().ScanConcurrency, time.Second, testLogger(),
+				WithAdaptiveConcurrency(tc.adaptive))
+			for b.Loop() {
+				sc.ScanOnce(context.Background())
+				if tc.adaptive && sc.Concurrency() > limit {
+					b.Fatalf("concurrency %d exceeds NumCPU*4 = %d", sc.Concurrency(), limit)
+				}
+			}
+			b.ReportMetric(float64(sc.Concurrency()), "concurrency")
+		})
+	}
+}
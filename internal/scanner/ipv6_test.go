@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// newIPv6OpenCode starts a fake OpenCode listening on [::1] only, skipping
+// the test where the IPv6 loopback is unavailable.
+func newIPv6OpenCode(t *testing.T) (*httptest.Server, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(fakeOpenCodeHandler("/global/health", "/project/current", true, "v6proj", "/home/test/v6proj", "1.0.0"))
+	srv.Listener.Close()
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestProbePort_IPv6(t *testing.T) {
+	_, port := newIPv6OpenCode(t)
+	if conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), 100*time.Millisecond); err == nil {
+		conn.Close()
+		t.Skipf("port %d is also in use on IPv4", port)
+	}
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithProbeRetries(0, 0))
+	sc.probePort(context.Background(), port)
+	if reg.Len() != 0 {
+		t.Fatal("without IPv6 probing an IPv6-only backend should not be found")
+	}
+
+	sc = New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithIPv6Probe(true))
+	sc.probePort(context.Background(), port)
+	b, ok := reg.Lookup("v6proj")
+	if !ok {
+		t.Fatal("expected the IPv6 backend to be registered")
+	}
+	if b.BindIP != registry.IPv6BindIP {
+		t.Errorf("BindIP = %q, want %q", b.BindIP, registry.IPv6BindIP)
+	}
+	if want := net.JoinHostPort("::1", strconv.Itoa(port)); b.Addr() != want {
+		t.Errorf("Addr() = %q, want %q", b.Addr(), want)
+	}
+}
+
+func TestProbePort_PrefersIPv4(t *testing.T) {
+	srv := fakeOpenCode(true, "v4proj", "/home/test/v4proj", "1.0.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithIPv6Probe(true))
+	sc.probePort(context.Background(), port)
+	b, ok := reg.Lookup("v4proj")
+	if !ok {
+		t.Fatal("expected the backend to be registered")
+	}
+	if b.BindIP != registry.DefaultBindIP {
+		t.Errorf("BindIP = %q, want %q", b.BindIP, registry.DefaultBindIP)
+	}
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// countDials wraps the scanner's dialer and returns the number of TCP
+// connections it has opened so far.
+func countDials(s *Scanner) *atomic.Int32 {
+	var n atomic.Int32
+	dial := s.transport.DialContext
+	s.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		n.Add(1)
+		return dial(ctx, network, addr)
+	}
+	return &n
+}
+
+func TestProbePort_ReusesConnection(t *testing.T) {
+	srv := fakeOpenCode(true, "proj", "/home/test/proj", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger())
+	dials := countDials(sc)
+
+	sc.probePort(context.Background(), port)
+	sc.probePort(context.Background(), port)
+
+	if _, ok := reg.Lookup("proj"); !ok {
+		t.Fatal("expected backend to be registered")
+	}
+	if got := dials.Load(); got != 1 {
+		t.Errorf("expected 1 TCP connection for two probes, got %d", got)
+	}
+}
+
+// BenchmarkScan scans 100 fake backends per iteration, with and without
+// connection reuse.
+func BenchmarkScan(b *testing.B) {
+	const n = 100
+	listeners := consecutiveListeners(b, n)
+	base := listeners[0].Addr().(*net.TCPAddr).Port
+	for i, ln := range listeners {
+		srv := httptest.NewUnstartedServer(fakeOpenCodeHandler("/global/health", "/project/current",
+			true, "proj", fmt.Sprintf("/home/test/proj%d", i), "1.0"))
+		srv.Listener.Close()
+		srv.Listener = ln
+		srv.Start()
+		b.Cleanup(srv.Close)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		keepAlive bool
+	}{
+		{"keepalive", true},
+		{"no-keepalive", false},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			reg := registry.New(time.Minute, testLogger())
+			sc := New(reg, base, base+n-1, time.Minute, 20, 2*time.Second, testLogger())
+			sc.transport.DisableKeepAlives = !tc.keepAlive
+			for b.Loop() {
+				sc.ScanOnce(context.Background())
+			}
+			b.StopTimer()
+			if reg.Len() != n {
+				b.Fatalf("expected %d backends, got %d", n, reg.Len())
+			}
+			sc.transport.CloseIdleConnections()
+		})
+	}
+}
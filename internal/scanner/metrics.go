@@ -0,0 +1,36 @@
+package scanner
+
+import (
+	"time"
+)
+
+// ScanMetrics summarises the most recent completed scan.
+type ScanMetrics struct {
+	// PortsScanned counts ports actually probed; excluded and backed-off
+	// ports are not included.
+	PortsScanned int64 `json:"ports_scanned"`
+	// BackendsFound counts ports that answered the health probe as healthy.
+	BackendsFound int64 `json:"backends_found"`
+	// ScanDurationMs is the wall time of the scan, rounded up to a whole
+	// millisecond so a completed scan never reports zero.
+	ScanDurationMs int64 `json:"scan_duration_ms"`
+	// LastScanTime is when the scan finished. Zero until the first scan.
+	LastScanTime time.Time `json:"last_scan_time"`
+}
+
+// LastMetrics returns the metrics of the most recent completed scan.
+func (s *Scanner) LastMetrics() ScanMetrics {
+	if m := s.metrics.Load(); m != nil {
+		return *m
+	}
+	return ScanMetrics{}
+}
+
+func (s *Scanner) recordMetrics(ports, found int64, elapsed time.Duration) {
+	s.metrics.Store(&ScanMetrics{
+		PortsScanned:   ports,
+		BackendsFound:  found,
+		ScanDurationMs: int64((elapsed + time.Millisecond - 1) / time.Millisecond),
+		LastScanTime:   time.Now(),
+	})
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// consecutiveListeners opens n listeners on adjacent localhost ports so a
+// scan range can cover exactly those servers.
+func consecutiveListeners(t testing.TB, n int) []net.Listener {
+	t.Helper()
+	for attempt := 0; attempt < 20; attempt++ {
+		first, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		base := first.Addr().(*net.TCPAddr).Port
+		listeners := []net.Listener{first}
+		for i := 1; i < n; i++ {
+			ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", base+i))
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, ln)
+		}
+		if len(listeners) == n {
+			return listeners
+		}
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
+	t.Fatalf("could not find %d consecutive free ports", n)
+	return nil
+}
+
+func TestScan_RecordsMetrics(t *testing.T) {
+	listeners := consecutiveListeners(t, 3)
+	for i, healthy := range []bool{true, false, true} {
+		srv := httptest.NewUnstartedServer(fakeOpenCodeHandler("/global/health", "/project/current",
+			healthy, fmt.Sprintf("proj%d", i), fmt.Sprintf("/home/test/proj%d", i), "1.0"))
+		srv.Listener.Close()
+		srv.Listener = listeners[i]
+		srv.Start()
+		defer srv.Close()
+	}
+	start := listeners[0].Addr().(*net.TCPAddr).Port
+
+	sc := New(registry.New(30*time.Second, testLogger()), start, start+2, 5*time.Second, 3, time.Second, testLogger())
+	if m := sc.LastMetrics(); !m.LastScanTime.IsZero() {
+		t.Fatalf("expected zero metrics before the first scan, got %+v", m)
+	}
+
+	before := time.Now()
+	sc.ScanOnce(context.Background())
+	m := sc.LastMetrics()
+	if m.PortsScanned != 3 || m.BackendsFound != 2 {
+		t.Errorf("expected 3 ports scanned and 2 backends found, got %+v", m)
+	}
+	if m.ScanDurationMs <= 0 {
+		t.Errorf("expected positive scan duration, got %d", m.ScanDurationMs)
+	}
+	if m.LastScanTime.Before(before) {
+		t.Errorf("LastScanTime %s predates the scan", m.LastScanTime)
+	}
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"opencoderouter/internal/registry"
+)
+
+// ErrUnreachable is returned by Register when no healthy OpenCode instance
+// answers on the port.
+var ErrUnreachable = errors.New("no healthy OpenCode instance answers on this port")
+
+// WithPassive stops the scanner from sweeping the port range. Backends
+// announce themselves through Register instead, and each scan re-probes
+// only the ports already registered, which keeps LastSeen current and lets
+// Prune expire backends that went away.
+func WithPassive(enabled bool) Option {
+	return func(s *Scanner) {
+		s.passive = enabled
+	}
+}
+
+// Passive reports whether the scanner runs in passive mode.
+func (s *Scanner) Passive() bool {
+	return s.passive
+}
+
+// registeredPorts yields the ports of the scanned (not pinned) backends in
+// the registry, in ascending order.
+func (s *Scanner) registeredPorts() iter.Seq[int] {
+	var ports []int
+	for _, b := range s.registry.All() {
+		if !b.Manual {
+			ports = append(ports, b.Port)
+		}
+	}
+	slices.Sort(ports)
+	return slices.Values(slices.Compact(ports))
+}
+
+// Register probes port once and, if a healthy OpenCode instance answers,
+// registers it with the details it reports, as a scan would. This is how
+// backends self-register in passive mode; registering again updates the
+// entry. Ports the scanner never probes, such as --exclude-ports, are
+// refused.
+func (s *Scanner) Register(ctx context.Context, port int) (*registry.Backend, error) {
+	if s.isExcluded(port) {
+		return nil, fmt.Errorf("port %d is excluded from scanning", port)
+	}
+	if s.dryRun {
+		return nil, errors.New("dry run: backends are not registered")
+	}
+	finding, outcome := s.probe(ctx, port)
+	if outcome == probeFailed || finding == nil {
+		return nil, ErrUnreachable
+	}
+	s.register([]*probeFinding{finding})
+	b, ok := s.registry.LookupByPort(port)
+	if !ok {
+		return nil, fmt.Errorf("registration of port %d refused by the slug collision strategy", port)
+	}
+	s.logger.Info("backend self-registered", "port", port, "slug", b.Slug, "version", b.Version)
+	return b, nil
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func TestScan_PassiveProbesRegisteredPortsOnly(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	reg.Upsert(30005, "known", "/home/test/known", "1.0")
+	reg.UpsertManual(30007, "pinned", "/home/test/pinned", "1.0")
+	sc := New(reg, 30000, 30009, 5*time.Second, 4, time.Second, testLogger(),
+		WithPassive(true), WithProbeRetries(0, 0))
+
+	var (
+		mu     sync.Mutex
+		dialed = map[int]int{}
+	)
+	sc.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, p, _ := net.SplitHostPort(addr)
+		port, _ := strconv.Atoi(p)
+		mu.Lock()
+		dialed[port]++
+		mu.Unlock()
+		return nil, errors.New("refused by test dialer")
+	}
+
+	sc.scan(context.Background(), false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dialed[30005] == 0 {
+		t.Error("the registered port was not re-probed")
+	}
+	for port := 30000; port <= 30009; port++ {
+		if port != 30005 && dialed[port] != 0 {
+			t.Errorf("port %d was dialed %d times in passive mode", port, dialed[port])
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	srv := fakeOpenCode(true, "selfreg", "/home/test/selfreg", "2.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 1, 1, 5*time.Second, 1, time.Second, testLogger(), WithPassive(true))
+	b, err := sc.Register(context.Background(), port)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if b.Slug != "selfreg" || b.Version != "2.0" || b.Manual {
+		t.Errorf("registered %+v", b)
+	}
+
+	unhealthy := fakeOpenCode(false, "sick", "/home/test/sick", "2.0")
+	defer unhealthy.Close()
+	if _, err := sc.Register(context.Background(), extractPort(t, unhealthy.URL)); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("unhealthy backend: err = %v, want ErrUnreachable", err)
+	}
+
+	excluded := New(reg, 1, 1, 5*time.Second, 1, time.Second, testLogger(), WithExcludePorts([]int{port}))
+	if _, err := excluded.Register(context.Background(), port); err == nil {
+		t.Error("an excluded port should be refused")
+	}
+}
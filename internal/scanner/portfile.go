@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"opencoderouter/internal/registry"
+)
+
+// portFileContent matches what PortFileWriter writes, so stale files from an
+// earlier run can be told apart from anything else in the directory.
+var portFileContent = regexp.MustCompile(`^\S+:\d+\n$`)
+
+// PortFileWriter mirrors the registry into a directory for shell tooling:
+// one file per slug, named after it, holding the host:port of the slug's
+// primary instance and a newline ("127.0.0.1:4096", or "[::1]:4096" for an
+// IPv6 backend), e.g.
+//
+//	curl "http://$(cat /tmp/opencode-ports/myproject)/global/health"
+type PortFileWriter struct {
+	dir      string
+	registry *registry.Registry
+	logger   *slog.Logger
+}
+
+// NewPortFileWriter returns a writer for dir, which Write creates if needed.
+func NewPortFileWriter(dir string, reg *registry.Registry, logger *slog.Logger) *PortFileWriter {
+	return &PortFileWriter{dir: dir, registry: reg, logger: logger}
+}
+
+// WithPortDir makes Run write the registered backends' ports into dir after
+// every scan, as described for PortFileWriter. Empty disables it.
+func WithPortDir(dir string) Option {
+	return func(s *Scanner) {
+		s.portDir = dir
+	}
+}
+
+// Write brings the directory in line with the registry. Files whose content
+// is unchanged are left alone and others are replaced atomically; files for
+// slugs no longer registered are deleted. Only files that look like port
+// files are deleted, so other entries in the directory survive.
+func (p *PortFileWriter) Write() error {
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return fmt.Errorf("create port dir: %w", err)
+	}
+
+	want := make(map[string][]byte)
+	for _, slug := range p.registry.Slugs() {
+		b, ok := p.registry.Lookup(slug)
+		if !ok || !safeFileName(slug) {
+			continue
+		}
+		want[slug] = []byte(b.Addr() + "\n")
+	}
+
+	var errs []error
+	for slug, content := range want {
+		if err := p.writeFile(slug, content); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("read port dir: %w", err))...)
+	}
+	for _, e := range entries {
+		if _, ok := want[e.Name()]; ok || !e.Type().IsRegular() {
+			continue
+		}
+		if err := p.removeIfPortFile(e.Name()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Clear deletes every port file in the directory, for shutdown, so scripts
+// do not find ports of backends the router no longer tracks.
+func (p *PortFileWriter) Clear() error {
+	entries, err := os.ReadDir(p.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read port dir: %w", err)
+	}
+	var errs []error
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			if err := p.removeIfPortFile(e.Name()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeFile replaces name with content unless it already holds it.
+func (p *PortFileWriter) writeFile(name string, content []byte) error {
+	path := filepath.Join(p.dir, name)
+	if cur, err := os.ReadFile(path); err == nil && bytes.Equal(cur, content) {
+		return nil
+	}
+	tmp, err := os.CreateTemp(p.dir, "."+name+".*")
+	if err != nil {
+		return fmt.Errorf("write port file %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write port file %s: %w", name, err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write port file %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write port file %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write port file %s: %w", name, err)
+	}
+	p.logger.Debug("port file written", "slug", name, "path", path)
+	return nil
+}
+
+// removeIfPortFile deletes name when its content looks like a port file.
+func (p *PortFileWriter) removeIfPortFile(name string) error {
+	path := filepath.Join(p.dir, name)
+	content, err := os.ReadFile(path)
+	if err != nil || !portFileContent.Match(content) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove port file %s: %w", name, err)
+	}
+	p.logger.Debug("port file removed", "slug", name, "path", path)
+	return nil
+}
+
+// safeFileName reports whether slug can be used as a file name as is.
+func safeFileName(slug string) bool {
+	return slug != "" && !strings.HasPrefix(slug, ".") && !strings.ContainsAny(slug, `/\`)
+}
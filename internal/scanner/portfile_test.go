@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func readPortFile(t *testing.T, dir, slug string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, slug))
+	if err != nil {
+		t.Fatalf("read port file %s: %v", slug, err)
+	}
+	return string(data)
+}
+
+func TestPortFileWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ports")
+	reg := registry.New(time.Millisecond, testLogger())
+	reg.Upsert(30000, "alpha", "/home/test/alpha", "1.0")
+	reg.UpsertManual(30001, "beta", "/home/test/beta", "1.0")
+	pw := NewPortFileWriter(dir, reg, testLogger())
+
+	// Created, along with the directory.
+	if err := pw.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := readPortFile(t, dir, "alpha"); got != "127.0.0.1:30000\n" {
+		t.Errorf("alpha = %q", got)
+	}
+	if got := readPortFile(t, dir, "beta"); got != "127.0.0.1:30001\n" {
+		t.Errorf("beta = %q", got)
+	}
+
+	// Updated when the port changes.
+	reg.UpsertManual(30005, "beta", "/home/test/beta", "1.0")
+	if err := pw.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := readPortFile(t, dir, "beta"); got != "127.0.0.1:30005\n" {
+		t.Errorf("beta after port change = %q", got)
+	}
+
+	// Deleted once pruned; unrelated files are left alone.
+	notes := filepath.Join(dir, "README")
+	if err := os.WriteFile(notes, []byte("not a port file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if removed := reg.Prune(); len(removed) != 1 || removed[0] != "alpha" {
+		t.Fatalf("Prune removed %v, want [alpha]", removed)
+	}
+	if err := pw.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "alpha")); !os.IsNotExist(err) {
+		t.Errorf("alpha port file should be deleted after prune, stat err = %v", err)
+	}
+	if _, err := os.Stat(notes); err != nil {
+		t.Errorf("unrelated file should survive: %v", err)
+	}
+
+	if err := pw.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "README" {
+		t.Errorf("after Clear the directory holds %v, want only README", entries)
+	}
+}
+
+func TestPortFileWriter_RemovesStaleFilesFromEarlierRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gone"), []byte("127.0.0.1:30009\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pw := NewPortFileWriter(dir, registry.New(30*time.Second, testLogger()), testLogger())
+	if err := pw.Write(); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gone")); !os.IsNotExist(err) {
+		t.Errorf("stale port file should be deleted, stat err = %v", err)
+	}
+}
+
+func TestRun_WritesPortFiles(t *testing.T) {
+	srv := fakeOpenCode(true, "myproject", "/home/test/myproject", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	dir := t.TempDir()
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, time.Hour, 1, 2*time.Second, testLogger(), WithPortDir(dir))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sc.Run(ctx)
+		close(done)
+	}()
+
+	path := filepath.Join(dir, "myproject")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, err := os.ReadFile(path); err == nil {
+			if want := fmt.Sprintf("127.0.0.1:%d\n", port); string(data) != want {
+				t.Fatalf("port file = %q, want %q", data, want)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("port file not written after the first scan")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("port files should be cleared when Run returns, stat err = %v", err)
+	}
+}
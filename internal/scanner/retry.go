@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// healthStatusError is a health check answered with a status other than 200.
+type healthStatusError int
+
+func (e healthStatusError) Error() string {
+	return fmt.Sprintf("health check returned %d", int(e))
+}
+
+// WithProbeRetries retries a health check that fails transiently up to
+// maxRetries more times, waiting retryDelay with 50% jitter before each
+// attempt. Zero maxRetries probes each port once.
+func WithProbeRetries(maxRetries int, retryDelay time.Duration) Option {
+	return func(s *Scanner) {
+		s.maxRetries = max(maxRetries, 0)
+		s.retryDelay = max(retryDelay, 0)
+	}
+}
+
+// retryPolicy returns the current retry count and delay.
+func (s *Scanner) retryPolicy() (int, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxRetries, s.retryDelay
+}
+
+// getHealthRetrying is getHealth retried on transient failures. Failed
+// attempts before the last are logged at debug level; the last is returned
+// for the caller to handle.
+func (s *Scanner) getHealthRetrying(ctx context.Context, port int, baseURL string) (*HealthResponse, error) {
+	maxRetries, delay := s.retryPolicy()
+	for attempt := 0; ; attempt++ {
+		health, err := s.getHealth(ctx, baseURL)
+		if err == nil || attempt >= maxRetries || !transientProbeError(err) || ctx.Err() != nil {
+			return health, err
+		}
+		s.logger.Debug("health probe failed, retrying",
+			"port", port, "url", baseURL, "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(jitter(delay)):
+		}
+	}
+}
+
+// transientProbeError reports whether a failed health check is worth
+// retrying. A refused connection means nothing listens on the port, and a
+// 4xx, an opt-out or a non-OpenCode payload will not change on a retry;
+// timeouts, dropped connections and 5xx responses might.
+func transientProbeError(err error) bool {
+	var status healthStatusError
+	switch {
+	case errors.Is(err, ErrRejected),
+		errors.Is(err, ErrNotOpenCode),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EADDRNOTAVAIL), // e.g. no IPv6 loopback
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.EAFNOSUPPORT),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, http.ErrSchemeMismatch),
+		errors.As(err, new(tls.RecordHeaderError)):
+		return false
+	case errors.As(err, &status):
+		return status >= 500
+	}
+	return true
+}
+
+// jitter returns d randomised by up to 50% either way.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int64N(int64(d)+1))
+}
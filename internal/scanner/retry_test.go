@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// flakyOpenCode serves a fake OpenCode whose health endpoint answers the
+// first failures requests with fail.
+func flakyOpenCode(failures int32, fail func(w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	backend := fakeOpenCodeHandler("/global/health", "/project/current", true, "flaky", "/home/test/flaky", "1.0.0")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/global/health" && calls.Add(1) <= failures {
+			fail(w)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	return srv, &calls
+}
+
+func TestProbePort_RetriesTransientFailures(t *testing.T) {
+	srv, calls := flakyOpenCode(2, func(w http.ResponseWriter) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	})
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithProbeRetries(2, time.Millisecond))
+
+	if outcome := sc.probePort(context.Background(), port); outcome != probeAdded {
+		t.Fatalf("outcome = %v, want probeAdded", outcome)
+	}
+	if _, ok := reg.Lookup("flaky"); !ok {
+		t.Fatal("expected the backend to be registered after two failed attempts")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("health endpoint called %d times, want 3", got)
+	}
+}
+
+func TestProbePort_RetriesDroppedConnections(t *testing.T) {
+	srv, _ := flakyOpenCode(2, func(w http.ResponseWriter) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	})
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithProbeRetries(2, time.Millisecond))
+
+	sc.probePort(context.Background(), port)
+	if _, ok := reg.Lookup("flaky"); !ok {
+		t.Fatal("expected the backend to be registered once the connection held")
+	}
+}
+
+func TestProbePort_GivesUpAfterMaxRetries(t *testing.T) {
+	srv, calls := flakyOpenCode(3, func(w http.ResponseWriter) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	})
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithProbeRetries(2, time.Millisecond))
+
+	if outcome := sc.probePort(context.Background(), port); outcome != probeFailed {
+		t.Fatalf("outcome = %v, want probeFailed", outcome)
+	}
+	if reg.Len() != 0 {
+		t.Errorf("expected nothing registered, got %d", reg.Len())
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("health endpoint called %d times, want 3", got)
+	}
+}
+
+func TestProbePort_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := flakyOpenCode(1, func(w http.ResponseWriter) {
+		http.NotFound(w, nil)
+	})
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithProbeRetries(2, time.Millisecond))
+
+	sc.probePort(context.Background(), port)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("health endpoint called %d times, want 1", got)
+	}
+}
+
+func TestTransientProbeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"5xx", healthStatusError(http.StatusBadGateway), true},
+		{"4xx", healthStatusError(http.StatusNotFound), false},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, false},
+		{"reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"rejected", ErrRejected, false},
+		{"not opencode", ErrNotOpenCode, false},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("unexpected EOF"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientProbeError(tt.err); got != tt.want {
+				t.Errorf("transientProbeError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	const d = 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if got := jitter(d); got < d/2 || got > d*3/2 {
+			t.Fatalf("jitter(%s) = %s, want within ±50%%", d, got)
+		}
+	}
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %s, want 0", got)
+	}
+}
@@ -2,27 +2,73 @@ package scanner
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
 	"math"
+	"net"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"opencoderouter/internal/config"
 	"opencoderouter/internal/registry"
 )
 
-// healthResponse is the shape of GET /global/health
-type healthResponse struct {
+// HealthResponse is the shape of GET /global/health
+type HealthResponse struct {
 	Healthy bool   `json:"healthy"`
 	Version string `json:"version"`
 }
 
+// ErrNotOpenCode is returned by CheckHealth when a port answers its health
+// path with 200 but the body is not an OpenCode health payload, e.g. another
+// service's unrelated /global/health.
+var ErrNotOpenCode = errors.New("not an OpenCode health response")
+
+// ErrRejected is returned for a probe answered with the
+// "X-OpenCode-Scanner: reject" header: the backend asks not to be
+// registered, however healthy it is.
+var ErrRejected = errors.New("backend opted out of scanning")
+
+// HeaderScanner is the response header a backend sets to "reject" to opt out
+// of registration.
+const HeaderScanner = "X-OpenCode-Scanner"
+
+// rejected reports whether resp carries the opt-out header.
+func rejected(resp *http.Response) bool {
+	return strings.EqualFold(strings.TrimSpace(resp.Header.Get(HeaderScanner)), "reject")
+}
+
+// decodeHealth parses an OpenCode health payload. Both "healthy" (a
+// boolean) and "version" (a string) must be present; other fields are
+// ignored so newer OpenCode releases can add to the payload.
+func decodeHealth(r io.Reader) (*HealthResponse, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	}
+	var h HealthResponse
+	for name, dst := range map[string]any{"healthy": &h.Healthy, "version": &h.Version} {
+		raw, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %q", ErrNotOpenCode, name)
+		}
+		if err := json.Unmarshal(raw, dst); err != nil || string(raw) == "null" {
+			return nil, fmt.Errorf("%w: invalid %q", ErrNotOpenCode, name)
+		}
+	}
+	return &h, nil
+}
+
 // projectResponse is the shape of GET /project/current
 type projectResponse struct {
 	ID   string `json:"id"`
@@ -32,13 +78,280 @@ type projectResponse struct {
 
 // Scanner periodically probes a port range on localhost for OpenCode serve instances.
 type Scanner struct {
-	registry    *registry.Registry
-	portStart   int
-	portEnd     int
+	registry  *registry.Registry
+	portStart int
+	portEnd   int
+	logger    *slog.Logger
+
+	// mu guards the settings below, which Reconfigure may change while running.
+	mu          sync.RWMutex
 	interval    time.Duration
 	concurrency int
+	adaptive    bool   // see WithAdaptiveConcurrency
+	cpuIdle     uint64 // last CPU sample for adaptive concurrency
+	cpuTotal    uint64
 	client      *http.Client
-	logger      *slog.Logger
+	transport   *http.Transport // shared by every client, so Reconfigure keeps the pool
+	maxRetries  int             // see WithProbeRetries
+	retryDelay  time.Duration
+	reconfigure chan struct{}
+	trigger     chan struct{}
+
+	portGroups     []config.PortRange // see WithPortGroups
+	passive        bool               // see WithPassive
+	excluded       map[int]bool
+	excludedRanges []config.PortRange
+	blocked        map[int]bool
+	probeH2C       bool
+	probeTLS       bool
+	insecureTLS    bool
+	healthPath     string
+	projectPath    string
+	userAgent      string
+	dryRun         bool
+	probeIPv6      bool
+	portDir        string // see WithPortDir
+
+	scans   scanHistory
+	readCPU func() (idle, total uint64, err error)
+
+	cycle    atomic.Uint64 // incremented once per scan
+	failures sync.Map      // port → portBackoff
+	metrics  atomic.Pointer[ScanMetrics]
+}
+
+// Probe connection pooling. Scans revisit the same ports every interval, so
+// idle connections are kept to skip a TCP handshake per port per scan. Each
+// port is a separate host, so the total pool is sized to the scan range.
+const (
+	probeIdleConnsPerHost = 2
+	probeIdleConnTimeout  = 30 * time.Second
+)
+
+// maxBackoffCycles caps how many scan intervals a failing port may be skipped.
+const maxBackoffCycles = 32
+
+// portBackoff records consecutive probe failures of one port and the first
+// scan cycle in which it should be probed again.
+type portBackoff struct {
+	failures  int
+	nextCycle uint64
+}
+
+// Option configures optional Scanner behaviour.
+type Option func(*Scanner)
+
+// WithH2CProbe makes the scanner check newly discovered backends for h2c
+// (cleartext HTTP/2) support and record the result in the registry.
+func WithH2CProbe(enabled bool) Option {
+	return func(s *Scanner) {
+		s.probeH2C = enabled
+	}
+}
+
+// WithIPv6Probe makes the scanner also look for backends on the IPv6
+// loopback [::1] when a port does not answer on 127.0.0.1. A port serving
+// both is registered on IPv4.
+func WithIPv6Probe(enabled bool) Option {
+	return func(s *Scanner) {
+		s.probeIPv6 = enabled
+	}
+}
+
+// probeIPs returns the loopback addresses probed on each port, in order of
+// preference.
+func (s *Scanner) probeIPs() []string {
+	if s.probeIPv6 {
+		return []string{registry.DefaultBindIP, registry.IPv6BindIP}
+	}
+	return []string{registry.DefaultBindIP}
+}
+
+// probeHealth runs the health check on ip:port, over HTTPS first when TLS
+// probing is enabled, and returns the base URL that answered.
+func (s *Scanner) probeHealth(ctx context.Context, ip string, port int) (baseURL string, health *HealthResponse, useTLS bool, err error) {
+	host := net.JoinHostPort(ip, strconv.Itoa(port))
+	if s.probeTLS {
+		tlsURL := "https://" + host
+		if health, err = s.getHealthRetrying(ctx, port, tlsURL); err == nil && health.Healthy {
+			return tlsURL, health, true, nil
+		}
+	}
+	baseURL = "http://" + host
+	health, err = s.getHealthRetrying(ctx, port, baseURL)
+	return baseURL, health, false, err
+}
+
+// WithDryRun makes the scanner log the backends it would register instead
+// of writing anything to the registry.
+func WithDryRun(enabled bool) Option {
+	return func(s *Scanner) {
+		s.dryRun = enabled
+	}
+}
+
+// WithExcludePorts keeps the scanner from ever probing the given ports,
+// such as the router's own listen port.
+func WithExcludePorts(ports []int) Option {
+	return func(s *Scanner) {
+		s.excluded = make(map[int]bool, len(ports))
+		for _, p := range ports {
+			s.excluded[p] = true
+		}
+	}
+}
+
+// WithPortGroups makes the scanner probe the given disjoint ranges instead
+// of portStart-portEnd. Empty keeps the single range. Pass it before
+// WithAdaptiveConcurrency, which sizes concurrency from the port count.
+func WithPortGroups(groups []config.PortRange) Option {
+	return func(s *Scanner) {
+		s.portGroups = append([]config.PortRange(nil), groups...)
+	}
+}
+
+// ranges returns the port ranges each scan probes.
+func (s *Scanner) ranges() []config.PortRange {
+	if len(s.portGroups) > 0 {
+		return s.portGroups
+	}
+	return []config.PortRange{{Start: s.portStart, End: s.portEnd}}
+}
+
+// ports yields every port in the scanned ranges, in order, or in passive
+// mode the registered ports.
+func (s *Scanner) ports() iter.Seq[int] {
+	if s.passive {
+		return s.registeredPorts()
+	}
+	return func(yield func(int) bool) {
+		for _, r := range s.ranges() {
+			for port := r.Start; port <= r.End; port++ {
+				if !yield(port) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// portCount returns the number of ports in the scanned ranges.
+func (s *Scanner) portCount() int {
+	n := 0
+	for _, r := range s.ranges() {
+		n += r.End - r.Start + 1
+	}
+	return n
+}
+
+// rangeString formats the scanned ranges for logs, e.g. "30000-30999".
+func (s *Scanner) rangeString() string {
+	parts := make([]string, 0, len(s.ranges()))
+	for _, r := range s.ranges() {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// WithExcludeRanges keeps the scanner from ever probing ports in the given
+// ranges.
+func WithExcludeRanges(ranges []config.PortRange) Option {
+	return func(s *Scanner) {
+		s.excludedRanges = append([]config.PortRange(nil), ranges...)
+	}
+}
+
+// WithBlocklist keeps the scanner from ever probing the given ports, for
+// known non-OpenCode services inside the scan range.
+func WithBlocklist(ports []int) Option {
+	return func(s *Scanner) {
+		s.blocked = make(map[int]bool, len(ports))
+		for _, p := range ports {
+			s.blocked[p] = true
+		}
+	}
+}
+
+// wellKnownPorts are services that never run OpenCode. Scan ranges that
+// include them draw a warning; they are still probed unless excluded.
+var wellKnownPorts = map[int]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "dns",
+	80:    "http",
+	110:   "pop3",
+	143:   "imap",
+	443:   "https",
+	445:   "smb",
+	3306:  "mysql",
+	5432:  "postgresql",
+	6379:  "redis",
+	27017: "mongodb",
+}
+
+// warnWellKnownPorts logs the wellKnownPorts the scan range would probe.
+func (s *Scanner) warnWellKnownPorts() {
+	var ports []int
+	var services []string
+	for port := range s.ports() {
+		if name, ok := wellKnownPorts[port]; ok && !s.isExcluded(port) {
+			ports = append(ports, port)
+			services = append(services, name)
+		}
+	}
+	if len(ports) > 0 {
+		s.logger.Warn("scan range includes well-known ports that never run OpenCode; consider --scan-blocklist",
+			"ports", ports, "services", services)
+	}
+}
+
+// isExcluded reports whether port must never be probed.
+func (s *Scanner) isExcluded(port int) bool {
+	if s.excluded[port] || s.blocked[port] {
+		return true
+	}
+	for _, r := range s.excludedRanges {
+		if r.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTLSProbe makes the scanner try HTTPS on each port before plain HTTP.
+// insecureSkipVerify accepts self-signed certificates, which is the norm for
+// local instances.
+func WithTLSProbe(enabled, insecureSkipVerify bool) Option {
+	return func(s *Scanner) {
+		s.probeTLS = enabled
+		s.insecureTLS = insecureSkipVerify
+	}
+}
+
+// WithProbePaths overrides the health and project endpoints probed on each
+// port, for OpenCode forks that serve them elsewhere. Empty values keep the
+// defaults.
+func WithProbePaths(healthPath, projectPath string) Option {
+	return func(s *Scanner) {
+		if healthPath != "" {
+			s.healthPath = healthPath
+		}
+		if projectPath != "" {
+			s.projectPath = projectPath
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent sent on every probe request, for
+// backends that firewall unrecognised clients. Empty keeps the default.
+func WithUserAgent(ua string) Option {
+	return func(s *Scanner) {
+		if ua != "" {
+			s.userAgent = ua
+		}
+	}
 }
 
 // New creates a new Scanner.
@@ -49,32 +362,141 @@ func New(
 	concurrency int,
 	probeTimeout time.Duration,
 	logger *slog.Logger,
+	opts ...Option,
 ) *Scanner {
-	return &Scanner{
+	s := &Scanner{
 		registry:    reg,
 		portStart:   portStart,
 		portEnd:     portEnd,
 		interval:    interval,
 		concurrency: concurrency,
-		client: &http.Client{
-			Timeout: probeTimeout,
-		},
-		logger: logger,
+		reconfigure: make(chan struct{}, 1),
+		trigger:     make(chan struct{}, 1),
+		healthPath:  config.DefaultHealthPath,
+		projectPath: config.DefaultProjectPath,
+		userAgent:   config.DefaultProbeUserAgent,
+		maxRetries:  config.DefaultProbeRetries,
+		retryDelay:  config.DefaultProbeRetryDelay,
+		readCPU:     readProcStat,
+		logger:      logger,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.transport = s.newProbeTransport()
+	s.client = s.newProbeClient(probeTimeout)
+	return s
+}
+
+// newProbeTransport builds the keep-alive transport shared by all probes.
+// With TLS probing enabled it carries the configured certificate
+// verification setting.
+func (s *Scanner) newProbeTransport() *http.Transport {
+	t := &http.Transport{
+		DialContext:         (&net.Dialer{KeepAlive: 30 * time.Second}).DialContext,
+		DisableKeepAlives:   false,
+		MaxIdleConns:        probeIdleConnsPerHost * max(s.portCount(), 1),
+		MaxIdleConnsPerHost: probeIdleConnsPerHost,
+		IdleConnTimeout:     probeIdleConnTimeout,
+	}
+	if s.probeTLS {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: s.insecureTLS}
+	}
+	return t
+}
+
+// newProbeClient builds the HTTP client used for probes on the shared
+// transport.
+func (s *Scanner) newProbeClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: s.transport}
+}
+
+// Reconfigure applies the scan interval, concurrency (fixed or adaptive),
+// probe timeout and probe retries from cfg. A running scan loop picks up
+// the new interval on its next tick.
+func (s *Scanner) Reconfigure(cfg config.Config) {
+	s.mu.Lock()
+	s.interval = cfg.ScanInterval
+	s.adaptive = cfg.ScanConcurrencyAuto
+	s.concurrency = cfg.ScanConcurrency
+	if s.adaptive {
+		s.concurrency = s.autoConcurrency()
+	}
+	s.client = s.newProbeClient(cfg.ProbeTimeout)
+	s.maxRetries, s.retryDelay = cfg.ProbeRetries, cfg.ProbeRetryDelay
+	s.mu.Unlock()
+
+	select {
+	case s.reconfigure <- struct{}{}:
+	default:
+	}
+}
+
+// Trigger requests an immediate scan from the running loop. Requests made
+// while one is already pending are coalesced.
+func (s *Scanner) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Interval returns the current scan interval.
+func (s *Scanner) Interval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.interval
+}
+
+func (s *Scanner) httpClient() *http.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
 }
 
 // Run starts the scan loop. Blocks until ctx is cancelled.
 func (s *Scanner) Run(ctx context.Context) {
+	s.scans.mu.Lock()
+	s.scans.runCtx = ctx
+	s.scans.mu.Unlock()
+
+	s.mu.RLock()
+	interval, concurrency := s.interval, s.concurrency
+	s.mu.RUnlock()
+
 	s.logger.Info("scanner started",
-		"port_range", fmt.Sprintf("%d-%d", s.portStart, s.portEnd),
-		"interval", s.interval,
-		"concurrency", s.concurrency,
+		"port_range", s.rangeString(),
+		"passive", s.passive,
+		"interval", interval,
+		"concurrency", concurrency,
 	)
+	s.warnWellKnownPorts()
+
+	// Port files are written here rather than in scan, which stays free of
+	// side effects outside the registry.
+	var portFiles *PortFileWriter
+	if s.portDir != "" {
+		portFiles = NewPortFileWriter(s.portDir, s.registry, s.logger)
+		defer func() {
+			if err := portFiles.Clear(); err != nil {
+				s.logger.Warn("failed to clear port files", "dir", s.portDir, "error", err)
+			}
+		}()
+	}
+	writePortFiles := func() {
+		if portFiles == nil {
+			return
+		}
+		if err := portFiles.Write(); err != nil {
+			s.logger.Warn("failed to write port files", "dir", s.portDir, "error", err)
+		}
+	}
 
 	// Run immediately on start, then on ticker.
-	s.scan(ctx)
+	s.ScanOnce(ctx)
+	writePortFiles()
 
-	ticker := time.NewTicker(s.interval)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -82,57 +504,220 @@ func (s *Scanner) Run(ctx context.Context) {
 		case <-ctx.Done():
 			s.logger.Info("scanner stopped")
 			return
+		case <-s.reconfigure:
+			ticker.Reset(s.Interval())
+		case <-s.trigger:
+			s.ScanOnce(ctx)
+			writePortFiles()
 		case <-ticker.C:
-			s.scan(ctx)
+			s.scan(ctx, true)
+			writePortFiles()
 		}
 	}
 }
 
-// scan probes all ports in the range concurrently.
-func (s *Scanner) scan(ctx context.Context) {
-	sem := make(chan struct{}, s.concurrency)
-	var wg sync.WaitGroup
+// ScanResult counts the registry changes made by one scan.
+type ScanResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// probeOutcome is what probePort did to the registry.
+type probeOutcome int
 
-	for port := s.portStart; port <= s.portEnd; port++ {
+const (
+	probeNone probeOutcome = iota
+	probeFailed
+	probeAdded
+	probeUpdated
+)
+
+// ScanOnce probes every port in the range concurrently, ignoring failure
+// backoff, then prunes stale backends.
+func (s *Scanner) ScanOnce(ctx context.Context) ScanResult {
+	return s.scan(ctx, false)
+}
+
+// scan probes the port ranges. With backoff, ports that failed N times in a
+// row are probed only every min(2^N, 32) scans; periodic scans use this to
+// cut noise from closed ports, while on-demand scans probe everything.
+func (s *Scanner) scan(ctx context.Context, backoff bool) ScanResult {
+	start := time.Now()
+	cycle := s.cycle.Add(1)
+	s.mu.RLock()
+	adaptive := s.adaptive
+	s.mu.RUnlock()
+	if adaptive {
+		s.adaptConcurrency()
+	}
+	concurrency := s.Concurrency()
+
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		resMu    sync.Mutex
+		result   ScanResult
+		findings []*probeFinding
+		probed   atomic.Int64
+		found    atomic.Int64
+	)
+	tally := func(outcome probeOutcome) {
+		switch outcome {
+		case probeAdded:
+			result.Added++
+		case probeUpdated:
+			result.Updated++
+		}
+	}
+	// Backends found by the probes are registered together once they are
+	// all done, taking the registry lock once per scan rather than per port.
+	register := func() {
+		wg.Wait()
+		for _, outcome := range s.register(findings) {
+			tally(outcome)
+		}
+	}
+
+	for port := range s.ports() {
 		select {
 		case <-ctx.Done():
-			return
+			register()
+			return result
 		default:
 		}
+		if s.isExcluded(port) || (backoff && s.backingOff(port, cycle)) {
+			continue
+		}
 
 		wg.Add(1)
 		sem <- struct{}{} // acquire semaphore slot
 		go func(p int) {
 			defer wg.Done()
 			defer func() { <-sem }() // release slot
-			s.probePort(ctx, p)
+			finding, outcome := s.probe(ctx, p)
+			s.recordOutcome(p, cycle, outcome)
+			probed.Add(1)
+			if outcome != probeFailed {
+				found.Add(1)
+			}
+			resMu.Lock()
+			if finding != nil {
+				findings = append(findings, finding)
+			} else {
+				tally(outcome)
+			}
+			resMu.Unlock()
 		}(port)
 	}
 
-	wg.Wait()
+	register()
 
 	// Prune stale backends that haven't been seen recently.
 	removed := s.registry.Prune()
 	if len(removed) > 0 {
 		s.logger.Info("pruned stale backends", "count", len(removed), "slugs", removed)
 	}
+	result.Removed = len(removed)
+	s.recordMetrics(probed.Load(), found.Load(), time.Since(start))
+	return result
 }
 
-// probePort checks if an OpenCode instance is running on the given port.
-func (s *Scanner) probePort(ctx context.Context, port int) {
-	baseURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+// backingOff reports whether port should be skipped in cycle.
+func (s *Scanner) backingOff(port int, cycle uint64) bool {
+	v, ok := s.failures.Load(port)
+	return ok && cycle < v.(portBackoff).nextCycle
+}
 
-	// Step 1: Health check.
-	health, err := s.getHealth(ctx, baseURL)
-	if err != nil {
-		return // port not serving OpenCode (or down) — silent
-	}
-	if !health.Healthy {
+// recordOutcome updates port's failure streak after a probe in cycle.
+func (s *Scanner) recordOutcome(port int, cycle uint64, outcome probeOutcome) {
+	if outcome != probeFailed {
+		s.failures.Delete(port)
 		return
 	}
+	var b portBackoff
+	if v, ok := s.failures.Load(port); ok {
+		b = v.(portBackoff)
+	}
+	b.failures++
+	skip := uint64(maxBackoffCycles)
+	if b.failures < 5 {
+		skip = 1 << b.failures
+	}
+	b.nextCycle = cycle + skip
+	s.failures.Store(port, b)
+}
+
+// probeFinding is what probe learned about an OpenCode instance, for
+// register to record.
+type probeFinding struct {
+	port        int
+	projectName string
+	projectPath string
+	version     string
+	useTLS      bool
+	bindIP      string
+	h2c         *bool // nil when not probed
+	tags        []string
+	tagsErr     error
+	sessions    []registry.SessionMetadata
+	sessionsErr error
+}
+
+// probePort checks if an OpenCode instance is running on the given port and
+// registers it.
+func (s *Scanner) probePort(ctx context.Context, port int) probeOutcome {
+	finding, outcome := s.probe(ctx, port)
+	if finding == nil {
+		return outcome
+	}
+	return s.register([]*probeFinding{finding})[0]
+}
+
+// probe checks if an OpenCode instance is running on the given port without
+// registering it. It returns a finding for register, or nil with the
+// outcome when there is nothing to register: the probe failed or this is a
+// dry run.
+func (s *Scanner) probe(ctx context.Context, port int) (*probeFinding, probeOutcome) {
+	// Step 1: Health check, on IPv4 and then, with IPv6 probing, on ::1.
+	var (
+		baseURL string
+		health  *HealthResponse
+		err     error
+		useTLS  bool
+		bindIP  string
+	)
+	for _, ip := range s.probeIPs() {
+		baseURL, health, useTLS, err = s.probeHealth(ctx, ip, port)
+		if err == nil && health.Healthy {
+			bindIP = ip
+			break
+		}
+		if errors.Is(err, ErrRejected) {
+			break
+		}
+	}
+	if errors.Is(err, ErrRejected) {
+		s.logger.Debug("backend opted out of scanning", "port", port)
+	}
+	if err != nil || !health.Healthy {
+		// Port not serving OpenCode (or down) — silent, but note the failure
+		// if a backend was registered there.
+		if !s.dryRun {
+			s.registry.RecordUnhealthy(port)
+		}
+		return nil, probeFailed
+	}
 
 	// Step 2: Get project info.
 	project, err := s.getProject(ctx, baseURL)
+	if errors.Is(err, ErrRejected) {
+		s.logger.Debug("backend opted out of scanning", "port", port)
+		if !s.dryRun {
+			s.registry.RecordUnhealthy(port)
+		}
+		return nil, probeFailed
+	}
 	if err != nil {
 		project = &projectResponse{
 			ID:   fmt.Sprintf("port-%d", port),
@@ -154,61 +739,173 @@ func (s *Scanner) probePort(ctx context.Context, port int) {
 		projectName = filepath.Base(projectPath)
 	}
 
-	s.registry.Upsert(port, projectName, projectPath, health.Version)
+	_, known := s.registry.LookupByPort(port)
+	if s.dryRun {
+		s.logger.Info("dry run: would register backend",
+			"port", port, "project", projectName, "path", projectPath, "version", health.Version,
+			"tls", useTLS, "bind_ip", bindIP, "known", known)
+		if known {
+			return nil, probeUpdated
+		}
+		return nil, probeAdded
+	}
 
-	backend, ok := s.registry.LookupByPort(port)
-	if !ok {
-		return
+	f := &probeFinding{
+		port:        port,
+		projectName: projectName,
+		projectPath: projectPath,
+		version:     health.Version,
+		useTLS:      useTLS,
+		bindIP:      bindIP,
+	}
+	if !known && s.probeH2C && !useTLS {
+		h2c := s.supportsH2C(ctx, baseURL)
+		f.h2c = &h2c
 	}
+	f.tags, f.tagsErr = s.getTags(ctx, baseURL)
+	f.sessions, f.sessionsErr = s.getSessions(ctx, baseURL)
+	return f, probeNone
+}
 
-	sessions, err := s.getSessions(ctx, baseURL)
-	if err != nil {
-		s.logger.Debug("session probe failed", "port", port, "error", err)
-		return
+// register records findings in the registry with one BatchUpsert, then
+// their TLS, h2c, tag and session details. It returns each finding's
+// outcome.
+func (s *Scanner) register(findings []*probeFinding) []probeOutcome {
+	entries := make([]registry.UpsertEntry, len(findings))
+	for i, f := range findings {
+		entries[i] = registry.UpsertEntry{Port: f.port, ProjectName: f.projectName, ProjectPath: f.projectPath, Version: f.version}
 	}
-	s.registry.ReplaceSessions(backend.Slug, sessions)
+	isNew := s.registry.BatchUpsert(entries)
+
+	outcomes := make([]probeOutcome, len(findings))
+	for i, f := range findings {
+		s.registry.SetTLS(f.port, f.useTLS)
+		s.registry.SetBindIP(f.port, f.bindIP)
+		if f.h2c != nil && isNew[i] {
+			s.registry.SetSupportsH2C(f.port, *f.h2c)
+		}
+
+		backend, ok := s.registry.LookupByPort(f.port)
+		if !ok {
+			outcomes[i] = probeNone // rejected by the slug collision strategy
+			continue
+		}
+		outcomes[i] = probeUpdated
+		if isNew[i] {
+			outcomes[i] = probeAdded
+		}
+
+		// Tags are optional; on errors other than 404 keep the ones we have.
+		if f.tagsErr != nil {
+			s.logger.Debug("tags probe failed", "port", f.port, "error", f.tagsErr)
+		} else {
+			s.registry.SetTags(f.port, f.tags)
+		}
+		if f.sessionsErr != nil {
+			s.logger.Debug("session probe failed", "port", f.port, "error", f.sessionsErr)
+			continue
+		}
+		s.registry.ReplaceSessions(backend.Slug, f.sessions)
+	}
+	return outcomes
 }
 
-// getHealth calls GET /global/health on the target.
-func (s *Scanner) getHealth(ctx context.Context, baseURL string) (*healthResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/global/health", nil)
+// getHealth calls GET {healthPath} (default /global/health) on the target.
+func (s *Scanner) getHealth(ctx context.Context, baseURL string) (*HealthResponse, error) {
+	return checkHealth(ctx, s.httpClient(), baseURL, s.healthPath, s.userAgent)
+}
+
+// CheckHealth calls GET healthPath on baseURL (e.g. "http://127.0.0.1:4096")
+// and decodes the OpenCode health payload. Any status other than 200 is an
+// error, as is a 200 whose body lacks the OpenCode fields (ErrNotOpenCode);
+// a 200 reporting healthy=false is not. A response with the
+// "X-OpenCode-Scanner: reject" header yields ErrRejected.
+func CheckHealth(ctx context.Context, client *http.Client, baseURL, healthPath string) (*HealthResponse, error) {
+	return checkHealth(ctx, client, baseURL, healthPath, "")
+}
+
+// checkHealth is CheckHealth with a User-Agent; empty leaves Go's default.
+func checkHealth(ctx context.Context, client *http.Client, baseURL, healthPath, userAgent string) (*HealthResponse, error) {
+	req, err := newProbeRequest(ctx, http.MethodGet, baseURL+healthPath, userAgent)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
+	if rejected(resp) {
+		return nil, ErrRejected
+	}
 	if resp.StatusCode != http.StatusOK {
-		if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil {
-			s.logger.Debug("health response drain failed", "error", copyErr)
-		}
-		return nil, fmt.Errorf("health check returned %d", resp.StatusCode)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, healthStatusError(resp.StatusCode)
 	}
 
-	var h healthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
-		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	return decodeHealth(resp.Body)
+}
+
+// newProbeRequest builds a body-less probe request carrying userAgent.
+func newProbeRequest(ctx context.Context, method, url, userAgent string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
 	}
-	return &h, nil
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return req, nil
+}
+
+// maxDrain bounds how much of an unread response body drainAndClose reads.
+const maxDrain = 64 << 10
+
+// drainAndClose reads what is left of body before closing it, so the
+// connection can return to the keep-alive pool.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrain))
+	body.Close()
 }
 
-// getProject calls GET /project/current on the target.
+// supportsH2C sends an OPTIONS request asking to upgrade to h2c and reports
+// whether the backend switched protocols.
+func (s *Scanner) supportsH2C(ctx context.Context, baseURL string) bool {
+	req, err := newProbeRequest(ctx, http.MethodOptions, baseURL+"/", s.userAgent)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+	req.Header.Set("Upgrade", "h2c")
+	// An empty SETTINGS payload, base64url-encoded, is a valid HTTP2-Settings value.
+	req.Header.Set("HTTP2-Settings", "")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusSwitchingProtocols
+}
+
+// getProject calls GET {projectPath} (default /project/current) on the target.
 func (s *Scanner) getProject(ctx context.Context, baseURL string) (*projectResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/project/current", nil)
+	req, err := newProbeRequest(ctx, http.MethodGet, baseURL+s.projectPath, s.userAgent)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
+	if rejected(resp) {
+		return nil, ErrRejected
+	}
 	if resp.StatusCode != http.StatusOK {
 		if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil {
 			s.logger.Debug("project response drain failed", "error", copyErr)
@@ -248,16 +945,16 @@ func (s *Scanner) getSessions(ctx context.Context, baseURL string) ([]registry.S
 }
 
 func (s *Scanner) getSessionsFromEndpoint(ctx context.Context, endpointURL string) ([]registry.SessionMetadata, int, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL, nil)
+	req, err := newProbeRequest(ctx, http.MethodGet, endpointURL, s.userAgent)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil {
@@ -1,19 +1,28 @@
 package scanner
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"opencoderouter/internal/config"
 	"opencoderouter/internal/registry"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func testLogger() *slog.Logger {
@@ -22,8 +31,18 @@ func testLogger() *slog.Logger {
 
 // fakeOpenCode creates an httptest.Server that mimics OpenCode's health + project endpoints.
 func fakeOpenCode(healthy bool, projectName, projectPath, version string) *httptest.Server {
+	return fakeOpenCodeAt("/global/health", "/project/current", healthy, projectName, projectPath, version)
+}
+
+// fakeOpenCodeAt is fakeOpenCode with the health and project endpoints
+// served at custom paths.
+func fakeOpenCodeAt(healthEndpoint, projectEndpoint string, healthy bool, projectName, projectPath, version string) *httptest.Server {
+	return httptest.NewServer(fakeOpenCodeHandler(healthEndpoint, projectEndpoint, healthy, projectName, projectPath, version))
+}
+
+func fakeOpenCodeHandler(healthEndpoint, projectEndpoint string, healthy bool, projectName, projectPath, version string) http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(healthEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"healthy": healthy,
@@ -32,7 +51,7 @@ func fakeOpenCode(healthy bool, projectName, projectPath, version string) *httpt
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
-	mux.HandleFunc("/project/current", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(projectEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"id":   projectName,
@@ -48,7 +67,7 @@ func fakeOpenCode(healthy bool, projectName, projectPath, version string) *httpt
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 	})
-	return httptest.NewServer(mux)
+	return mux
 }
 
 func extractPort(t *testing.T, url string) int {
@@ -94,6 +113,128 @@ func TestProbePort_Healthy(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// probePort — custom probe paths
+// ---------------------------------------------------------------------------
+
+func TestProbePort_CustomPaths(t *testing.T) {
+	srv := fakeOpenCodeAt("/api/v2/health", "/api/v2/project", true, "forked", "/home/test/forked", "2.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	// Default paths miss the fork's endpoints.
+	reg := registry.New(30*time.Second, testLogger())
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger()).probePort(context.Background(), port)
+	if reg.Len() != 0 {
+		t.Fatalf("expected no backend with default paths, got %d", reg.Len())
+	}
+
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(),
+		WithProbePaths("/api/v2/health", "/api/v2/project"))
+	sc.probePort(context.Background(), port)
+	b, ok := reg.Lookup("forked")
+	if !ok {
+		t.Fatal("expected 'forked' registered with custom paths")
+	}
+	if b.ProjectPath != "/home/test/forked" || b.Version != "2.0" {
+		t.Errorf("unexpected backend: %+v", b)
+	}
+}
+
+func TestProbePort_UserAgent(t *testing.T) {
+	var mu sync.Mutex
+	agents := map[string]string{}
+	inner := fakeOpenCodeHandler("/global/health", "/project/current", true, "agent", "/home/test/agent", "1.0")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		inner.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger()).probePort(context.Background(), port)
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(),
+		WithUserAgent("custom-probe/2")).getProject(context.Background(), srv.URL)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := agents["/global/health"]; got != config.DefaultProbeUserAgent {
+		t.Errorf("health probe User-Agent = %q, want %q", got, config.DefaultProbeUserAgent)
+	}
+	if got := agents["/project/current"]; got != "custom-probe/2" {
+		t.Errorf("project probe User-Agent = %q, want custom-probe/2", got)
+	}
+}
+
+func TestProbePort_RejectHeader(t *testing.T) {
+	for _, path := range []string{"/global/health", "/project/current"} {
+		t.Run(path, func(t *testing.T) {
+			inner := fakeOpenCodeHandler("/global/health", "/project/current", true, "shy", "/home/test/shy", "1.0")
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == path {
+					w.Header().Set(HeaderScanner, "reject")
+				}
+				inner.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+			port := extractPort(t, srv.URL)
+
+			reg := registry.New(30*time.Second, testLogger())
+			sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger())
+			if outcome := sc.probePort(context.Background(), port); outcome != probeFailed {
+				t.Errorf("outcome = %v, want probeFailed", outcome)
+			}
+			if reg.Len() != 0 {
+				t.Errorf("expected the healthy backend that opted out to stay unregistered, got %d", reg.Len())
+			}
+		})
+	}
+}
+
+func TestProbePort_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(fakeOpenCodeHandler("/global/health", "/project/current", true, "secure", "/home/test/secure", "1.0"))
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	// Without TLS probing the HTTPS-only backend is not discovered.
+	reg := registry.New(30*time.Second, testLogger())
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger()).probePort(context.Background(), port)
+	if reg.Len() != 0 {
+		t.Fatalf("expected no backend without TLS probing, got %d", reg.Len())
+	}
+
+	// Verification rejects the test server's self-signed certificate.
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(),
+		WithTLSProbe(true, false)).probePort(context.Background(), port)
+	if reg.Len() != 0 {
+		t.Fatalf("expected certificate verification to fail, got %d backends", reg.Len())
+	}
+
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(),
+		WithTLSProbe(true, true)).probePort(context.Background(), port)
+	b, ok := reg.Lookup("secure")
+	if !ok || !b.TLS {
+		t.Fatalf("expected TLS backend registered, got %+v, %v", b, ok)
+	}
+}
+
+func TestProbePort_TLSFallsBackToHTTP(t *testing.T) {
+	srv := fakeOpenCode(true, "plain", "/home/test/plain", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(),
+		WithTLSProbe(true, true)).probePort(context.Background(), port)
+	b, ok := reg.Lookup("plain")
+	if !ok || b.TLS {
+		t.Fatalf("expected plain HTTP backend registered, got %+v, %v", b, ok)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // probePort — unhealthy instance
 // ---------------------------------------------------------------------------
@@ -186,6 +327,74 @@ func TestProbePort_MalformedHealth(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// CheckHealth — strict payload validation
+// ---------------------------------------------------------------------------
+
+func TestCheckHealth_StrictValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+		want    HealthResponse
+	}{
+		{"opencode", `{"healthy":true,"version":"1.2.3"}`, false, HealthResponse{Healthy: true, Version: "1.2.3"}},
+		{"unhealthy opencode", `{"healthy":false,"version":"1.2.3"}`, false, HealthResponse{Version: "1.2.3"}},
+		{"extra fields ignored", `{"healthy":true,"version":"1.2.3","uptime":42}`, false, HealthResponse{Healthy: true, Version: "1.2.3"}},
+		{"other service", `{"status":"UP"}`, true, HealthResponse{}},
+		{"missing healthy", `{"version":"1.2.3","status":"ok"}`, true, HealthResponse{}},
+		{"missing version", `{"healthy":true}`, true, HealthResponse{}},
+		{"healthy not bool", `{"healthy":"yes","version":"1.2.3"}`, true, HealthResponse{}},
+		{"null healthy", `{"healthy":null,"version":"1.2.3"}`, true, HealthResponse{}},
+		{"version not string", `{"healthy":true,"version":2}`, true, HealthResponse{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			h, err := CheckHealth(context.Background(), srv.Client(), srv.URL, "/global/health")
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotOpenCode) {
+					t.Fatalf("err = %v, want ErrNotOpenCode", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckHealth: %v", err)
+			}
+			if *h != tt.want {
+				t.Errorf("health = %+v, want %+v", *h, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbePort_NonOpenCodeHealth(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "UP", "checks": []string{}})
+	})
+	mux.HandleFunc("/project/current", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "x", "name": "x", "path": "/x"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger())
+
+	sc.probePort(context.Background(), port)
+
+	if reg.Len() != 0 {
+		t.Error("a coincidental health endpoint without OpenCode fields should not be registered")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // probePort — health returns non-200
 // ---------------------------------------------------------------------------
@@ -341,7 +550,7 @@ func TestScan_SessionDisappearanceAcrossCycles(t *testing.T) {
 	reg := registry.New(30*time.Second, testLogger())
 	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger())
 
-	sc.scan(context.Background())
+	sc.ScanOnce(context.Background())
 	if got := len(reg.ListSessions("proj")); got != 2 {
 		t.Fatalf("expected 2 sessions after first scan, got %d", got)
 	}
@@ -350,7 +559,7 @@ func TestScan_SessionDisappearanceAcrossCycles(t *testing.T) {
 	sessions = []map[string]interface{}{{"id": "s-2", "title": "second"}}
 	mu.Unlock()
 
-	sc.scan(context.Background())
+	sc.ScanOnce(context.Background())
 	list := reg.ListSessions("proj")
 	if len(list) != 1 {
 		t.Fatalf("expected 1 session after second scan, got %d", len(list))
@@ -431,7 +640,7 @@ func TestScan_MultipleBackends(t *testing.T) {
 	reg := registry.New(30*time.Second, testLogger())
 	sc := New(reg, minPort, maxPort, 5*time.Second, 10, 2*time.Second, testLogger())
 
-	sc.scan(context.Background())
+	sc.ScanOnce(context.Background())
 
 	if reg.Len() != 2 {
 		t.Errorf("expected 2 healthy backends, got %d", reg.Len())
@@ -447,6 +656,58 @@ func TestScan_MultipleBackends(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Dry run
+// ---------------------------------------------------------------------------
+
+func TestScan_DryRunLeavesRegistryEmpty(t *testing.T) {
+	srv1 := fakeOpenCode(true, "alpha", "/home/test/alpha", "1.0")
+	defer srv1.Close()
+	srv2 := fakeOpenCode(true, "beta", "/home/test/beta", "2.0")
+	defer srv2.Close()
+	port1, port2 := extractPort(t, srv1.URL), extractPort(t, srv2.URL)
+
+	reg := registry.New(30*time.Second, testLogger(), registry.WithDryRun(true))
+	events, cancel := reg.Subscribe()
+	defer cancel()
+	sc := New(reg, min(port1, port2), max(port1, port2), 5*time.Second, 10, 2*time.Second, testLogger(),
+		WithDryRun(true))
+
+	result := sc.ScanOnce(context.Background())
+
+	if reg.Len() != 0 {
+		t.Errorf("dry run registered %d backends, want 0", reg.Len())
+	}
+	if result.Added != 2 {
+		t.Errorf("ScanResult.Added = %d, want the 2 backends that would be registered", result.Added)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("dry run emitted a registry event: %+v", ev)
+	default:
+	}
+}
+
+func TestScan_DryRunKeepsExistingBackends(t *testing.T) {
+	srv := fakeOpenCode(false, "down", "/home/test/down", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(time.Millisecond, testLogger(), registry.WithDryRun(true))
+	reg.Upsert(port, "down", "/home/test/down", "1.0")
+	time.Sleep(5 * time.Millisecond)
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger(), WithDryRun(true))
+
+	result := sc.ScanOnce(context.Background())
+
+	if _, ok := reg.Lookup("down"); !ok || result.Removed != 0 {
+		t.Error("dry run should not prune stale backends")
+	}
+	if h, _ := reg.History("down"); len(h) != 1 {
+		t.Errorf("dry run should not record failed probes, history = %v", h)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Scan with context cancellation
 // ---------------------------------------------------------------------------
@@ -459,7 +720,7 @@ func TestScan_ContextCancelled(t *testing.T) {
 	cancel() // cancel immediately
 
 	// Should not panic or hang.
-	sc.scan(ctx)
+	sc.ScanOnce(ctx)
 }
 
 // ---------------------------------------------------------------------------
@@ -489,3 +750,342 @@ func TestRun_StopsOnCancel(t *testing.T) {
 		t.Error("Run did not exit after context cancellation")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// h2c capability probe
+// ---------------------------------------------------------------------------
+
+func TestProbePort_H2CSupport(t *testing.T) {
+	plain := fakeOpenCode(true, "plain", "/home/test/plain", "1.0")
+	defer plain.Close()
+
+	upgraded := fakeOpenCode(true, "upgraded", "/home/test/upgraded", "1.0")
+	upgraded.Config.Handler = h2c.NewHandler(upgraded.Config.Handler, &http2.Server{})
+	defer upgraded.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 0, 0, 5*time.Second, 1, 2*time.Second, testLogger(), WithH2CProbe(true))
+
+	for _, srv := range []*httptest.Server{plain, upgraded} {
+		port, _ := strconv.Atoi(srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+		sc.probePort(context.Background(), port)
+	}
+
+	if b, ok := reg.Lookup("plain"); !ok || b.SupportsH2C {
+		t.Errorf("plain backend: got %+v, %v; want SupportsH2C=false", b, ok)
+	}
+	if b, ok := reg.Lookup("upgraded"); !ok || !b.SupportsH2C {
+		t.Errorf("h2c backend: got %+v, %v; want SupportsH2C=true", b, ok)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// StartScan / ScanStatus
+// ---------------------------------------------------------------------------
+
+func TestStartScan_KeepsRecentScans(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 1, 0, 5*time.Second, 1, time.Second, testLogger()) // empty range
+
+	var ids []string
+	for i := 0; i < maxScanRecords+2; i++ {
+		ids = append(ids, sc.StartScan(context.Background()))
+	}
+	if _, ok := sc.ScanStatus(ids[0]); ok {
+		t.Error("expected oldest scan to be evicted")
+	}
+
+	last := ids[len(ids)-1]
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if rec, ok := sc.ScanStatus(last); ok && rec.Status == ScanComplete {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected latest scan to complete")
+}
+
+func TestStartScan_CancelledWithRun(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, time.Hour, 1, 10*time.Second, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sc.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if hits.Load() == 0 {
+		t.Fatal("Run never probed the backend")
+	}
+
+	id := sc.StartScan(context.Background())
+	cancel()
+	<-done
+
+	deadline = time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if rec, ok := sc.ScanStatus(id); ok && rec.Status == ScanComplete {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("on-demand scan kept running after Run's context was cancelled")
+}
+
+// ---------------------------------------------------------------------------
+// Failure backoff
+// ---------------------------------------------------------------------------
+
+func TestScan_BacksOffFailingPorts(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		healthy bool
+		hits    []uint64
+	)
+	var sc *Scanner
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits = append(hits, sc.cycle.Load())
+		if !healthy {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"healthy": true, "version": "1.0"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	port := extractPort(t, srv.URL)
+	reg := registry.New(time.Hour, testLogger())
+	// Each probe is a single attempt, so hits line up with scan cycles.
+	sc = New(reg, port, port, 5*time.Second, 1, time.Second, testLogger(), WithProbeRetries(0, 0))
+
+	for i := 0; i < 40; i++ {
+		sc.scan(context.Background(), true)
+	}
+	mu.Lock()
+	got := append([]uint64(nil), hits...)
+	mu.Unlock()
+	want := []uint64{1, 3, 7, 15, 31}
+	if !slices.Equal(got, want) {
+		t.Fatalf("probed in cycles %v, want %v", got, want)
+	}
+
+	// A full scan ignores backoff, and success resets the streak.
+	mu.Lock()
+	healthy = true
+	hits = nil
+	mu.Unlock()
+	sc.ScanOnce(context.Background())
+	sc.scan(context.Background(), true)
+	sc.scan(context.Background(), true)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) != 3 {
+		t.Errorf("expected a probe every cycle after recovery, got cycles %v", hits)
+	}
+}
+
+func TestScan_SkipsExcludedPorts(t *testing.T) {
+	srv := fakeOpenCode(true, "router", "/home/test/router", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, time.Second, testLogger(), WithExcludePorts([]int{port}))
+	if res := sc.ScanOnce(context.Background()); res.Added != 0 || reg.Len() != 0 {
+		t.Fatalf("expected excluded port to be skipped, got %+v and %d backends", res, reg.Len())
+	}
+
+	sc = New(reg, port, port, 5*time.Second, 1, time.Second, testLogger())
+	if res := sc.ScanOnce(context.Background()); res.Added != 1 {
+		t.Fatalf("expected port to be probed without exclusion, got %+v", res)
+	}
+}
+
+func TestScan_PortGroups(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	groups := []config.PortRange{{Start: 30000, End: 30002}, {Start: 30010, End: 30011}}
+	sc := New(reg, 30000, 30011, 5*time.Second, 4, time.Second, testLogger(), WithPortGroups(groups))
+
+	var (
+		mu     sync.Mutex
+		dialed = map[int]int{}
+	)
+	sc.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, p, _ := net.SplitHostPort(addr)
+		port, _ := strconv.Atoi(p)
+		mu.Lock()
+		dialed[port]++
+		mu.Unlock()
+		return nil, errors.New("refused by test dialer")
+	}
+
+	sc.scan(context.Background(), false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for port := 30000; port <= 30011; port++ {
+		inGroup := groups[0].Contains(port) || groups[1].Contains(port)
+		if inGroup && dialed[port] == 0 {
+			t.Errorf("port %d in a scan group was never dialed", port)
+		}
+		if !inGroup && dialed[port] != 0 {
+			t.Errorf("port %d between the groups was dialed %d times", port, dialed[port])
+		}
+	}
+}
+
+func TestScan_SkipsExcludedRanges(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 30000, 30009, 5*time.Second, 4, time.Second, testLogger(),
+		WithExcludeRanges([]config.PortRange{{Start: 30002, End: 30004}, {Start: 30009, End: 30009}}))
+
+	var (
+		mu     sync.Mutex
+		dialed = map[int]int{}
+	)
+	sc.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, p, _ := net.SplitHostPort(addr)
+		port, _ := strconv.Atoi(p)
+		mu.Lock()
+		dialed[port]++
+		mu.Unlock()
+		return nil, errors.New("refused by test dialer")
+	}
+
+	sc.scan(context.Background(), false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for port := 30000; port <= 30009; port++ {
+		excluded := (port >= 30002 && port <= 30004) || port == 30009
+		if excluded && dialed[port] != 0 {
+			t.Errorf("excluded port %d was dialed %d times", port, dialed[port])
+		}
+		if !excluded && dialed[port] == 0 {
+			t.Errorf("port %d outside the excluded ranges was never dialed", port)
+		}
+	}
+}
+
+func TestScan_SkipsBlocklist(t *testing.T) {
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 30000, 30005, 5*time.Second, 4, time.Second, testLogger(),
+		WithBlocklist([]int{30001, 30002}))
+
+	var (
+		mu     sync.Mutex
+		dialed = map[int]int{}
+	)
+	sc.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, p, _ := net.SplitHostPort(addr)
+		port, _ := strconv.Atoi(p)
+		mu.Lock()
+		dialed[port]++
+		mu.Unlock()
+		return nil, errors.New("refused by test dialer")
+	}
+
+	sc.scan(context.Background(), false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for port := 30000; port <= 30005; port++ {
+		blocked := port == 30001 || port == 30002
+		if blocked && dialed[port] != 0 {
+			t.Errorf("blocklisted port %d was dialed %d times", port, dialed[port])
+		}
+		if !blocked && dialed[port] == 0 {
+			t.Errorf("port %d outside the blocklist was never dialed", port)
+		}
+	}
+}
+
+func TestRun_WarnsWellKnownPorts(t *testing.T) {
+	run := func(start, end int, opts ...Option) string {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		sc := New(registry.New(30*time.Second, testLogger()), start, end, time.Hour, 1, time.Second, logger, opts...)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // Run returns after its startup checks
+		sc.Run(ctx)
+		return buf.String()
+	}
+
+	out := run(20, 25)
+	if !strings.Contains(out, "well-known ports") || !strings.Contains(out, "ssh") || !strings.Contains(out, "smtp") {
+		t.Errorf("expected a well-known ports warning naming ssh and smtp, got %q", out)
+	}
+	if out := run(20, 25, WithBlocklist([]int{21, 22, 23, 25})); strings.Contains(out, "well-known ports") {
+		t.Errorf("blocklisted ports should not be warned about, got %q", out)
+	}
+	if out := run(30000, 30010); out != "" {
+		t.Errorf("expected no warning for the default range, got %q", out)
+	}
+}
+
+func TestScan_BatchesUpserts(t *testing.T) {
+	// Ports 30000-30002 each reach their own fake project.
+	addrs := map[string]string{}
+	for i, name := range []string{"alpha", "beta", "gamma"} {
+		srv := fakeOpenCode(true, name, "/home/test/"+name, "1.0")
+		defer srv.Close()
+		addrs[strconv.Itoa(30000+i)] = strings.TrimPrefix(srv.URL, "http://")
+	}
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, 30000, 30003, 5*time.Second, 4, time.Second, testLogger())
+	dialer := &net.Dialer{}
+	sc.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, p, _ := net.SplitHostPort(addr)
+		if target, ok := addrs[p]; ok {
+			return dialer.DialContext(ctx, network, target)
+		}
+		return nil, errors.New("refused by test dialer")
+	}
+
+	events, cancel := reg.Subscribe()
+	defer cancel()
+	if res := sc.scan(context.Background(), false); res.Added != 3 {
+		t.Fatalf("scan added %d backends, want 3", res.Added)
+	}
+
+	var batches, added int
+	for len(events) > 0 {
+		ev := <-events
+		if ev.Type == registry.EventBatch {
+			batches++
+			for _, change := range ev.Events {
+				if change.Type == registry.EventAdded {
+					added++
+				}
+			}
+		} else if ev.Type == registry.EventAdded {
+			t.Errorf("backend %s added outside the batch", ev.Slug)
+		}
+	}
+	if batches != 1 || added != 3 {
+		t.Errorf("got %d batch events adding %d backends, want 1 adding 3", batches, added)
+	}
+}
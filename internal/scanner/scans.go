@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxScanRecords is how many on-demand scan results are kept for lookup.
+const maxScanRecords = 10
+
+// Scan states reported by ScanRecord.Status.
+const (
+	ScanRunning  = "running"
+	ScanComplete = "complete"
+)
+
+// ScanRecord tracks an on-demand scan started with StartScan.
+type ScanRecord struct {
+	ID         string     `json:"scan_id"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ScanResult
+}
+
+// scanHistory keeps the most recent on-demand scans, oldest first.
+type scanHistory struct {
+	mu      sync.Mutex
+	records []*ScanRecord
+	runCtx  context.Context // set by Run; cancels on-demand scans at shutdown
+}
+
+// StartScan runs ScanOnce in the background and returns an ID for ScanStatus.
+// The scan outlives ctx, usually the triggering request, and keeps only its
+// values; it is cancelled with the context passed to Run instead.
+func (s *Scanner) StartScan(ctx context.Context) string {
+	rec := &ScanRecord{ID: uuid.NewString(), Status: ScanRunning, StartedAt: time.Now()}
+
+	s.scans.mu.Lock()
+	s.scans.records = append(s.scans.records, rec)
+	if len(s.scans.records) > maxScanRecords {
+		s.scans.records = s.scans.records[len(s.scans.records)-maxScanRecords:]
+	}
+	runCtx := s.scans.runCtx
+	s.scans.mu.Unlock()
+
+	scanCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := func() bool { return true }
+	if runCtx != nil {
+		stop = context.AfterFunc(runCtx, cancel)
+	}
+	go func() {
+		defer cancel()
+		defer stop()
+		result := s.ScanOnce(scanCtx)
+		finished := time.Now()
+
+		s.scans.mu.Lock()
+		rec.ScanResult = result
+		rec.Status = ScanComplete
+		rec.FinishedAt = &finished
+		s.scans.mu.Unlock()
+
+		s.logger.Info("on-demand scan complete", "scan_id", rec.ID,
+			"added", result.Added, "updated", result.Updated, "removed", result.Removed)
+	}()
+	return rec.ID
+}
+
+// ScanStatus returns a snapshot of a recent on-demand scan. Only the last
+// maxScanRecords scans are retained.
+func (s *Scanner) ScanStatus(id string) (ScanRecord, bool) {
+	s.scans.mu.Lock()
+	defer s.scans.mu.Unlock()
+	for _, rec := range s.scans.records {
+		if rec.ID == id {
+			return *rec, true
+		}
+	}
+	return ScanRecord{}, false
+}
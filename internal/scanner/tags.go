@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// tagsPath is the optional backend endpoint listing a project's tags.
+const tagsPath = "/project/tags"
+
+// tagsResponse is the shape of GET /project/tags
+type tagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// getTags calls GET /project/tags on the target. A 404 means the backend
+// does not publish tags and yields no tags and no error.
+func (s *Scanner) getTags(ctx context.Context, baseURL string) ([]string, error) {
+	req, err := newProbeRequest(ctx, http.MethodGet, baseURL+tagsPath, s.userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		if _, copyErr := io.Copy(io.Discard, resp.Body); copyErr != nil {
+			s.logger.Debug("tags response drain failed", "error", copyErr)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("tags endpoint returned %d", resp.StatusCode)
+	}
+
+	var t tagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, fmt.Errorf("failed to decode tags response: %w", err)
+	}
+	return t.Tags, nil
+}
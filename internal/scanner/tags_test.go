@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+// fakeOpenCodeWithTags serves a healthy backend whose /project/tags endpoint
+// answers with the current value of body, or with status when body is empty.
+func fakeOpenCodeWithTags(status *atomic.Int32, body *atomic.Value) *httptest.Server {
+	mux := fakeOpenCodeHandler("/global/health", "/project/current", true, "tagged", "/home/test/tagged", "1.0").(*http.ServeMux)
+	mux.HandleFunc(tagsPath, func(w http.ResponseWriter, r *http.Request) {
+		if code := int(status.Load()); code != http.StatusOK {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body.Load().(string)))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestProbePort_Tags(t *testing.T) {
+	var status atomic.Int32
+	var body atomic.Value
+	status.Store(http.StatusOK)
+	body.Store(`{"tags":["experimental","gpu"]}`)
+	srv := fakeOpenCodeWithTags(&status, &body)
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger())
+	sc.probePort(context.Background(), port)
+
+	b, ok := reg.Lookup("tagged")
+	if !ok || !slices.Equal(b.Tags, []string{"experimental", "gpu"}) {
+		t.Fatalf("expected tags [experimental gpu], got %+v, %v", b, ok)
+	}
+
+	// A server error keeps the last known tags.
+	status.Store(http.StatusInternalServerError)
+	sc.probePort(context.Background(), port)
+	if b, _ := reg.Lookup("tagged"); !slices.Equal(b.Tags, []string{"experimental", "gpu"}) {
+		t.Errorf("expected tags to survive a failed tags probe, got %q", b.Tags)
+	}
+
+	// 404 means the backend no longer publishes tags.
+	status.Store(http.StatusNotFound)
+	sc.probePort(context.Background(), port)
+	if b, _ := reg.Lookup("tagged"); len(b.Tags) != 0 {
+		t.Errorf("expected no tags after 404, got %q", b.Tags)
+	}
+}
+
+func TestProbePort_NoTagsEndpoint(t *testing.T) {
+	srv := fakeOpenCode(true, "plain", "/home/test/plain", "1.0")
+	defer srv.Close()
+	port := extractPort(t, srv.URL)
+
+	reg := registry.New(30*time.Second, testLogger())
+	New(reg, port, port, 5*time.Second, 1, 2*time.Second, testLogger()).probePort(context.Background(), port)
+
+	b, ok := reg.Lookup("plain")
+	if !ok {
+		t.Fatal("expected backend without a tags endpoint to be registered")
+	}
+	if len(b.Tags) != 0 {
+		t.Errorf("expected no tags, got %q", b.Tags)
+	}
+}
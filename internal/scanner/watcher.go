@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher watches project root directories and calls onChange as soon as a
+// new project directory or PID file appears, so the scanner doesn't have to
+// wait for its next tick.
+//
+// Each root and its immediate subdirectories are watched; fsnotify is not
+// recursive, and a PID file is typically written inside the project itself.
+type Watcher struct {
+	roots    []string
+	onChange func()
+	logger   *slog.Logger
+}
+
+// NewWatcher creates a Watcher for the given root directories.
+func NewWatcher(roots []string, onChange func(), logger *slog.Logger) *Watcher {
+	cleaned := make([]string, 0, len(roots))
+	for _, root := range roots {
+		cleaned = append(cleaned, filepath.Clean(root))
+	}
+	return &Watcher{roots: cleaned, onChange: onChange, logger: logger}
+}
+
+// Start begins watching in the background until ctx is cancelled.
+// Roots that don't exist are skipped with a warning.
+func (w *Watcher) Start(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("fsnotify.NewWatcher: %w", err)
+	}
+
+	watched := 0
+	for _, root := range w.roots {
+		if err := w.addTree(fw, root); err != nil {
+			w.logger.Warn("cannot watch directory", "path", root, "error", err)
+			continue
+		}
+		watched++
+	}
+	if watched == 0 {
+		fw.Close()
+		return fmt.Errorf("no watchable directories in %v", w.roots)
+	}
+
+	w.logger.Info("watching for new projects", "roots", w.roots)
+	go w.run(ctx, fw)
+	return nil
+}
+
+// addTree watches root and each directory directly beneath it.
+func (w *Watcher) addTree(fw *fsnotify.Watcher, root string) error {
+	if err := fw.Add(root); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			if err := fw.Add(filepath.Join(root, e.Name())); err != nil {
+				w.logger.Debug("cannot watch subdirectory", "path", e.Name(), "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context, fw *fsnotify.Watcher) {
+	defer fw.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(fw, ev)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Debug("watcher error", "error", err)
+		}
+	}
+}
+
+func (w *Watcher) handleEvent(fw *fsnotify.Watcher, ev fsnotify.Event) {
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+		return
+	}
+
+	if strings.HasSuffix(ev.Name, ".pid") {
+		w.logger.Debug("PID file written, triggering scan", "path", ev.Name)
+		w.onChange()
+		return
+	}
+
+	if !ev.Has(fsnotify.Create) || !w.isRootChild(ev.Name) {
+		return
+	}
+	info, err := os.Stat(ev.Name)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	if err := fw.Add(ev.Name); err != nil {
+		w.logger.Debug("cannot watch new directory", "path", ev.Name, "error", err)
+	}
+	w.logger.Debug("new project directory, triggering scan", "path", ev.Name)
+	w.onChange()
+}
+
+// isRootChild reports whether path sits directly inside one of the roots.
+func (w *Watcher) isRootChild(path string) bool {
+	parent := filepath.Dir(path)
+	for _, root := range w.roots {
+		if root == parent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+)
+
+func startTestWatcher(t *testing.T, root string) <-chan struct{} {
+	t.Helper()
+	triggered := make(chan struct{}, 16)
+	w := NewWatcher([]string{root}, func() { triggered <- struct{}{} }, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return triggered
+}
+
+func waitTriggered(t *testing.T, triggered <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-triggered:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected scan trigger after %s", what)
+	}
+}
+
+func TestWatcher_NewDirectoryTriggers(t *testing.T) {
+	root := t.TempDir()
+	triggered := startTestWatcher(t, root)
+
+	if err := os.MkdirAll(filepath.Join(root, "new-project"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	waitTriggered(t, triggered, "new project directory")
+}
+
+func TestWatcher_PIDFileTriggers(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "existing")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	triggered := startTestWatcher(t, root)
+
+	f, err := os.Create(filepath.Join(project, "opencode.pid"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	f.Close()
+	waitTriggered(t, triggered, "PID file inside existing project")
+}
+
+func TestWatcher_IgnoresOrdinaryFiles(t *testing.T) {
+	root := t.TempDir()
+	triggered := startTestWatcher(t, root)
+
+	f, err := os.Create(filepath.Join(root, "notes.txt"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	f.Close()
+
+	select {
+	case <-triggered:
+		t.Fatal("ordinary file should not trigger a scan")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcher_StartFailsWithoutRoots(t *testing.T) {
+	w := NewWatcher([]string{filepath.Join(t.TempDir(), "missing")}, func() {}, testLogger())
+	if err := w.Start(context.Background()); err == nil {
+		t.Fatal("expected error when no root can be watched")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Trigger runs a scan without waiting for the ticker
+// ---------------------------------------------------------------------------
+
+func TestRun_TriggerScansImmediately(t *testing.T) {
+	// Reserve a port, then leave it closed for the initial scan.
+	srv := fakeOpenCode(true, "late", "/home/test/late", "1.0")
+	port, _ := strconv.Atoi(srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+	handler := srv.Config.Handler
+	srv.Close()
+
+	reg := registry.New(30*time.Second, testLogger())
+	sc := New(reg, port, port, time.Hour, 1, 200*time.Millisecond, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sc.Run(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	if reg.Len() != 0 {
+		t.Fatalf("expected nothing discovered before backend starts, got %d", reg.Len())
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		t.Skipf("port %d no longer available: %v", port, err)
+	}
+	late := &http.Server{Handler: handler}
+	go func() { _ = late.Serve(ln) }()
+	defer late.Close()
+
+	root := t.TempDir()
+	w := NewWatcher([]string{root}, sc.Trigger, testLogger())
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("watcher Start: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "late"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := reg.Lookup("late"); ok {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("expected watcher-triggered scan to discover backend before the next tick")
+}
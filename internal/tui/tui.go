@@ -0,0 +1,226 @@
+// Package tui is a terminal dashboard for the router: a live table of the
+// registered backends, refreshed every second, for developers who would
+// rather not open the web dashboard.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshInterval is how often the table is rebuilt from the registry.
+const refreshInterval = time.Second
+
+// Config wires the dashboard to the router.
+type Config struct {
+	// Backends lists the registered backends, e.g. Registry.All.
+	Backends func() []*registry.Backend
+	// StaleAfter decides which backends show as stale.
+	StaleAfter time.Duration
+	// URL returns the address Enter opens for a backend.
+	URL func(b *registry.Backend) string
+	// Rescan starts an on-demand scan, e.g. Scanner.Trigger.
+	Rescan func()
+	// Open opens a URL in the browser; nil means OpenBrowser.
+	Open func(url string) error
+	// Title is shown above the table, e.g. the listen address.
+	Title string
+}
+
+// Model is the bubbletea model of the dashboard.
+type Model struct {
+	cfg      Config
+	backends []*registry.Backend
+	cursor   int
+	status   string // last action, shown under the table
+	now      time.Time
+}
+
+// tickMsg asks the model to refresh its table.
+type tickMsg time.Time
+
+// NewModel returns a dashboard model showing the backends as of now.
+func NewModel(cfg Config) Model {
+	if cfg.Open == nil {
+		cfg.Open = OpenBrowser
+	}
+	m := Model{cfg: cfg}
+	m.refresh(time.Now())
+	return m
+}
+
+// Run shows the dashboard on the terminal until the user quits or ctx is
+// done.
+func Run(ctx context.Context, cfg Config) error {
+	_, err := tea.NewProgram(NewModel(cfg), tea.WithContext(ctx), tea.WithAltScreen()).Run()
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// Init implements tea.Model.
+func (m Model) Init() tea.Cmd {
+	return tick()
+}
+
+// Update implements tea.Model: arrow keys (or j/k) move the selection,
+// Enter opens the selected backend, r rescans and q quits.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		m.refresh(time.Time(msg))
+		return m, tick()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.backends)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if b := m.Selected(); b != nil && m.cfg.URL != nil {
+				url := m.cfg.URL(b)
+				if err := m.cfg.Open(url); err != nil {
+					m.status = fmt.Sprintf("open %s: %v", url, err)
+				} else {
+					m.status = "opened " + url
+				}
+			}
+		case "r":
+			if m.cfg.Rescan != nil {
+				m.cfg.Rescan()
+				m.status = "scan triggered"
+			}
+		}
+	}
+	return m, nil
+}
+
+// Selected returns the highlighted backend, or nil if there are none.
+func (m Model) Selected() *registry.Backend {
+	if m.cursor < 0 || m.cursor >= len(m.backends) {
+		return nil
+	}
+	return m.backends[m.cursor]
+}
+
+// refresh reloads the backends, sorted by slug then port, keeping the
+// selection on the same backend when it is still registered.
+func (m *Model) refresh(now time.Time) {
+	selected := m.Selected()
+	m.now = now
+	m.backends = nil
+	if m.cfg.Backends != nil {
+		m.backends = m.cfg.Backends()
+	}
+	sort.Slice(m.backends, func(i, j int) bool {
+		if m.backends[i].Slug != m.backends[j].Slug {
+			return m.backends[i].Slug < m.backends[j].Slug
+		}
+		return m.backends[i].Port < m.backends[j].Port
+	})
+	if selected != nil {
+		for i, b := range m.backends {
+			if b.Port == selected.Port {
+				m.cursor = i
+				break
+			}
+		}
+	}
+	if m.cursor >= len(m.backends) {
+		m.cursor = max(len(m.backends)-1, 0)
+	}
+}
+
+// View implements tea.Model.
+func (m Model) View() string {
+	var sb strings.Builder
+	if m.cfg.Title != "" {
+		sb.WriteString(m.cfg.Title + "\n\n")
+	}
+	fmt.Fprintf(&sb, "  %-30s %-6s %-9s %-12s %s\n", "SLUG", "PORT", "STATUS", "VERSION", "LAST SEEN")
+	if len(m.backends) == 0 {
+		sb.WriteString("  no backends registered yet\n")
+	}
+	for i, b := range m.backends {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		fmt.Fprintf(&sb, "%s %-30s %-6d %-9s %-12s %s\n",
+			cursor, truncate(b.Slug, 30), b.Port, m.statusOf(b), truncate(b.Version, 12), relative(m.now.Sub(b.LastSeen)))
+	}
+	if m.status != "" {
+		sb.WriteString("\n" + m.status + "\n")
+	}
+	sb.WriteString("\n↑/↓ select • enter open • r rescan • q quit\n")
+	return sb.String()
+}
+
+func (m Model) statusOf(b *registry.Backend) string {
+	switch {
+	case b.Draining:
+		return "draining"
+	case b.Manual:
+		return "pinned"
+	case m.now.Sub(b.LastSeen) < m.cfg.StaleAfter:
+		return "healthy"
+	default:
+		return "stale"
+	}
+}
+
+// relative formats an age like "3s ago" or "2m ago".
+func relative(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
+// OpenBrowser opens url with the platform's default handler: open on
+// macOS, xdg-open elsewhere.
+func OpenBrowser(url string) error {
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	cmd := exec.Command(name, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModel(t *testing.T, backends []*registry.Backend) (Model, *[]string, *int) {
+	t.Helper()
+	var opened []string
+	var rescans int
+	m := NewModel(Config{
+		Backends:   func() []*registry.Backend { return backends },
+		StaleAfter: 30 * time.Second,
+		URL:        func(b *registry.Backend) string { return "http://localhost:8080/" + b.Slug + "/" },
+		Rescan:     func() { rescans++ },
+		Open: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
+	})
+	return m, &opened, &rescans
+}
+
+func press(t *testing.T, m Model, keys ...tea.KeyMsg) (Model, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var next tea.Model
+		next, cmd = m.Update(k)
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+var (
+	keyUp    = tea.KeyMsg{Type: tea.KeyUp}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+	keyEnter = tea.KeyMsg{Type: tea.KeyEnter}
+)
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func sampleBackends() []*registry.Backend {
+	now := time.Now()
+	return []*registry.Backend{
+		{Slug: "web", Port: 4097, Version: "1.0", LastSeen: now},
+		{Slug: "api", Port: 4096, Version: "1.1", LastSeen: now.Add(-time.Minute)},
+		{Slug: "old", Port: 4098, Version: "0.9", LastSeen: now, Draining: true},
+	}
+}
+
+func TestUpdate_Navigation(t *testing.T) {
+	m, _, _ := testModel(t, sampleBackends())
+	if b := m.Selected(); b == nil || b.Slug != "api" {
+		t.Fatalf("initial selection = %v, want api (sorted first)", b)
+	}
+
+	m, _ = press(t, m, keyDown, keyDown, keyDown) // stops at the last row
+	if b := m.Selected(); b.Slug != "web" {
+		t.Errorf("after three downs selected %s, want web", b.Slug)
+	}
+	m, _ = press(t, m, keyUp, runeKey('k'), keyUp) // stops at the first row
+	if b := m.Selected(); b.Slug != "api" {
+		t.Errorf("after moving up selected %s, want api", b.Slug)
+	}
+	m, _ = press(t, m, runeKey('j'))
+	if b := m.Selected(); b.Slug != "old" {
+		t.Errorf("j selected %s, want old", b.Slug)
+	}
+}
+
+func TestUpdate_EnterOpensURL(t *testing.T) {
+	m, opened, _ := testModel(t, sampleBackends())
+	m, _ = press(t, m, keyDown, keyDown, keyEnter)
+	if len(*opened) != 1 || (*opened)[0] != "http://localhost:8080/web/" {
+		t.Fatalf("opened %v, want the web backend's URL", *opened)
+	}
+	if !strings.Contains(m.View(), "opened http://localhost:8080/web/") {
+		t.Errorf("view does not report the opened URL:\n%s", m.View())
+	}
+}
+
+func TestUpdate_EnterReportsOpenError(t *testing.T) {
+	m, _, _ := testModel(t, sampleBackends())
+	m.cfg.Open = func(string) error { return errors.New("no browser") }
+	m, _ = press(t, m, keyEnter)
+	if !strings.Contains(m.View(), "no browser") {
+		t.Errorf("view does not report the open error:\n%s", m.View())
+	}
+}
+
+func TestUpdate_Rescan(t *testing.T) {
+	m, _, rescans := testModel(t, nil)
+	m, cmd := press(t, m, runeKey('r'))
+	if *rescans != 1 || cmd != nil {
+		t.Errorf("r: %d rescans, cmd %v; want one rescan and no command", *rescans, cmd)
+	}
+	if !strings.Contains(m.View(), "scan triggered") {
+		t.Errorf("view does not report the scan:\n%s", m.View())
+	}
+}
+
+func TestUpdate_Quit(t *testing.T) {
+	for _, k := range []tea.KeyMsg{runeKey('q'), {Type: tea.KeyCtrlC}} {
+		m, _, _ := testModel(t, nil)
+		_, cmd := press(t, m, k)
+		if cmd == nil {
+			t.Fatalf("%s: no command, want tea.Quit", k)
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Errorf("%s: command did not quit", k)
+		}
+	}
+}
+
+func TestUpdate_TickRefreshes(t *testing.T) {
+	backends := sampleBackends()
+	m := NewModel(Config{
+		Backends:   func() []*registry.Backend { return backends },
+		StaleAfter: 30 * time.Second,
+	})
+	m, _ = press(t, m, keyDown) // select "old"
+
+	// "api" goes away; the selection stays on "old".
+	all := sampleBackends()
+	backends = []*registry.Backend{all[0], all[2]}
+	next, cmd := m.Update(tickMsg(time.Now()))
+	m = next.(Model)
+	if cmd == nil {
+		t.Error("tick did not schedule the next refresh")
+	}
+	if b := m.Selected(); b == nil || b.Slug != "old" {
+		t.Errorf("selection after refresh = %v, want old", b)
+	}
+}
+
+func TestView(t *testing.T) {
+	m, _, _ := testModel(t, sampleBackends())
+	view := m.View()
+	for _, want := range []string{"SLUG", "api", "4096", "stale", "1m ago", "web", "healthy", "old", "draining", "q quit"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if empty, _, _ := testModel(t, nil); !strings.Contains(empty.View(), "no backends registered yet") {
+		t.Errorf("empty view:\n%s", empty.View())
+	}
+}
@@ -0,0 +1,24 @@
+// Package version holds the router's build information. Release builds
+// set it with -ldflags, e.g.
+//
+//	-X opencoderouter/internal/version.Version=v1.2.3
+//	-X opencoderouter/internal/version.Commit=$(git rev-parse HEAD)
+//
+// See the Makefile's build target.
+package version
+
+var (
+	// Version is the router release, reported in the
+	// X-OpenCode-Router-Version response header and GET /api/health.
+	Version = "dev"
+	// Commit is the git commit the router was built from.
+	Commit = "unknown"
+	// BuildTime is when the binary was built, in RFC 3339 UTC.
+	BuildTime = "unknown"
+)
+
+// String returns the version, commit and build time on one line, as printed
+// at startup.
+func String() string {
+	return Version + " (commit " + Commit + ", built " + BuildTime + ")"
+}
@@ -0,0 +1,27 @@
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildVarsAreStrings(t *testing.T) {
+	// -ldflags -X only sets package-level string variables; a const or any
+	// other type would silently ignore the flag.
+	for name, v := range map[string]any{"Version": &Version, "Commit": &Commit, "BuildTime": &BuildTime} {
+		if reflect.TypeOf(v).Elem().Kind() != reflect.String {
+			t.Errorf("%s must be a string variable", name)
+		}
+		if *v.(*string) == "" {
+			t.Errorf("%s should have a non-empty default", name)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	defer func(v, c, b string) { Version, Commit, BuildTime = v, c, b }(Version, Commit, BuildTime)
+	Version, Commit, BuildTime = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+	if got, want := String(), "v1.2.3 (commit abc123, built 2026-01-02T03:04:05Z)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+// Package watcher watches individual files for changes.
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a FileWatcher waits after the last event for a
+// file before calling back, so a burst of events from one save is reported
+// once.
+const DefaultDebounce = 200 * time.Millisecond
+
+// FileWatcher calls onChange when a file is written, created or replaced.
+//
+// The file's directory is watched rather than the file itself: editors such
+// as vim save by writing a new file and renaming it over the old one, which
+// would end a watch on the original inode. The file need not exist yet.
+type FileWatcher struct {
+	path     string
+	onChange func()
+	debounce time.Duration
+	logger   *slog.Logger
+}
+
+// NewFileWatcher creates a FileWatcher for path with DefaultDebounce.
+func NewFileWatcher(path string, onChange func(), logger *slog.Logger) *FileWatcher {
+	return &FileWatcher{
+		path:     filepath.Clean(path),
+		onChange: onChange,
+		debounce: DefaultDebounce,
+		logger:   logger,
+	}
+}
+
+// Start begins watching in the background until ctx is cancelled. It fails
+// when the file's directory cannot be watched.
+func (w *FileWatcher) Start(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("fsnotify.NewWatcher: %w", err)
+	}
+	if err := fw.Add(filepath.Dir(w.path)); err != nil {
+		fw.Close()
+		return fmt.Errorf("watch %s: %w", filepath.Dir(w.path), err)
+	}
+
+	w.logger.Info("watching file for changes", "path", w.path)
+	go w.run(ctx, fw)
+	return nil
+}
+
+func (w *FileWatcher) run(ctx context.Context, fw *fsnotify.Watcher) {
+	defer fw.Close()
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != w.path || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+				continue
+			}
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			w.logger.Debug("watched file changed", "path", w.path)
+			w.onChange()
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Debug("file watcher error", "path", w.path, "error", err)
+		}
+	}
+}
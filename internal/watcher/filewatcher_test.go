@@ -0,0 +1,92 @@
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func startFileWatcher(t *testing.T, path string) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	w := NewFileWatcher(path, func() { calls.Add(1) }, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return &calls
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileWatcher_DebouncesWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pinned.json")
+	writeFile(t, path, "[]")
+	calls := startFileWatcher(t, path)
+
+	for i := 0; i < 5; i++ {
+		writeFile(t, path, `[{"port":4200,"project_path":"/opt/app"}]`)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("callback ran %d times for a burst of writes, want 1", n)
+	}
+
+	writeFile(t, path, "[]")
+	time.Sleep(300 * time.Millisecond)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("callback ran %d times after a second write, want 2", n)
+	}
+}
+
+func TestFileWatcher_RenameOverFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pinned.json")
+	writeFile(t, path, "[]")
+	calls := startFileWatcher(t, path)
+
+	// The way vim and most editors save.
+	tmp := filepath.Join(dir, ".pinned.json.swp")
+	writeFile(t, tmp, `[{"port":4200,"project_path":"/opt/app"}]`)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("callback ran %d times after a rename, want 1", n)
+	}
+}
+
+func TestFileWatcher_IgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	calls := startFileWatcher(t, filepath.Join(dir, "pinned.json"))
+
+	writeFile(t, filepath.Join(dir, "other.json"), "[]")
+	time.Sleep(300 * time.Millisecond)
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("callback ran %d times for another file, want 0", n)
+	}
+}
+
+func TestFileWatcher_MissingDirectory(t *testing.T) {
+	w := NewFileWatcher(filepath.Join(t.TempDir(), "absent", "pinned.json"), func() {}, testLogger())
+	if err := w.Start(context.Background()); err == nil {
+		t.Fatal("expected an error for a directory that does not exist")
+	}
+}
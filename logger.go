@@ -1,13 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+
+	"opencoderouter/internal/config"
 )
 
-func setupLogger() (*slog.Logger, string, func()) {
+func setupLogger(cfg config.Config) (*slog.Logger, string, func()) {
 	logFile, logPath := openLogFile()
 	logWriter := io.Discard
 	closeFn := func() {}
@@ -21,7 +24,7 @@ func setupLogger() (*slog.Logger, string, func()) {
 		logPath = "disabled (io.Discard)"
 	}
 
-	logger := slog.New(slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger := config.NewLogger(cfg, logWriter)
 	slog.SetDefault(logger)
 
 	return logger, logPath, closeFn
@@ -45,3 +48,39 @@ func openLogFile() (*os.File, string) {
 
 	return nil, ""
 }
+
+// setupTraceFile opens cfg.TraceFile for proxy.WithTrace, or returns nil
+// when tracing is disabled.
+func setupTraceFile(cfg config.Config) (io.Writer, func(), error) {
+	if cfg.TraceFile == "" {
+		return nil, func() {}, nil
+	}
+	f, err := os.OpenFile(cfg.TraceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("open trace file: %w", err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+// setupAccessLogger returns a JSON logger for proxied requests, or nil when
+// access logging is disabled.
+func setupAccessLogger(cfg config.Config) (*slog.Logger, func(), error) {
+	if !cfg.AccessLog {
+		return nil, func() {}, nil
+	}
+
+	var w io.Writer = os.Stderr
+	closeFn := func() {}
+	if cfg.AccessLogFile != "" {
+		f, err := os.OpenFile(cfg.AccessLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, closeFn, fmt.Errorf("open access log: %w", err)
+		}
+		w = f
+		closeFn = func() {
+			_ = f.Close()
+		}
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})), closeFn, nil
+}
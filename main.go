@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+
+	"opencoderouter/internal/version"
 )
 
 func main() {
@@ -12,15 +14,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger, logPath, closeLogger := setupLogger()
+	logger, logPath, closeLogger := setupLogger(cfg)
 	defer closeLogger()
 
+	fmt.Fprintf(os.Stderr, "OpenCodeRouter %s\n", version.String())
 	fmt.Fprintf(os.Stderr, "Logs: %s\n", logPath)
 	logger.Info("OpenCodeRouter starting",
+		"version", version.Version,
+		"commit", version.Commit,
+		"build_time", version.BuildTime,
 		"log_file", logPath,
-		"listen", cfg.ListenAddr,
+		"listen", cfg.ListenDisplay(),
 		"username", cfg.Username,
-		"scan_range", fmt.Sprintf("%d-%d", cfg.ScanPortStart, cfg.ScanPortEnd),
+		"scan_range", cfg.ScanRangeString(),
 		"session_range", fmt.Sprintf("%d-%d", cfg.SessionPortStart, cfg.SessionPortEnd),
 		"scan_interval", cfg.ScanInterval,
 		"mdns", cfg.EnableMDNS,
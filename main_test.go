@@ -1,9 +1,25 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"opencoderouter/internal/api"
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/proxy"
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestParseLikelyOrphansFromLsofOutputFiltersToOpencodeAndRange(t *testing.T) {
@@ -50,3 +66,269 @@ func TestHandleStartupOrphanOfferNoCleanupByDefault(t *testing.T) {
 	logger := slog.Default()
 	handleStartupOrphanOffer(31010, 31000, false, logger)
 }
+
+func TestReloadConfigAppliesScannerAndRegistrySettings(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	path := filepath.Join(t.TempDir(), "router.json")
+	if err := os.WriteFile(path, []byte(`{"scan_interval": "5s"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.ConfigFile = path
+	loaded, err := config.LoadFile(path, cfg)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	cfg = loaded
+
+	reg := registry.New(cfg.StaleAfter, logger)
+	sc := scanner.New(reg, 31000, 31001, cfg.ScanInterval, cfg.ScanConcurrency, cfg.ProbeTimeout, logger)
+	targets := reloadTargets{scanner: sc, registry: reg}
+
+	if err := os.WriteFile(path, []byte(`{"scan_interval": "12s", "stale_after": "50ms", "port": 9999}`), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	next, err := reloadConfig(cfg, targets, logger)
+	if err != nil {
+		t.Fatalf("reloadConfig: %v", err)
+	}
+
+	if got := sc.Interval(); got != 12*time.Second {
+		t.Fatalf("scanner interval = %s, want 12s", got)
+	}
+	if next.ScanInterval != 12*time.Second {
+		t.Fatalf("returned ScanInterval = %s, want 12s", next.ScanInterval)
+	}
+	if next.ListenPort != cfg.ListenPort {
+		t.Fatalf("listen port must not change without restart, got %d", next.ListenPort)
+	}
+
+	reg.Upsert(31000, "alpha", "/tmp/alpha", "1.0")
+	time.Sleep(100 * time.Millisecond)
+	if removed := reg.Prune(); len(removed) != 1 {
+		t.Fatalf("expected new stale-after to prune backend, removed=%v", removed)
+	}
+}
+
+func TestReloadConfigKeepsExplicitFlags(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	path := filepath.Join(t.TempDir(), "router.json")
+	if err := os.WriteFile(path, []byte(`{"scan_interval": "12s", "probe_timeout": "3s"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	// As if started with --scan-interval 7s --config router.json.
+	cfg := config.Defaults()
+	cfg.ConfigFile = path
+	cfg.ScanInterval = 7 * time.Second
+	reg := registry.New(cfg.StaleAfter, logger)
+	sc := scanner.New(reg, 31000, 31001, cfg.ScanInterval, cfg.ScanConcurrency, cfg.ProbeTimeout, logger)
+	targets := reloadTargets{scanner: sc, registry: reg, flags: map[string]string{"scan-interval": "7s", "config": path}}
+
+	next, err := reloadConfig(cfg, targets, logger)
+	if err != nil {
+		t.Fatalf("reloadConfig: %v", err)
+	}
+	if next.ScanInterval != 7*time.Second || sc.Interval() != 7*time.Second {
+		t.Errorf("scan interval = %s (scanner %s), want the 7s given on the command line", next.ScanInterval, sc.Interval())
+	}
+	if next.ProbeTimeout != 3*time.Second {
+		t.Errorf("probe timeout = %s, want 3s from the file", next.ProbeTimeout)
+	}
+}
+
+func TestReloadConfigRejectsInvalidFile(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	path := filepath.Join(t.TempDir(), "router.json")
+	if err := os.WriteFile(path, []byte(`{"scan_interval": "10ms"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.ConfigFile = path
+	reg := registry.New(cfg.StaleAfter, logger)
+	sc := scanner.New(reg, 31000, 31001, cfg.ScanInterval, cfg.ScanConcurrency, cfg.ProbeTimeout, logger)
+
+	if _, err := reloadConfig(cfg, reloadTargets{scanner: sc, registry: reg}, logger); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if got := sc.Interval(); got != cfg.ScanInterval {
+		t.Fatalf("scanner interval changed on failed reload: %s", got)
+	}
+}
+
+func TestListFlagRepeatableAndCommaSeparated(t *testing.T) {
+	var l listFlag
+	for _, v := range []string{"~/a", " ~/b , ~/c ", ""} {
+		if err := l.Set(v); err != nil {
+			t.Fatalf("Set(%q): %v", v, err)
+		}
+	}
+	if got := l.String(); got != "~/a,~/b,~/c" {
+		t.Fatalf("listFlag = %q, want ~/a,~/b,~/c", got)
+	}
+}
+
+func TestRemoveDeadBackendsDropsExitedProcess(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	bin := filepath.Join(t.TempDir(), "fake-opencode")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nsleep 0.2\n"), 0o755); err != nil {
+		t.Fatalf("write fake binary: %v", err)
+	}
+
+	lnch := launcher.New(31500, 31510, logger, launcher.WithBinaryPath(bin))
+	defer lnch.Shutdown()
+	reg := registry.New(time.Hour, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go removeDeadBackends(ctx, lnch, reg)
+
+	project := t.TempDir()
+	if err := lnch.Launch([]string{project}); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	port := lnch.Status()[0].Port
+	reg.Upsert(port, "proj", project, "1.0")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for lnch.Status()[0].State == launcher.ProcessRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("process did not exit")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	exited := time.Now()
+
+	for reg.Len() != 0 {
+		if time.Since(exited) > 100*time.Millisecond {
+			t.Fatalf("backend still registered %s after process exit", time.Since(exited))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDashboardBackendCopyButtons(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(getWebFS()))
+	defer srv.Close()
+
+	fetch := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		return string(body)
+	}
+
+	page := fetch("/")
+	for _, want := range []string{`id="backends-table"`, `id="quick-open"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("dashboard HTML missing %s", want)
+		}
+	}
+	ui := fetch("/js/ui.js")
+	for _, want := range []string{"data-url=", "navigator.clipboard.writeText(this.dataset.url)", "e.key.toLowerCase() === 'k'"} {
+		if !strings.Contains(ui, want) {
+			t.Errorf("dashboard script missing %q", want)
+		}
+	}
+}
+
+func TestDashboardTemplateRendersTheme(t *testing.T) {
+	var b strings.Builder
+	data := struct{ Theme, Version, Commit, BuildTime string }{"light", "v1.2.3", "abc123", "2026-01-02T03:04:05Z"}
+	if err := getDashboardTemplate().Execute(&b, data); err != nil {
+		t.Fatalf("execute dashboard template: %v", err)
+	}
+	if !strings.Contains(b.String(), `<body data-theme="light">`) {
+		t.Error("dashboard template does not render the theme on <body>")
+	}
+	if !strings.Contains(b.String(), `<span id="router-version">v1.2.3</span>`) {
+		t.Error("dashboard footer does not show the router version")
+	}
+
+	css, err := webAssets.ReadFile("web/styles.css")
+	if err != nil {
+		t.Fatalf("read styles.css: %v", err)
+	}
+	for _, want := range []string{"[data-theme=dark]", "[data-theme=light]"} {
+		if !strings.Contains(string(css), want) {
+			t.Errorf("styles.css missing %s selector", want)
+		}
+	}
+}
+
+// newTestServerHandler wires a proxy router and the session API into the
+// main listener's handler the way run does.
+func newTestServerHandler(cfg config.Config) http.Handler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reg := registry.New(cfg.StaleAfter, logger)
+	rt := proxy.New(reg, cfg, logger, http.NotFoundHandler())
+	apiRouter := api.NewRouter(api.RouterConfig{Fallback: rt, CORS: rt.CORS()})
+	return serverHandler(cfg, rt, apiRouter)
+}
+
+func TestServerHandlerBasicAuthCoversSessionAPI(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Defaults()
+	cfg.BasicAuthUser = "alice"
+	cfg.BasicAuthPass = string(hash)
+	h := newTestServerHandler(cfg)
+
+	for _, path := range []string{"/api/sessions", "/api/events", "/api/backends"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without credentials: status = %d, want 401", path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/backends", nil)
+	req.SetBasicAuth("alice", "secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /api/backends with credentials: status = %d, want 200", w.Code)
+	}
+}
+
+func TestServerHandlerAllowlistCoversSessionAPI(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.AllowedClientCIDRs = []string{"10.0.0.0/8"}
+	h := newTestServerHandler(cfg)
+
+	for _, path := range []string{"/api/sessions", "/api/backends"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.168.1.20:5000"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("GET %s from a denied client: status = %d, want 403", path, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/backends", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /api/backends from an allowed client: status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/launcher"
+	"opencoderouter/internal/proxy"
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/scanner"
+)
+
+// fakeServeEnv makes the test binary act as `opencode serve` when the
+// launcher runs it as a child process.
+const fakeServeEnv = "OCR_TEST_FAKE_OPENCODE_SERVE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeServeEnv) == "1" {
+		fakeOpenCodeServe(os.Args[1:])
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeOpenCodeServe serves the health and project endpoints the scanner
+// probes, for the working directory, on `serve --port N`.
+func fakeOpenCodeServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.Int("port", 0, "")
+	if len(args) == 0 || args[0] != "serve" {
+		fmt.Fprintln(os.Stderr, "usage: serve --port N")
+		os.Exit(2)
+	}
+	_ = fs.Parse(args[1:])
+	dir, _ := os.Getwd()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/global/health", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"healthy": true, "version": "stub"})
+	})
+	mux.HandleFunc("/project/current", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": filepath.Base(dir), "name": filepath.Base(dir), "path": dir})
+	})
+	if err := http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", *port), mux); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// freePortRange returns the first of n ports starting at a currently free one.
+func freePortRange(t *testing.T, n int) (int, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	start := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return start, start + n - 1
+}
+
+func TestLaunchedProcessesAreDiscoveredAndListed(t *testing.T) {
+	bin, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable: %v", err)
+	}
+	t.Setenv(fakeServeEnv, "1")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	root := t.TempDir()
+	var dirs []string
+	for _, name := range []string{"alpha", "bravo"} {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	start, end := freePortRange(t, 20)
+	lnch := launcher.New(start, end, logger, launcher.WithBinaryPath(bin))
+	defer lnch.Shutdown()
+	if err := lnch.Launch(dirs); err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.Username = "tester"
+	reg := registry.New(time.Minute, logger)
+	sc := scanner.New(reg, start, end, time.Minute, 20, time.Second, logger)
+	deadline := time.Now().Add(10 * time.Second)
+	for reg.Len() < len(dirs) {
+		if time.Now().After(deadline) {
+			t.Fatalf("launched backends not discovered; registry has %d", reg.Len())
+		}
+		sc.ScanOnce(context.Background())
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	srv := httptest.NewServer(proxy.New(reg, cfg, logger, http.NotFoundHandler(), proxy.WithLauncher(lnch)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/processes")
+	if err != nil {
+		t.Fatalf("GET /api/processes: %v", err)
+	}
+	var procs []launcher.ProcessStatus
+	if err := json.NewDecoder(resp.Body).Decode(&procs); err != nil {
+		t.Fatalf("decode processes: %v", err)
+	}
+	resp.Body.Close()
+	if len(procs) != len(dirs) {
+		t.Fatalf("expected %d processes, got %+v", len(dirs), procs)
+	}
+	for i, p := range procs {
+		if p.Path != dirs[i] || p.PID == 0 || p.State != launcher.ProcessRunning || p.Port < start || p.Port > end {
+			t.Errorf("unexpected process %d: %+v", i, p)
+		}
+	}
+
+	// Requests reach the launched process through the proxy.
+	resp, err = http.Get(srv.URL + "/bravo/global/health")
+	if err != nil {
+		t.Fatalf("proxy request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-OpenCode-Slug") != "bravo" {
+		t.Errorf("expected 200 from bravo, got %d %q (%s)", resp.StatusCode, resp.Header.Get("X-OpenCode-Slug"), body)
+	}
+
+	lnch.Shutdown()
+	for _, p := range lnch.Status() {
+		if p.State == launcher.ProcessRunning {
+			t.Errorf("process for %s still running after Shutdown", p.Path)
+		}
+	}
+}
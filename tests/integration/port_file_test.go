@@ -0,0 +1,85 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/api"
+	"opencoderouter/internal/auth"
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/proxy"
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/session"
+)
+
+func TestPortFileReportsOSAssignedPort(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := config.Defaults()
+	cfg.ListenPort = 0
+	cfg.ListenAddr = "127.0.0.1:0"
+	cfg.PortFile = filepath.Join(t.TempDir(), "ocr.port")
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	ln, err := cfg.Listen()
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	if cfg.ListenPort == 0 {
+		t.Fatal("Listen should record the OS-assigned port")
+	}
+	if err := cfg.WritePortFile(); err != nil {
+		t.Fatalf("WritePortFile: %v", err)
+	}
+
+	reg := registry.New(cfg.StaleAfter, logger)
+	reg.Upsert(31000, "alpha", "/tmp/alpha", "1.0")
+	handler := api.NewRouter(api.RouterConfig{
+		SessionManager:  newFakeSessionManager(),
+		SessionEventBus: session.NewEventBus(16),
+		AuthConfig:      auth.Defaults(),
+		Fallback:        proxy.New(reg, cfg, logger, http.NotFoundHandler()),
+	})
+	srv := &http.Server{Handler: handler}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	raw, err := os.ReadFile(cfg.PortFile)
+	if err != nil {
+		t.Fatalf("read port file: %v", err)
+	}
+	port, err := strconv.Atoi(string(raw))
+	if err != nil {
+		t.Fatalf("port file %q is not a decimal port: %v", raw, err)
+	}
+	if port != cfg.ListenPort {
+		t.Errorf("port file = %d, want %d", port, cfg.ListenPort)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/api/health", port))
+	if err != nil {
+		t.Fatalf("GET /api/health on discovered port: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if body["backends"] != float64(1) {
+		t.Errorf("backends = %v, want 1", body["backends"])
+	}
+}
@@ -0,0 +1,92 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"opencoderouter/internal/api"
+	"opencoderouter/internal/auth"
+	"opencoderouter/internal/config"
+	"opencoderouter/internal/proxy"
+	"opencoderouter/internal/registry"
+	"opencoderouter/internal/session"
+)
+
+func TestUnixSocketListenerServesHealth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Keep the path short: sun_path is limited to ~104 bytes on some platforms.
+	dir, err := os.MkdirTemp("", "ocr")
+	if err != nil {
+		t.Fatalf("mkdtemp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := config.Defaults()
+	cfg.ListenAddr = ""
+	cfg.UnixSocket = filepath.Join(dir, "router.sock")
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	reg := registry.New(cfg.StaleAfter, logger)
+	reg.Upsert(31000, "alpha", "/tmp/alpha", "1.0")
+
+	handler := api.NewRouter(api.RouterConfig{
+		SessionManager:  newFakeSessionManager(),
+		SessionEventBus: session.NewEventBus(16),
+		AuthConfig:      auth.Defaults(),
+		Fallback:        proxy.New(reg, cfg, logger, http.NotFoundHandler()),
+	})
+
+	ln, err := cfg.Listen()
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	srv := &http.Server{Handler: handler}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	info, err := os.Stat(cfg.UnixSocket)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != config.UnixSocketMode {
+		t.Errorf("socket mode = %o, want %o", perm, config.UnixSocketMode)
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", cfg.UnixSocket)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://unix/api/health")
+	if err != nil {
+		t.Fatalf("GET /api/health over unix socket: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if body["backends"] != float64(1) {
+		t.Errorf("backends = %v, want 1", body["backends"])
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"html/template"
 	"io/fs"
 	"net/http"
 )
@@ -16,3 +17,9 @@ func getWebFS() http.FileSystem {
 	}
 	return http.FS(fsys)
 }
+
+// getDashboardTemplate parses the dashboard page, which carries the theme
+// picked by the proxy.
+func getDashboardTemplate() *template.Template {
+	return template.Must(template.ParseFS(webAssets, "web/index.html"))
+}
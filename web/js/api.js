@@ -1,6 +1,6 @@
 import { state } from './state.js';
 import { DOM } from './dom.js';
-import { render } from './ui.js';
+import { render, renderRemotes, renderPeers, renderBackends } from './ui.js';
 
 export function normalizeSSEtoView(sseSession) {
   if (!sseSession) return null;
@@ -130,3 +130,39 @@ export async function loadInitial() {
     setTimeout(loadInitial, 5000);
   }
 }
+
+export async function loadRemotes() {
+  try {
+    const res = await fetch('/api/remotes');
+    if (!res.ok) throw new Error(`HTTP error! status: ${res.status}`);
+    state.remotes = (await res.json()) || [];
+    renderRemotes();
+  } catch (e) {
+    console.error('Failed to load remote routers', e);
+  }
+  setTimeout(loadRemotes, 10000);
+}
+
+export async function loadPeers() {
+  try {
+    const res = await fetch('/api/peers');
+    if (!res.ok) throw new Error(`HTTP error! status: ${res.status}`);
+    state.peers = (await res.json()) || [];
+    renderPeers();
+  } catch (e) {
+    console.error('Failed to load peer routers', e);
+  }
+  setTimeout(loadPeers, 10000);
+}
+
+export async function loadBackends() {
+  try {
+    const res = await fetch('/api/backends');
+    if (!res.ok) throw new Error(`HTTP error! status: ${res.status}`);
+    state.backends = (await res.json()) || [];
+    renderBackends();
+  } catch (e) {
+    console.error('Failed to load backends', e);
+  }
+  setTimeout(loadBackends, 5000);
+}
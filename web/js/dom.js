@@ -23,7 +23,17 @@ export const DOM = {
   chatInput: null,
   chatContainer: null,
   splitResizer: null,
-  btnSendChat: null
+  btnSendChat: null,
+  remotesTable: null,
+  remotesBody: null,
+  remotesEmpty: null,
+  peersTable: null,
+  peersBody: null,
+  peersEmpty: null,
+  backendsTable: null,
+  backendsBody: null,
+  backendsEmpty: null,
+  quickOpen: null
 };
 
 export function initDOM() {
@@ -52,4 +62,14 @@ export function initDOM() {
   DOM.chatContainer = document.getElementById('chat-container');
   DOM.splitResizer = document.getElementById('split-resizer');
   DOM.btnSendChat = document.getElementById('btn-send-chat');
+  DOM.remotesTable = document.getElementById('remotes-table');
+  DOM.remotesBody = document.getElementById('remotes-body');
+  DOM.remotesEmpty = document.getElementById('remotes-empty');
+  DOM.peersTable = document.getElementById('peers-table');
+  DOM.peersBody = document.getElementById('peers-body');
+  DOM.peersEmpty = document.getElementById('peers-empty');
+  DOM.backendsTable = document.getElementById('backends-table');
+  DOM.backendsBody = document.getElementById('backends-body');
+  DOM.backendsEmpty = document.getElementById('backends-empty');
+  DOM.quickOpen = document.getElementById('quick-open');
 }
\ No newline at end of file
@@ -3,7 +3,7 @@ import { initMarked } from './utils.js';
 import { initUI, render } from './ui.js';
 import { initChat } from './chat.js';
 import { initTerminalUI, attachTerminal } from './terminal.js';
-import { loadInitial } from './api.js';
+import { loadInitial, loadRemotes, loadPeers, loadBackends } from './api.js';
 import { state } from './state.js';
 
 document.addEventListener('DOMContentLoaded', () => {
@@ -44,4 +44,7 @@ document.addEventListener('DOMContentLoaded', () => {
   });
 
   loadInitial();
+  loadRemotes();
+  loadPeers();
+  loadBackends();
 });
\ No newline at end of file
@@ -1,5 +1,9 @@
 export const state = {
   sessions: new Map(),
+  remotes: [],
+  peers: [],
+  backends: [],
+  backendFilter: '',
   filter: '',
   sortCol: 'id',
   sortDesc: false,
@@ -68,13 +68,126 @@ export function render() {
   }
 }
 
+export function renderRemotes() {
+  DOM.remotesBody.innerHTML = '';
+
+  state.remotes.forEach(r => {
+    const tr = document.createElement('tr');
+    tr.innerHTML = `
+      <td class="id-col">${r.instance}</td>
+      <td>${r.username || '-'}</td>
+      <td><span class="workspace-col truncate" title="${r.host}:${r.port}">${r.host}:${r.port}</span></td>
+      <td>${r.version || '-'}</td>
+      <td><a class="remote-link" href="${r.url}" target="_blank" rel="noopener">OPEN</a></td>
+    `;
+    DOM.remotesBody.appendChild(tr);
+  });
+
+  const empty = state.remotes.length === 0;
+  DOM.remotesTable.style.display = empty ? 'none' : 'table';
+  DOM.remotesEmpty.style.display = empty ? 'block' : 'none';
+}
+
+export function renderPeers() {
+  DOM.peersBody.innerHTML = '';
+
+  state.peers.forEach(p => {
+    const addr = p.ip.includes(':') ? `[${p.ip}]:${p.port}` : `${p.ip}:${p.port}`;
+    const tr = document.createElement('tr');
+    tr.innerHTML = `
+      <td class="id-col">${p.host}</td>
+      <td>${p.username || '-'}</td>
+      <td>${addr}</td>
+      <td>${p.projects}</td>
+      <td><a class="remote-link" href="http://${addr}/" target="_blank" rel="noopener">OPEN</a></td>
+    `;
+    DOM.peersBody.appendChild(tr);
+  });
+
+  const empty = state.peers.length === 0;
+  DOM.peersTable.style.display = empty ? 'none' : 'table';
+  DOM.peersEmpty.style.display = empty ? 'block' : 'none';
+}
+
+function copyCell(href, text) {
+  return `<a class="remote-link" href="${href}" target="_blank" rel="noopener">${text}</a>
+        <button type="button" class="cyber-button copy-button" data-url="${href}" onclick="navigator.clipboard.writeText(this.dataset.url)">COPY</button>`;
+}
+
+function visibleBackends() {
+  const filterText = state.backendFilter.toLowerCase();
+  return state.backends.filter(b => !filterText || b.slug.toLowerCase().includes(filterText));
+}
+
+export function renderBackends() {
+  DOM.backendsBody.innerHTML = '';
+
+  const port = window.location.port ? `:${window.location.port}` : '';
+  const visible = visibleBackends();
+  visible.forEach(b => {
+    const domainURL = `${window.location.protocol}//${b.domain}${port}/`;
+    const tr = document.createElement('tr');
+    tr.innerHTML = `
+      <td class="id-col">${b.slug}</td>
+      <td>${copyCell(b.url, b.path_prefix)}</td>
+      <td>${copyCell(domainURL, b.domain)}</td>
+      <td>${b.version || '-'}</td>
+    `;
+    // Tags come from the backend, so build them as text rather than HTML.
+    (b.tags || []).forEach(tag => {
+      const badge = document.createElement('span');
+      badge.className = 'tag-badge';
+      badge.textContent = tag;
+      tr.firstElementChild.appendChild(badge);
+    });
+    DOM.backendsBody.appendChild(tr);
+  });
+
+  const empty = visible.length === 0;
+  DOM.backendsTable.style.display = empty ? 'none' : 'table';
+  DOM.backendsEmpty.style.display = empty ? 'block' : 'none';
+}
+
+function closeQuickOpen() {
+  DOM.quickOpen.hidden = true;
+  DOM.quickOpen.value = '';
+  state.backendFilter = '';
+  renderBackends();
+}
+
 export function initUI() {
+  // Ctrl+K (Cmd+K on macOS) filters backends by slug; Enter opens the first match.
+  document.addEventListener('keydown', (e) => {
+    if (DOM.viewTerminal.style.display !== 'none') return; // leave Ctrl+K to the terminal
+    if ((e.ctrlKey || e.metaKey) && e.key.toLowerCase() === 'k') {
+      e.preventDefault();
+      DOM.quickOpen.hidden = false;
+      DOM.quickOpen.focus();
+    }
+  });
+
+  DOM.quickOpen.addEventListener('input', (e) => {
+    state.backendFilter = e.target.value;
+    renderBackends();
+  });
+
+  DOM.quickOpen.addEventListener('keydown', (e) => {
+    if (e.key === 'Escape') {
+      closeQuickOpen();
+    } else if (e.key === 'Enter') {
+      const [first] = visibleBackends();
+      if (first) window.open(first.url, '_blank', 'noopener');
+      closeQuickOpen();
+    }
+  });
+
+
   DOM.searchInput.addEventListener('input', (e) => {
     state.filter = e.target.value;
     render();
   });
 
-  document.querySelectorAll('.cyber-table th').forEach(th => {
+  document.querySelectorAll('#sessions-table th').forEach(th => {
     if (th.textContent.includes('ACTIONS')) return;
     th.style.cursor = 'pointer';
     th.title = 'Click to sort';